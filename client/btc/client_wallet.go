@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
+	"fmt"
 
+	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltbtc"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
@@ -28,6 +31,11 @@ func (ec *BtcElectrumClient) SyncWallet(ctx context.Context) error {
 	if w == nil {
 		return ErrNoWallet
 	}
+	// older wallet data may have sync times for confirmed transactions
+	err := ec.migrateTxTimes(ctx)
+	if err != nil {
+		fmt.Printf("cannot migrate transaction times yet - %v\n", err)
+	}
 	subscriptions, err := w.ListSubscriptions()
 	if err != nil {
 		return err
@@ -67,9 +75,9 @@ func (ec *BtcElectrumClient) SyncWallet(ctx context.Context) error {
 // Python console-like subset
 /////////////////////////////
 
-// Spend tries to create a new transaction to pay an amount from the wallet to
-// toAddress. It returns Tx & Txid as hex strings and the index of any change
-// output or 0 if none.
+// Spend tries to create a new transaction to pay an amount from the wallet's
+// default account to toAddress. It returns Tx & Txid as hex strings and the
+// index of any change output or 0 if none.
 // The wallet password is required in order to sign the tx.
 func (ec *BtcElectrumClient) Spend(
 	pw string,
@@ -77,6 +85,18 @@ func (ec *BtcElectrumClient) Spend(
 	toAddress string,
 	feeLevel wallet.FeeLevel) (int, string, string, error) {
 
+	return ec.AccountSpend(pw, wallet.DefaultAccount, amount, toAddress, feeLevel)
+}
+
+// AccountSpend is Spend from the coins of an HD account. Any change goes to
+// the account.
+func (ec *BtcElectrumClient) AccountSpend(
+	pw string,
+	account uint32,
+	amount int64,
+	toAddress string,
+	feeLevel wallet.FeeLevel) (int, string, string, error) {
+
 	w := ec.GetWallet()
 	if w == nil {
 		return 0, "", "", ErrNoWallet
@@ -86,7 +106,7 @@ func (ec *BtcElectrumClient) Spend(
 	if err != nil {
 		return 0, "", "", err
 	}
-	changeIndex, wireTx, err := w.Spend(pw, amount, address, feeLevel)
+	changeIndex, wireTx, err := w.AccountSpend(pw, account, amount, address, feeLevel)
 	if err != nil {
 		return 0, "", "", err
 	}
@@ -99,6 +119,99 @@ func (ec *BtcElectrumClient) Spend(
 	return changeIndex, rawTxHex, txidHex, nil
 }
 
+// SpendMany pays every payTo output in one transaction from the coins of an
+// HD account, e.g. a batch of withdrawals. Any change goes to the account. The
+// options, if not nil, choose the coins to spend or avoid and may set a sat/vB
+// fee rate or an absolute fee. It returns the change output index or -1, the
+// raw tx and the txid.
+func (ec *BtcElectrumClient) SpendMany(
+	pw string,
+	account uint32,
+	payTo []client.PayTo,
+	feeLevel wallet.FeeLevel,
+	opts *wallet.SpendOptions) (int, string, string, error) {
+
+	w := ec.GetWallet()
+	if w == nil {
+		return 0, "", "", ErrNoWallet
+	}
+	w.UpdateTip(ec.Tip())
+	var outputs []wallet.TransactionOutput
+	for _, p := range payTo {
+		address, err := btcutil.DecodeAddress(p.Address, ec.ClientConfig.Params)
+		if err != nil {
+			return 0, "", "", err
+		}
+		outputs = append(outputs, wallet.TransactionOutput{Address: address, Value: p.Amount})
+	}
+	changeIndex, wireTx, err := w.SpendMany(pw, account, outputs, feeLevel, opts)
+	if err != nil {
+		return 0, "", "", err
+	}
+	txidHex := wireTx.TxHash().String()
+	b, err := serializeWireTx(wireTx)
+	if err != nil {
+		return 0, "", "", err
+	}
+	rawTxHex := hex.EncodeToString(b)
+	return changeIndex, rawTxHex, txidHex, nil
+}
+
+// EstimateSpend previews SpendMany without signing so the user can confirm the
+// fee. It returns the coins the spend would use, the outputs with the change
+// and the estimated vsize and fee. It works for watch-only wallets.
+func (ec *BtcElectrumClient) EstimateSpend(
+	account uint32,
+	payTo []client.PayTo,
+	feeLevel wallet.FeeLevel,
+	opts *wallet.SpendOptions) (*wallet.SpendEstimate, error) {
+
+	w := ec.GetWallet()
+	if w == nil {
+		return nil, ErrNoWallet
+	}
+	w.UpdateTip(ec.Tip())
+	var outputs []wallet.TransactionOutput
+	for _, p := range payTo {
+		address, err := btcutil.DecodeAddress(p.Address, ec.ClientConfig.Params)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, wallet.TransactionOutput{Address: address, Value: p.Amount})
+	}
+	return w.EstimateSpend(account, outputs, feeLevel, opts)
+}
+
+// BuildUnsignedTx builds a transaction paying amount to toAddress from the
+// coins of an HD account without signing it. It returns the change output index
+// and the serialized unsigned tx. It works for watch-only wallets and the tx can
+// be signed elsewhere.
+func (ec *BtcElectrumClient) BuildUnsignedTx(
+	account uint32,
+	amount int64,
+	toAddress string,
+	feeLevel wallet.FeeLevel) (int, string, error) {
+
+	w := ec.GetWallet()
+	if w == nil {
+		return 0, "", ErrNoWallet
+	}
+	w.UpdateTip(ec.Tip())
+	address, err := btcutil.DecodeAddress(toAddress, ec.ClientConfig.Params)
+	if err != nil {
+		return 0, "", err
+	}
+	changeIndex, wireTx, err := w.BuildUnsignedTx(account, amount, address, feeLevel)
+	if err != nil {
+		return 0, "", err
+	}
+	b, err := serializeWireTx(wireTx)
+	if err != nil {
+		return 0, "", err
+	}
+	return changeIndex, hex.EncodeToString(b), nil
+}
+
 // GetPrivKeyForAddress gets the wallet private key-pair as a WIF given an
 // wallet address and the wallet password.
 func (ec *BtcElectrumClient) GetPrivKeyForAddress(pw, addr string) (string, error) {
@@ -320,16 +433,16 @@ func (ec *BtcElectrumClient) ListFrozenUnspent() ([]wallet.Utxo, error) {
 // UnusedAddress gets a new unused wallet receive address and subscribes for
 // ElectrumX address status notify events on the returned address.
 func (ec *BtcElectrumClient) UnusedAddress(ctx context.Context) (string, error) {
-	return ec.getNewAddress(ctx, false)
+	return ec.getNewAddress(ctx, wallet.DefaultAccount, false)
 }
 
 // ChangeAddress gets a new unused wallet change address and subscribes for
 // ElectrumX address status notify events on the returned address.
 func (ec *BtcElectrumClient) ChangeAddress(ctx context.Context) (string, error) {
-	return ec.getNewAddress(ctx, true)
+	return ec.getNewAddress(ctx, wallet.DefaultAccount, true)
 }
 
-func (ec *BtcElectrumClient) getNewAddress(ctx context.Context, internal bool) (string, error) {
+func (ec *BtcElectrumClient) getNewAddress(ctx context.Context, account uint32, internal bool) (string, error) {
 	w := ec.GetWallet()
 	if w == nil {
 		return "", ErrNoWallet
@@ -341,9 +454,9 @@ func (ec *BtcElectrumClient) getNewAddress(ctx context.Context, internal bool) (
 	var address btcutil.Address
 	var err error
 	if internal {
-		address, err = w.GetUnusedAddress(wallet.CHANGE)
+		address, err = w.GetUnusedAccountAddress(account, wallet.CHANGE)
 	} else {
-		address, err = w.GetUnusedAddress(wallet.RECEIVING)
+		address, err = w.GetUnusedAccountAddress(account, wallet.RECEIVING)
 	}
 	if err != nil {
 		return "", err
@@ -444,7 +557,7 @@ func (ec *BtcElectrumClient) FeeRate(ctx context.Context, confTarget int64) (int
 	switch ec.ClientConfig.Params {
 	case &chaincfg.MainNetParams:
 		return 30000, nil
-	case &chaincfg.TestNet3Params:
+	case &chaincfg.TestNet3Params, wltbtc.TestNet4Params, wltbtc.SigNetParams:
 		return 1500, nil
 	case &chaincfg.RegressionNetParams:
 		return 1500, nil
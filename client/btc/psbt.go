@@ -0,0 +1,117 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/bisoncraft/go-electrum-client/client"
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+)
+
+// CreatePsbt funds a PSBT paying payTo from the coins of an HD account and
+// returns it base64 encoded with the change output index. The PSBT has the
+// prevouts and BIP32 derivations a hardware wallet or Bitcoin Core needs to
+// sign it. It works for watch-only wallets.
+func (ec *BtcElectrumClient) CreatePsbt(
+	account uint32,
+	payTo []client.PayTo,
+	feeLevel wallet.FeeLevel) (string, int, error) {
+
+	w := ec.GetWallet()
+	if w == nil {
+		return "", 0, ErrNoWallet
+	}
+	w.UpdateTip(ec.Tip())
+	var outputs []wallet.TransactionOutput
+	for _, p := range payTo {
+		address, err := btcutil.DecodeAddress(p.Address, ec.ClientConfig.Params)
+		if err != nil {
+			return "", 0, err
+		}
+		outputs = append(outputs, wallet.TransactionOutput{Address: address, Value: p.Amount})
+	}
+	packet, changeIndex, err := w.CreatePsbt(account, outputs, feeLevel)
+	if err != nil {
+		return "", 0, err
+	}
+	psbtB64, err := packet.B64Encode()
+	if err != nil {
+		return "", 0, err
+	}
+	return psbtB64, changeIndex, nil
+}
+
+// SignPsbt signs the inputs of a base64 PSBT that spend wallet coins. It
+// returns the updated PSBT and the number of inputs signed.
+func (ec *BtcElectrumClient) SignPsbt(pw, psbtB64 string, opts *wallet.PsbtSignOptions) (string, int, error) {
+	w := ec.GetWallet()
+	if w == nil {
+		return "", 0, ErrNoWallet
+	}
+	packet, err := decodePsbt(psbtB64)
+	if err != nil {
+		return "", 0, err
+	}
+	signed, err := w.SignPsbt(pw, packet, opts)
+	if err != nil {
+		return "", 0, err
+	}
+	psbtB64, err = packet.B64Encode()
+	if err != nil {
+		return "", 0, err
+	}
+	return psbtB64, signed, nil
+}
+
+// CombinePsbt merges the signatures of base64 PSBTs of the same transaction.
+func (ec *BtcElectrumClient) CombinePsbt(psbts []string) (string, error) {
+	var packets []*psbt.Packet
+	for _, psbtB64 := range psbts {
+		packet, err := decodePsbt(psbtB64)
+		if err != nil {
+			return "", err
+		}
+		packets = append(packets, packet)
+	}
+	combined, err := wallet.CombinePsbt(packets...)
+	if err != nil {
+		return "", err
+	}
+	return combined.B64Encode()
+}
+
+// FinalizePsbt finalizes the inputs of a fully signed base64 PSBT.
+func (ec *BtcElectrumClient) FinalizePsbt(psbtB64 string) (string, error) {
+	packet, err := decodePsbt(psbtB64)
+	if err != nil {
+		return "", err
+	}
+	if err := wallet.FinalizePsbt(packet); err != nil {
+		return "", err
+	}
+	return packet.B64Encode()
+}
+
+// ExtractPsbtTx returns the raw tx hex and the txid of a finalized base64 PSBT
+// ready to Broadcast.
+func (ec *BtcElectrumClient) ExtractPsbtTx(psbtB64 string) (string, string, error) {
+	packet, err := decodePsbt(psbtB64)
+	if err != nil {
+		return "", "", err
+	}
+	tx, err := wallet.ExtractPsbtTx(packet)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := serializeWireTx(tx)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(b), tx.TxHash().String(), nil
+}
+
+func decodePsbt(psbtB64 string) (*psbt.Packet, error) {
+	return psbt.NewFromRawBytes(bytes.NewReader([]byte(psbtB64)), true)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/bisoncraft/go-electrum-client/client"
+	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
 )
 
@@ -22,6 +23,14 @@ func (ec *BtcElectrumClient) RescanWallet(ctx context.Context) error {
 		return ErrNoElectrumX
 	}
 
+	for _, account := range w.ListAccounts() {
+		ec.rescanAccount(ctx, w, node, account.Number)
+	}
+	return nil
+}
+
+// rescanAccount adds subscriptions for the account's keys that have history.
+func (ec *BtcElectrumClient) rescanAccount(ctx context.Context, w wallet.ElectrumWallet, node electrumx.ElectrumX, account uint32) {
 	// highest key index we will try for now
 	highestKeyIndex := 100
 	historyHitIndex := 0
@@ -30,12 +39,13 @@ func (ec *BtcElectrumClient) RescanWallet(ctx context.Context) error {
 		// flip-flop internal/external to improve locality
 		for change := 0; change < 2; change++ {
 			keyPath := &wallet.KeyPath{
-				Change: wallet.KeyChange(change),
-				Index:  keyIndex,
+				Account: account,
+				Change:  wallet.KeyChange(change),
+				Index:   keyIndex,
 			}
 			address, err := w.GetAddress(keyPath)
 			if err != nil {
-				fmt.Printf("bad address for: %d:%d\n", keyIndex, change)
+				fmt.Printf("bad address for: %d:%d:%d\n", account, keyIndex, change)
 				continue
 			}
 			scripthash, err := addressToElectrumScripthash(address)
@@ -87,6 +97,4 @@ func (ec *BtcElectrumClient) RescanWallet(ctx context.Context) error {
 			break
 		}
 	}
-
-	return nil
 }
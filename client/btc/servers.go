@@ -0,0 +1,66 @@
+package btc
+
+import (
+	"github.com/bisoncraft/go-electrum-client/electrumx"
+)
+
+// Manage the servers of the running ElectrumX network. Changes are persisted
+// and acted upon by the network without a restart.
+
+// AddServer adds a server to the known servers. Adding a banned server lifts
+// the ban.
+func (ec *BtcElectrumClient) AddServer(addr *electrumx.NodeServerAddr) error {
+	node := ec.GetX()
+	if node == nil {
+		return ErrNoElectrumX
+	}
+	return node.AddServer(addr)
+}
+
+// RemoveServer removes a server from the known servers and disconnects it if
+// it is running.
+func (ec *BtcElectrumClient) RemoveServer(addr *electrumx.NodeServerAddr) error {
+	node := ec.GetX()
+	if node == nil {
+		return ErrNoElectrumX
+	}
+	return node.RemoveServer(addr)
+}
+
+// BanServer disconnects a server if it is running and stops it being used
+// again.
+func (ec *BtcElectrumClient) BanServer(addr *electrumx.NodeServerAddr) error {
+	node := ec.GetX()
+	if node == nil {
+		return ErrNoElectrumX
+	}
+	return node.BanServer(addr)
+}
+
+// ListKnownServers returns all the servers known to the network.
+func (ec *BtcElectrumClient) ListKnownServers() ([]*electrumx.KnownServer, error) {
+	node := ec.GetX()
+	if node == nil {
+		return nil, ErrNoElectrumX
+	}
+	return node.ListKnownServers()
+}
+
+// SetPreferredLeader makes a server the preferred leader and switches to it.
+// A nil addr clears the preference.
+func (ec *BtcElectrumClient) SetPreferredLeader(addr *electrumx.NodeServerAddr) error {
+	node := ec.GetX()
+	if node == nil {
+		return ErrNoElectrumX
+	}
+	return node.SetPreferredLeader(addr)
+}
+
+// ForceLeaderSwitch drops the current leader server for another one.
+func (ec *BtcElectrumClient) ForceLeaderSwitch() error {
+	node := ec.GetX()
+	if node == nil {
+		return ErrNoElectrumX
+	}
+	return node.ForceLeaderSwitch()
+}
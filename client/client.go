@@ -19,6 +19,7 @@ package client
 
 import (
 	"context"
+	"time"
 
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
@@ -37,6 +38,12 @@ const (
 	GAP_LIMIT = 10
 )
 
+// PayTo is a transaction output paying Amount to Address.
+type PayTo struct {
+	Address string
+	Amount  int64
+}
+
 type ElectrumClient interface {
 	Start(ctx context.Context) error
 	Stop()
@@ -47,10 +54,12 @@ type ElectrumClient interface {
 	//
 	RegisterTipChangeNotify() (<-chan int64, error)
 	UnregisterTipChangeNotify()
+	SubscribeEvents(bufSize int, policy electrumx.OverflowPolicy, types ...electrumx.EventType) (*electrumx.EventSubscription, error)
 	//
 	CreateWallet(pw string) error
 	LoadWallet(pw string) error
 	RecreateWallet(ctx context.Context, pw, mnenomic string) error
+	CreateWatchOnlyWallet(ctx context.Context, pw, xpub string) error
 	//
 	SyncWallet(ctx context.Context) error
 	RescanWallet(ctx context.Context) error
@@ -59,8 +68,12 @@ type ElectrumClient interface {
 	// Subset of electrum-like methods
 	Tip() int64
 	Synced() bool
+	Connected() bool
 	GetBlockHeader(height int64) (*electrumx.ClientBlockHeader, error)
 	GetBlockHeaders(startHeight, count int64) ([]*electrumx.ClientBlockHeader, error)
+	GetBlockHeaderByHash(hash string) (*electrumx.ClientBlockHeader, error)
+	GetHeightForBlockHash(hash string) (int64, error)
+	MedianTimePast(height int64) (time.Time, error)
 	Spend(pw string, amount int64, toAddress string, feeLevel wallet.FeeLevel) (int, string, string, error)
 	GetPrivKeyForAddress(pw, addr string) (string, error)
 	ListUnspent() ([]wallet.Utxo, error)
@@ -75,6 +88,24 @@ type ElectrumClient interface {
 	GetWalletTx(txid string) (int, bool, []byte, error)
 	GetWalletSpents() ([]wallet.Stxo, error)
 	Balance() (int64, int64, int64, error)
+	//
+	// HD accounts
+	CreateAccount(pw, name string) (uint32, error)
+	ListAccounts() ([]wallet.Account, error)
+	AccountUnusedAddress(ctx context.Context, account uint32) (string, error)
+	AccountBalance(account uint32) (int64, int64, int64, error)
+	ListAccountUnspent(account uint32) ([]wallet.Utxo, error)
+	AccountSpend(pw string, account uint32, amount int64, toAddress string, feeLevel wallet.FeeLevel) (int, string, string, error)
+	SpendMany(pw string, account uint32, payTo []PayTo, feeLevel wallet.FeeLevel, opts *wallet.SpendOptions) (int, string, string, error)
+	EstimateSpend(account uint32, payTo []PayTo, feeLevel wallet.FeeLevel, opts *wallet.SpendOptions) (*wallet.SpendEstimate, error)
+	BuildUnsignedTx(account uint32, amount int64, toAddress string, feeLevel wallet.FeeLevel) (int, string, error)
+	//
+	// BIP174 PSBTs, base64 encoded
+	CreatePsbt(account uint32, payTo []PayTo, feeLevel wallet.FeeLevel) (string, int, error)
+	SignPsbt(pw, psbtB64 string, opts *wallet.PsbtSignOptions) (string, int, error)
+	CombinePsbt(psbts []string) (string, error)
+	FinalizePsbt(psbtB64 string) (string, error)
+	ExtractPsbtTx(psbtB64 string) (string, string, error)
 
 	// adapt and pass thru to electrumx
 	Broadcast(ctx context.Context, rawTx []byte) (string, error)
@@ -86,6 +117,11 @@ type ElectrumClient interface {
 	GetAddressHistory(ctx context.Context, addr string) (electrumx.HistoryResult, error)
 	GetAddressUnspent(ctx context.Context, addr string) (electrumx.ListUnspentResult, error)
 
-	//coin specific extra for server protocol - use dummy method for non-implmenting coins.
-	// firo EXX addresses
+	// manage the running electrumx network servers
+	AddServer(addr *electrumx.NodeServerAddr) error
+	RemoveServer(addr *electrumx.NodeServerAddr) error
+	BanServer(addr *electrumx.NodeServerAddr) error
+	ListKnownServers() ([]*electrumx.KnownServer, error)
+	SetPreferredLeader(addr *electrumx.NodeServerAddr) error
+	ForceLeaderSwitch() error
 }
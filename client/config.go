@@ -25,7 +25,17 @@ type ClientConfig struct {
 	// bip44 mainnet
 	CoinType wallet.CoinType
 
-	// Net type - mainnet, testnet or regtest
+	// Create or recreate the wallet on the legacy bitcoin coin type key
+	// derivation path - for recreating from the mnemonic of a wallet made
+	// before the SLIP-44 coin type was used. Default false.
+	LegacyHDPath bool
+
+	// Key derivation and address scheme of a new segwit coin wallet: bip44
+	// (P2PKH), bip49 (P2SH-P2WPKH), bip84 (P2WPKH) or bip86 (P2TR). Default
+	// "" is bip84. Ignored by coins without segwit.
+	AddressScheme wallet.AddressScheme
+
+	// Net type - mainnet, testnet, testnet4, signet or regtest
 	NetType string
 
 	// Network parameters - make more general if it cannot adapt to other coins.
@@ -72,6 +82,18 @@ type ClientConfig struct {
 	// then the MaxFee will be used instead.
 	FeeAPI url.URL
 
+	// Optional JSON file of ElectrumX network parameters - start points,
+	// checkpoints, peer limits and seed servers - replacing the coin's
+	// embedded ones. See electrumx.LoadNetParams.
+	NetParamsFile string
+
+	// Optional directory of a verified headers snapshot to bootstrap the
+	// blockchain headers from and the hex ed25519 public key it must be signed
+	// with; a snapshot is not imported without the key. See
+	// electrumx.ExportHeadersSnapshot.
+	HeadersSnapshot    string
+	HeadersSnapshotKey string
+
 	// If not testing do not overwrite existing wallet files
 	Testing bool
 
@@ -94,31 +116,36 @@ func NewDefaultConfig() *ClientConfig {
 }
 func (cc *ClientConfig) MakeWalletConfig() *wallet.WalletConfig {
 	wc := wallet.WalletConfig{
-		Coin:         cc.Coin,
-		CoinType:     cc.CoinType,
-		NetType:      cc.NetType,
-		Params:       cc.Params,
-		StoreEncSeed: cc.StoreEncSeed,
-		DataDir:      cc.DataDir,
-		DbType:       cc.DbType,
-		DB:           cc.DB,
-		LowFee:       cc.LowFee,
-		MediumFee:    cc.MediumFee,
-		HighFee:      cc.HighFee,
-		MaxFee:       cc.MaxFee,
-		Testing:      cc.Testing,
+		Coin:          cc.Coin,
+		CoinType:      cc.CoinType,
+		LegacyHDPath:  cc.LegacyHDPath,
+		AddressScheme: cc.AddressScheme,
+		NetType:       cc.NetType,
+		Params:        cc.Params,
+		StoreEncSeed:  cc.StoreEncSeed,
+		DataDir:       cc.DataDir,
+		DbType:        cc.DbType,
+		DB:            cc.DB,
+		LowFee:        cc.LowFee,
+		MediumFee:     cc.MediumFee,
+		HighFee:       cc.HighFee,
+		MaxFee:        cc.MaxFee,
+		Testing:       cc.Testing,
 	}
 	return &wc
 }
 
 func (cc *ClientConfig) MakeElectrumXConfig() *electrumx.ElectrumXConfig {
 	ex := electrumx.ElectrumXConfig{
-		NetType:     cc.NetType,
-		Params:      cc.Params, // only genesis .. TODO: remove
-		DataDir:     cc.DataDir,
-		TrustedPeer: cc.TrustedPeer,
-		ProxyPort:   cc.ProxyPort,
-		Testing:     cc.Testing,
+		NetType:            cc.NetType,
+		Params:             cc.Params, // only genesis .. TODO: remove
+		DataDir:            cc.DataDir,
+		TrustedPeer:        cc.TrustedPeer,
+		ProxyPort:          cc.ProxyPort,
+		NetParamsFile:      cc.NetParamsFile,
+		HeadersSnapshot:    cc.HeadersSnapshot,
+		HeadersSnapshotKey: cc.HeadersSnapshotKey,
+		Testing:            cc.Testing,
 	}
 	return &ex
 }
@@ -2,7 +2,7 @@ package dash
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 )
@@ -12,52 +12,105 @@ func (ec *DashElectrumClient) Tip() (tip int64) {
 	return ec.GetX().GetTip()
 }
 
-// tipChange receives notifications from network leader nodes. If an api user
-// has registered to receive tip change notifications - forward the notification
-// on the client's registered channel. rcvTipChangeNotify is a single unbuffered
-// channel kept open by network.go. Run as a goroutine from client startup.
+const (
+	// buffered tip events for the client; older tips are coalesced
+	tipEventsBufSize = 8
+	// buffered scripthash events for the client; more are queued while the
+	// client is busy, never dropped.
+	scripthashEventsBufSize = 1024
+)
+
+// tipChange receives tip change and reorg events from network leader nodes
+// and updates the wallet tip. Api users receive their own events through
+// RegisterTipChangeNotify or SubscribeEvents so cannot hold us up. Run as a
+// goroutine from client startup.
 func (ec *DashElectrumClient) tipChange(ctx context.Context) {
+	defer ec.tipEvents.Unsubscribe()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case tip, ok := <-ec.rcvTipChangeNotify:
+		case ev, ok := <-ec.tipEvents.Events():
 			if !ok {
 				return
 			}
 			// update wallet's notion of the tip for confirmations
-			ec.updateWalletTip(tip)
-			// user
-			ec.sendTipChangeNotifyMtx.RLock()
-			if ec.sendTipChangeNotify != nil {
-				ec.sendTipChangeNotify <- tip
-			}
-			ec.sendTipChangeNotifyMtx.RUnlock()
+			ec.updateWalletTip(ev.Height)
 		}
 	}
 }
 
-// RegisterTipChangeNotify sends a new tip change channel back to an api user
+// RegisterTipChangeNotify sends a new tip change channel back to an api user.
+// Any number of channels can be registered. Each channel holds only the latest
+// tip; a slow reader misses intermediate tips.
 func (ec *DashElectrumClient) RegisterTipChangeNotify() (<-chan int64, error) {
-	ec.sendTipChangeNotifyMtx.Lock()
-	defer ec.sendTipChangeNotifyMtx.Unlock()
-
-	if ec.sendTipChangeNotify != nil {
-		return nil, errors.New("notify already registered - unregister to close the channel")
+	node := ec.GetX()
+	if node == nil {
+		return nil, ErrNoElectrumX
 	}
-	ec.sendTipChangeNotify = make(chan int64, 1)
-	return ec.sendTipChangeNotify, nil
+	sub, err := node.SubscribeEvents(1, electrumx.Coalesce, electrumx.EventNewTip, electrumx.EventReorg)
+	if err != nil {
+		return nil, err
+	}
+	ec.tipChangeSubsMtx.Lock()
+	ec.tipChangeSubs = append(ec.tipChangeSubs, sub)
+	ec.tipChangeSubsMtx.Unlock()
+
+	tipCh := make(chan int64, 1)
+	go forwardTips(sub, tipCh)
+	return tipCh, nil
 }
 
-// UnregisterTipChangeNotify closes the current tip change channel
+// UnregisterTipChangeNotify closes all the registered tip change channels
 func (ec *DashElectrumClient) UnregisterTipChangeNotify() {
-	ec.sendTipChangeNotifyMtx.Lock()
-	defer ec.sendTipChangeNotifyMtx.Unlock()
+	ec.tipChangeSubsMtx.Lock()
+	defer ec.tipChangeSubsMtx.Unlock()
 
-	if ec.sendTipChangeNotify != nil {
-		close(ec.sendTipChangeNotify)
-		ec.sendTipChangeNotify = nil
+	for _, sub := range ec.tipChangeSubs {
+		sub.Unsubscribe()
 	}
+	ec.tipChangeSubs = nil
+}
+
+// forwardTips sends the tip from each event to tipCh replacing any tip not yet
+// read. It closes tipCh when the subscription ends.
+func forwardTips(sub *electrumx.EventSubscription, tipCh chan int64) {
+	defer close(tipCh)
+	for ev := range sub.Events() {
+		select {
+		case tipCh <- ev.Height:
+			continue
+		default:
+		}
+		// we are the only sender so there is room after discarding the old tip
+		select {
+		case <-tipCh:
+		default:
+		}
+		tipCh <- ev.Height
+	}
+}
+
+// SubscribeEvents returns a new subscription to electrumx network events. See
+// electrumx.Network.SubscribeEvents.
+func (ec *DashElectrumClient) SubscribeEvents(bufSize int, policy electrumx.OverflowPolicy, types ...electrumx.EventType) (*electrumx.EventSubscription, error) {
+	node := ec.GetX()
+	if node == nil {
+		return nil, ErrNoElectrumX
+	}
+	return node.SubscribeEvents(bufSize, policy, types...)
+}
+
+// Connected returns true if the electrumx network has a running leader. Use
+// SubscribeEvents to be told of network state changes; i.e. EventConnected,
+// EventDisconnected, EventLeaderChange, EventSyncing, EventSyncComplete,
+// EventRecoveryStarted and EventRecoveryEnded.
+func (ec *DashElectrumClient) Connected() bool {
+	node := ec.GetX()
+	if node == nil {
+		return false
+	}
+	return node.IsConnected()
 }
 
 // Synced returns the headers sync status
@@ -74,3 +127,35 @@ func (ec *DashElectrumClient) GetBlockHeader(height int64) (*electrumx.ClientBlo
 func (ec *DashElectrumClient) GetBlockHeaders(startHeight, count int64) ([]*electrumx.ClientBlockHeader, error) {
 	return ec.GetX().GetBlockHeaders(startHeight, count)
 }
+
+// GetBlockHeaderByHash returns the block header with block hash 'hash' from
+// ElectrumXInterface current stored headers
+func (ec *DashElectrumClient) GetBlockHeaderByHash(hash string) (*electrumx.ClientBlockHeader, error) {
+	return ec.GetX().GetBlockHeaderByHash(hash)
+}
+
+// GetHeightForBlockHash returns the height of the block with block hash 'hash'
+// from ElectrumXInterface current stored headers
+func (ec *DashElectrumClient) GetHeightForBlockHash(hash string) (int64, error) {
+	return ec.GetX().GetHeightForBlockHash(hash)
+}
+
+// blockTime returns the time of the block at height. Blocks before the stored
+// headers' start point are fetched with a checkpoint proof.
+func (ec *DashElectrumClient) blockTime(ctx context.Context, height int64) (time.Time, error) {
+	node := ec.GetX()
+	if node == nil {
+		return time.Time{}, ErrNoElectrumX
+	}
+	hdr, err := node.FetchBlockHeader(ctx, height)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return hdr.Timestamp, nil
+}
+
+// MedianTimePast returns the median time past of the block at height. Lock
+// times are checked against this (BIP113).
+func (ec *DashElectrumClient) MedianTimePast(height int64) (time.Time, error) {
+	return ec.GetX().MedianTimePast(height)
+}
@@ -26,21 +26,21 @@ type DashElectrumClient struct {
 	Wallet wallet.ElectrumWallet
 	// Interface tp ElectrumX servers for a coin network
 	X electrumx.ElectrumX
-	// Receive tip change notify channel from electrumx
-	rcvTipChangeNotify <-chan int64
-	// Forward tip change notify to external user if registered
-	sendTipChangeNotify    chan int64
-	sendTipChangeNotifyMtx sync.RWMutex
+	// Receive tip change events from electrumx
+	tipEvents *electrumx.EventSubscription
+	// Forward tip changes to external users if registered
+	tipChangeSubs    []*electrumx.EventSubscription
+	tipChangeSubsMtx sync.Mutex
 }
 
 func NewDashElectrumClient(cfg *client.ClientConfig) client.ElectrumClient {
 	ec := DashElectrumClient{
-		Cancel:              nil,
-		ClientConfig:        cfg,
-		Wallet:              nil,
-		X:                   nil,
-		rcvTipChangeNotify:  nil,
-		sendTipChangeNotify: nil,
+		Cancel:        nil,
+		ClientConfig:  cfg,
+		Wallet:        nil,
+		X:             nil,
+		tipEvents:     nil,
+		tipChangeSubs: nil,
 	}
 	return &ec
 }
@@ -126,7 +126,11 @@ func (ec *DashElectrumClient) Start(parentCtx context.Context) error {
 	if err != nil {
 		return err
 	}
-	ec.rcvTipChangeNotify, err = ec.X.GetTipChangeNotify()
+	ec.tipEvents, err = ec.X.SubscribeEvents(
+		tipEventsBufSize,
+		electrumx.Coalesce,
+		electrumx.EventNewTip,
+		electrumx.EventReorg)
 	if err != nil {
 		return err
 	}
@@ -181,6 +185,11 @@ func (ec *DashElectrumClient) RecreateWallet(ctx context.Context, pw, mnenomic s
 	return ec.RescanWallet(ctx)
 }
 
+// CreateWatchOnlyWallet is not implemented for Dash wallets.
+func (ec *DashElectrumClient) CreateWatchOnlyWallet(ctx context.Context, pw, xpub string) error {
+	return wallet.ErrWalletFnNotImplemented
+}
+
 // LoadWallet loads an existing wallet. The password is required to decrypt
 // the stored xpub, xprv and other sensitive data
 func (ec *DashElectrumClient) LoadWallet(pw string) error {
@@ -203,10 +212,15 @@ func (ec *DashElectrumClient) LoadWallet(pw string) error {
 // Interface methods in blockchain.go
 //
 // Tip() (int64, bool)
-// RegisterTipChangeNotify(tipChange func(height int64)) error
+// RegisterTipChangeNotify() (<-chan int64, error)
 // UnegisterTipChangeNotify()
-// GetBlockHeader(height int64) *wire.BlockHeader
-// GetBlockHeaders(startHeight, count int64) ([]*wire.BlockHeader, error)
+// SubscribeEvents(bufSize int, policy electrumx.OverflowPolicy, types ...electrumx.EventType) (*electrumx.EventSubscription, error)
+// Connected() bool
+// GetBlockHeader(height int64) (*electrumx.ClientBlockHeader, error)
+// GetBlockHeaders(startHeight, count int64) ([]*electrumx.ClientBlockHeader, error)
+// GetBlockHeaderByHash(hash string) (*electrumx.ClientBlockHeader, error)
+// GetHeightForBlockHash(hash string) (int64, error)
+// MedianTimePast(height int64) (time.Time, error)
 
 // Interface methods in client_wallet.go
 //
@@ -222,6 +236,26 @@ func (ec *DashElectrumClient) LoadWallet(pw string) error {
 // UnFreezeUTXO((txid string, out uint32) error
 // GetWalletTx(txid string) (int, bool, []byte, error)
 // GetWalletSpents() ([]wallet.Stxo, error)
+// AccountSpend(pw string, account uint32, amount int64, toAddress string, feeLevel wallet.FeeLevel) (int, string, string, error)
+// SpendMany(pw string, account uint32, payTo []client.PayTo, feeLevel wallet.FeeLevel, opts *wallet.SpendOptions) (int, string, string, error)
+// EstimateSpend(account uint32, payTo []client.PayTo, feeLevel wallet.FeeLevel, opts *wallet.SpendOptions) (*wallet.SpendEstimate, error)
+// BuildUnsignedTx(account uint32, amount int64, toAddress string, feeLevel wallet.FeeLevel) (int, string, error)
+
+// Interface methods in psbt.go
+//
+// CreatePsbt(account uint32, payTo []client.PayTo, feeLevel wallet.FeeLevel) (string, int, error)
+// SignPsbt(pw, psbtB64 string, opts *wallet.PsbtSignOptions) (string, int, error)
+// CombinePsbt(psbts []string) (string, error)
+// FinalizePsbt(psbtB64 string) (string, error)
+// ExtractPsbtTx(psbtB64 string) (string, string, error)
+
+// Interface methods in accounts.go
+//
+// CreateAccount(pw, name string) (uint32, error)
+// ListAccounts() ([]wallet.Account, error)
+// AccountUnusedAddress(ctx context.Context, account uint32) (string, error)
+// AccountBalance(account uint32) (int64, int64, int64, error)
+// ListAccountUnspent(account uint32) ([]wallet.Utxo, error)
 
 // Interface methods in client_node.go
 //
@@ -229,5 +263,14 @@ func (ec *DashElectrumClient) LoadWallet(pw string) error {
 // GetRawTransaction(ctx context.Context,txid string) ([]byte, error)
 // GetAddressHistory(ctx context.Context, addr string) (electrumx.HistoryResult, error)
 // GetAddressUnspent(ctx context.Context, addr string) (electrumx.ListUnspentResult, error)
+
+// Interface methods in servers.go
+//
+// AddServer(addr *electrumx.NodeServerAddr) error
+// RemoveServer(addr *electrumx.NodeServerAddr) error
+// BanServer(addr *electrumx.NodeServerAddr) error
+// ListKnownServers() ([]*electrumx.KnownServer, error)
+// SetPreferredLeader(addr *electrumx.NodeServerAddr) error
+// ForceLeaderSwitch() error
 //
 //////////////////////////////////////////////////////////////////////////////
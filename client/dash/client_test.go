@@ -3,31 +3,28 @@ package dash
 import (
 	"testing"
 
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltdash"
 )
 
 var (
-	// bitcoin genesis mainnet
-	bgen           = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	// bitcoin genesis mainnet pubkey hash as a dash address
+	bgen           = "XjhqDGJH37VEpecoDGmtJrmEB7VoD8Lb39"
 	bgenScriptHash = "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"
 	// goele wallet regtest
-	a1           = "mvP2UeXooRghYvsX7H7XVj78FY49jJw6Sq"
+	a1           = "ybBX2oBAPf87GVv2pSmmZMzwWAXWL3jGXo"
 	a1Scripthash = "6036b7e9dcb352f2d7bb4ad0efe0f06e03ba58fad4d16e943a25ae41082d1934"
-	// electrum wallet regtest
-	ab           = "bcrt1q3fx029uese6mrhvq68u4l6me49refj8maqxvfv"
-	abScripthash = "02c21ac0ef859617cbb7ae68943b9af8fb99699d32ea35cb449384aac17b93d5"
-	// electrumX regtest
-	aWSH           = "bcrt1qdql55es0t6afs9gy9th2magjncahp0fxhs4jkn20mqjt4hjyjesqvp5ls8"
-	aWSHScripthash = "c27062c2d7439f6b583704f576478141490a2197e6052bbde14851f24ac0e8b5"
+	// segwit addresses do not exist on dash
+	ab   = "bcrt1q3fx029uese6mrhvq68u4l6me49refj8maqxvfv"
+	aWSH = "bcrt1qdql55es0t6afs9gy9th2magjncahp0fxhs4jkn20mqjt4hjyjesqvp5ls8"
 )
 
 func TestElectrumScripthash(t *testing.T) {
-	_, err := addrToElectrumScripthash("", &chaincfg.MainNetParams)
+	_, err := addrToElectrumScripthash("", wltdash.DashMainNetParams)
 	if err == nil {
 		t.Fatal(err)
 	}
 
-	shGen, err := addrToElectrumScripthash(bgen, &chaincfg.MainNetParams)
+	shGen, err := addrToElectrumScripthash(bgen, wltdash.DashMainNetParams)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -35,7 +32,7 @@ func TestElectrumScripthash(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	sh1, err := addrToElectrumScripthash(a1, &chaincfg.RegressionNetParams)
+	sh1, err := addrToElectrumScripthash(a1, wltdash.DashRegtestParams)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -43,19 +40,10 @@ func TestElectrumScripthash(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	shb, err := addrToElectrumScripthash(ab, &chaincfg.RegressionNetParams)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if shb != abScripthash {
-		t.Fatal(err)
-	}
-
-	shWSH, err := addrToElectrumScripthash(aWSH, &chaincfg.RegressionNetParams)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if shWSH != aWSHScripthash {
-		t.Fatal(err)
+	for _, addr := range []string{ab, aWSH} {
+		_, err = addrToElectrumScripthash(addr, wltdash.DashRegtestParams)
+		if err == nil {
+			t.Fatalf("segwit address %s accepted", addr)
+		}
 	}
 }
@@ -178,8 +178,8 @@ func (ec *DashElectrumClient) ImportAndSweep(ctx context.Context, importedKeyPai
 	}
 	for _, tx := range txs {
 		var sweepBuf bytes.Buffer
-		sweepBuf.Grow(tx.SerializeSize())
-		tx.Serialize(&sweepBuf)
+		sweepBuf.Grow(tx.SerializeSizeStripped())
+		tx.SerializeNoWitness(&sweepBuf)
 	}
 
 	return nil
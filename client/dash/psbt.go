@@ -0,0 +1,32 @@
+package dash
+
+import (
+	"github.com/bisoncraft/go-electrum-client/client"
+	"github.com/bisoncraft/go-electrum-client/wallet"
+)
+
+// PSBTs are a bitcoin format and are not implemented for Dash.
+
+func (ec *DashElectrumClient) CreatePsbt(
+	account uint32,
+	payTo []client.PayTo,
+	feeLevel wallet.FeeLevel) (string, int, error) {
+
+	return "", 0, wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *DashElectrumClient) SignPsbt(pw, psbtB64 string, opts *wallet.PsbtSignOptions) (string, int, error) {
+	return "", 0, wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *DashElectrumClient) CombinePsbt(psbts []string) (string, error) {
+	return "", wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *DashElectrumClient) FinalizePsbt(psbtB64 string) (string, error) {
+	return "", wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *DashElectrumClient) ExtractPsbtTx(psbtB64 string) (string, string, error) {
+	return "", "", wallet.ErrWalletFnNotImplemented
+}
@@ -8,19 +8,19 @@ import (
 
 	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltdash"
 )
 
-func makeBitcoinRegtestTestConfig() (*client.ClientConfig, error) {
+func makeDashRegtestTestConfig() (*client.ClientConfig, error) {
 	cfg := client.NewDefaultConfig()
-	cfg.CoinType = wallet.Bitcoin
-	cfg.Params = &chaincfg.RegressionNetParams
+	cfg.CoinType = wallet.Dash
+	cfg.Params = wltdash.DashRegtestParams
 	cfg.StoreEncSeed = true
 	appDir, err := client.GetConfigPath()
 	if err != nil {
 		return nil, err
 	}
-	regtestTestDir := filepath.Join(appDir, "btc", "regtest", "test")
+	regtestTestDir := filepath.Join(appDir, "dash", "regtest", "test")
 	err = os.MkdirAll(regtestTestDir, os.ModeDir|0777)
 	if err != nil {
 		return nil, err
@@ -34,13 +34,13 @@ func rmTestDir() error {
 	if err != nil {
 		return err
 	}
-	regtestTestDir := filepath.Join(appDir, "btc", "regtest", "test")
+	regtestTestDir := filepath.Join(appDir, "dash", "regtest", "test")
 	return os.RemoveAll(regtestTestDir)
 }
 
 // Create a new standard wallet
 func TestWalletCreation(t *testing.T) {
-	cfg, err := makeBitcoinRegtestTestConfig()
+	cfg, err := makeDashRegtestTestConfig()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -52,7 +52,7 @@ func TestWalletCreation(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Println("made a btcWallet")
+	fmt.Println("made a dash wallet")
 
 	adr, err := ec.GetWallet().GetUnusedAddress(wallet.EXTERNAL)
 	if err != nil {
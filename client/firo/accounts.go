@@ -0,0 +1,53 @@
+package firo
+
+import (
+	"context"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+)
+
+// CreateAccount adds a named HD account to the wallet and returns its account
+// number. Use RescanWallet after creating the accounts of a recreated wallet.
+func (ec *FiroElectrumClient) CreateAccount(pw, name string) (uint32, error) {
+	w := ec.GetWallet()
+	if w == nil {
+		return 0, ErrNoWallet
+	}
+	return w.CreateAccount(pw, name)
+}
+
+// ListAccounts returns the wallet's HD accounts, the default account first.
+func (ec *FiroElectrumClient) ListAccounts() ([]wallet.Account, error) {
+	w := ec.GetWallet()
+	if w == nil {
+		return nil, ErrNoWallet
+	}
+	return w.ListAccounts(), nil
+}
+
+// AccountUnusedAddress gets a new unused receive address of the account and
+// subscribes for ElectrumX address status notify events on the returned
+// address.
+func (ec *FiroElectrumClient) AccountUnusedAddress(ctx context.Context, account uint32) (string, error) {
+	return ec.getNewAddress(ctx, account, false)
+}
+
+// AccountBalance returns the confirmed, unconfirmed and locked balances of the
+// account.
+func (ec *FiroElectrumClient) AccountBalance(account uint32) (int64, int64, int64, error) {
+	w := ec.GetWallet()
+	if w == nil {
+		return 0, 0, 0, ErrNoWallet
+	}
+	return w.AccountBalance(account)
+}
+
+// ListAccountUnspent returns a list of the utxos of the account.
+func (ec *FiroElectrumClient) ListAccountUnspent(account uint32) ([]wallet.Utxo, error) {
+	w := ec.GetWallet()
+	if w == nil {
+		return nil, ErrNoWallet
+	}
+	w.UpdateTip(ec.Tip())
+	return w.ListAccountUnspent(account)
+}
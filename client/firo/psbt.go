@@ -0,0 +1,32 @@
+package firo
+
+import (
+	"github.com/bisoncraft/go-electrum-client/client"
+	"github.com/bisoncraft/go-electrum-client/wallet"
+)
+
+// PSBTs are a bitcoin format and are not implemented for Firo.
+
+func (ec *FiroElectrumClient) CreatePsbt(
+	account uint32,
+	payTo []client.PayTo,
+	feeLevel wallet.FeeLevel) (string, int, error) {
+
+	return "", 0, wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *FiroElectrumClient) SignPsbt(pw, psbtB64 string, opts *wallet.PsbtSignOptions) (string, int, error) {
+	return "", 0, wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *FiroElectrumClient) CombinePsbt(psbts []string) (string, error) {
+	return "", wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *FiroElectrumClient) FinalizePsbt(psbtB64 string) (string, error) {
+	return "", wallet.ErrWalletFnNotImplemented
+}
+
+func (ec *FiroElectrumClient) ExtractPsbtTx(psbtB64 string) (string, string, error) {
+	return "", "", wallet.ErrWalletFnNotImplemented
+}
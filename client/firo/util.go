@@ -16,7 +16,8 @@ import (
 func newWireTx(b []byte, checkIo bool) (*wire.MsgTx, error) {
 	tx := wire.NewMsgTx(wire.TxVersion)
 	r := bytes.NewBuffer(b)
-	err := tx.Deserialize(r)
+	// Firo has no segwit so never the witness encoding
+	err := tx.DeserializeNoWitness(r)
 	if err != nil {
 		return nil, err
 	}
@@ -32,9 +33,9 @@ func newWireTx(b []byte, checkIo bool) (*wire.MsgTx, error) {
 }
 
 func serializeWireTx(tx *wire.MsgTx) ([]byte, error) {
-	b := make([]byte, 0, tx.SerializeSize())
+	b := make([]byte, 0, tx.SerializeSizeStripped())
 	w := bytes.NewBuffer(b)
-	err := tx.Serialize(w)
+	err := tx.SerializeNoWitness(w)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltfiro"
 )
 
 // Here is the client interface between the node & wallet for monitoring the
@@ -59,10 +60,10 @@ func addressToElectrumScripthash(address btcutil.Address) (string, error) {
 	return pkScriptToElectrumScripthash(pkScript), nil
 }
 
-// addrToElectrumScripthash takes a bech or legacy bitcoin address and makes an electrum
-// 1.4 protocol 'scripthash'
+// addrToElectrumScripthash takes a P2PKH, P2SH or exchange firo address and
+// makes an electrum 1.4 protocol 'scripthash'
 func addrToElectrumScripthash(addr string, network *chaincfg.Params) (string, error) {
-	address, err := btcutil.DecodeAddress(addr, network)
+	address, err := wltfiro.DecodeAddress(addr, network)
 	if err != nil {
 		return "", err
 	}
@@ -144,12 +145,18 @@ func (ec *FiroElectrumClient) removeSubscription(pkScript string) error {
 func (ec *FiroElectrumClient) addressStatusNotify(ctx context.Context) error {
 	node := ec.GetX()
 
-	scripthashNotifyCh, err := node.GetScripthashNotify()
+	// Every status change must reach the wallet; a lost one leaves its
+	// history stale until the next resync.
+	sub, err := node.SubscribeEvents(
+		scripthashEventsBufSize,
+		electrumx.Unbounded,
+		electrumx.EventScripthashStatus)
 	if err != nil {
 		return err
 	}
 
 	go func() {
+		defer sub.Unsubscribe()
 
 		fmt.Println("=== Waiting for address change notifications ===")
 
@@ -160,11 +167,12 @@ func (ec *FiroElectrumClient) addressStatusNotify(ctx context.Context) error {
 				fmt.Println("ctx.Done - in client scripthash notify - exiting thread")
 				return
 
-			case status, ok := <-scripthashNotifyCh:
+			case ev, ok := <-sub.Events():
 				if !ok {
 					fmt.Println("scripthash notify channel closed - exiting thread")
 					return
 				}
+				status := ev.Scripthash
 
 				if status.Status == "" {
 					// fmt.Println("status.Status is null no history yet; ignoring...")
@@ -271,9 +279,10 @@ func (ec *FiroElectrumClient) GetAddressHistoryFromNode(ctx context.Context, sub
 }
 
 // GetRawTransactionFromNode requests a raw hex transaction for a subscribed address
-// from ElectrumX keyed on a txid. This txid is usually taken from an ElectrumX
-// history list.
-func (ec *FiroElectrumClient) GetRawTransactionFromNode(ctx context.Context, txid string) (*wire.MsgTx, time.Time, error) {
+// from ElectrumX keyed on a txid. This txid and it's height are usually taken
+// from an ElectrumX history list. The time returned is the block time for a
+// confirmed transaction, otherwise now.
+func (ec *FiroElectrumClient) GetRawTransactionFromNode(ctx context.Context, txid string, height int64) (*wire.MsgTx, time.Time, error) {
 	node := ec.GetX()
 	if node == nil {
 		return nil, time.Time{}, ErrNoElectrumX
@@ -290,8 +299,34 @@ func (ec *FiroElectrumClient) GetRawTransactionFromNode(ctx context.Context, txi
 	if err != nil {
 		return nil, time.Time{}, err
 	}
-	txTime := time.Now()
-	return msgTx, txTime, nil
+	return msgTx, ec.txTime(ctx, height), nil
+}
+
+// txTime returns the time for a wallet transaction at height. A confirmed
+// transaction has it's block time. An unconfirmed one has the time it is first
+// seen which the wallet keeps until the transaction confirms.
+func (ec *FiroElectrumClient) txTime(ctx context.Context, height int64) time.Time {
+	if height <= 0 {
+		return time.Now()
+	}
+	blkTime, err := ec.blockTime(ctx, height)
+	if err != nil {
+		fmt.Printf("no block time for height %d - %v\n", height, err)
+		return time.Now()
+	}
+	return blkTime
+}
+
+// migrateTxTimes migrates the times of confirmed transactions in older wallet
+// data to their block times.
+func (ec *FiroElectrumClient) migrateTxTimes(ctx context.Context) error {
+	w := ec.GetWallet()
+	if w == nil {
+		return ErrNoWallet
+	}
+	return w.MigrateTxTimes(func(height int64) (time.Time, error) {
+		return ec.blockTime(ctx, height)
+	})
 }
 
 // addTxHistoryToWallet adds new transaction details for an ElectrumX history list
@@ -304,7 +339,7 @@ func (ec *FiroElectrumClient) addTxHistoryToWallet(ctx context.Context, history
 			continue
 		}
 		// add or update the wallet transaction
-		msgTx, txtime, err := ec.GetRawTransactionFromNode(ctx, h.TxHash)
+		msgTx, txtime, err := ec.GetRawTransactionFromNode(ctx, h.TxHash, h.Height)
 		if err != nil {
 			continue
 		}
@@ -16,12 +16,13 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client/btc"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltbtc"
 	"github.com/btcsuite/btcd/chaincfg"
 )
 
 var (
 	coins = []string{"btc"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "signet", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -57,7 +58,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
 			cfg.Params = &chaincfg.TestNet3Params
@@ -70,6 +71,24 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
+		case "testnet4":
+			cfg.NetType = electrumx.Testnet4
+			cfg.RPCTestPort = 48887
+			cfg.Params = wltbtc.TestNet4Params
+			cfg.TrustedPeer = &electrumx.NodeServerAddr{
+				Net: "ssl", Addr: "mempool.space:40002",
+			}
+			cfg.StoreEncSeed = true
+			cfg.Testing = true
+		case "signet":
+			cfg.NetType = electrumx.Signet
+			cfg.RPCTestPort = 38887
+			cfg.Params = wltbtc.SigNetParams
+			cfg.TrustedPeer = &electrumx.NodeServerAddr{
+				Net: "ssl", Addr: "mempool.space:60602",
+			}
+			cfg.StoreEncSeed = true
+			cfg.Testing = true
 		case "mainnet":
 			cfg.Params = &chaincfg.MainNetParams
 			cfg.NetType = electrumx.Mainnet
@@ -103,7 +122,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 
 func configure() (string, *client.ClientConfig, error) {
 	coin := flag.String("coin", "btc", "coin name")
-	net := flag.String("net", "regtest", "network type; testnet, mainnet, regtest")
+	net := flag.String("net", "regtest", "network type; testnet, testnet4, signet, mainnet, regtest")
 	pass := flag.String("pass", "", "wallet password")
 	flag.Parse()
 	cfg, err := makeBasicConfig(*coin, *net)
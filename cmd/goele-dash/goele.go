@@ -16,12 +16,12 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client/dash"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltdash"
 )
 
 var (
 	coins = []string{"dash"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -50,23 +50,23 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 		case "simnet", "regtest":
 			cfg.NetType = electrumx.Regtest
 			cfg.RPCTestPort = 28885
-			cfg.Params = &chaincfg.RegressionNetParams
+			cfg.Params = wltdash.DashRegtestParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "127.0.0.1:50002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18885
-			cfg.Params = &chaincfg.TestNet3Params
+			cfg.Params = wltdash.DashTestNetParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "dash-electrum-testnet.pshenmic.dev:50002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 		case "mainnet":
-			cfg.Params = &chaincfg.MainNetParams
+			cfg.Params = wltdash.DashMainNetParams
 			cfg.NetType = electrumx.Mainnet
 			cfg.RPCTestPort = 8885
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
@@ -109,7 +109,7 @@ func configure() (string, *client.ClientConfig, error) {
 func checkSimnetHelp(cfg *client.ClientConfig) string {
 	var help string
 	switch cfg.Params {
-	case &chaincfg.RegressionNetParams:
+	case wltdash.DashRegtestParams:
 		help = "check out simnet harness scripts at client/btc/test_harness\n" +
 			"README.md, src_harness.sh & ex.sh\n" +
 			"Then when goele starts navigate to client/btc/rpctest and use the\n" +
@@ -16,12 +16,12 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client/firo"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltfiro"
 )
 
 var (
 	coins = []string{"firo"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -44,32 +44,29 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 
 	switch coin {
 	case "firo":
-		cfg.CoinType = wallet.Bitcoin
+		cfg.CoinType = wallet.Firo
 		cfg.Coin = coin
 		switch net {
 		case "simnet", "regtest":
-			cfg.CoinType = 136
 			cfg.NetType = electrumx.Regtest
 			cfg.RPCTestPort = 28886
-			cfg.Params = &chaincfg.RegressionNetParams
+			cfg.Params = wltfiro.FiroRegtestParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "127.0.0.1:50002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
-		case "testnet", "testnet3", "testnet4":
-			cfg.CoinType = 136
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18886
-			cfg.Params = &chaincfg.TestNet3Params
+			cfg.Params = wltfiro.FiroTestNetParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "95.179.164.13:51002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 		case "mainnet":
-			cfg.CoinType = 136
-			cfg.Params = &chaincfg.MainNetParams
+			cfg.Params = wltfiro.FiroMainNetParams
 			cfg.NetType = electrumx.Mainnet
 			cfg.RPCTestPort = 8886
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
@@ -114,7 +111,7 @@ func configure() (string, *client.ClientConfig, error) {
 func checkSimnetHelp(cfg *client.ClientConfig) string {
 	var help string
 	switch cfg.Params {
-	case &chaincfg.RegressionNetParams:
+	case wltfiro.FiroRegtestParams:
 		help = "check out simnet harness scripts at client/btc/test_harness\n" +
 			"README.md, src_harness.sh & ex.sh\n" +
 			"Then when goele starts navigate to client/btc/rpctest and use the\n" +
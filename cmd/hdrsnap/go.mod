@@ -0,0 +1,3 @@
+module github.com/bisoncraft/go-electrum-client/cmd/hdrsnap
+
+go 1.19
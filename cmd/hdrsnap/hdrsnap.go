@@ -0,0 +1,169 @@
+package main
+
+// Export or import a verified blockchain headers snapshot for fast bootstrap.
+//
+// The wallet for the coin and net should not be running.
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bisoncraft/go-electrum-client/client"
+	"github.com/bisoncraft/go-electrum-client/electrumx"
+	"github.com/bisoncraft/go-electrum-client/electrumx/elxbtc"
+	"github.com/bisoncraft/go-electrum-client/electrumx/elxdash"
+	"github.com/bisoncraft/go-electrum-client/electrumx/elxfiro"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltbtc"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+var (
+	coins = []string{"btc", "dash", "firo"}
+	nets  = []string{"mainnet", "testnet", "testnet4", "signet", "regtest"}
+)
+
+func contains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
+
+// makeElectrumXConfig fills in the ElectrumX config for the coin and net the
+// same way the coin's client does.
+func makeElectrumXConfig(coin, net, netParamsFile string) (*electrumx.ElectrumXConfig, error) {
+	if !contains(coins, coin) {
+		return nil, errors.New("invalid coin")
+	}
+	if !contains(nets, net) {
+		return nil, errors.New("invalid net")
+	}
+	appDir, err := client.GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	coinNetDir := filepath.Join(appDir, coin, net)
+	err = os.MkdirAll(coinNetDir, os.ModeDir|0777)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &electrumx.ElectrumXConfig{
+		NetType:       net,
+		DataDir:       coinNetDir,
+		NetParamsFile: netParamsFile,
+	}
+	switch net {
+	case electrumx.Regtest:
+		cfg.Params = &chaincfg.RegressionNetParams
+	case electrumx.Testnet:
+		cfg.Params = &chaincfg.TestNet3Params
+	case electrumx.Testnet4:
+		cfg.Params = wltbtc.TestNet4Params
+	case electrumx.Signet:
+		cfg.Params = wltbtc.SigNetParams
+	case electrumx.Mainnet:
+		cfg.Params = &chaincfg.MainNetParams
+	}
+	switch coin {
+	case "btc":
+		_, err = elxbtc.NewElectrumXInterface(cfg)
+	case "dash":
+		_, err = elxdash.NewElectrumXInterface(cfg)
+	case "firo":
+		_, err = elxfiro.NewElectrumXInterface(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// genKey prints a new hex ed25519 signing key and it's public key.
+func genKey() error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	fmt.Println("signing key:", hex.EncodeToString(priv.Seed()))
+	fmt.Println("public key: ", hex.EncodeToString(pub))
+	return nil
+}
+
+func run() error {
+	help := flag.Bool("help", false, "usage help")
+	coin := flag.String("coin", "btc", "coin name: btc, dash, firo")
+	net := flag.String("net", "regtest", "network type; testnet, testnet4, signet, mainnet, regtest")
+	action := flag.String("action", "export", "action: 'export' or 'import' a snapshot or 'genkey' to make a signing key")
+	dir := flag.String("dir", "", "snapshot directory")
+	signKey := flag.String("signkey", "", "export: hex ed25519 signing key seed; unsigned if ''")
+	trustKey := flag.String("trustkey", "", "import: hex ed25519 public key the snapshot must be signed with; required")
+	netParams := flag.String("netparams", "", "optional network parameters JSON file overriding the coin's own")
+
+	flag.Parse()
+	if *help {
+		flag.Usage()
+		os.Exit(0)
+	}
+	if *action == "genkey" {
+		return genKey()
+	}
+	if *dir == "" {
+		return errors.New("a snapshot directory is required")
+	}
+	cfg, err := makeElectrumXConfig(*coin, *net, *netParams)
+	if err != nil {
+		return err
+	}
+
+	switch *action {
+	case "export":
+		var key ed25519.PrivateKey
+		if *signKey != "" {
+			seed, err := hex.DecodeString(*signKey)
+			if err != nil || len(seed) != ed25519.SeedSize {
+				return errors.New("invalid signing key")
+			}
+			key = ed25519.NewKeyFromSeed(seed)
+		}
+		m, err := electrumx.ExportHeadersSnapshot(cfg, *dir, key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("exported %d headers to %s\ntip: %d %s\nchainwork: %s\n",
+			m.NumHeaders, *dir, m.TipHeight, m.TipHash, m.ChainWork)
+	case "import":
+		if *trustKey == "" {
+			return electrumx.ErrNoSnapshotKey
+		}
+		key, err := electrumx.ParseSnapshotKey(*trustKey)
+		if err != nil {
+			return err
+		}
+		m, err := electrumx.ImportHeadersSnapshot(cfg, *dir, key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported %d headers from %s\ntip: %d %s\n",
+			m.NumHeaders, *dir, m.TipHeight, m.TipHash)
+	default:
+		return fmt.Errorf("unknown action %s", *action)
+	}
+	return nil
+}
+
+func main() {
+	fmt.Println("Goele hdrsnap", client.GoeleVersion)
+	err := run()
+	if err != nil {
+		fmt.Println(err, " - exiting")
+		os.Exit(1)
+	}
+}
@@ -15,12 +15,13 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client/btc"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltbtc"
 	"github.com/btcsuite/btcd/chaincfg"
 )
 
 var (
 	coins = []string{"btc"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "signet", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -57,7 +58,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
 			cfg.Params = &chaincfg.TestNet3Params
@@ -71,6 +72,26 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
+		case "testnet4":
+			cfg.NetType = electrumx.Testnet4
+			cfg.RPCTestPort = 48887
+			cfg.Params = wltbtc.TestNet4Params
+			cfg.TrustedPeer = &electrumx.NodeServerAddr{
+				Net: "ssl", Addr: "mempool.space:40002",
+			}
+			cfg.StoreEncSeed = true
+			cfg.Testing = true
+			fmt.Println(net)
+		case "signet":
+			cfg.NetType = electrumx.Signet
+			cfg.RPCTestPort = 38887
+			cfg.Params = wltbtc.SigNetParams
+			cfg.TrustedPeer = &electrumx.NodeServerAddr{
+				Net: "ssl", Addr: "mempool.space:60602",
+			}
+			cfg.StoreEncSeed = true
+			cfg.Testing = true
+			fmt.Println(net)
 		case "mainnet":
 			cfg.Params = &chaincfg.MainNetParams
 			cfg.NetType = electrumx.Mainnet
@@ -103,15 +124,18 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 	return cfg, nil
 }
 
-func configure() (string, string, string, *client.ClientConfig, error) {
+func configure() (string, string, string, string, *client.ClientConfig, error) {
 	help := flag.Bool("help", false, "usage help")
 	coin := flag.String("coin", "btc", "coin name")
-	net := flag.String("net", "regtest", "network type; testnet, mainnet, regtest")
+	net := flag.String("net", "regtest", "network type; testnet, testnet4, signet, mainnet, regtest")
 	pass := flag.String("pass", "", "wallet password")
-	action := flag.String("action", "create", "action: 'create'a new wallet or 'recreate' from seed")
+	action := flag.String("action", "create", "action: 'create'a new wallet, 'recreate' from seed or 'watch' an account xpub")
 	seed := flag.String("seed", "", "'seed words for recreate' inside ''; example: 'word1 word2 ... word12'")
 	test_wallet := flag.Bool("tw", false, "known test wallets override for regtest/testnet")
 	dbType := flag.String("dbtype", "bbolt", "set database type: 'bbolt' default, 'sqlite'")
+	legacyPath := flag.Bool("legacypath", false, "recreate on the key path and P2WPKH addresses of a wallet made before SLIP-44 coin types and address schemes")
+	scheme := flag.String("scheme", "bip84", "address scheme: 'bip84' P2WPKH default, 'bip86' P2TR, 'bip49' P2SH-P2WPKH, 'bip44' P2PKH")
+	xpub := flag.String("xpub", "", "account extended public key for watch, may have a key origin for PSBTs: '[fingerprint/84h/0h/0h]zpub..'; xpub/ypub/zpub sets the scheme unless -scheme is given")
 
 	flag.Parse()
 	if *help {
@@ -125,30 +149,42 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 	fmt.Println("seed:", *seed)
 	fmt.Println("test_wallet:", *test_wallet)
 	fmt.Println("dbtype:", *dbType)
+	fmt.Println("legacypath:", *legacyPath)
+	fmt.Println("scheme:", *scheme)
+	fmt.Println("xpub:", *xpub)
 	if *test_wallet {
+		// the test wallet harness addresses are on the legacy path
+		*legacyPath = true
 		switch *net {
 		case "regtest", "simnet":
 			*seed = "jungle pair grass super coral bubble tomato sheriff pulp cancel luggage wagon"
 		case "testnet", "testnet3":
 			*seed = "canyon trip truly ritual lonely quiz romance rose alone journey like bronze"
 		default:
-			return "", "", "", nil, errors.New("no test_wallet for mainnet")
+			return "", "", "", "", nil, errors.New("no test_wallet for mainnet")
 		}
 	}
-	if *action == "create" && *pass == "" {
-		return "", "", "", nil, errors.New("wallet create needs a password")
+	if *action == "watch" {
+		if *pass == "" {
+			return "", "", "", "", nil, errors.New("wallet watch needs a password")
+		}
+		if *xpub == "" {
+			return "", "", "", "", nil, errors.New("wallet watch needs an account xpub")
+		}
+	} else if *action == "create" && *pass == "" {
+		return "", "", "", "", nil, errors.New("wallet create needs a password")
 	} else if *action == "recreate" {
 		if *pass == "" {
-			return "", "", "", nil, errors.New("wallet recreate needs a new password - " +
+			return "", "", "", "", nil, errors.New("wallet recreate needs a new password - " +
 				"can be different to the previous password")
 		}
 		if *seed == "" {
-			return "", "", "", nil, errors.New("wallet recreate needs the old wallet seed")
+			return "", "", "", "", nil, errors.New("wallet recreate needs the old wallet seed")
 		}
 		words := strings.SplitN(*seed, " ", 12)
 		fmt.Printf("%q (len %d)\n", words, len(words))
 		if len(words) != 12 {
-			return "", "", "", nil, errors.New("a seed must have 12 words each separated by a space")
+			return "", "", "", "", nil, errors.New("a seed must have 12 words each separated by a space")
 		}
 		var bad bool
 		for _, word := range words {
@@ -158,14 +194,31 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 			}
 		}
 		if bad {
-			return "", "", "", nil, errors.New("malformed seed -- did you put extra spaces?")
+			return "", "", "", "", nil, errors.New("malformed seed -- did you put extra spaces?")
 		}
 	}
 	cfg, err := makeBasicConfig(*coin, *net)
+	if err != nil {
+		return "", "", "", "", nil, err
+	}
 	if *dbType == "sqlite" {
 		cfg.DbType = "sqlite"
 	}
-	return *action, *pass, *seed, cfg, err
+	cfg.LegacyHDPath = *legacyPath
+	var schemeSet bool
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "scheme" {
+			schemeSet = true
+		}
+	})
+	// a watch-only wallet takes its scheme from the xpub version by default
+	if *action != "watch" || schemeSet {
+		cfg.AddressScheme, err = wallet.ParseAddressScheme(*scheme)
+		if err != nil {
+			return "", "", "", "", nil, err
+		}
+	}
+	return *action, *pass, *seed, *xpub, cfg, nil
 }
 
 func checkSimnetHelp(cfg *client.ClientConfig) string {
@@ -184,7 +237,7 @@ func checkSimnetHelp(cfg *client.ClientConfig) string {
 
 func main() {
 	fmt.Println("Goele mkwallet", client.GoeleVersion)
-	action, pass, seed, cfg, err := configure()
+	action, pass, seed, xpub, cfg, err := configure()
 	fmt.Println(action, pass, seed)
 	if err != nil {
 		fmt.Println(err, " - exiting")
@@ -210,6 +263,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if action == "watch" {
+		// make a watch-only wallet and scan for the account's history
+		err = ec.CreateWatchOnlyWallet(context.TODO(), pass, xpub)
+		if err != nil {
+			fmt.Println(err, " - exiting")
+		}
+		return
+	}
+
 	// recreate the client's wallet
 	// for non-mainnet testing recreate a wallet with a known set of keys if -tw ..
 	err = ec.RecreateWallet(context.TODO(), pass, seed)
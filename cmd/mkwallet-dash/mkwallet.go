@@ -15,12 +15,12 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client/dash"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltdash"
 )
 
 var (
 	coins = []string{"dash"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -49,17 +49,17 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 		case "simnet", "regtest":
 			cfg.NetType = electrumx.Regtest
 			cfg.RPCTestPort = 28887
-			cfg.Params = &chaincfg.RegressionNetParams
+			cfg.Params = wltdash.DashRegtestParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "127.0.0.1:50002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
-			cfg.Params = &chaincfg.TestNet3Params
+			cfg.Params = wltdash.DashTestNetParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "dash-electrum-testnet.pshenmic.dev:50002",
 			}
@@ -67,7 +67,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.Testing = true
 			fmt.Println(net)
 		case "mainnet":
-			cfg.Params = &chaincfg.MainNetParams
+			cfg.Params = wltdash.DashMainNetParams
 			cfg.NetType = electrumx.Mainnet
 			cfg.RPCTestPort = 8887
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
@@ -108,6 +108,7 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 	seed := flag.String("seed", "", "'seed words for recreate' inside ''; example: 'word1 word2 ... word12'")
 	test_wallet := flag.Bool("tw", false, "known test wallets override for regtest/testnet")
 	dbType := flag.String("dbtype", "bbolt", "set database type: 'bbolt' default, 'sqlite'")
+	legacyPath := flag.Bool("legacypath", false, "recreate on the bitcoin coin type key path of a wallet made before SLIP-44 coin types")
 
 	flag.Parse()
 	if *help {
@@ -121,7 +122,10 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 	fmt.Println("seed:", *seed)
 	fmt.Println("test_wallet:", *test_wallet)
 	fmt.Println("dbtype:", *dbType)
+	fmt.Println("legacypath:", *legacyPath)
 	if *test_wallet {
+		// the test wallet harness addresses are on the legacy path
+		*legacyPath = true
 		switch *net {
 		case "regtest", "simnet":
 			*seed = "jungle pair grass super coral bubble tomato sheriff pulp cancel luggage wagon"
@@ -158,16 +162,20 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 		}
 	}
 	cfg, err := makeBasicConfig(*coin, *net)
+	if err != nil {
+		return "", "", "", nil, err
+	}
 	if *dbType == "sqlite" {
 		cfg.DbType = "sqlite"
 	}
-	return *action, *pass, *seed, cfg, err
+	cfg.LegacyHDPath = *legacyPath
+	return *action, *pass, *seed, cfg, nil
 }
 
 func checkSimnetHelp(cfg *client.ClientConfig) string {
 	var help string
 	switch cfg.Params {
-	case &chaincfg.RegressionNetParams:
+	case wltdash.DashRegtestParams:
 		help = "check out simnet harness scripts at client/btc/test_harness\n" +
 			"README.md, src_harness.sh & ex.sh\n" +
 			"Then when goele starts navigate to client/btc/rpctest and use the\n" +
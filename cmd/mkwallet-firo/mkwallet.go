@@ -15,12 +15,12 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client/firo"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltfiro"
 )
 
 var (
 	coins = []string{"firo"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -47,21 +47,19 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 		cfg.Coin = coin
 		switch net {
 		case "simnet", "regtest":
-			cfg.CoinType = 136
 			cfg.NetType = electrumx.Regtest
 			cfg.RPCTestPort = 28887
-			cfg.Params = &chaincfg.RegressionNetParams
+			cfg.Params = wltfiro.FiroRegtestParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "127.0.0.1:50002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
-		case "testnet", "testnet3", "testnet4":
-			cfg.CoinType = 136
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
-			cfg.Params = &chaincfg.TestNet3Params
+			cfg.Params = wltfiro.FiroTestNetParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "95.179.164.13:51002",
 			}
@@ -69,8 +67,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.Testing = true
 			fmt.Println(net)
 		case "mainnet":
-			cfg.CoinType = 136
-			cfg.Params = &chaincfg.MainNetParams
+			cfg.Params = wltfiro.FiroMainNetParams
 			cfg.NetType = electrumx.Mainnet
 			cfg.RPCTestPort = 8887
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
@@ -113,6 +110,7 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 	seed := flag.String("seed", "", "'seed words for recreate' inside ''; example: 'word1 word2 ... word12'")
 	test_wallet := flag.Bool("tw", false, "known test wallets override for regtest/testnet")
 	dbType := flag.String("dbtype", "bbolt", "set database type: 'bbolt' default, 'sqlite'")
+	legacyPath := flag.Bool("legacypath", false, "recreate on the bitcoin coin type key path of a wallet made before SLIP-44 coin types")
 
 	flag.Parse()
 	if *help {
@@ -126,7 +124,10 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 	fmt.Println("seed:", *seed)
 	fmt.Println("test_wallet:", *test_wallet)
 	fmt.Println("dbtype:", *dbType)
+	fmt.Println("legacypath:", *legacyPath)
 	if *test_wallet {
+		// the test wallet harness addresses are on the legacy path
+		*legacyPath = true
 		switch *net {
 		case "regtest", "simnet":
 			*seed = "jungle pair grass super coral bubble tomato sheriff pulp cancel luggage wagon"
@@ -163,16 +164,20 @@ func configure() (string, string, string, *client.ClientConfig, error) {
 		}
 	}
 	cfg, err := makeBasicConfig(*coin, *net)
+	if err != nil {
+		return "", "", "", nil, err
+	}
 	if *dbType == "sqlite" {
 		cfg.DbType = "sqlite"
 	}
-	return *action, *pass, *seed, cfg, err
+	cfg.LegacyHDPath = *legacyPath
+	return *action, *pass, *seed, cfg, nil
 }
 
 func checkSimnetHelp(cfg *client.ClientConfig) string {
 	var help string
 	switch cfg.Params {
-	case &chaincfg.RegressionNetParams:
+	case wltfiro.FiroRegtestParams:
 		help = "check out simnet harness scripts at client/btc/test_harness\n" +
 			"README.md, src_harness.sh & ex.sh\n" +
 			"Then when goele starts navigate to client/btc/rpctest and use the\n" +
@@ -16,6 +16,7 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltbtc"
 	"github.com/btcsuite/btcd/chaincfg"
 )
 
@@ -23,7 +24,7 @@ const blockchain_headers = "blockchain_headers"
 
 var (
 	coins = []string{"btc"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "signet", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -60,7 +61,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
 			cfg.Params = &chaincfg.TestNet3Params
@@ -74,6 +75,26 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
+		case "testnet4":
+			cfg.NetType = electrumx.Testnet4
+			cfg.RPCTestPort = 48887
+			cfg.Params = wltbtc.TestNet4Params
+			cfg.TrustedPeer = &electrumx.NodeServerAddr{
+				Net: "ssl", Addr: "mempool.space:40002",
+			}
+			cfg.StoreEncSeed = true
+			cfg.Testing = true
+			fmt.Println(net)
+		case "signet":
+			cfg.NetType = electrumx.Signet
+			cfg.RPCTestPort = 38887
+			cfg.Params = wltbtc.SigNetParams
+			cfg.TrustedPeer = &electrumx.NodeServerAddr{
+				Net: "ssl", Addr: "mempool.space:60602",
+			}
+			cfg.StoreEncSeed = true
+			cfg.Testing = true
+			fmt.Println(net)
 		case "mainnet":
 			cfg.Params = &chaincfg.MainNetParams
 			cfg.NetType = electrumx.Mainnet
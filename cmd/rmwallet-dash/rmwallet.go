@@ -16,14 +16,14 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltdash"
 )
 
 const blockchain_headers = "blockchain_headers"
 
 var (
 	coins = []string{"dash"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -46,23 +46,23 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 
 	switch coin {
 	case "dash":
-		cfg.CoinType = wallet.Bitcoin
+		cfg.CoinType = wallet.Dash
 		cfg.Coin = coin
 		switch net {
 		case "simnet", "regtest":
 			cfg.NetType = electrumx.Regtest
 			cfg.RPCTestPort = 28887
-			cfg.Params = &chaincfg.RegressionNetParams
+			cfg.Params = wltdash.DashRegtestParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "127.0.0.1:50002",
 			}
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
-			cfg.Params = &chaincfg.TestNet3Params
+			cfg.Params = wltdash.DashTestNetParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				Net: "ssl", Addr: "dash-electrum-testnet.pshenmic.dev:50002",
 			}
@@ -70,7 +70,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.Testing = true
 			fmt.Println(net)
 		case "mainnet":
-			cfg.Params = &chaincfg.MainNetParams
+			cfg.Params = wltdash.DashMainNetParams
 			cfg.NetType = electrumx.Mainnet
 			cfg.RPCTestPort = 8887
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
@@ -150,7 +150,7 @@ func main() {
 		}
 	}
 
-	if cfg.Params == &chaincfg.RegressionNetParams {
+	if cfg.Params == wltdash.DashRegtestParams {
 		headers := path.Join(cfg.DataDir, blockchain_headers)
 		fmt.Println(headers)
 		if _, err := os.Stat(headers); errors.Is(err, os.ErrNotExist) {
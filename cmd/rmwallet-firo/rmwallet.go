@@ -16,14 +16,14 @@ import (
 	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/bisoncraft/go-electrum-client/wallet/wltfiro"
 )
 
 const blockchain_headers = "blockchain_headers"
 
 var (
 	coins = []string{"btc"} // add as implemented
-	nets  = []string{"mainnet", "testnet", "testnet3", "testnet4", "regtest", "simnet"}
+	nets  = []string{"mainnet", "testnet", "testnet3", "regtest", "simnet"}
 )
 
 func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
@@ -46,13 +46,13 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 
 	switch coin {
 	case "btc":
-		cfg.CoinType = wallet.Bitcoin
+		cfg.CoinType = wallet.Firo
 		cfg.Coin = coin
 		switch net {
 		case "simnet", "regtest":
 			cfg.NetType = electrumx.Regtest
 			cfg.RPCTestPort = 28887
-			cfg.Params = &chaincfg.RegressionNetParams
+			cfg.Params = wltfiro.FiroRegtestParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				// Net: "ssl", Addr: "127.0.0.1:57002", // debug server
 				Net: "ssl", Addr: "127.0.0.1:53002",
@@ -60,10 +60,10 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.StoreEncSeed = true
 			cfg.Testing = true
 			fmt.Println(net)
-		case "testnet", "testnet3", "testnet4":
+		case "testnet", "testnet3":
 			cfg.NetType = electrumx.Testnet
 			cfg.RPCTestPort = 18887
-			cfg.Params = &chaincfg.TestNet3Params
+			cfg.Params = wltfiro.FiroTestNetParams
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
 				// Net: "ssl", Addr: "testnet.aranguren.org:51002",
 				// Net: "tcp", Addr: "testnet.aranguren.org:51001",
@@ -75,7 +75,7 @@ func makeBasicConfig(coin, net string) (*client.ClientConfig, error) {
 			cfg.Testing = true
 			fmt.Println(net)
 		case "mainnet":
-			cfg.Params = &chaincfg.MainNetParams
+			cfg.Params = wltfiro.FiroMainNetParams
 			cfg.NetType = electrumx.Mainnet
 			cfg.RPCTestPort = 8887
 			cfg.TrustedPeer = &electrumx.NodeServerAddr{
@@ -154,7 +154,7 @@ func main() {
 		}
 	}
 
-	if cfg.Params == &chaincfg.RegressionNetParams {
+	if cfg.Params == wltfiro.FiroRegtestParams {
 		headers := path.Join(cfg.DataDir, blockchain_headers)
 		fmt.Println(headers)
 		if _, err := os.Stat(headers); errors.Is(err, os.ErrNotExist) {
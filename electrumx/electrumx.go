@@ -8,7 +8,9 @@ import (
 	"context"
 	"encoding/hex"
 	"io"
+	"math/big"
 	"net"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
 )
@@ -66,9 +68,11 @@ const (
 )
 
 const (
-	MAINNET = "mainnet"
-	TESTNET = "testnet"
-	REGTEST = "regtest"
+	MAINNET  = "mainnet"
+	TESTNET  = "testnet"
+	TESTNET4 = "testnet4"
+	SIGNET   = "signet"
+	REGTEST  = "regtest"
 )
 
 // TODO: remove
@@ -86,10 +90,14 @@ func (wh *WireHash) String() string {
 }
 
 type BlockHeader struct {
-	Version int32
-	Hash    WireHash
-	Prev    WireHash
-	Merkle  WireHash
+	Version   int32
+	Hash      WireHash
+	Prev      WireHash
+	Merkle    WireHash
+	Timestamp uint32
+	Bits      uint32
+	// 32 bit for most coins; FiroPoW has a 64 bit nonce
+	Nonce uint64
 }
 
 type HeaderDeserializer interface {
@@ -98,9 +106,18 @@ type HeaderDeserializer interface {
 
 // For client use
 type ClientBlockHeader struct {
-	Hash   string
-	Prev   string
-	Merkle string
+	Height    int64
+	Version   int32
+	Hash      string
+	Prev      string
+	Merkle    string
+	Timestamp time.Time
+	Bits      uint32
+	Nonce     uint64
+	// Work is the proof of work this block represents, calculated from Bits.
+	// Cumulative chainwork is not known as the headers are only stored from
+	// the coin's start point checkpoint.
+	Work *big.Int
 }
 
 type ElectrumXConfig struct {
@@ -121,10 +138,27 @@ type ElectrumXConfig struct {
 	// Filled in by each coin in ElectrumXInterface
 	StartPoint int64
 
+	// Block hash at the start point. A headers snapshot must start with it;
+	// if "" the snapshot's signature vouches for it's first header.
+	// Filled in by each coin in ElectrumXInterface
+	StartHash string
+
 	// Genesis for each network: mainnet, testnet, regtest
 	// Filled in by each coin in ElectrumXInterface
 	Genesis string
 
+	// Known block hashes by height for each network. A headers snapshot must
+	// match any it covers.
+	// Filled in by each coin in ElectrumXInterface
+	Checkpoints []Checkpoint
+
+	// True if the block hash is also the proof of work hash so that headers
+	// can be checked against their target. PowLimit is the easiest target
+	// allowed or nil if not checked.
+	// Filled in by each coin in ElectrumXInterface
+	HashIsPoW bool
+	PowLimit  *big.Int
+
 	// Maximum online peers for each network
 	// Filled in by each coin in ElectrumXInterface
 	MaxOnlinePeers int
@@ -133,7 +167,16 @@ type ElectrumXConfig struct {
 	// Filled in by each coin in ElectrumXInterface
 	Flags uint8
 
-	// mainnet, testnet, regtest
+	// Servers added to the known servers when there are none
+	// Filled in by each coin in ElectrumXInterface
+	SeedServers []*NodeServerAddr
+
+	// Optional JSON file of network parameters replacing those embedded for
+	// the coin. See LoadNetParams.
+	NetParamsFile string
+
+	// mainnet, testnet, testnet4, signet, regtest. Each network has it's own
+	// data directory.
 	NetType string
 
 	// NetType parameters.. can chaincfg adapt for all coins? for now we use the NetType
@@ -155,12 +198,29 @@ type ElectrumXConfig struct {
 	// For now it *must be set*
 	TrustedPeer *NodeServerAddr
 
+	// Optional directory of a headers snapshot made by ExportHeadersSnapshot.
+	// If it has more headers than the headers file it is verified and used
+	// instead of fetching those headers from the network.
+	HeadersSnapshot string
+
+	// Hex encoded ed25519 public key a headers snapshot must be signed with.
+	// Required to import a snapshot.
+	HeadersSnapshotKey string
+
 	// If not testing do not overwrite existing wallet files
 	Testing bool
 }
 
+// Checkpoint is a known block hash at a height.
+type Checkpoint struct {
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
 var Regtest string = "regtest"
 var Testnet string = "testnet"
+var Testnet4 string = "testnet4"
+var Signet string = "signet"
 var Mainnet string = "mainnet"
 
 var DebugMode bool
@@ -170,12 +230,21 @@ type ElectrumX interface {
 
 	GetTip() int64
 	GetSyncStatus() bool
+	IsConnected() bool
 	GetBlockHeader(height int64) (*ClientBlockHeader, error)
 	GetBlockHeaders(startHeight int64, blockCount int64) ([]*ClientBlockHeader, error)
-	GetTipChangeNotify() (<-chan int64, error)
+	GetBlockHeaderByHash(hash string) (*ClientBlockHeader, error)
+	GetHeightForBlockHash(hash string) (int64, error)
+	MedianTimePast(height int64) (time.Time, error)
+	FetchBlockHeader(ctx context.Context, height int64) (*ClientBlockHeader, error)
 
 	SubscribeScripthashNotify(ctx context.Context, scripthash string) (*ScripthashStatusResult, error)
 	UnsubscribeScripthashNotify(ctx context.Context, scripthash string)
+
+	SubscribeEvents(bufSize int, policy OverflowPolicy, types ...EventType) (*EventSubscription, error)
+	// Deprecated: use SubscribeEvents.
+	GetTipChangeNotify() (<-chan int64, error)
+	// Deprecated: use SubscribeEvents.
 	GetScripthashNotify() (<-chan *ScripthashStatusResult, error)
 
 	GetHistory(ctx context.Context, scripthash string) (HistoryResult, error)
@@ -185,4 +254,11 @@ type ElectrumX interface {
 	//
 	EstimateFeeRate(ctx context.Context, confTarget int64) (int64, error)
 	Broadcast(ctx context.Context, rawTx string) (string, error)
+	//
+	AddServer(addr *NodeServerAddr) error
+	RemoveServer(addr *NodeServerAddr) error
+	BanServer(addr *NodeServerAddr) error
+	ListKnownServers() ([]*KnownServer, error)
+	SetPreferredLeader(addr *NodeServerAddr) error
+	ForceLeaderSwitch() error
 }
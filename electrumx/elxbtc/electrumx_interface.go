@@ -2,9 +2,11 @@ package elxbtc
 
 import (
 	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/btcsuite/btcd/wire"
@@ -12,23 +14,16 @@ import (
 
 // These configure ElectrumX network for: BTC
 const (
-	BTC_COIN                     = "btc"
-	BTC_HEADER_SIZE              = 80
-	BTC_STARTPOINT_REGTEST       = 0
-	BTC_STARTPOINT_TESTNET       = 2560000
-	BTC_STARTPOINT_MAINNET       = 823000
-	BTC_GENESIS_REGTEST          = "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206"
-	BTC_GENESIS_TESTNET          = "000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943"
-	BTC_GENESIS_MAINNET          = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
-	BTC_MAX_ONLINE_PEERS_REGTEST = 0
-	BTC_MAX_ONLINE_PEERS_TESTNET = 3
-	BTC_MAX_ONLINE_PEERS_MAINNET = 10
-	BTC_MAX_ONION                = 2
-	BTC_STRATEGY_FLAGS_REGTEST   = electrumx.NoDeleteKnownPeers
-	BTC_STRATEGY_FLAGS_TESTNET   = electrumx.Default
-	BTC_STRATEGY_FLAGS_MAINNET   = electrumx.Default
+	BTC_COIN        = "btc"
+	BTC_HEADER_SIZE = 80
 )
 
+// Start points, genesis, peer limits, strategy flags and seed servers for each
+// network. Can be overridden with ElectrumXConfig NetParamsFile.
+//
+//go:embed netparams.json
+var netParamsJSON []byte
+
 type headerDeserialzer struct{}
 
 func (d headerDeserialzer) Deserialize(r io.Reader) (*electrumx.BlockHeader, error) {
@@ -43,6 +38,9 @@ func (d headerDeserialzer) Deserialize(r io.Reader) (*electrumx.BlockHeader, err
 	blockHeader.Hash = electrumx.WireHash(chainHash)
 	blockHeader.Prev = electrumx.WireHash(wireHdr.PrevBlock)
 	blockHeader.Merkle = electrumx.WireHash(wireHdr.MerkleRoot)
+	blockHeader.Timestamp = uint32(wireHdr.Timestamp.Unix())
+	blockHeader.Bits = wireHdr.Bits
+	blockHeader.Nonce = uint64(wireHdr.Nonce)
 	return blockHeader, nil
 }
 
@@ -54,26 +52,19 @@ type ElectrumXInterface struct {
 func NewElectrumXInterface(config *electrumx.ElectrumXConfig) (*ElectrumXInterface, error) {
 	config.Coin = BTC_COIN
 	config.BlockHeaderSize = BTC_HEADER_SIZE
-	config.MaxOnion = BTC_MAX_ONION
-
-	switch config.NetType {
-	case electrumx.Regtest:
-		config.Flags = BTC_STRATEGY_FLAGS_REGTEST
-		config.Genesis = BTC_GENESIS_REGTEST
-		config.StartPoint = BTC_STARTPOINT_REGTEST
-		config.MaxOnlinePeers = BTC_MAX_ONLINE_PEERS_REGTEST
-	case electrumx.Testnet:
-		config.Flags = BTC_STRATEGY_FLAGS_TESTNET
-		config.Genesis = BTC_GENESIS_TESTNET
-		config.StartPoint = BTC_STARTPOINT_TESTNET
-		config.MaxOnlinePeers = BTC_MAX_ONLINE_PEERS_TESTNET
-	case electrumx.Mainnet:
-		config.Flags = BTC_STRATEGY_FLAGS_MAINNET
-		config.Genesis = BTC_GENESIS_MAINNET
-		config.StartPoint = BTC_STARTPOINT_MAINNET
-		config.MaxOnlinePeers = BTC_MAX_ONLINE_PEERS_MAINNET
-	default:
-		return nil, fmt.Errorf("config error")
+
+	netParams, err := electrumx.LoadNetParams(netParamsJSON, BTC_COIN, config.NetType, config.NetParamsFile)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+	netParams.Apply(config)
+	config.HashIsPoW = true
+	if config.Params != nil {
+		for _, cp := range config.Params.Checkpoints {
+			config.Checkpoints = append(config.Checkpoints,
+				electrumx.Checkpoint{Height: int64(cp.Height), Hash: cp.Hash.String()})
+		}
+		config.PowLimit = config.Params.PowLimit
 	}
 
 	config.HeaderDeserializer = &headerDeserialzer{}
@@ -114,6 +105,13 @@ func (x *ElectrumXInterface) GetSyncStatus() bool {
 	return x.network.Synced()
 }
 
+func (x *ElectrumXInterface) IsConnected() bool {
+	if x.network == nil {
+		return false
+	}
+	return x.network.Connected()
+}
+
 func (x *ElectrumXInterface) GetBlockHeader(height int64) (*electrumx.ClientBlockHeader, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -128,6 +126,42 @@ func (x *ElectrumXInterface) GetBlockHeaders(startHeight int64, blockCount int64
 	return x.network.BlockHeaders(startHeight, blockCount)
 }
 
+func (x *ElectrumXInterface) GetBlockHeaderByHash(hash string) (*electrumx.ClientBlockHeader, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.BlockHeaderByHash(hash)
+}
+
+func (x *ElectrumXInterface) GetHeightForBlockHash(hash string) (int64, error) {
+	if x.network == nil {
+		return 0, ErrNoNetwork
+	}
+	return x.network.HeightForBlockHash(hash)
+}
+
+func (x *ElectrumXInterface) MedianTimePast(height int64) (time.Time, error) {
+	if x.network == nil {
+		return time.Time{}, ErrNoNetwork
+	}
+	return x.network.MedianTimePast(height)
+}
+
+func (x *ElectrumXInterface) FetchBlockHeader(ctx context.Context, height int64) (*electrumx.ClientBlockHeader, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.FetchBlockHeader(ctx, height)
+}
+
+func (x *ElectrumXInterface) SubscribeEvents(bufSize int, policy electrumx.OverflowPolicy, types ...electrumx.EventType) (*electrumx.EventSubscription, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.SubscribeEvents(bufSize, policy, types...), nil
+}
+
+// Deprecated: use SubscribeEvents.
 func (x *ElectrumXInterface) GetTipChangeNotify() (<-chan int64, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -135,6 +169,7 @@ func (x *ElectrumXInterface) GetTipChangeNotify() (<-chan int64, error) {
 	return x.network.GetTipChangeNotify(), nil
 }
 
+// Deprecated: use SubscribeEvents.
 func (x *ElectrumXInterface) GetScripthashNotify() (<-chan *electrumx.ScripthashStatusResult, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -197,3 +232,45 @@ func (x *ElectrumXInterface) EstimateFeeRate(ctx context.Context, confTarget int
 	}
 	return x.network.EstimateFeeRate(ctx, confTarget)
 }
+
+func (x *ElectrumXInterface) AddServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.AddServer(addr)
+}
+
+func (x *ElectrumXInterface) RemoveServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.RemoveServer(addr)
+}
+
+func (x *ElectrumXInterface) BanServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.BanServer(addr)
+}
+
+func (x *ElectrumXInterface) ListKnownServers() ([]*electrumx.KnownServer, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.ListKnownServers(), nil
+}
+
+func (x *ElectrumXInterface) SetPreferredLeader(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.SetPreferredLeader(addr)
+}
+
+func (x *ElectrumXInterface) ForceLeaderSwitch() error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.ForceLeaderSwitch()
+}
@@ -3,9 +3,12 @@ package elxdash
 import (
 	"bytes"
 	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"time"
 
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/btcsuite/btcd/wire"
@@ -14,23 +17,19 @@ import (
 
 // These configure ElectrumX network for: DASH
 const (
-	DASH_COIN                     = "dash"
-	DASH_HEADER_SIZE              = 80 // https://docs.dash.org/en/stable/docs/core/reference/block-chain-block-headers.html
-	DASH_STARTPOINT_REGTEST       = 0
-	DASH_STARTPOINT_TESTNET       = 1225000 // March/April 2025
-	DASH_STARTPOINT_MAINNET       = 2248000 // March/April 2025
-	DASH_GENESIS_REGTEST          = "000008ca1832a4baf228eb1553c03d3a2c8e02399550dd6ea8d65cec3ef23d2e"
-	DASH_GENESIS_TESTNET          = "00000bafbc94add76cb75e2ec92894837288a481e5c005f6563d91623bf8bc2c"
-	DASH_GENESIS_MAINNET          = "00000ffd590b1485b3caadc19b22e6379c733355108f107a430458cdf3407ab6"
-	DASH_MAX_ONLINE_PEERS_REGTEST = 0
-	DASH_MAX_ONLINE_PEERS_TESTNET = 1
-	DASH_MAX_ONLINE_PEERS_MAINNET = 3
-	DASH_MAX_ONION                = 1
-	DASH_STRATEGY_FLAGS_REGTEST   = electrumx.NoDeleteKnownPeers
-	DASH_STRATEGY_FLAGS_TESTNET   = electrumx.NoDeleteKnownPeers
-	DASH_STRATEGY_FLAGS_MAINNET   = electrumx.NoDeleteKnownPeers // 1..5 servers per kool_guy
+	DASH_COIN        = "dash"
+	DASH_HEADER_SIZE = 80 // https://docs.dash.org/en/stable/docs/core/reference/block-chain-block-headers.html
 )
 
+// Start points, genesis, peer limits, strategy flags and seed servers for each
+// network. Can be overridden with ElectrumXConfig NetParamsFile.
+//
+//go:embed netparams.json
+var netParamsJSON []byte
+
+// Easiest X11 proof of work target on mainnet and testnet: ~uint256(0) >> 20
+var dashPowLimit = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 236), big.NewInt(1))
+
 type headerDeserialzer struct{}
 
 // Deserialize deserializes a Dash block header and keeps an in memory copy of
@@ -61,6 +60,9 @@ func (d headerDeserialzer) Deserialize(r io.Reader) (*electrumx.BlockHeader, err
 	blockHeader.Version = wireHdr.Version
 	blockHeader.Prev = electrumx.WireHash(wireHdr.PrevBlock)
 	blockHeader.Merkle = electrumx.WireHash(wireHdr.MerkleRoot)
+	blockHeader.Timestamp = uint32(wireHdr.Timestamp.Unix())
+	blockHeader.Bits = wireHdr.Bits
+	blockHeader.Nonce = uint64(wireHdr.Nonce)
 	return blockHeader, nil
 }
 
@@ -72,27 +74,17 @@ type ElectrumXInterface struct {
 func NewElectrumXInterface(config *electrumx.ElectrumXConfig) (*ElectrumXInterface, error) {
 	config.Coin = DASH_COIN
 	config.BlockHeaderSize = DASH_HEADER_SIZE
-	config.MaxOnion = DASH_MAX_ONION
 
+	netParams, err := electrumx.LoadNetParams(netParamsJSON, DASH_COIN, config.NetType, config.NetParamsFile)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+	netParams.Apply(config)
 	switch config.NetType {
-	case electrumx.Regtest:
-		config.Flags = DASH_STRATEGY_FLAGS_REGTEST
-		config.Genesis = DASH_GENESIS_REGTEST
-		config.StartPoint = DASH_STARTPOINT_REGTEST
-		config.MaxOnlinePeers = DASH_MAX_ONLINE_PEERS_REGTEST
-	case electrumx.Testnet:
-		config.Flags = DASH_STRATEGY_FLAGS_TESTNET
-		config.Genesis = DASH_GENESIS_TESTNET
-		config.StartPoint = DASH_STARTPOINT_TESTNET
-		config.MaxOnlinePeers = DASH_MAX_ONLINE_PEERS_TESTNET
-	case electrumx.Mainnet:
-		config.Flags = DASH_STRATEGY_FLAGS_MAINNET
-		config.Genesis = DASH_GENESIS_MAINNET
-		config.StartPoint = DASH_STARTPOINT_MAINNET
-		config.MaxOnlinePeers = DASH_MAX_ONLINE_PEERS_MAINNET
-	default:
-		return nil, fmt.Errorf("config error")
+	case electrumx.Testnet, electrumx.Mainnet:
+		config.PowLimit = dashPowLimit
 	}
+	config.HashIsPoW = true
 
 	config.HeaderDeserializer = &headerDeserialzer{}
 	x := ElectrumXInterface{
@@ -132,6 +124,13 @@ func (x *ElectrumXInterface) GetSyncStatus() bool {
 	return x.network.Synced()
 }
 
+func (x *ElectrumXInterface) IsConnected() bool {
+	if x.network == nil {
+		return false
+	}
+	return x.network.Connected()
+}
+
 func (x *ElectrumXInterface) GetBlockHeader(height int64) (*electrumx.ClientBlockHeader, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -146,6 +145,42 @@ func (x *ElectrumXInterface) GetBlockHeaders(startHeight int64, blockCount int64
 	return x.network.BlockHeaders(startHeight, blockCount)
 }
 
+func (x *ElectrumXInterface) GetBlockHeaderByHash(hash string) (*electrumx.ClientBlockHeader, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.BlockHeaderByHash(hash)
+}
+
+func (x *ElectrumXInterface) GetHeightForBlockHash(hash string) (int64, error) {
+	if x.network == nil {
+		return 0, ErrNoNetwork
+	}
+	return x.network.HeightForBlockHash(hash)
+}
+
+func (x *ElectrumXInterface) MedianTimePast(height int64) (time.Time, error) {
+	if x.network == nil {
+		return time.Time{}, ErrNoNetwork
+	}
+	return x.network.MedianTimePast(height)
+}
+
+func (x *ElectrumXInterface) FetchBlockHeader(ctx context.Context, height int64) (*electrumx.ClientBlockHeader, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.FetchBlockHeader(ctx, height)
+}
+
+func (x *ElectrumXInterface) SubscribeEvents(bufSize int, policy electrumx.OverflowPolicy, types ...electrumx.EventType) (*electrumx.EventSubscription, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.SubscribeEvents(bufSize, policy, types...), nil
+}
+
+// Deprecated: use SubscribeEvents.
 func (x *ElectrumXInterface) GetTipChangeNotify() (<-chan int64, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -153,6 +188,7 @@ func (x *ElectrumXInterface) GetTipChangeNotify() (<-chan int64, error) {
 	return x.network.GetTipChangeNotify(), nil
 }
 
+// Deprecated: use SubscribeEvents.
 func (x *ElectrumXInterface) GetScripthashNotify() (<-chan *electrumx.ScripthashStatusResult, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -215,3 +251,45 @@ func (x *ElectrumXInterface) EstimateFeeRate(ctx context.Context, confTarget int
 	}
 	return x.network.EstimateFeeRate(ctx, confTarget)
 }
+
+func (x *ElectrumXInterface) AddServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.AddServer(addr)
+}
+
+func (x *ElectrumXInterface) RemoveServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.RemoveServer(addr)
+}
+
+func (x *ElectrumXInterface) BanServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.BanServer(addr)
+}
+
+func (x *ElectrumXInterface) ListKnownServers() ([]*electrumx.KnownServer, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.ListKnownServers(), nil
+}
+
+func (x *ElectrumXInterface) SetPreferredLeader(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.SetPreferredLeader(addr)
+}
+
+func (x *ElectrumXInterface) ForceLeaderSwitch() error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.ForceLeaderSwitch()
+}
@@ -3,9 +3,12 @@ package elxfiro
 import (
 	"bytes"
 	"context"
+	_ "embed"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/bisoncraft/go-electrum-client/electrumx"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -24,21 +27,14 @@ const (
 	FIRO_HEADER_SIZE_REGTEST = 80
 	FIRO_HEADER_SIZE_FIROPOW = 120
 	// FIRO_HEADER_SIZE              = 80 // check this for MTP legacy. Now FiroPoW (ProgPow clone) .. should be 80
-	FIRO_STARTPOINT_REGTEST       = 0
-	FIRO_STARTPOINT_TESTNET       = 170_000
-	FIRO_STARTPOINT_MAINNET       = 987_000
-	FIRO_GENESIS_REGTEST          = "a42b98f04cc2916e8adfb5d9db8a2227c4629bc205748ed2f33180b636ee885b"
-	FIRO_GENESIS_TESTNET          = "aa22adcc12becaf436027ffe62a8fb21b234c58c23865291e5dc52cf53f64fca"
-	FIRO_GENESIS_MAINNET          = "4381deb85b1b2c9843c222944b616d997516dcbd6a964e1eaf0def0830695233"
-	FIRO_MAX_ONLINE_PEERS_REGTEST = 0
-	FIRO_MAX_ONLINE_PEERS_TESTNET = 0 // only one testnet server 95.179.164.13:51002 - v0.14.14.0
-	FIRO_MAX_ONLINE_PEERS_MAINNET = 3 // only 4 servers                              - v0.14.14.0
-	FIRO_MAX_ONION                = 0
-	FIRO_STRATEGY_FLAGS_REGTEST   = electrumx.NoDeleteKnownPeers // only one server
-	FIRO_STRATEGY_FLAGS_TESTNET   = electrumx.NoDeleteKnownPeers // only one server
-	FIRO_STRATEGY_FLAGS_MAINNET   = electrumx.NoDeleteKnownPeers // 4 servers
 )
 
+// Start points, genesis, peer limits, strategy flags and seed servers for each
+// network. Can be overridden with ElectrumXConfig NetParamsFile.
+//
+//go:embed netparams.json
+var netParamsJSON []byte
+
 type headerDeserializer struct{}
 
 func (d headerDeserializer) Deserialize(r io.Reader) (*electrumx.BlockHeader, error) {
@@ -64,6 +60,11 @@ func (d headerDeserializer) Deserialize(r io.Reader) (*electrumx.BlockHeader, er
 	blockHeader.Version = wireHdr.Version
 	blockHeader.Prev = electrumx.WireHash(wireHdr.PrevBlock)
 	blockHeader.Merkle = electrumx.WireHash(wireHdr.MerkleRoot)
+	blockHeader.Timestamp = uint32(wireHdr.Timestamp.Unix())
+	blockHeader.Bits = wireHdr.Bits
+	// FiroPoW: the 4 bytes after bits are the block height and the 64 bit
+	// nonce follows in the extra bytes
+	blockHeader.Nonce = binary.LittleEndian.Uint64(fullHeader[FIRO_HEADER_SIZE : FIRO_HEADER_SIZE+8])
 	return blockHeader, nil
 }
 
@@ -81,6 +82,9 @@ func (d regtestHeaderDeserializer) Deserialize(r io.Reader) (*electrumx.BlockHea
 	blockHeader.Hash = electrumx.WireHash(chainHash)
 	blockHeader.Prev = electrumx.WireHash(wireHdr.PrevBlock)
 	blockHeader.Merkle = electrumx.WireHash(wireHdr.MerkleRoot)
+	blockHeader.Timestamp = uint32(wireHdr.Timestamp.Unix())
+	blockHeader.Bits = wireHdr.Bits
+	blockHeader.Nonce = uint64(wireHdr.Nonce)
 	return blockHeader, nil
 }
 
@@ -91,33 +95,24 @@ type ElectrumXInterface struct {
 
 func NewElectrumXInterface(config *electrumx.ElectrumXConfig) (*ElectrumXInterface, error) {
 	config.Coin = FIRO_COIN
-	config.MaxOnion = FIRO_MAX_ONION
 
 	switch config.NetType {
 	case electrumx.Regtest:
-		config.Flags = FIRO_STRATEGY_FLAGS_REGTEST
 		config.HeaderDeserializer = regtestHeaderDeserializer{}
 		config.BlockHeaderSize = FIRO_HEADER_SIZE_REGTEST
-		config.Genesis = FIRO_GENESIS_REGTEST
-		config.StartPoint = FIRO_STARTPOINT_REGTEST
-		config.MaxOnlinePeers = FIRO_MAX_ONLINE_PEERS_REGTEST
-	case electrumx.Testnet:
-		config.Flags = FIRO_STRATEGY_FLAGS_TESTNET
-		config.HeaderDeserializer = headerDeserializer{}
-		config.BlockHeaderSize = FIRO_HEADER_SIZE_FIROPOW
-		config.Genesis = FIRO_GENESIS_TESTNET
-		config.StartPoint = FIRO_STARTPOINT_TESTNET
-		config.MaxOnlinePeers = FIRO_MAX_ONLINE_PEERS_TESTNET
-	case electrumx.Mainnet:
-		config.Flags = FIRO_STRATEGY_FLAGS_TESTNET
+	case electrumx.Testnet, electrumx.Mainnet:
 		config.HeaderDeserializer = headerDeserializer{}
 		config.BlockHeaderSize = FIRO_HEADER_SIZE_FIROPOW
-		config.Genesis = FIRO_GENESIS_MAINNET
-		config.StartPoint = FIRO_STARTPOINT_MAINNET
-		config.MaxOnlinePeers = FIRO_MAX_ONLINE_PEERS_MAINNET
 	default:
 		return nil, fmt.Errorf("config error")
 	}
+	netParams, err := electrumx.LoadNetParams(netParamsJSON, FIRO_COIN, config.NetType, config.NetParamsFile)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+	netParams.Apply(config)
+	// the block hash is not the MTP or FiroPoW proof of work hash
+	config.HashIsPoW = false
 
 	x := ElectrumXInterface{
 		config:  config,
@@ -156,6 +151,13 @@ func (x *ElectrumXInterface) GetSyncStatus() bool {
 	return x.network.Synced()
 }
 
+func (x *ElectrumXInterface) IsConnected() bool {
+	if x.network == nil {
+		return false
+	}
+	return x.network.Connected()
+}
+
 func (x *ElectrumXInterface) GetBlockHeader(height int64) (*electrumx.ClientBlockHeader, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -170,6 +172,42 @@ func (x *ElectrumXInterface) GetBlockHeaders(startHeight int64, blockCount int64
 	return x.network.BlockHeaders(startHeight, blockCount)
 }
 
+func (x *ElectrumXInterface) GetBlockHeaderByHash(hash string) (*electrumx.ClientBlockHeader, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.BlockHeaderByHash(hash)
+}
+
+func (x *ElectrumXInterface) GetHeightForBlockHash(hash string) (int64, error) {
+	if x.network == nil {
+		return 0, ErrNoNetwork
+	}
+	return x.network.HeightForBlockHash(hash)
+}
+
+func (x *ElectrumXInterface) MedianTimePast(height int64) (time.Time, error) {
+	if x.network == nil {
+		return time.Time{}, ErrNoNetwork
+	}
+	return x.network.MedianTimePast(height)
+}
+
+func (x *ElectrumXInterface) FetchBlockHeader(ctx context.Context, height int64) (*electrumx.ClientBlockHeader, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.FetchBlockHeader(ctx, height)
+}
+
+func (x *ElectrumXInterface) SubscribeEvents(bufSize int, policy electrumx.OverflowPolicy, types ...electrumx.EventType) (*electrumx.EventSubscription, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.SubscribeEvents(bufSize, policy, types...), nil
+}
+
+// Deprecated: use SubscribeEvents.
 func (x *ElectrumXInterface) GetTipChangeNotify() (<-chan int64, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -177,6 +215,7 @@ func (x *ElectrumXInterface) GetTipChangeNotify() (<-chan int64, error) {
 	return x.network.GetTipChangeNotify(), nil
 }
 
+// Deprecated: use SubscribeEvents.
 func (x *ElectrumXInterface) GetScripthashNotify() (<-chan *electrumx.ScripthashStatusResult, error) {
 	if x.network == nil {
 		return nil, ErrNoNetwork
@@ -239,3 +278,45 @@ func (x *ElectrumXInterface) EstimateFeeRate(ctx context.Context, confTarget int
 	}
 	return x.network.EstimateFeeRate(ctx, confTarget)
 }
+
+func (x *ElectrumXInterface) AddServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.AddServer(addr)
+}
+
+func (x *ElectrumXInterface) RemoveServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.RemoveServer(addr)
+}
+
+func (x *ElectrumXInterface) BanServer(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.BanServer(addr)
+}
+
+func (x *ElectrumXInterface) ListKnownServers() ([]*electrumx.KnownServer, error) {
+	if x.network == nil {
+		return nil, ErrNoNetwork
+	}
+	return x.network.ListKnownServers(), nil
+}
+
+func (x *ElectrumXInterface) SetPreferredLeader(addr *electrumx.NodeServerAddr) error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.SetPreferredLeader(addr)
+}
+
+func (x *ElectrumXInterface) ForceLeaderSwitch() error {
+	if x.network == nil {
+		return ErrNoNetwork
+	}
+	return x.network.ForceLeaderSwitch()
+}
@@ -0,0 +1,381 @@
+package electrumx
+
+// Fan-out of network events to any number of independent subscribers.
+//
+// Publishing never blocks the publisher; i.e. header and scripthash processing
+// in the leader node. Each subscriber has it's own bounded buffer and an
+// overflow policy which decides what happens when the subscriber is too slow
+// to keep up.
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is the type of an Event.
+type EventType uint8
+
+const (
+	// EventNewTip - one or more new headers were connected. Height is the new
+	// tip.
+	EventNewTip EventType = iota + 1
+	// EventReorg - a header did not connect and the local chain was wound back.
+	// Height is the tip we wound back to and OldHeight the tip before.
+	EventReorg
+	// EventScripthashStatus - the status of a subscribed scripthash changed.
+	EventScripthashStatus
+	// EventLeaderChange - a new leader server was started or promoted. Leader
+	// is the server address.
+	EventLeaderChange
+	// EventSyncComplete - the leader finished syncing headers. Height is the
+	// synced tip.
+	EventSyncComplete
+
+	// Network state events
+
+	// EventConnected - the network has a running leader after starting or
+	// after being disconnected. Leader is the server address.
+	EventConnected
+	// EventDisconnected - the leader was lost and no other server could be
+	// made leader. The network keeps trying.
+	EventDisconnected
+	// EventSyncing - a leader started syncing headers from it's server.
+	EventSyncing
+	// EventRecoveryStarted - reorg recovery wound the headers back. The tip
+	// reported to clients is held at OldHeight until recovery ends.
+	EventRecoveryStarted
+	// EventRecoveryEnded - a new header connected after reorg recovery. Height
+	// is the new tip.
+	EventRecoveryEnded
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventNewTip:
+		return "new tip"
+	case EventReorg:
+		return "reorg"
+	case EventScripthashStatus:
+		return "scripthash status"
+	case EventLeaderChange:
+		return "leader change"
+	case EventSyncComplete:
+		return "sync complete"
+	case EventConnected:
+		return "connected"
+	case EventDisconnected:
+		return "disconnected"
+	case EventSyncing:
+		return "syncing"
+	case EventRecoveryStarted:
+		return "recovery started"
+	case EventRecoveryEnded:
+		return "recovery ended"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a network event sent to subscribers. Only the fields for the
+// event Type are set.
+type Event struct {
+	Type       EventType
+	Time       time.Time
+	Height     int64
+	OldHeight  int64
+	Scripthash *ScripthashStatusResult
+	Leader     string
+}
+
+// supersedes returns true if this event makes an older queued event
+// redundant.
+func (ev *Event) supersedes(older *Event) bool {
+	if ev.Type != older.Type {
+		return false
+	}
+	switch ev.Type {
+	case EventNewTip:
+		return true
+	case EventScripthashStatus:
+		return ev.Scripthash != nil && older.Scripthash != nil &&
+			ev.Scripthash.Scripthash == older.Scripthash.Scripthash
+	case EventLeaderChange:
+		return true
+	}
+	return false
+}
+
+// OverflowPolicy decides what happens to events for a subscriber whose buffer
+// is full.
+type OverflowPolicy uint8
+
+const (
+	// DropOldest discards the oldest queued event to make room.
+	DropOldest OverflowPolicy = iota
+	// DropNewest discards the incoming event.
+	DropNewest
+	// Coalesce removes queued events made redundant by the incoming event; an
+	// older tip by a newer tip or an older status of the same scripthash. If
+	// there is still no room the oldest event is discarded.
+	Coalesce
+	// Unbounded queues events beyond the buffer without limit so none is
+	// ever discarded. For subscribers that must see every event; i.e. the
+	// wallet's own scripthash statuses.
+	Unbounded
+)
+
+// EventSubscription receives events from the network.
+type EventSubscription struct {
+	id      uint64
+	broker  *EventBroker
+	types   map[EventType]bool // nil means all
+	policy  OverflowPolicy
+	ch      chan *Event
+	done    chan struct{}
+	dropped uint64
+	closed  bool
+	mtx     sync.Mutex
+
+	// Unbounded policy queue, moved to ch by pump
+	pending []*Event
+	wake    chan struct{}
+}
+
+// Events returns the channel to receive events on. The channel is closed on
+// Unsubscribe or when the network shuts down.
+func (s *EventSubscription) Events() <-chan *Event {
+	return s.ch
+}
+
+// Done returns a channel that is closed when the subscription ends.
+func (s *EventSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped returns how many events were discarded for this subscriber by the
+// overflow policy.
+func (s *EventSubscription) Dropped() uint64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.dropped
+}
+
+// Unsubscribe stops the subscription and closes the events channel.
+func (s *EventSubscription) Unsubscribe() {
+	s.broker.remove(s.id)
+	s.close()
+}
+
+func (s *EventSubscription) close() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	// the pump of an Unbounded subscription owns the send side of ch
+	if s.policy != Unbounded {
+		close(s.ch)
+	}
+	close(s.done)
+}
+
+func (s *EventSubscription) wants(t EventType) bool {
+	return s.types == nil || s.types[t]
+}
+
+// send queues an event without blocking, applying the overflow policy if the
+// buffer is full. Only the publisher sends; the subscriber may be receiving
+// concurrently.
+func (s *EventSubscription) send(ev *Event) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return
+	}
+	if s.policy == Unbounded {
+		// always through the queue to keep events in order
+		s.pending = append(s.pending, ev)
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+	select {
+	case s.ch <- ev:
+		return
+	default:
+	}
+	switch s.policy {
+	case DropNewest:
+		s.dropped++
+	case DropOldest:
+		select {
+		case <-s.ch:
+			s.dropped++
+		default:
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			s.dropped++
+		}
+	case Coalesce:
+		s.coalesce(ev)
+	}
+}
+
+// coalesce drains the queue, removes events superseded by ev then requeues
+// what is left plus ev - locked
+func (s *EventSubscription) coalesce(ev *Event) {
+	queued := make([]*Event, 0, cap(s.ch)+1)
+drain:
+	for {
+		select {
+		case older := <-s.ch:
+			queued = append(queued, older)
+		default:
+			break drain
+		}
+	}
+	kept := make([]*Event, 0, len(queued)+1)
+	for _, older := range queued {
+		if ev.supersedes(older) {
+			s.dropped++
+			continue
+		}
+		kept = append(kept, older)
+	}
+	kept = append(kept, ev)
+	for len(kept) > cap(s.ch) {
+		kept = kept[1:]
+		s.dropped++
+	}
+	for _, e := range kept {
+		s.ch <- e // cannot block; we hold the only send side
+	}
+}
+
+// pump moves the queued events of an Unbounded subscription to its channel,
+// waiting for the subscriber as long as needed. It closes the channel when
+// the subscription ends.
+func (s *EventSubscription) pump() {
+	defer close(s.ch)
+	for {
+		var ev *Event
+		s.mtx.Lock()
+		if len(s.pending) > 0 {
+			ev = s.pending[0]
+			s.pending[0] = nil
+			s.pending = s.pending[1:]
+		}
+		s.mtx.Unlock()
+		if ev == nil {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		select {
+		case s.ch <- ev:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// EventBroker fans out events to subscribers.
+type EventBroker struct {
+	subs   map[uint64]*EventSubscription
+	nextID uint64
+	closed bool
+	mtx    sync.Mutex
+}
+
+func newEventBroker() *EventBroker {
+	return &EventBroker{
+		subs: make(map[uint64]*EventSubscription),
+	}
+}
+
+// subscribe adds a subscriber with a buffer of bufSize events. If no types are
+// given all event types are received.
+func (b *EventBroker) subscribe(bufSize int, policy OverflowPolicy, types ...EventType) *EventSubscription {
+	if bufSize < 1 {
+		bufSize = 1
+	}
+	var typeSet map[EventType]bool
+	if len(types) > 0 {
+		typeSet = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			typeSet[t] = true
+		}
+	}
+	s := &EventSubscription{
+		broker: b,
+		types:  typeSet,
+		policy: policy,
+		ch:     make(chan *Event, bufSize),
+		done:   make(chan struct{}),
+	}
+	if policy == Unbounded {
+		s.wake = make(chan struct{}, 1)
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.closed {
+		s.closed = true
+		close(s.ch)
+		close(s.done)
+		return s
+	}
+	b.nextID++
+	s.id = b.nextID
+	b.subs[s.id] = s
+	if policy == Unbounded {
+		go s.pump()
+	}
+	return s
+}
+
+func (b *EventBroker) remove(id uint64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	delete(b.subs, id)
+}
+
+// publish sends an event to all interested subscribers. It never blocks.
+func (b *EventBroker) publish(ev *Event) {
+	if b == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	b.mtx.Lock()
+	subs := make([]*EventSubscription, 0, len(b.subs))
+	for _, s := range b.subs {
+		if s.wants(ev.Type) {
+			subs = append(subs, s)
+		}
+	}
+	b.mtx.Unlock()
+	for _, s := range subs {
+		s.send(ev)
+	}
+}
+
+// close ends all subscriptions. No new subscriptions can be made.
+func (b *EventBroker) close() {
+	b.mtx.Lock()
+	subs := b.subs
+	b.subs = make(map[uint64]*EventSubscription)
+	b.closed = true
+	b.mtx.Unlock()
+	for _, s := range subs {
+		s.close()
+	}
+}
@@ -0,0 +1,238 @@
+package electrumx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func drainEvents(sub *EventSubscription) []*Event {
+	var got []*Event
+	for {
+		select {
+		case ev := <-sub.Events():
+			got = append(got, ev)
+		default:
+			return got
+		}
+	}
+}
+
+func TestEventBrokerFanOut(t *testing.T) {
+	b := newEventBroker()
+	all := b.subscribe(10, DropOldest)
+	tips := b.subscribe(10, DropOldest, EventNewTip)
+
+	b.publish(&Event{Type: EventNewTip, Height: 100})
+	b.publish(&Event{Type: EventLeaderChange, Leader: "electrum.example.com:50002"})
+
+	got := drainEvents(all)
+	if len(got) != 2 || got[0].Type != EventNewTip || got[1].Type != EventLeaderChange {
+		t.Fatalf("all: got %d events", len(got))
+	}
+	if got[0].Time.IsZero() {
+		t.Fatal("event time not set")
+	}
+	got = drainEvents(tips)
+	if len(got) != 1 || got[0].Height != 100 {
+		t.Fatalf("tips: got %d events", len(got))
+	}
+
+	tips.Unsubscribe()
+	if _, ok := <-tips.Events(); ok {
+		t.Fatal("expected closed channel")
+	}
+	// no panic publishing after unsubscribe
+	b.publish(&Event{Type: EventNewTip, Height: 101})
+	tips.Unsubscribe()
+
+	b.close()
+	if _, ok := <-all.Events(); !ok {
+		t.Fatal("expected queued event before close")
+	}
+	if _, ok := <-all.Events(); ok {
+		t.Fatal("expected closed channel")
+	}
+	late := b.subscribe(1, DropOldest)
+	if _, ok := <-late.Done(); ok {
+		t.Fatal("expected closed subscription after broker close")
+	}
+}
+
+func TestEventOverflowPolicies(t *testing.T) {
+	b := newEventBroker()
+	oldest := b.subscribe(2, DropOldest)
+	newest := b.subscribe(2, DropNewest)
+	for h := int64(1); h <= 4; h++ {
+		b.publish(&Event{Type: EventNewTip, Height: h})
+	}
+
+	got := drainEvents(oldest)
+	if len(got) != 2 || got[0].Height != 3 || got[1].Height != 4 {
+		t.Fatalf("DropOldest: got %d events", len(got))
+	}
+	if oldest.Dropped() != 2 {
+		t.Fatalf("DropOldest: dropped %d", oldest.Dropped())
+	}
+	got = drainEvents(newest)
+	if len(got) != 2 || got[0].Height != 1 || got[1].Height != 2 {
+		t.Fatalf("DropNewest: got %d events", len(got))
+	}
+	if newest.Dropped() != 2 {
+		t.Fatalf("DropNewest: dropped %d", newest.Dropped())
+	}
+}
+
+func TestEventCoalesce(t *testing.T) {
+	b := newEventBroker()
+	sub := b.subscribe(3, Coalesce)
+	status := func(sh, st string) *Event {
+		return &Event{
+			Type:       EventScripthashStatus,
+			Scripthash: &ScripthashStatusResult{Scripthash: sh, Status: st},
+		}
+	}
+	b.publish(status("aa", "1"))
+	b.publish(&Event{Type: EventNewTip, Height: 100})
+	b.publish(status("bb", "1"))
+	// full: replaces the older tip
+	b.publish(&Event{Type: EventNewTip, Height: 101})
+	// full: replaces the older status of "aa"
+	b.publish(status("aa", "2"))
+
+	got := drainEvents(sub)
+	if len(got) != 3 {
+		t.Fatalf("got %d events", len(got))
+	}
+	if got[0].Scripthash.Scripthash != "bb" ||
+		got[1].Height != 101 ||
+		got[2].Scripthash.Scripthash != "aa" || got[2].Scripthash.Status != "2" {
+		t.Fatal("unexpected coalesced events")
+	}
+	if sub.Dropped() != 2 {
+		t.Fatalf("dropped %d", sub.Dropped())
+	}
+
+	// nothing to coalesce - oldest goes
+	b.publish(status("aa", "3"))
+	b.publish(status("bb", "3"))
+	b.publish(status("cc", "3"))
+	b.publish(status("dd", "3"))
+	got = drainEvents(sub)
+	if len(got) != 3 || got[0].Scripthash.Scripthash != "bb" {
+		t.Fatalf("got %d events", len(got))
+	}
+}
+
+func TestEventUnbounded(t *testing.T) {
+	b := newEventBroker()
+	sub := b.subscribe(2, Unbounded, EventScripthashStatus)
+	// many more distinct scripthashes than the buffer with no reader
+	for i := 0; i < 1000; i++ {
+		b.publish(&Event{
+			Type:       EventScripthashStatus,
+			Scripthash: &ScripthashStatusResult{Scripthash: fmt.Sprintf("%04d", i), Status: "1"},
+		})
+	}
+	for i := 0; i < 1000; i++ {
+		select {
+		case ev := <-sub.Events():
+			if want := fmt.Sprintf("%04d", i); ev.Scripthash.Scripthash != want {
+				t.Fatalf("got scripthash %s want %s", ev.Scripthash.Scripthash, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("missing event %d", i)
+		}
+	}
+	if sub.Dropped() != 0 {
+		t.Fatalf("dropped %d", sub.Dropped())
+	}
+
+	b.publish(&Event{Type: EventScripthashStatus, Scripthash: &ScripthashStatusResult{Scripthash: "aa"}})
+	sub.Unsubscribe()
+	for range sub.Events() {
+	}
+	// no panic publishing after unsubscribe
+	b.publish(&Event{Type: EventScripthashStatus, Scripthash: &ScripthashStatusResult{Scripthash: "bb"}})
+	sub.Unsubscribe()
+}
+
+func TestNetworkStateEvents(t *testing.T) {
+	net := mkNetwork("")
+	net.events = newEventBroker()
+	sub := net.SubscribeEvents(10, DropOldest, EventConnected, EventDisconnected, EventLeaderChange)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := mkPeer(ctx, 1, "electrum.example.com:50002", 100, 0)
+	net.setLeader(leader)
+	got := drainEvents(sub)
+	if len(got) != 2 || got[0].Type != EventConnected || got[1].Type != EventLeaderChange {
+		t.Fatalf("got %d events", len(got))
+	}
+	if got[0].Leader != "electrum.example.com:50002" {
+		t.Fatalf("bad leader %s", got[0].Leader)
+	}
+
+	// new leader while connected
+	net.setLeader(mkPeer(ctx, 2, "electrum2.example.com:50002", 100, 0))
+	got = drainEvents(sub)
+	if len(got) != 1 || got[0].Type != EventLeaderChange {
+		t.Fatalf("got %d events", len(got))
+	}
+
+	// leader lost
+	net.updateConnected()
+	if len(drainEvents(sub)) != 0 {
+		t.Fatal("unexpected event while leader running")
+	}
+	cancel()
+	net.updateConnected()
+	net.updateConnected()
+	got = drainEvents(sub)
+	if len(got) != 1 || got[0].Type != EventDisconnected {
+		t.Fatalf("got %d events", len(got))
+	}
+}
+
+func TestDeprecatedNotify(t *testing.T) {
+	net := &Network{events: newEventBroker()}
+	tipCh := net.GetTipChangeNotify()
+	if net.GetTipChangeNotify() != tipCh {
+		t.Fatal("expected the same tip channel")
+	}
+	shCh := net.GetScripthashNotify()
+
+	net.events.publish(&Event{Type: EventNewTip, Height: 101})
+	select {
+	case tip := <-tipCh:
+		if tip != 101 {
+			t.Fatalf("got tip %d", tip)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tip")
+	}
+	// none dropped while the client is not reading
+	for i := 0; i < 100; i++ {
+		net.events.publish(&Event{
+			Type:       EventScripthashStatus,
+			Scripthash: &ScripthashStatusResult{Scripthash: fmt.Sprintf("%04d", i), Status: "1"},
+		})
+	}
+	for i := 0; i < 100; i++ {
+		select {
+		case status := <-shCh:
+			if want := fmt.Sprintf("%04d", i); status.Scripthash != want {
+				t.Fatalf("got scripthash %s want %s", status.Scripthash, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("missing status %d", i)
+		}
+	}
+
+	net.events.close()
+	for range tipCh {
+	}
+	for range shCh {
+	}
+}
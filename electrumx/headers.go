@@ -9,8 +9,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
 
 const (
@@ -19,6 +24,8 @@ const (
 	// Bitcoin ElectrumX chunk size - may vary for other coins' ElectrumX servers
 	// in which case this becomes a variable.
 	ELECTRUM_MAGIC_NUMHDR = 2016
+	// Number of blocks used for the median time past
+	MEDIAN_TIME_BLOCKS = 11
 )
 
 var ErrSyncing = errors.New("syncing in progress")
@@ -43,6 +50,9 @@ func (wh WireHash) StringRev() string {
 }
 
 type headers struct {
+	// coin and network the headers are for
+	coin    string
+	netType string
 	// blockchain header size - per coin.
 	headerSize int
 	// blockchain_headers file to persist headers we know in datadir
@@ -53,9 +63,14 @@ type headers struct {
 	// that is block 0.
 	startPoint        int64
 	headerDeserialzer HeaderDeserializer
-	// decoded headers stored by height
-	hdrs        map[int64]*BlockHeader
-	blkHdrs     map[WireHash]int64
+	// the most recent decoded headers by height and by block hash
+	recent       map[int64]*BlockHeader
+	recentHashes map[WireHash]int64
+	// older headers read back from blockchain_headers file
+	cache *hdrCache
+	// on disk block hash -> height index
+	idxFilePath string
+	index       *hashIndex
 	hdrsMtx     sync.RWMutex
 	tip         atomic.Int64
 	synced      bool
@@ -65,21 +80,21 @@ type headers struct {
 
 func newHeaders(cfg *ElectrumXConfig) *headers {
 	filePath := filepath.Join(cfg.DataDir, HEADER_FILE_NAME)
-	hdrsMapInitSize := 2 * ELECTRUM_MAGIC_NUMHDR //4032
-	hdrsMap := make(map[int64]*BlockHeader, hdrsMapInitSize)
-	bhdrsMap := make(map[WireHash]int64, hdrsMapInitSize)
+	idxFilePath := filepath.Join(cfg.DataDir, HEADER_INDEX_FILE_NAME)
 	headerDeserialzer := cfg.HeaderDeserializer
 	hdrs := headers{
+		coin:              cfg.Coin,
+		netType:           cfg.NetType,
 		headerSize:        cfg.BlockHeaderSize,
 		hdrFilePath:       filePath,
+		idxFilePath:       idxFilePath,
 		startPoint:        cfg.StartPoint,
 		headerDeserialzer: headerDeserialzer,
-		hdrs:              hdrsMap,
-		blkHdrs:           bhdrsMap,
 		synced:            false,
 		recovery:          false,
 		recoveryTip:       0,
 	}
+	hdrs.clearMem()
 	return &hdrs
 }
 
@@ -113,9 +128,27 @@ func (h *headers) statFileSize() (int64, error) {
 	return fi.Size(), nil
 }
 
+// numHeadersInFile returns the number of headers in the 'blockchain_headers'
+// file creating an empty file if none exists.
+func (h *headers) numHeadersInFile() (int64, error) {
+	hdrFile, err := os.OpenFile(h.hdrFilePath, os.O_CREATE|os.O_RDWR, 0664)
+	if err != nil {
+		return 0, err
+	}
+	hdrFile.Close()
+	size, err := h.statFileSize()
+	if err != nil {
+		return 0, err
+	}
+	return h.bytesToNumHdrs(size)
+}
+
 // appendHeadersFile appends headers from server 'blockchain.block.header(s)' calls
 // to 'blockchain_headers' file. Also appends headers received from the
 // 'blockchain.headers.subscribe' events. Returns the number of headers written.
+//
+// The headers are fsync'ed before the metadata is updated. A failed write is
+// cut off so the file never ends in a partial header.
 func (h *headers) appendHeadersFile(rawHdrs []byte) (int64, error) {
 	numBytes := len(rawHdrs)
 	numHdrs, err := h.bytesToNumHdrs(int64(numBytes))
@@ -127,7 +160,24 @@ func (h *headers) appendHeadersFile(rawHdrs []byte) (int64, error) {
 		return 0, err
 	}
 	defer hdrFile.Close()
+	fi, err := hdrFile.Stat()
+	if err != nil {
+		return 0, err
+	}
+	oldSize := fi.Size()
 	_, err = hdrFile.Write(rawHdrs)
+	if err == nil {
+		err = hdrFile.Sync()
+	}
+	if err != nil {
+		hdrFile.Truncate(oldSize)
+		return 0, err
+	}
+	totalHdrs, err := h.bytesToNumHdrs(oldSize + int64(numBytes))
+	if err != nil {
+		return 0, err
+	}
+	err = h.writeMeta(totalHdrs, time.Time{})
 	if err != nil {
 		return 0, err
 	}
@@ -153,7 +203,7 @@ func (h *headers) truncateHeadersFile(numHeaders int64) (int64, error) {
 	if newByteLen < 0 {
 		newByteLen = 0
 	}
-	err = os.Truncate(h.hdrFilePath, newByteLen)
+	err = h.truncateToNum(newByteLen / headerSize)
 	if err != nil {
 		return 0, err
 	}
@@ -162,36 +212,24 @@ func (h *headers) truncateHeadersFile(numHeaders int64) (int64, error) {
 		return 0, err
 	}
 	newNumHeaders, _ := h.bytesToNumHdrs(newSize)
-	return newNumHeaders, nil
-}
-
-func (h *headers) readAllBytesFromFile() ([]byte, error) {
-	hdrFile, err := os.OpenFile(h.hdrFilePath, os.O_CREATE|os.O_RDWR, 0664)
-	if err != nil {
-		return nil, err
-	}
-	defer hdrFile.Close()
-	fsize, err := h.statFileSize()
+	err = h.writeMeta(newNumHeaders, time.Time{})
 	if err != nil {
-		return nil, err
-	}
-	b := make([]byte, fsize)
-	hdrFile.Seek(0, 0)
-	n, err := hdrFile.Read(b)
-	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	if n != int(fsize) {
-		return nil, errors.New("read less than file size")
+	if h.index != nil {
+		err = h.index.setIndexed(newNumHeaders)
+		if err != nil {
+			return 0, err
+		}
 	}
-	return b, nil
+	return newNumHeaders, nil
 }
 
 // ----------------------------------------------------------------------------
-// Maps
+// In memory headers
 // ----------------------------------------------------------------------------
 
-// Store blockHeaders starting at startHeight in the 'hdrs' map
+// Store blockHeaders starting at startHeight in memory and index them
 func (h *headers) store(b []byte, startHeight int64) error {
 	numHdrs, err := h.bytesToNumHdrs(int64(len(b)))
 	if err != nil {
@@ -207,17 +245,26 @@ func (h *headers) store(b []byte, startHeight int64) error {
 			return err
 		}
 		at := startHeight + i
-		h.hdrs[at] = blkHdr
-		blkHash := blkHdr.Hash
-		h.blkHdrs[blkHash] = at
+		h.putRecent(at, blkHdr)
+		if h.index != nil {
+			err = h.index.add(blkHdr.Hash, h.hdrPos(at))
+			if err != nil {
+				return err
+			}
+		}
 	}
+	h.trimRecent(startHeight + numHdrs - 1)
 	return nil
 }
 
 func (h *headers) removeOneHdrFromTip() {
 	h.hdrsMtx.Lock()
 	defer h.hdrsMtx.Unlock()
-	delete(h.hdrs, h.getTip())
+	tip := h.getTip()
+	h.dropRecent(tip)
+	if h.cache != nil {
+		h.cache.remove(tip)
+	}
 	h.decTip(1)
 }
 
@@ -238,15 +285,11 @@ func (h *headers) getClientSynced() bool {
 	return h.synced
 }
 
-// // reverse lookup
-// func (n *Node) getHeaderForBlockHash(blkHash *WireHash) *BlockHeader {
-// 	h := n.networkHeaders
-// 	height := h.blkHdrs[*blkHash]
-// 	return h.hdrs[height]
-// }
-
 func (h *headers) getTipHash() WireHash {
-	hdr := h.hdrs[h.getTip()]
+	hdr := h.getHeaderAt(h.getTip())
+	if hdr == nil {
+		return WireHash{}
+	}
 	return hdr.Hash
 }
 
@@ -256,31 +299,43 @@ func (h *headers) checkCanConnect(incomingHdr *BlockHeader) bool {
 }
 
 // storeOneHdr stores one block header at h.tip+1 and updates h.tip
-// the header is assumed to be valid and can connect
-func (h *headers) storeOneHdr(blkHdr *BlockHeader) {
+// the header is assumed to be valid and can connect. If the header cannot be
+// indexed it is not stored and h.tip is unchanged
+func (h *headers) storeOneHdr(blkHdr *BlockHeader) error {
 	h.hdrsMtx.Lock()
 	defer h.hdrsMtx.Unlock()
 	at := h.getTip() + 1
-	// at := h.tip + 1
-	h.hdrs[at] = blkHdr
-	blkHash := blkHdr.Hash
-	h.blkHdrs[blkHash] = at
+	if h.index != nil {
+		err := h.index.add(blkHdr.Hash, h.hdrPos(at))
+		if err != nil {
+			return fmt.Errorf("index - %w", err)
+		}
+	}
+	h.putRecent(at, blkHdr)
 	h.incTip(1)
+	h.trimRecent(at)
+	return nil
 }
 
 // Verify headers prev hash back from tip. If 'all' is true 'depth' is ignored
 // and the whole chain is verified
 func (h *headers) verifyFromTip(depth int64, all bool) error {
+	if all {
+		return h.verifyAll()
+	}
 	h.hdrsMtx.RLock()
 	defer h.hdrsMtx.RUnlock()
 	downTo := h.getTip() - depth
-	if downTo < 0 || all {
+	if downTo < h.startPoint {
 		downTo = h.startPoint
 	}
 	var height int64
 	for height = h.getTip(); height > downTo; height-- {
-		thisHdr := h.hdrs[height]
-		prevHdr := h.hdrs[height-1]
+		thisHdr := h.headerAt(height)
+		prevHdr := h.headerAt(height - 1)
+		if thisHdr == nil || prevHdr == nil {
+			return fmt.Errorf("verify failed: missing header near height %d", height)
+		}
 		prevHdrBlkHash := prevHdr.Hash
 		if prevHdrBlkHash != thisHdr.Prev {
 			return fmt.Errorf("verify failed: height %d", height)
@@ -291,8 +346,28 @@ func (h *headers) verifyFromTip(depth int64, all bool) error {
 	return nil
 }
 
+// verifyAll verifies the whole chain in the headers file from the start point
+// to the tip.
 func (h *headers) verifyAll() error {
-	return h.verifyFromTip(0, true)
+	return h.verifyFromPos(0)
+}
+
+// verifyFromPos verifies the chain in the headers file from position pos to
+// the tip, reading the file in chunks.
+func (h *headers) verifyFromPos(pos int64) error {
+	numHeaders := h.hdrPos(h.getTip()) + 1
+	var prevHash WireHash
+	first := true
+	return h.forEachFileHdr(max(pos, 0), numHeaders, func(pos int64, blkHdrs []*BlockHeader) error {
+		for i, blkHdr := range blkHdrs {
+			if !first && blkHdr.Prev != prevHash {
+				return fmt.Errorf("verify failed: height %d", h.startPoint+pos+int64(i))
+			}
+			first = false
+			prevHash = blkHdr.Hash
+		}
+		return nil
+	})
 }
 
 // how many headers given a number of bytes .. panics if header size is 0
@@ -315,21 +390,40 @@ func (h *headers) bytesToNumHdrs(numBytes int64) (int64, error) {
 // Client api
 // ----------------------------------------------------------------------------
 
+// clientBlockHeader makes the client view of a stored block header.
+func clientBlockHeader(height int64, blkHdr *BlockHeader) *ClientBlockHeader {
+	return &ClientBlockHeader{
+		Height:    height,
+		Version:   blkHdr.Version,
+		Hash:      blkHdr.Hash.StringRev(),
+		Prev:      blkHdr.Prev.StringRev(),
+		Merkle:    blkHdr.Merkle.StringRev(),
+		Timestamp: time.Unix(int64(blkHdr.Timestamp), 0),
+		Bits:      blkHdr.Bits,
+		Nonce:     blkHdr.Nonce,
+		Work:      blockchain.CalcWork(blkHdr.Bits),
+	}
+}
+
+// parseBlockHash parses a block hash string as shown by block explorers.
+func parseBlockHash(hash string) (WireHash, error) {
+	chainHash, err := chainhash.NewHashFromStr(hash)
+	if err != nil {
+		return WireHash{}, err
+	}
+	return WireHash(*chainHash), nil
+}
+
 // getBlockHeader returns the block header for height. If out of range will
 // return nil.
 func (h *headers) getBlockHeader(height int64) (*ClientBlockHeader, error) {
 	h.hdrsMtx.RLock()
 	defer h.hdrsMtx.RUnlock()
-	blkHdr := h.hdrs[height]
+	blkHdr := h.headerAt(height)
 	if blkHdr == nil {
 		return nil, fmt.Errorf("no block header stored for height %d", height)
 	}
-	hdr := &ClientBlockHeader{
-		Hash:   blkHdr.Hash.StringRev(),
-		Prev:   blkHdr.Prev.StringRev(),
-		Merkle: blkHdr.Merkle.StringRev(),
-	}
-	return hdr, nil
+	return clientBlockHeader(height, blkHdr), nil
 }
 
 // getBlockHeaders returns the stored block headers for the requested range.
@@ -353,27 +447,89 @@ func (h *headers) getBlockHeaders(startHeight, count int64) ([]*ClientBlockHeade
 	}
 	var hdrs = make([]*ClientBlockHeader, 0, 3)
 	for i := startHeight; i < blkEndRange; i++ {
-		blkHdr := h.hdrs[i]
-		hdr := &ClientBlockHeader{
-			Hash:   blkHdr.Hash.StringRev(),
-			Prev:   blkHdr.Prev.StringRev(),
-			Merkle: blkHdr.Merkle.StringRev(),
+		blkHdr := h.headerAt(i)
+		if blkHdr == nil {
+			return nil, fmt.Errorf("no block header stored for height %d", i)
 		}
-		hdrs = append(hdrs, hdr)
+		hdrs = append(hdrs, clientBlockHeader(i, blkHdr))
 	}
 	return hdrs, nil
 }
 
+// getBlockHeaderByHash returns the stored block header with block hash 'hash'.
+func (h *headers) getBlockHeaderByHash(hash string) (*ClientBlockHeader, error) {
+	blkHash, err := parseBlockHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	h.hdrsMtx.RLock()
+	defer h.hdrsMtx.RUnlock()
+	height, ok := h.heightForHash(blkHash)
+	if !ok {
+		return nil, fmt.Errorf("no block header stored for hash %s", hash)
+	}
+	blkHdr := h.headerAt(height)
+	if blkHdr == nil {
+		return nil, fmt.Errorf("no block header stored for height %d", height)
+	}
+	return clientBlockHeader(height, blkHdr), nil
+}
+
+// getHeightForBlockHash returns the height of the stored block header with
+// block hash 'hash'.
+func (h *headers) getHeightForBlockHash(hash string) (int64, error) {
+	blkHash, err := parseBlockHash(hash)
+	if err != nil {
+		return 0, err
+	}
+	height, ok := h.getHeightForHash(blkHash)
+	if !ok {
+		return 0, fmt.Errorf("no block header stored for hash %s", hash)
+	}
+	return height, nil
+}
+
+// medianTimePast returns the median of the timestamps of the block at height
+// and the MEDIAN_TIME_BLOCKS-1 blocks before it. This is the time that lock
+// times are checked against (BIP113).
+//
+// Near the genesis block fewer blocks are used, as consensus does. Above a
+// start point checkpoint the blocks before the checkpoint are not stored so
+// the first MEDIAN_TIME_BLOCKS-1 heights after the start point return error.
+func (h *headers) medianTimePast(height int64) (time.Time, error) {
+	h.hdrsMtx.RLock()
+	defer h.hdrsMtx.RUnlock()
+	if height < h.startPoint || height > h.getTip() {
+		return time.Time{}, fmt.Errorf("no block header stored for height %d", height)
+	}
+	from := height - MEDIAN_TIME_BLOCKS + 1
+	if from < h.startPoint {
+		if h.startPoint > 0 {
+			return time.Time{}, fmt.Errorf("not enough block headers stored for median time at height %d", height)
+		}
+		from = 0
+	}
+	timestamps := make([]int64, 0, MEDIAN_TIME_BLOCKS)
+	for i := from; i <= height; i++ {
+		blkHdr := h.headerAt(i)
+		if blkHdr == nil {
+			return time.Time{}, fmt.Errorf("no block header stored for height %d", i)
+		}
+		timestamps = append(timestamps, int64(blkHdr.Timestamp))
+	}
+	slices.Sort(timestamps)
+	return time.Unix(timestamps[len(timestamps)/2], 0), nil
+}
+
 // ----------------------------------------------------------------------------
 // test
 // ----------------------------------------------------------------------------
 
 // Use *only* in headers_test.go
 func (h *headers) ClearMaps() {
-	h.hdrs = nil
-	h.blkHdrs = nil
-	h.hdrs = make(map[int64]*BlockHeader, 10)
-	h.blkHdrs = make(map[WireHash]int64, 10)
+	h.hdrsMtx.Lock()
+	defer h.hdrsMtx.Unlock()
+	h.clearMem()
 }
 
 // dump the top 'depth' hash - prev hashes
@@ -381,17 +537,23 @@ func (h *headers) dbgDumpTipHashes(depth int64) {
 	tip := h.getTip()
 	fmt.Printf("--- Dump of the top %d stored headers ---\n", depth)
 	for i := tip; i > tip-depth; i-- {
-		hash := h.hdrs[i].Hash.StringRev()
-		prev := h.hdrs[i].Prev.StringRev()
+		hdr := h.getHeaderAt(i)
+		if hdr == nil {
+			break
+		}
+		hash := hdr.Hash.StringRev()
+		prev := hdr.Prev.StringRev()
 		fmt.Printf("height: %d hash: %s prev: %s\n", i, hash, prev)
 	}
 }
 
 // dump one decoded block header
 func (h *headers) dumpAt(height int64) {
-	h.hdrsMtx.Lock()
-	defer h.hdrsMtx.Unlock()
-	hdr := h.hdrs[height]
+	hdr := h.getHeaderAt(height)
+	if hdr == nil {
+		fmt.Println("no header at height", height)
+		return
+	}
 	fmt.Println("Hash:          ", hdr.Hash.StringRev(), "Height: ", height)
 	fmt.Println("--------------------------")
 	fmt.Println("Previous Hash: ", hdr.Prev.StringRev())
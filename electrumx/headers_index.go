@@ -0,0 +1,334 @@
+package electrumx
+
+// On disk block hash -> height index for the 'blockchain_headers' file.
+//
+// The index is an open addressing hash table with linear probing kept in the
+// 'blockchain_headers_index' file next to the headers file. Each slot holds
+// the first 4 bytes of a block hash and the header's position in the headers
+// file. Only a few slots are read for a lookup so memory use does not grow
+// with the chain.
+//
+// Entries are never removed. When headers are truncated from the tip in reorg
+// recovery the entries for them become stale; a lookup checks the candidate
+// header's full hash so stale entries and prefix collisions are skipped.
+//
+// The index is derived data. If it is missing, from an older version, or does
+// not match the headers file it is rebuilt or caught up from the headers file.
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// File name of the index; under the same directory as HEADER_FILE_NAME
+	HEADER_INDEX_FILE_NAME = "blockchain_headers_index"
+
+	hashIndexMagic      = "GEHI"
+	hashIndexVersion    = 1
+	hashIndexHeaderSize = 32
+	hashIndexSlotSize   = 8
+	// initial number of slots; a power of 2
+	hashIndexInitSlots = 1 << 16
+)
+
+var errBadHashIndex = errors.New("bad hash index file")
+
+// hashIndex is the on disk hash table.
+//
+// File layout, little endian:
+//
+//	header: magic [4]byte, version uint32, slots uint64, count uint64,
+//	        indexed uint64
+//	slots:  key uint32 (hash[0:4]), pos uint32 (position in headers file + 1;
+//	        0 is an empty slot)
+type hashIndex struct {
+	path    string
+	file    *os.File
+	slots   uint64
+	count   uint64 // used slots
+	indexed int64  // number of headers from the start of the headers file indexed
+	mtx     sync.Mutex
+}
+
+// openHashIndex opens the index file at path creating a new empty index if it
+// does not exist or is not usable.
+func openHashIndex(path string) (*hashIndex, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0664)
+	if err != nil {
+		return nil, err
+	}
+	idx := &hashIndex{
+		path: path,
+		file: f,
+	}
+	err = idx.readHeader()
+	if err != nil {
+		err = idx.reset(hashIndexInitSlots)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+func (idx *hashIndex) close() error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	return idx.file.Close()
+}
+
+// readHeader reads and checks the index file header - not locked
+func (idx *hashIndex) readHeader() error {
+	var b [hashIndexHeaderSize]byte
+	_, err := idx.file.ReadAt(b[:], 0)
+	if err != nil {
+		return err
+	}
+	if string(b[0:4]) != hashIndexMagic {
+		return errBadHashIndex
+	}
+	if binary.LittleEndian.Uint32(b[4:8]) != hashIndexVersion {
+		return errBadHashIndex
+	}
+	slots := binary.LittleEndian.Uint64(b[8:16])
+	if slots == 0 || slots&(slots-1) != 0 {
+		return errBadHashIndex
+	}
+	fi, err := idx.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() != hashIndexHeaderSize+int64(slots)*hashIndexSlotSize {
+		return errBadHashIndex
+	}
+	idx.slots = slots
+	idx.count = binary.LittleEndian.Uint64(b[16:24])
+	idx.indexed = int64(binary.LittleEndian.Uint64(b[24:32]))
+	return nil
+}
+
+// writeHeader - not locked
+func (idx *hashIndex) writeHeader() error {
+	var b [hashIndexHeaderSize]byte
+	copy(b[0:4], hashIndexMagic)
+	binary.LittleEndian.PutUint32(b[4:8], hashIndexVersion)
+	binary.LittleEndian.PutUint64(b[8:16], idx.slots)
+	binary.LittleEndian.PutUint64(b[16:24], idx.count)
+	binary.LittleEndian.PutUint64(b[24:32], uint64(idx.indexed))
+	_, err := idx.file.WriteAt(b[:], 0)
+	return err
+}
+
+// reset empties the index - not locked
+func (idx *hashIndex) reset(slots uint64) error {
+	err := idx.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+	err = idx.file.Truncate(hashIndexHeaderSize + int64(slots)*hashIndexSlotSize)
+	if err != nil {
+		return err
+	}
+	idx.slots = slots
+	idx.count = 0
+	idx.indexed = 0
+	return idx.writeHeader()
+}
+
+func hashKey(hash WireHash) uint32 {
+	return binary.LittleEndian.Uint32(hash[0:4])
+}
+
+func (idx *hashIndex) slotOffset(slot uint64) int64 {
+	return hashIndexHeaderSize + int64(slot)*hashIndexSlotSize
+}
+
+// readSlot - not locked
+func (idx *hashIndex) readSlot(slot uint64) (key, pos uint32, err error) {
+	var b [hashIndexSlotSize]byte
+	_, err = idx.file.ReadAt(b[:], idx.slotOffset(slot))
+	if err != nil {
+		return 0, 0, err
+	}
+	return binary.LittleEndian.Uint32(b[0:4]), binary.LittleEndian.Uint32(b[4:8]), nil
+}
+
+// writeSlot - not locked
+func (idx *hashIndex) writeSlot(slot uint64, key, pos uint32) error {
+	var b [hashIndexSlotSize]byte
+	binary.LittleEndian.PutUint32(b[0:4], key)
+	binary.LittleEndian.PutUint32(b[4:8], pos)
+	_, err := idx.file.WriteAt(b[:], idx.slotOffset(slot))
+	return err
+}
+
+// insertKey puts key, pos in the first free slot from the key's home slot.
+// An identical entry is not duplicated - not locked
+func (idx *hashIndex) insertKey(key, pos uint32) error {
+	mask := idx.slots - 1
+	for slot := uint64(key) & mask; ; slot = (slot + 1) & mask {
+		k, p, err := idx.readSlot(slot)
+		if err != nil {
+			return err
+		}
+		if p == 0 {
+			idx.count++
+			return idx.writeSlot(slot, key, pos)
+		}
+		if k == key && p == pos {
+			return nil
+		}
+	}
+}
+
+// grow doubles the table size rehashing all the entries - not locked
+func (idx *hashIndex) grow() error {
+	tmpPath := idx.path + ".tmp"
+	newIdx := &hashIndex{path: tmpPath}
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0664)
+	if err != nil {
+		return err
+	}
+	newIdx.file = f
+	err = newIdx.reset(idx.slots * 2)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	// stream the old slots
+	const chunkSlots = 4096
+	buf := make([]byte, chunkSlots*hashIndexSlotSize)
+	for slot := uint64(0); slot < idx.slots; slot += chunkSlots {
+		n := min(chunkSlots, idx.slots-slot)
+		b := buf[:n*hashIndexSlotSize]
+		_, err = idx.file.ReadAt(b, idx.slotOffset(slot))
+		if err != nil && !errors.Is(err, io.EOF) {
+			f.Close()
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			rec := b[i*hashIndexSlotSize:]
+			pos := binary.LittleEndian.Uint32(rec[4:8])
+			if pos == 0 {
+				continue
+			}
+			err = newIdx.insertKey(binary.LittleEndian.Uint32(rec[0:4]), pos)
+			if err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	newIdx.indexed = idx.indexed
+	err = newIdx.writeHeader()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	idx.file.Close()
+	err = os.Rename(tmpPath, idx.path)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	idx.file = f
+	idx.slots = newIdx.slots
+	idx.count = newIdx.count
+	return nil
+}
+
+// add indexes the header with hash at position pos in the headers file.
+func (idx *hashIndex) add(hash WireHash, pos int64) error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	err := idx.insertHash(hash, pos)
+	if err != nil {
+		return err
+	}
+	return idx.writeHeader()
+}
+
+// addMany indexes hashes for consecutive headers starting at position pos.
+func (idx *hashIndex) addMany(hashes []WireHash, pos int64) error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	for i, hash := range hashes {
+		err := idx.insertHash(hash, pos+int64(i))
+		if err != nil {
+			return err
+		}
+	}
+	return idx.writeHeader()
+}
+
+// insertHash - locked
+func (idx *hashIndex) insertHash(hash WireHash, pos int64) error {
+	if pos < 0 || pos >= int64(^uint32(0)) {
+		return errors.New("header position out of range for index")
+	}
+	// keep the load factor at or under 1/2
+	if (idx.count+1)*2 > idx.slots {
+		err := idx.grow()
+		if err != nil {
+			return err
+		}
+	}
+	err := idx.insertKey(hashKey(hash), uint32(pos+1))
+	if err != nil {
+		return err
+	}
+	if pos+1 > idx.indexed {
+		idx.indexed = pos + 1
+	}
+	return nil
+}
+
+// lookup returns the position in the headers file of the header with hash.
+// isMatch checks a candidate position holds that hash.
+func (idx *hashIndex) lookup(hash WireHash, isMatch func(pos int64) bool) (int64, bool, error) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	key := hashKey(hash)
+	mask := idx.slots - 1
+	for slot := uint64(key) & mask; ; slot = (slot + 1) & mask {
+		k, p, err := idx.readSlot(slot)
+		if err != nil {
+			return 0, false, err
+		}
+		if p == 0 {
+			return 0, false, nil
+		}
+		if k != key {
+			continue
+		}
+		pos := int64(p) - 1
+		if pos < idx.indexed && isMatch(pos) {
+			return pos, true, nil
+		}
+	}
+}
+
+// getIndexed returns the number of headers from the start of the headers file
+// that are indexed.
+func (idx *hashIndex) getIndexed() int64 {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	return idx.indexed
+}
+
+// setIndexed winds back the number of indexed headers after the headers file
+// was truncated.
+func (idx *hashIndex) setIndexed(numHeaders int64) error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	if numHeaders >= idx.indexed {
+		return nil
+	}
+	idx.indexed = max(numHeaders, 0)
+	return idx.writeHeader()
+}
@@ -0,0 +1,288 @@
+package electrumx
+
+// Headers file integrity.
+//
+// A small metadata file 'blockchain_headers.meta' is kept next to the headers
+// file. It records what the headers file is for - coin, network, start point
+// and header size - and a checksum of the last HEADER_META_CHECK_NUM headers.
+// It is replaced atomically after every append or truncate of the headers file
+// and both are fsync'ed.
+//
+// At startup repairHeadersFile checks the headers file against the metadata.
+// A file for another network is discarded, a partial record left by a crash is
+// cut off and if the checksum does not match the headers it covers are
+// truncated. Every HEADER_FULL_CHECK_INTERVAL, or with no metadata, the chain
+// is verified from the start and truncated to the last header that links. The
+// missing headers are then fetched again from the server by the normal sync.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// Number of headers at the end of the headers file covered by the checksum
+	HEADER_META_CHECK_NUM = 144
+	// How often the whole headers file chain is verified at startup even if the
+	// checksum matches
+	HEADER_FULL_CHECK_INTERVAL = 7 * 24 * time.Hour
+)
+
+// headersMeta is the metadata record for the headers file.
+type headersMeta struct {
+	Coin       string `json:"coin"`
+	NetType    string `json:"net"`
+	StartPoint int64  `json:"start_point"`
+	HeaderSize int    `json:"header_size"`
+	// number of headers in the headers file when last written
+	NumHeaders int64 `json:"num_headers"`
+	// sha256 of the raw bytes of the last CheckNum headers
+	CheckNum int64  `json:"check_num"`
+	Checksum string `json:"checksum"`
+	// unix time the whole chain was last verified
+	FullCheck int64 `json:"full_check"`
+}
+
+// sameFileAs returns true if the metadata describes a headers file for the
+// same coin, network, start point and header size.
+func (m *headersMeta) sameFileAs(other *headersMeta) bool {
+	return m.Coin == other.Coin &&
+		m.NetType == other.NetType &&
+		m.StartPoint == other.StartPoint &&
+		m.HeaderSize == other.HeaderSize
+}
+
+// metaFilePath is the headers file path plus '.meta'
+func (h *headers) metaFilePath() string {
+	return h.hdrFilePath + ".meta"
+}
+
+// newMeta returns metadata describing our headers file with no headers.
+func (h *headers) newMeta() *headersMeta {
+	return &headersMeta{
+		Coin:       h.coin,
+		NetType:    h.netType,
+		StartPoint: h.startPoint,
+		HeaderSize: h.headerSize,
+	}
+}
+
+// readMeta reads the metadata file. Returns nil, nil if there is none.
+func (h *headers) readMeta() (*headersMeta, error) {
+	b, err := os.ReadFile(h.metaFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	meta := &headersMeta{}
+	err = json.Unmarshal(b, meta)
+	if err != nil {
+		// unreadable is as good as missing
+		return nil, nil
+	}
+	return meta, nil
+}
+
+// checksum returns the checksum of the last HEADER_META_CHECK_NUM headers of
+// the first numHeaders headers in the headers file and how many headers it
+// covers.
+func (h *headers) checksum(numHeaders int64) (string, int64, error) {
+	checkNum := min(numHeaders, HEADER_META_CHECK_NUM)
+	b, err := h.readBytesFromFile(numHeaders-checkNum, checkNum)
+	if err != nil {
+		return "", 0, err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), checkNum, nil
+}
+
+// writeMeta atomically replaces the metadata file for a headers file of
+// numHeaders headers. fullCheck is the time the whole chain was last verified;
+// if zero the previous time is kept.
+func (h *headers) writeMeta(numHeaders int64, fullCheck time.Time) error {
+	meta := h.newMeta()
+	meta.NumHeaders = numHeaders
+	sum, checkNum, err := h.checksum(numHeaders)
+	if err != nil {
+		return err
+	}
+	meta.CheckNum = checkNum
+	meta.Checksum = sum
+	if fullCheck.IsZero() {
+		old, _ := h.readMeta()
+		if old != nil && old.sameFileAs(meta) {
+			meta.FullCheck = old.FullCheck
+		}
+	} else {
+		meta.FullCheck = fullCheck.Unix()
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return writeFileSync(h.metaFilePath(), b)
+}
+
+// writeFileSync writes a file to a temporary file, fsyncs and renames it over
+// path.
+func writeFileSync(path string, b []byte) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0664)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir makes a rename in dir durable where the os supports it.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// linkedUpTo verifies the chain in the headers file from position 'from' up
+// to but not including position 'to' and returns the number of headers from
+// the start of the file that can be trusted. Where the chain breaks either
+// side may be damaged so both are dropped.
+func (h *headers) linkedUpTo(from, to int64) int64 {
+	var prevHash WireHash
+	good := from
+	first := true
+	err := h.forEachFileHdr(from, to, func(pos int64, blkHdrs []*BlockHeader) error {
+		for i, blkHdr := range blkHdrs {
+			if !first && blkHdr.Prev != prevHash {
+				good = max(good-1, 0)
+				return errors.New("break")
+			}
+			first = false
+			prevHash = blkHdr.Hash
+			good = pos + int64(i) + 1
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("headers file chain breaks at height %d\n", h.startPoint+good)
+	}
+	return good
+}
+
+// truncateToNum truncates the headers file to numHeaders headers and fsyncs.
+func (h *headers) truncateToNum(numHeaders int64) error {
+	f, err := os.OpenFile(h.hdrFilePath, os.O_RDWR, 0664)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = f.Truncate(numHeaders * int64(h.headerSize))
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// repairHeadersFile checks the headers file against it's metadata and repairs
+// it by truncating to the last trusted header. Returns the number of good
+// headers now in the file. Headers after that are fetched again by the sync.
+func (h *headers) repairHeadersFile() (int64, error) {
+	// make sure there is a file
+	hdrFile, err := os.OpenFile(h.hdrFilePath, os.O_CREATE|os.O_RDWR, 0664)
+	if err != nil {
+		return 0, err
+	}
+	hdrFile.Close()
+	size, err := h.statFileSize()
+	if err != nil {
+		return 0, err
+	}
+	headerSize := int64(h.headerSize)
+	numHeaders := size / headerSize
+	good := numHeaders
+
+	meta, err := h.readMeta()
+	if err != nil {
+		return 0, err
+	}
+	fullCheck := false
+	switch {
+	case meta != nil && !meta.sameFileAs(h.newMeta()):
+		// another network's file
+		fmt.Printf("headers file is for %s %s start point %d header size %d - discarding\n",
+			meta.Coin, meta.NetType, meta.StartPoint, meta.HeaderSize)
+		good = 0
+		if h.index != nil {
+			h.index.close()
+			h.index = nil
+		}
+		os.Remove(h.idxFilePath)
+	case meta == nil || numHeaders < meta.NumHeaders:
+		// no metadata yet or we crashed truncating the file
+		fullCheck = true
+	default:
+		sum, checkNum, err := h.checksum(meta.NumHeaders)
+		if err != nil || sum != meta.Checksum || checkNum != meta.CheckNum {
+			// Any of the checksummed headers may be bad. Drop them and
+			// anything after; the sync fetches them again.
+			drop := min(meta.NumHeaders, max(meta.CheckNum, HEADER_META_CHECK_NUM))
+			good = meta.NumHeaders - drop
+			fmt.Printf("headers file checksum mismatch - refetching the last %d headers\n", numHeaders-good)
+			break
+		}
+		if time.Since(time.Unix(meta.FullCheck, 0)) > HEADER_FULL_CHECK_INTERVAL {
+			fullCheck = true
+			break
+		}
+		// headers appended after the last metadata write
+		if numHeaders > meta.NumHeaders {
+			good = h.linkedUpTo(max(meta.NumHeaders-1, 0), numHeaders)
+		}
+	}
+	if fullCheck && numHeaders > 0 {
+		fmt.Println("verifying headers file")
+		good = h.linkedUpTo(0, numHeaders)
+	}
+
+	if good*headerSize != size {
+		fmt.Printf("repairing headers file: %d good headers of %d bytes\n", good, size)
+		err = h.truncateToNum(good)
+		if err != nil {
+			return 0, err
+		}
+	}
+	var checked time.Time
+	if fullCheck || good == 0 {
+		checked = time.Now()
+	}
+	err = h.writeMeta(good, checked)
+	if err != nil {
+		return 0, err
+	}
+	return good, nil
+}
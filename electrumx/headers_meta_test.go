@@ -0,0 +1,186 @@
+package electrumx
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func mkMetaHeaders(t *testing.T, dir string, numHdrs int) (*headers, []byte) {
+	t.Helper()
+	b, _ := mkHdrChain(t, numHdrs)
+	h := mkStoreHeaders(dir, 1000)
+	h.coin = "btc"
+	h.netType = "regtest"
+	_, err := h.appendHeadersFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, b
+}
+
+func headersFileSize(t *testing.T, h *headers) int64 {
+	t.Helper()
+	size, err := h.statFileSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return size
+}
+
+func TestRepairHeadersFile(t *testing.T) {
+	const numHdrs = 300
+	h, b := mkMetaHeaders(t, t.TempDir(), numHdrs)
+
+	meta, err := h.readMeta()
+	if err != nil || meta == nil {
+		t.Fatalf("no metadata: %v", err)
+	}
+	if meta.NumHeaders != numHdrs || meta.CheckNum != HEADER_META_CHECK_NUM {
+		t.Fatalf("bad metadata %+v", meta)
+	}
+
+	// good file
+	good, err := h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if good != numHdrs {
+		t.Fatalf("got %d good headers", good)
+	}
+
+	// partial record left by a crash
+	f, err := os.OpenFile(h.hdrFilePath, os.O_WRONLY|os.O_APPEND, 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(b[:BTC_HEADER_SIZE/2])
+	f.Close()
+	good, err = h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if good != numHdrs || headersFileSize(t, h) != numHdrs*BTC_HEADER_SIZE {
+		t.Fatalf("partial record not removed: %d", good)
+	}
+
+	// damaged header near the tip - prev hash of position 290 broken. The
+	// checksum no longer matches.
+	damaged := make([]byte, len(b))
+	copy(damaged, b)
+	damaged[290*BTC_HEADER_SIZE+4] ^= 0xff
+	err = os.WriteFile(h.hdrFilePath, damaged, 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, err = h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the checksummed headers are dropped to be fetched again
+	const kept = numHdrs - HEADER_META_CHECK_NUM
+	if good != kept || headersFileSize(t, h) != kept*BTC_HEADER_SIZE {
+		t.Fatalf("expected truncate to %d headers, got %d", kept, good)
+	}
+	meta, _ = h.readMeta()
+	if meta.NumHeaders != kept {
+		t.Fatalf("metadata not updated: %d", meta.NumHeaders)
+	}
+}
+
+func TestRepairHeadersFileCrashBeforeMeta(t *testing.T) {
+	const numHdrs = 300
+	h, b := mkMetaHeaders(t, t.TempDir(), numHdrs)
+	err := os.WriteFile(h.hdrFilePath, b[:200*BTC_HEADER_SIZE], 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.writeMeta(200, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// headers fsync'ed but the metadata not yet written
+	f, err := os.OpenFile(h.hdrFilePath, os.O_WRONLY|os.O_APPEND, 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(b[200*BTC_HEADER_SIZE:])
+	f.Close()
+	good, err := h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if good != numHdrs {
+		t.Fatalf("got %d good headers", good)
+	}
+}
+
+func TestRepairHeadersFileMiddle(t *testing.T) {
+	const numHdrs = 300
+	h, b := mkMetaHeaders(t, t.TempDir(), numHdrs)
+	damaged := make([]byte, len(b))
+	copy(damaged, b)
+	damaged[50*BTC_HEADER_SIZE+4] ^= 0xff
+	err := os.WriteFile(h.hdrFilePath, damaged, 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// checksum of the tail still matches; found by the periodic full check
+	err = h.writeMeta(numHdrs, time.Now().Add(-2*HEADER_FULL_CHECK_INTERVAL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, err := h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if good != 49 {
+		t.Fatalf("expected truncate to 49 headers, got %d", good)
+	}
+}
+
+func TestRepairHeadersFileOtherNetwork(t *testing.T) {
+	h, _ := mkMetaHeaders(t, t.TempDir(), 10)
+	h.netType = "testnet"
+	good, err := h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if good != 0 || headersFileSize(t, h) != 0 {
+		t.Fatalf("expected empty headers file, got %d", good)
+	}
+	meta, _ := h.readMeta()
+	if meta.NetType != "testnet" || meta.NumHeaders != 0 {
+		t.Fatalf("bad metadata %+v", meta)
+	}
+}
+
+func TestRepairHeadersFileChecksumAppended(t *testing.T) {
+	const numHdrs = 300
+	h, b := mkMetaHeaders(t, t.TempDir(), numHdrs)
+	err := os.WriteFile(h.hdrFilePath, b[:200*BTC_HEADER_SIZE], 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.writeMeta(200, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a checksummed header damaged and more appended after the metadata
+	// write
+	damaged := make([]byte, len(b))
+	copy(damaged, b)
+	damaged[150*BTC_HEADER_SIZE+36] ^= 0xff
+	err = os.WriteFile(h.hdrFilePath, damaged, 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, err := h.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const kept = 200 - HEADER_META_CHECK_NUM
+	if good != kept || headersFileSize(t, h) != kept*BTC_HEADER_SIZE {
+		t.Fatalf("expected truncate to %d headers, got %d", kept, good)
+	}
+}
@@ -0,0 +1,145 @@
+package electrumx
+
+// Block headers from before the start point.
+//
+// The headers file only holds headers from the coin's start point checkpoint.
+// An older header is fetched from the leader with a merkle proof to the start
+// point (ElectrumX 'blockchain.block.header' with a 'cp_height'). The proof's
+// root commits to every block hash up to the start point. We check the root
+// once against the start point header we hold and then check each older
+// header's proof against the same root.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+var errBadHeaderProof = errors.New("bad block header checkpoint proof")
+
+// merkleRootFromBranch returns the merkle root from a leaf hash at index and
+// it's merkle branch, as ElectrumX builds it.
+func merkleRootFromBranch(leaf WireHash, index int64, branch []string) (WireHash, error) {
+	hash := leaf
+	for _, hexHash := range branch {
+		sibling, err := parseBlockHash(hexHash)
+		if err != nil {
+			return WireHash{}, err
+		}
+		var b [2 * HashSize]byte
+		if index&1 == 1 {
+			copy(b[:HashSize], sibling[:])
+			copy(b[HashSize:], hash[:])
+		} else {
+			copy(b[:HashSize], hash[:])
+			copy(b[HashSize:], sibling[:])
+		}
+		hash = WireHash(chainhash.DoubleHashH(b[:]))
+		index >>= 1
+	}
+	if index != 0 {
+		return WireHash{}, errBadHeaderProof
+	}
+	return hash, nil
+}
+
+// checkHeaderProof deserializes the header in a checkpoint proof for the
+// header at height and checks the proof leads to it's root.
+func (h *headers) checkHeaderProof(height int64, proof *blockHeaderProofResult) (*BlockHeader, WireHash, error) {
+	b, err := hex.DecodeString(proof.Header)
+	if err != nil {
+		return nil, WireHash{}, err
+	}
+	if len(b) != h.headerSize {
+		return nil, WireHash{}, errBadHeaderProof
+	}
+	blkHdr, err := h.headerDeserialzer.Deserialize(bytes.NewReader(b))
+	if err != nil {
+		return nil, WireHash{}, err
+	}
+	root, err := parseBlockHash(proof.Root)
+	if err != nil {
+		return nil, WireHash{}, err
+	}
+	calcRoot, err := merkleRootFromBranch(blkHdr.Hash, height, proof.Branch)
+	if err != nil {
+		return nil, WireHash{}, err
+	}
+	if calcRoot != root {
+		return nil, WireHash{}, errBadHeaderProof
+	}
+	return blkHdr, root, nil
+}
+
+// checkpointRoot returns the merkle root of all block hashes up to the start
+// point, checked against the stored start point header. It is fetched once
+// from the leader and cached.
+func (net *Network) checkpointRoot(ctx context.Context, leader *peerNode) (WireHash, error) {
+	net.cpRootMtx.Lock()
+	defer net.cpRootMtx.Unlock()
+	if net.cpRoot != nil {
+		return *net.cpRoot, nil
+	}
+	h := net.headers
+	startHdr := h.getHeaderAt(h.startPoint)
+	if startHdr == nil {
+		return WireHash{}, errors.New("no start point header stored")
+	}
+	proof, err := leader.node.blockHeaderProof(ctx, h.startPoint, h.startPoint)
+	if err != nil {
+		return WireHash{}, err
+	}
+	blkHdr, root, err := h.checkHeaderProof(h.startPoint, proof)
+	if err != nil {
+		return WireHash{}, err
+	}
+	if blkHdr.Hash != startHdr.Hash {
+		return WireHash{}, errBadHeaderProof
+	}
+	net.cpRoot = &root
+	return root, nil
+}
+
+// FetchBlockHeader returns the block header at height. Headers from the start
+// point on are stored. Older headers are requested from the leader and checked
+// with a checkpoint proof.
+func (net *Network) FetchBlockHeader(ctx context.Context, height int64) (*ClientBlockHeader, error) {
+	if !net.started {
+		return nil, errNoNetwork
+	}
+	h := net.headers
+	if height >= h.startPoint {
+		return h.getBlockHeader(height)
+	}
+	if height < 0 {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+	// not held across the requests; proofs can be slow and a leader
+	// switch must not wait on them
+	net.peersMtx.RLock()
+	leader := net.getLeader()
+	net.peersMtx.RUnlock()
+	if leader == nil {
+		return nil, errNoLeader
+	}
+	cpRoot, err := net.checkpointRoot(ctx, leader)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := leader.node.blockHeaderProof(ctx, height, h.startPoint)
+	if err != nil {
+		return nil, err
+	}
+	blkHdr, root, err := h.checkHeaderProof(height, proof)
+	if err != nil {
+		return nil, err
+	}
+	if root != cpRoot {
+		return nil, errBadHeaderProof
+	}
+	return clientBlockHeader(height, blkHdr), nil
+}
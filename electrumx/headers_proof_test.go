@@ -0,0 +1,58 @@
+package electrumx
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// mkMerkleProof builds the ElectrumX style merkle branch and root for the
+// leaf at index. An odd hash at a level is paired with itself.
+func mkMerkleProof(leaves []WireHash, index int) ([]string, WireHash) {
+	var branch []string
+	level := leaves
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		branch = append(branch, level[index^1].StringRev())
+		next := make([]WireHash, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			b := append(level[i][:], level[i+1][:]...)
+			next = append(next, WireHash(chainhash.DoubleHashH(b)))
+		}
+		level = next
+		index >>= 1
+	}
+	return branch, level[0]
+}
+
+func TestCheckHeaderProof(t *testing.T) {
+	const numHdrs = 11
+	b, hashes := mkHdrChain(t, numHdrs)
+	h := mkStoreHeaders(t.TempDir(), 0)
+
+	for _, height := range []int64{0, 5, 10} {
+		branch, root := mkMerkleProof(hashes, int(height))
+		proof := &blockHeaderProofResult{
+			Branch: branch,
+			Header: hex.EncodeToString(b[height*BTC_HEADER_SIZE : (height+1)*BTC_HEADER_SIZE]),
+			Root:   root.StringRev(),
+		}
+		blkHdr, gotRoot, err := h.checkHeaderProof(height, proof)
+		if err != nil {
+			t.Fatalf("height %d: %v", height, err)
+		}
+		if blkHdr.Hash != hashes[height] || gotRoot != root {
+			t.Fatalf("height %d: bad header or root", height)
+		}
+
+		// header from another height
+		proof.Header = hex.EncodeToString(b[(height+1)%numHdrs*BTC_HEADER_SIZE:][:BTC_HEADER_SIZE])
+		_, _, err = h.checkHeaderProof(height, proof)
+		if err == nil {
+			t.Fatalf("height %d: expected error for wrong header", height)
+		}
+	}
+}
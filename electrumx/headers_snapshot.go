@@ -0,0 +1,429 @@
+package electrumx
+
+// Headers snapshots for a fast bootstrap.
+//
+// A snapshot is a directory holding a copy of a synced 'blockchain_headers'
+// file and a 'manifest.json' describing it: coin, network, start point, the
+// tip hash, the chainwork from the start point and a sha256 of the headers.
+// The manifest can be signed with an ed25519 key.
+//
+// Importing never trusts the manifest alone. It must be signed with the
+// configured trusted key. The headers are checked to start with the network's
+// start point hash if it is known, to link from there, to meet their proof of work target
+// where the block hash is the proof of work hash and to match the coin's
+// checkpoints. The retarget rules are not checked; the key vouches for the
+// headers between checkpoints. Only then is the snapshot copied over the
+// headers file. The normal sync then fetches
+// any newer headers from the network.
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+const (
+	// Manifest file name in a headers snapshot directory
+	SNAPSHOT_MANIFEST_NAME = "manifest.json"
+	// Current snapshot manifest version
+	SNAPSHOT_VERSION = 1
+)
+
+var (
+	ErrSnapshotNotNewer = errors.New("headers snapshot has no more headers than the headers file")
+	ErrNoSnapshotKey    = errors.New("a trusted key is required to import a headers snapshot")
+	errBadSnapshot      = errors.New("bad headers snapshot")
+)
+
+// SnapshotManifest describes the headers in a headers snapshot.
+type SnapshotManifest struct {
+	Version    int    `json:"version"`
+	Coin       string `json:"coin"`
+	NetType    string `json:"net"`
+	StartPoint int64  `json:"start_point"`
+	HeaderSize int    `json:"header_size"`
+	NumHeaders int64  `json:"num_headers"`
+	TipHeight  int64  `json:"tip_height"`
+	TipHash    string `json:"tip_hash"`
+	// hex sum of the work of each header from the start point
+	ChainWork string `json:"chainwork"`
+	// hex sha256 of the headers
+	Sha256 string `json:"sha256"`
+	// hex ed25519 public key and signature of the manifest with no signature
+	PubKey    string `json:"pubkey,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// signedBytes returns the bytes a manifest signature covers.
+func (m *SnapshotManifest) signedBytes() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	return json.Marshal(&unsigned)
+}
+
+// sign signs the manifest with key.
+func (m *SnapshotManifest) sign(key ed25519.PrivateKey) error {
+	m.PubKey = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	m.Signature = ""
+	b, err := m.signedBytes()
+	if err != nil {
+		return err
+	}
+	m.Signature = hex.EncodeToString(ed25519.Sign(key, b))
+	return nil
+}
+
+// verifySignature checks the manifest is signed by key.
+func (m *SnapshotManifest) verifySignature(key ed25519.PublicKey) error {
+	sig, err := hex.DecodeString(m.Signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: not signed", errBadSnapshot)
+	}
+	b, err := m.signedBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, b, sig) {
+		return fmt.Errorf("%w: bad signature", errBadSnapshot)
+	}
+	return nil
+}
+
+// ParseSnapshotKey parses a hex encoded ed25519 public key.
+func ParseSnapshotKey(hexKey string) (ed25519.PublicKey, error) {
+	b, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key size")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// readSnapshotManifest reads the manifest from a snapshot directory.
+func readSnapshotManifest(dir string) (*SnapshotManifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, SNAPSHOT_MANIFEST_NAME))
+	if err != nil {
+		return nil, err
+	}
+	m := &SnapshotManifest{}
+	err = json.Unmarshal(b, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// snapshotStats is what a pass over the headers of a snapshot finds.
+type snapshotStats struct {
+	tipHash   WireHash
+	chainWork *big.Int
+	// number of headers from the start that link
+	linked int64
+}
+
+// scanChain passes over the first numHeaders headers in the headers file
+// checking they link and adding up their work. If cfg is not nil each header
+// is also checked against the coin's proof of work rules and checkpoints and
+// the first against the start point hash.
+func (h *headers) scanChain(numHeaders int64, cfg *ElectrumXConfig) (*snapshotStats, error) {
+	checkpoints := make(map[int64]string)
+	if cfg != nil {
+		for _, cp := range cfg.Checkpoints {
+			checkpoints[cp.Height] = cp.Hash
+		}
+	}
+	stats := &snapshotStats{chainWork: new(big.Int)}
+	err := h.forEachFileHdr(0, numHeaders, func(pos int64, blkHdrs []*BlockHeader) error {
+		for i, blkHdr := range blkHdrs {
+			height := h.startPoint + pos + int64(i)
+			if stats.linked > 0 && blkHdr.Prev != stats.tipHash {
+				return fmt.Errorf("%w: chain breaks at height %d", errBadSnapshot, height)
+			}
+			if cfg != nil && stats.linked == 0 && cfg.StartHash != "" &&
+				blkHdr.Hash.StringRev() != cfg.StartHash {
+				return fmt.Errorf("%w: start point hash mismatch", errBadSnapshot)
+			}
+			if cfg != nil {
+				if cfg.HashIsPoW {
+					err := checkProofOfWork(blkHdr, cfg.PowLimit)
+					if err != nil {
+						return fmt.Errorf("%w: height %d: %v", errBadSnapshot, height, err)
+					}
+				}
+				cpHash, ok := checkpoints[height]
+				if ok && blkHdr.Hash.StringRev() != cpHash {
+					return fmt.Errorf("%w: checkpoint mismatch at height %d", errBadSnapshot, height)
+				}
+			}
+			stats.chainWork.Add(stats.chainWork, blockchain.CalcWork(blkHdr.Bits))
+			stats.tipHash = blkHdr.Hash
+			stats.linked++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// checkProofOfWork checks a block hash meets the target in it's header and
+// the target is no easier than powLimit if not nil.
+func checkProofOfWork(blkHdr *BlockHeader, powLimit *big.Int) error {
+	target := blockchain.CompactToBig(blkHdr.Bits)
+	if target.Sign() <= 0 {
+		return errors.New("target not positive")
+	}
+	if powLimit != nil && target.Cmp(powLimit) > 0 {
+		return errors.New("target above the pow limit")
+	}
+	hash := chainhash.Hash(blkHdr.Hash)
+	if blockchain.HashToBig(&hash).Cmp(target) > 0 {
+		return errors.New("hash above target")
+	}
+	return nil
+}
+
+// copyFileSync copies size bytes of src to a temporary file, fsyncs and
+// renames it over dst. If wantSum is not "" the hex sha256 of the bytes copied
+// must match it or dst is left alone. Returns the hex sha256.
+func copyFileSync(src, dst string, size int64, wantSum string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	tmpPath := dst + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0664)
+	if err != nil {
+		return "", err
+	}
+	sha := sha256.New()
+	_, err = io.CopyN(io.MultiWriter(out, sha), in, size)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	sum := hex.EncodeToString(sha.Sum(nil))
+	if err == nil && wantSum != "" && sum != wantSum {
+		err = errors.New("sha256 mismatch")
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	err = os.Rename(tmpPath, dst)
+	if err != nil {
+		return "", err
+	}
+	syncDir(filepath.Dir(dst))
+	return sum, nil
+}
+
+// fileSha256 returns the hex sha256 of the first size bytes of a file.
+func fileSha256(path string, size int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sha := sha256.New()
+	_, err = io.CopyN(sha, f, size)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sha.Sum(nil)), nil
+}
+
+// ExportHeadersSnapshot writes the headers file for the coin and network in
+// cfg to a snapshot in outDir with it's manifest. Only headers that link from
+// the start point are exported. If signKey is not nil the manifest is signed.
+// The network should not be running on the same data directory.
+func ExportHeadersSnapshot(cfg *ElectrumXConfig, outDir string, signKey ed25519.PrivateKey) (*SnapshotManifest, error) {
+	h := newHeaders(cfg)
+	numHeaders, err := h.repairHeadersFile()
+	if err != nil {
+		return nil, err
+	}
+	if numHeaders == 0 {
+		return nil, errors.New("no headers to export")
+	}
+	stats, err := h.scanChain(numHeaders, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(outDir, 0775)
+	if err != nil {
+		return nil, err
+	}
+	size := numHeaders * int64(h.headerSize)
+	sum, err := copyFileSync(h.hdrFilePath, filepath.Join(outDir, HEADER_FILE_NAME), size, "")
+	if err != nil {
+		return nil, err
+	}
+	m := &SnapshotManifest{
+		Version:    SNAPSHOT_VERSION,
+		Coin:       h.coin,
+		NetType:    h.netType,
+		StartPoint: h.startPoint,
+		HeaderSize: h.headerSize,
+		NumHeaders: numHeaders,
+		TipHeight:  h.startPoint + numHeaders - 1,
+		TipHash:    stats.tipHash.StringRev(),
+		ChainWork:  stats.chainWork.Text(16),
+		Sha256:     sum,
+	}
+	if signKey != nil {
+		err = m.sign(signKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = writeFileSync(filepath.Join(outDir, SNAPSHOT_MANIFEST_NAME), b)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ImportHeadersSnapshot verifies the snapshot in inDir and if it has more
+// headers than the headers file for the coin and network in cfg replaces the
+// headers file with it. The manifest must be signed with trustedKey and the
+// first header must be at cfg.StartHash if it is set. Returns
+// ErrSnapshotNotNewer if the headers file already has as many headers. The
+// network should not be running on the same data directory.
+func ImportHeadersSnapshot(cfg *ElectrumXConfig, inDir string,
+	trustedKey ed25519.PublicKey) (*SnapshotManifest, error) {
+
+	if trustedKey == nil {
+		return nil, ErrNoSnapshotKey
+	}
+	if cfg.StartHash == "" {
+		fmt.Printf("no start point hash for %s %s - the snapshot start is trusted on it's signature\n",
+			cfg.Coin, cfg.NetType)
+	}
+	m, err := readSnapshotManifest(inDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. The manifest is for our headers file and signed by the trusted key
+	h := newHeaders(cfg)
+	if m.Version != SNAPSHOT_VERSION {
+		return nil, fmt.Errorf("%w: unknown version %d", errBadSnapshot, m.Version)
+	}
+	if m.Coin != h.coin || m.NetType != h.netType || m.StartPoint != h.startPoint ||
+		m.HeaderSize != h.headerSize {
+		return nil, fmt.Errorf("%w: for %s %s start point %d header size %d", errBadSnapshot,
+			m.Coin, m.NetType, m.StartPoint, m.HeaderSize)
+	}
+	if m.NumHeaders <= 0 || m.TipHeight != m.StartPoint+m.NumHeaders-1 {
+		return nil, fmt.Errorf("%w: bad header count", errBadSnapshot)
+	}
+	err = m.verifySignature(trustedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Not older than what we have
+	numHeaders, err := h.repairHeadersFile()
+	if err != nil {
+		return nil, err
+	}
+	if numHeaders >= m.NumHeaders {
+		return m, ErrSnapshotNotNewer
+	}
+
+	// 3. The headers are the ones in the manifest
+	snapPath := filepath.Join(inDir, HEADER_FILE_NAME)
+	fi, err := os.Stat(snapPath)
+	if err != nil {
+		return nil, err
+	}
+	size := m.NumHeaders * int64(h.headerSize)
+	if fi.Size() != size {
+		return nil, fmt.Errorf("%w: headers file size %d", errBadSnapshot, fi.Size())
+	}
+	sum, err := fileSha256(snapPath, size)
+	if err != nil {
+		return nil, err
+	}
+	if sum != m.Sha256 {
+		return nil, fmt.Errorf("%w: sha256 mismatch", errBadSnapshot)
+	}
+
+	// 4. The chain starts at any start point hash, links, meets it's proof of
+	//    work and checkpoints and ends at the manifest's tip with it's
+	//    chainwork
+	snap := newHeaders(cfg)
+	snap.hdrFilePath = snapPath
+	stats, err := snap.scanChain(m.NumHeaders, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if stats.tipHash.StringRev() != m.TipHash {
+		return nil, fmt.Errorf("%w: tip hash mismatch", errBadSnapshot)
+	}
+	if stats.chainWork.Text(16) != m.ChainWork {
+		return nil, fmt.Errorf("%w: chainwork mismatch", errBadSnapshot)
+	}
+
+	// 5. Adopt unless the snapshot changed since it was checked. The hash
+	//    index is rebuilt from the new file.
+	_, err = copyFileSync(snapPath, h.hdrFilePath, size, m.Sha256)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(h.idxFilePath)
+	err = h.writeMeta(m.NumHeaders, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// importHeadersSnapshot imports the configured headers snapshot if any before
+// the headers sync. A snapshot that cannot be used is logged and ignored; the
+// headers are then synced from the network - locked under startMtx
+func (net *Network) importHeadersSnapshot() {
+	cfg := net.config
+	if cfg.HeadersSnapshot == "" {
+		return
+	}
+	if cfg.HeadersSnapshotKey == "" {
+		fmt.Printf("headers snapshot %s not imported - %v\n", cfg.HeadersSnapshot, ErrNoSnapshotKey)
+		return
+	}
+	trustedKey, err := ParseSnapshotKey(cfg.HeadersSnapshotKey)
+	if err != nil {
+		fmt.Printf("headers snapshot key - %v\n", err)
+		return
+	}
+	m, err := ImportHeadersSnapshot(cfg, cfg.HeadersSnapshot, trustedKey)
+	if err != nil {
+		if !errors.Is(err, ErrSnapshotNotNewer) {
+			fmt.Printf("headers snapshot %s not imported - %v\n", cfg.HeadersSnapshot, err)
+		}
+		return
+	}
+	fmt.Printf("imported headers snapshot up to height %d\n", m.TipHeight)
+}
@@ -0,0 +1,284 @@
+package electrumx
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// mkMinedHdrChain makes a chain of count headers that meet their regtest
+// proof of work target.
+func mkMinedHdrChain(t *testing.T, count int) ([]byte, []WireHash) {
+	t.Helper()
+	var buf bytes.Buffer
+	hashes := make([]WireHash, 0, count)
+	var prev chainhash.Hash
+	target := blockchain.CompactToBig(0x207fffff)
+	for i := 0; i < count; i++ {
+		wireHdr := wire.BlockHeader{
+			Version:   0x20000000,
+			PrevBlock: prev,
+			Timestamp: time.Unix(mkHdrChainTime+int64(i)*600, 0),
+			Bits:      0x207fffff,
+		}
+		for {
+			hash := wireHdr.BlockHash()
+			if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+				break
+			}
+			wireHdr.Nonce++
+		}
+		err := wireHdr.Serialize(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev = wireHdr.BlockHash()
+		hashes = append(hashes, WireHash(prev))
+	}
+	return buf.Bytes(), hashes
+}
+
+func mkSnapshotConfig(dir string, startPoint int64) *ElectrumXConfig {
+	return &ElectrumXConfig{
+		Coin:               "btc",
+		NetType:            Regtest,
+		DataDir:            dir,
+		StartPoint:         startPoint,
+		BlockHeaderSize:    BTC_HEADER_SIZE,
+		HeaderDeserializer: btcDeserializer,
+		HashIsPoW:          true,
+	}
+}
+
+func TestHeadersSnapshot(t *testing.T) {
+	const startPoint = 100
+	const numHdrs = 300
+	b, hashes := mkMinedHdrChain(t, numHdrs)
+
+	srcCfg := mkSnapshotConfig(t.TempDir(), startPoint)
+	h := newHeaders(srcCfg)
+	_, err := h.appendHeadersFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapDir := filepath.Join(t.TempDir(), "snapshot")
+	m, err := ExportHeadersSnapshot(srcCfg, snapDir, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.NumHeaders != numHdrs || m.TipHeight != startPoint+numHdrs-1 ||
+		m.TipHash != hashes[numHdrs-1].StringRev() {
+		t.Fatalf("bad manifest %+v", m)
+	}
+
+	// import into a data dir with a few headers
+	dstCfg := mkSnapshotConfig(t.TempDir(), startPoint)
+	dstCfg.StartHash = hashes[0].StringRev()
+	dstCfg.Checkpoints = []Checkpoint{{Height: startPoint + 10, Hash: hashes[10].StringRev()}}
+	dst := newHeaders(dstCfg)
+	_, err = dst.appendHeadersFile(b[:10*BTC_HEADER_SIZE])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ImportHeadersSnapshot(dstCfg, snapDir, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst.hdrFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, b) {
+		t.Fatal("imported headers differ")
+	}
+	n, err := dst.repairHeadersFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != numHdrs {
+		t.Fatalf("%d headers after import", n)
+	}
+	_, err = ImportHeadersSnapshot(dstCfg, snapDir, pub)
+	if !errors.Is(err, ErrSnapshotNotNewer) {
+		t.Fatalf("expected not newer, got %v", err)
+	}
+}
+
+func TestHeadersSnapshotRejected(t *testing.T) {
+	const startPoint = 0
+	const numHdrs = 50
+	b, hashes := mkMinedHdrChain(t, numHdrs)
+
+	tests := []struct {
+		name string
+		// change the snapshot and config before importing
+		modify func(t *testing.T, snapDir string, cfg *ElectrumXConfig)
+		// import with another key or none
+		otherKey bool
+		noKey    bool
+		wantErr  error
+	}{
+		{
+			name:     "other key",
+			otherKey: true,
+		},
+		{
+			name: "other net",
+			modify: func(t *testing.T, snapDir string, cfg *ElectrumXConfig) {
+				cfg.NetType = Testnet
+			},
+		},
+		{
+			name: "tampered headers",
+			modify: func(t *testing.T, snapDir string, cfg *ElectrumXConfig) {
+				path := filepath.Join(snapDir, HEADER_FILE_NAME)
+				snap, _ := os.ReadFile(path)
+				snap[len(snap)-1] ^= 1
+				os.WriteFile(path, snap, 0664)
+			},
+		},
+		{
+			name: "checkpoint mismatch",
+			modify: func(t *testing.T, snapDir string, cfg *ElectrumXConfig) {
+				cfg.Checkpoints = []Checkpoint{{Height: 20, Hash: hashes[21].StringRev()}}
+			},
+		},
+		{
+			name: "above pow limit",
+			modify: func(t *testing.T, snapDir string, cfg *ElectrumXConfig) {
+				cfg.PowLimit = blockchain.CompactToBig(0x1d00ffff)
+			},
+		},
+		{
+			name: "unsigned",
+			modify: func(t *testing.T, snapDir string, cfg *ElectrumXConfig) {
+				m, _ := readSnapshotManifest(snapDir)
+				m.Signature = ""
+				b, _ := json.Marshal(m)
+				os.WriteFile(filepath.Join(snapDir, SNAPSHOT_MANIFEST_NAME), b, 0664)
+			},
+		},
+		{
+			name:    "no trusted key",
+			noKey:   true,
+			wantErr: ErrNoSnapshotKey,
+		},
+		{
+			name: "start hash mismatch",
+			modify: func(t *testing.T, snapDir string, cfg *ElectrumXConfig) {
+				cfg.StartHash = hashes[1].StringRev()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcCfg := mkSnapshotConfig(t.TempDir(), startPoint)
+			_, err := newHeaders(srcCfg).appendHeadersFile(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, priv, _ := ed25519.GenerateKey(nil)
+			snapDir := t.TempDir()
+			_, err = ExportHeadersSnapshot(srcCfg, snapDir, priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dstCfg := mkSnapshotConfig(t.TempDir(), startPoint)
+			dstCfg.StartHash = hashes[0].StringRev()
+			trustedKey := pub
+			if tt.otherKey {
+				trustedKey, _, _ = ed25519.GenerateKey(nil)
+			}
+			if tt.noKey {
+				trustedKey = nil
+			}
+			if tt.modify != nil {
+				tt.modify(t, snapDir, dstCfg)
+			}
+			_, err = ImportHeadersSnapshot(dstCfg, snapDir, trustedKey)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			wantErr := tt.wantErr
+			if wantErr == nil {
+				wantErr = errBadSnapshot
+			}
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			n, _ := newHeaders(dstCfg).numHeadersInFile()
+			if n != 0 {
+				t.Fatalf("%d headers imported", n)
+			}
+		})
+	}
+}
+
+func TestHeadersSnapshotStartPoint(t *testing.T) {
+	const numHdrs = 50
+	b, hashes := mkMinedHdrChain(t, numHdrs)
+	params := &NetParams{
+		StartPoint: 823000,
+		Genesis:    "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206",
+	}
+
+	srcCfg := mkSnapshotConfig(t.TempDir(), 0)
+	params.Apply(srcCfg)
+	_, err := newHeaders(srcCfg).appendHeadersFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	snapDir := t.TempDir()
+	_, err = ExportHeadersSnapshot(srcCfg, snapDir, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		startHash string
+		wantErr   error
+	}{
+		{name: "start hash", startHash: hashes[0].StringRev()},
+		{name: "no start hash"},
+		{name: "other start hash", startHash: hashes[1].StringRev(), wantErr: errBadSnapshot},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *params
+			p.StartHash = tt.startHash
+			dstCfg := mkSnapshotConfig(t.TempDir(), 0)
+			p.Apply(dstCfg)
+			m, err := ImportHeadersSnapshot(dstCfg, snapDir, pub)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.StartPoint != 823000 || m.TipHeight != 823000+numHdrs-1 {
+				t.Fatalf("bad manifest %+v", m)
+			}
+			n, _ := newHeaders(dstCfg).numHeadersInFile()
+			if n != numHdrs {
+				t.Fatalf("%d headers imported", n)
+			}
+		})
+	}
+}
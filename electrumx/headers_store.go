@@ -0,0 +1,301 @@
+package electrumx
+
+// Bounded memory header store.
+//
+// Only the most recent headers below the tip are kept in memory. Older headers
+// are read from the 'blockchain_headers' file by offset as needed and kept in a
+// small LRU cache. Block hash lookups go through the on disk hash index. So
+// startup time and memory use stay flat as the chain grows.
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// Number of headers below the tip kept in memory
+	RECENT_HDRS_IN_MEM = 2 * ELECTRUM_MAGIC_NUMHDR // 4032
+	// Number of older headers read from file kept in the LRU cache
+	HDR_CACHE_SIZE = 1024
+)
+
+// hdrCache is an LRU cache of headers by height.
+type hdrCache struct {
+	size  int
+	order *list.List // front is most recently used
+	items map[int64]*list.Element
+	mtx   sync.Mutex
+}
+
+type hdrCacheEntry struct {
+	height int64
+	hdr    *BlockHeader
+}
+
+func newHdrCache(size int) *hdrCache {
+	return &hdrCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[int64]*list.Element, size),
+	}
+}
+
+func (c *hdrCache) get(height int64) *BlockHeader {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	el, ok := c.items[height]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*hdrCacheEntry).hdr
+}
+
+func (c *hdrCache) add(height int64, hdr *BlockHeader) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if el, ok := c.items[height]; ok {
+		el.Value.(*hdrCacheEntry).hdr = hdr
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[height] = c.order.PushFront(&hdrCacheEntry{height: height, hdr: hdr})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*hdrCacheEntry).height)
+	}
+}
+
+func (c *hdrCache) remove(height int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if el, ok := c.items[height]; ok {
+		c.order.Remove(el)
+		delete(c.items, height)
+	}
+}
+
+func (c *hdrCache) len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.order.Len()
+}
+
+// ----------------------------------------------------------------------------
+// File records
+// ----------------------------------------------------------------------------
+
+// hdrPos returns the position of the header for height in the headers file.
+func (h *headers) hdrPos(height int64) int64 {
+	return height - h.startPoint
+}
+
+// readBytesFromFile reads count raw headers from the headers file starting at
+// position pos.
+func (h *headers) readBytesFromFile(pos, count int64) ([]byte, error) {
+	if count <= 0 {
+		return []byte{}, nil
+	}
+	hdrFile, err := os.Open(h.hdrFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer hdrFile.Close()
+	headerSize := int64(h.headerSize)
+	b := make([]byte, count*headerSize)
+	n, err := hdrFile.ReadAt(b, pos*headerSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if int64(n) != count*headerSize {
+		return nil, errors.New("read past the end of the headers file")
+	}
+	return b, nil
+}
+
+// readHdrsFromFile reads and deserializes count headers from the headers file
+// starting at position pos.
+func (h *headers) readHdrsFromFile(pos, count int64) ([]*BlockHeader, error) {
+	b, err := h.readBytesFromFile(pos, count)
+	if err != nil {
+		return nil, err
+	}
+	rdr := bytes.NewBuffer(b)
+	blkHdrs := make([]*BlockHeader, 0, count)
+	for i := int64(0); i < count; i++ {
+		blkHdr, err := h.headerDeserialzer.Deserialize(rdr)
+		if err != nil {
+			return nil, err
+		}
+		blkHdrs = append(blkHdrs, blkHdr)
+	}
+	return blkHdrs, nil
+}
+
+// forEachFileHdr streams headers from position 'from' up to but not including
+// position 'to' from the headers file in chunks, calling fn with each chunk and
+// the position of it's first header.
+func (h *headers) forEachFileHdr(from, to int64, fn func(pos int64, blkHdrs []*BlockHeader) error) error {
+	for pos := from; pos < to; pos += ELECTRUM_MAGIC_NUMHDR {
+		count := min(ELECTRUM_MAGIC_NUMHDR, to-pos)
+		blkHdrs, err := h.readHdrsFromFile(pos, count)
+		if err != nil {
+			return err
+		}
+		err = fn(pos, blkHdrs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Store
+// ----------------------------------------------------------------------------
+
+// openStore opens the hash index and brings it up to date with the numHeaders
+// headers in the headers file. Then loads the most recent headers into memory
+// and sets the tip.
+func (h *headers) openStore(numHeaders int64) error {
+	if h.index == nil {
+		index, err := openHashIndex(h.idxFilePath)
+		if err != nil {
+			return err
+		}
+		h.index = index
+	}
+	err := h.index.setIndexed(numHeaders)
+	if err != nil {
+		return err
+	}
+	indexed := h.index.getIndexed()
+	if indexed < numHeaders {
+		fmt.Printf("indexing %d headers\n", numHeaders-indexed)
+		err = h.forEachFileHdr(indexed, numHeaders, func(pos int64, blkHdrs []*BlockHeader) error {
+			hashes := make([]WireHash, 0, len(blkHdrs))
+			for _, blkHdr := range blkHdrs {
+				hashes = append(hashes, blkHdr.Hash)
+			}
+			return h.index.addMany(hashes, pos)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	h.hdrsMtx.Lock()
+	defer h.hdrsMtx.Unlock()
+	h.clearMem()
+	from := max(0, numHeaders-RECENT_HDRS_IN_MEM)
+	blkHdrs, err := h.readHdrsFromFile(from, numHeaders-from)
+	if err != nil {
+		return err
+	}
+	for i, blkHdr := range blkHdrs {
+		h.putRecent(h.startPoint+from+int64(i), blkHdr)
+	}
+	h.setTip(h.startPoint + numHeaders - 1)
+	return nil
+}
+
+// putRecent - locked under hdrsMtx
+func (h *headers) putRecent(height int64, blkHdr *BlockHeader) {
+	if old, ok := h.recent[height]; ok {
+		delete(h.recentHashes, old.Hash)
+	}
+	h.recent[height] = blkHdr
+	h.recentHashes[blkHdr.Hash] = height
+}
+
+// dropRecent - locked under hdrsMtx
+func (h *headers) dropRecent(height int64) {
+	if old, ok := h.recent[height]; ok {
+		delete(h.recentHashes, old.Hash)
+		delete(h.recent, height)
+	}
+}
+
+// trimRecent drops in memory headers that are more than RECENT_HDRS_IN_MEM
+// below newest - locked under hdrsMtx
+func (h *headers) trimRecent(newest int64) {
+	if len(h.recent) <= RECENT_HDRS_IN_MEM {
+		return
+	}
+	for height := range h.recent {
+		if height <= newest-RECENT_HDRS_IN_MEM {
+			h.dropRecent(height)
+		}
+	}
+}
+
+// clearMem forgets all headers held in memory - locked under hdrsMtx
+func (h *headers) clearMem() {
+	h.recent = make(map[int64]*BlockHeader, RECENT_HDRS_IN_MEM+1)
+	h.recentHashes = make(map[WireHash]int64, RECENT_HDRS_IN_MEM+1)
+	h.cache = newHdrCache(HDR_CACHE_SIZE)
+}
+
+// headerAt returns the header at height from memory, the cache or the headers
+// file. Returns nil if there is no header stored at height - locked under
+// hdrsMtx for read
+func (h *headers) headerAt(height int64) *BlockHeader {
+	if blkHdr, ok := h.recent[height]; ok {
+		return blkHdr
+	}
+	if height < h.startPoint || height > h.getTip() {
+		return nil
+	}
+	if h.cache != nil {
+		if blkHdr := h.cache.get(height); blkHdr != nil {
+			return blkHdr
+		}
+	}
+	blkHdrs, err := h.readHdrsFromFile(h.hdrPos(height), 1)
+	if err != nil {
+		return nil
+	}
+	if h.cache != nil {
+		h.cache.add(height, blkHdrs[0])
+	}
+	return blkHdrs[0]
+}
+
+// getHeaderAt is headerAt for callers not holding hdrsMtx
+func (h *headers) getHeaderAt(height int64) *BlockHeader {
+	h.hdrsMtx.RLock()
+	defer h.hdrsMtx.RUnlock()
+	return h.headerAt(height)
+}
+
+// heightForHash looks up the height of a stored header from it's block hash
+// - locked under hdrsMtx for read
+func (h *headers) heightForHash(blkHash WireHash) (int64, bool) {
+	if height, ok := h.recentHashes[blkHash]; ok {
+		return height, true
+	}
+	if h.index == nil {
+		return 0, false
+	}
+	pos, ok, err := h.index.lookup(blkHash, func(pos int64) bool {
+		blkHdr := h.headerAt(h.startPoint + pos)
+		return blkHdr != nil && blkHdr.Hash == blkHash
+	})
+	if err != nil || !ok {
+		return 0, false
+	}
+	return h.startPoint + pos, true
+}
+
+// getHeightForHash is heightForHash for callers not holding hdrsMtx
+func (h *headers) getHeightForHash(blkHash WireHash) (int64, bool) {
+	h.hdrsMtx.RLock()
+	defer h.hdrsMtx.RUnlock()
+	return h.heightForHash(blkHash)
+}
@@ -0,0 +1,332 @@
+package electrumx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const mkHdrChainTime = 1_700_000_000
+
+// mkHdrChain makes count linked 80 byte btc style headers.
+func mkHdrChain(t *testing.T, count int) ([]byte, []WireHash) {
+	t.Helper()
+	var buf bytes.Buffer
+	hashes := make([]WireHash, 0, count)
+	var prev chainhash.Hash
+	for i := 0; i < count; i++ {
+		wireHdr := wire.BlockHeader{
+			Version:   0x20000000,
+			PrevBlock: prev,
+			Timestamp: time.Unix(mkHdrChainTime+int64(i)*600, 0),
+			Bits:      0x207fffff,
+			Nonce:     uint32(i),
+		}
+		err := wireHdr.Serialize(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev = wireHdr.BlockHash()
+		hashes = append(hashes, WireHash(prev))
+	}
+	return buf.Bytes(), hashes
+}
+
+func mkStoreHeaders(dir string, startPoint int64) *headers {
+	h := &headers{
+		headerSize:        BTC_HEADER_SIZE,
+		headerDeserialzer: btcDeserializer,
+		hdrFilePath:       filepath.Join(dir, HEADER_FILE_NAME),
+		idxFilePath:       filepath.Join(dir, HEADER_INDEX_FILE_NAME),
+		startPoint:        startPoint,
+	}
+	h.clearMem()
+	return h
+}
+
+func TestHeaderStore(t *testing.T) {
+	dir := t.TempDir()
+	const startPoint = 1000
+	const numHdrs = RECENT_HDRS_IN_MEM + 1000
+	b, hashes := mkHdrChain(t, numHdrs)
+
+	h := mkStoreHeaders(dir, startPoint)
+	_, err := h.appendHeadersFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := h.numHeadersInFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != numHdrs {
+		t.Fatalf("got %d headers in file", n)
+	}
+	err = h.openStore(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip := int64(startPoint + numHdrs - 1)
+	if h.getTip() != tip {
+		t.Fatalf("bad tip %d", h.getTip())
+	}
+	if len(h.recent) != RECENT_HDRS_IN_MEM {
+		t.Fatalf("got %d headers in memory", len(h.recent))
+	}
+	err = h.verifyAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.verifyFromTip(RECENT_HDRS_IN_MEM-1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// from memory, cache and file
+	for _, height := range []int64{tip, tip - 10, startPoint, startPoint + 1, startPoint} {
+		hdr := h.getHeaderAt(height)
+		if hdr == nil || hdr.Hash != hashes[height-startPoint] {
+			t.Fatalf("bad header at %d", height)
+		}
+	}
+	if h.cache.len() != 2 {
+		t.Fatalf("expected 2 cached headers, got %d", h.cache.len())
+	}
+	if h.getHeaderAt(startPoint-1) != nil || h.getHeaderAt(tip+1) != nil {
+		t.Fatal("expected no header out of range")
+	}
+
+	// hash lookups in memory and on disk
+	for _, pos := range []int64{0, 1, 500, numHdrs - 1} {
+		height, ok := h.getHeightForHash(hashes[pos])
+		if !ok || height != startPoint+pos {
+			t.Fatalf("hash lookup for position %d: got %d %v", pos, height, ok)
+		}
+	}
+	if _, ok := h.getHeightForHash(WireHash{1, 2, 3}); ok {
+		t.Fatal("found unknown hash")
+	}
+
+	// restart: index is up to date and not rebuilt
+	h.index.close()
+	h2 := mkStoreHeaders(dir, startPoint)
+	err = h2.openStore(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	height, ok := h2.getHeightForHash(hashes[1])
+	if !ok || height != startPoint+1 {
+		t.Fatal("hash lookup after restart")
+	}
+
+	// truncate: removed headers are no longer found
+	h2.synced = true
+	_, err = h2.truncateHeadersFile(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		h2.removeOneHdrFromTip()
+	}
+	if _, ok := h2.getHeightForHash(hashes[numHdrs-1]); ok {
+		t.Fatal("found truncated header")
+	}
+	if h2.getHeaderAt(tip) != nil {
+		t.Fatal("got truncated header")
+	}
+	h2.index.close()
+}
+
+func TestHashIndex(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, HEADER_INDEX_FILE_NAME)
+	idx, err := openHashIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// enough to grow the table
+	numHashes := hashIndexInitSlots
+	_, hashes := mkHdrChain(t, numHashes)
+	err = idx.addMany(hashes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.slots <= hashIndexInitSlots {
+		t.Fatalf("expected table to grow, slots %d", idx.slots)
+	}
+	// duplicate is not added twice
+	count := idx.count
+	err = idx.add(hashes[7], 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.count != count {
+		t.Fatal("duplicate added")
+	}
+	isMatch := func(hash WireHash) func(pos int64) bool {
+		return func(pos int64) bool {
+			return hashes[pos] == hash
+		}
+	}
+	for _, pos := range []int64{0, 7, int64(numHashes - 1)} {
+		got, ok, err := idx.lookup(hashes[pos], isMatch(hashes[pos]))
+		if err != nil || !ok || got != pos {
+			t.Fatalf("lookup position %d: got %d %v %v", pos, got, ok, err)
+		}
+	}
+	idx.close()
+
+	// reopen
+	idx, err = openHashIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.getIndexed() != int64(numHashes) {
+		t.Fatalf("got %d indexed", idx.getIndexed())
+	}
+	// wind back
+	err = idx.setIndexed(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok, _ := idx.lookup(hashes[7], isMatch(hashes[7]))
+	if ok {
+		t.Fatal("found header past indexed")
+	}
+	idx.close()
+
+	// garbage file is replaced
+	err = os.WriteFile(path, []byte("not an index"), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err = openHashIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.getIndexed() != 0 {
+		t.Fatal("expected empty index")
+	}
+	idx.close()
+}
+
+func TestHeaderLookups(t *testing.T) {
+	dir := t.TempDir()
+	const numHdrs = 20
+	b, hashes := mkHdrChain(t, numHdrs)
+	h := mkStoreHeaders(dir, 0)
+	_, err := h.appendHeadersFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.openStore(numHdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.index.close()
+
+	hash := hashes[7].StringRev()
+	hdr, err := h.getBlockHeaderByHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Height != 7 || hdr.Hash != hash || hdr.Prev != hashes[6].StringRev() {
+		t.Fatalf("bad header %v", hdr)
+	}
+	if hdr.Timestamp.Unix() != mkHdrChainTime+7*600 || hdr.Nonce != 7 ||
+		hdr.Bits != 0x207fffff || hdr.Version != 0x20000000 {
+		t.Fatalf("bad header fields %v", hdr)
+	}
+	if hdr.Work == nil || hdr.Work.Sign() <= 0 {
+		t.Fatal("expected work")
+	}
+	height, err := h.getHeightForBlockHash(hash)
+	if err != nil || height != 7 {
+		t.Fatalf("got height %d %v", height, err)
+	}
+	_, err = h.getHeightForBlockHash(WireHash{1}.StringRev())
+	if err == nil {
+		t.Fatal("expected error for unknown hash")
+	}
+	_, err = h.getBlockHeaderByHash("not a hash")
+	if err == nil {
+		t.Fatal("expected error for bad hash")
+	}
+
+	// median of heights 5..15
+	mtp, err := h.medianTimePast(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mtp.Unix() != mkHdrChainTime+10*600 {
+		t.Fatalf("bad median time %d", mtp.Unix())
+	}
+	// near genesis fewer blocks are used: median of heights 0..3
+	mtp, err = h.medianTimePast(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mtp.Unix() != mkHdrChainTime+2*600 {
+		t.Fatalf("bad median time %d", mtp.Unix())
+	}
+	_, err = h.medianTimePast(numHdrs)
+	if err == nil {
+		t.Fatal("expected error past tip")
+	}
+
+	// above a checkpoint the blocks before the start point are not known
+	h2 := mkStoreHeaders(t.TempDir(), 1000)
+	_, err = h2.appendHeadersFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h2.openStore(numHdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.index.close()
+	_, err = h2.medianTimePast(1005)
+	if err == nil {
+		t.Fatal("expected error near start point")
+	}
+	mtp, err = h2.medianTimePast(1010)
+	if err != nil || mtp.Unix() != mkHdrChainTime+5*600 {
+		t.Fatalf("bad median time %d %v", mtp.Unix(), err)
+	}
+}
+
+func TestStoreOneHdrIndexError(t *testing.T) {
+	dir := t.TempDir()
+	const startPoint = 1000
+	b, _ := mkHdrChain(t, 11)
+	hdrSize := int64(BTC_HEADER_SIZE)
+
+	h := mkStoreHeaders(dir, startPoint)
+	_, err := h.appendHeadersFile(b[:10*hdrSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.openStore(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip := h.getTip()
+	next, err := h.headerDeserialzer.Deserialize(bytes.NewReader(b[10*hdrSize:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the index can no longer be written
+	h.index.close()
+	err = h.storeOneHdr(next)
+	if err == nil {
+		t.Fatal("expected an index error")
+	}
+	if h.getTip() != tip || h.getHeaderAt(tip+1) != nil {
+		t.Fatal("header stored after index error")
+	}
+}
@@ -0,0 +1,188 @@
+package electrumx
+
+// Parallel headers download.
+//
+// On sync the headers from the end of the headers file up to the tip are
+// fetched in ELECTRUM_MAGIC_NUMHDR sized chunks. In each round the leader and up
+// to SYNC_HELPERS other connected peers fetch one chunk each at the same time.
+// The chunks are then checked to link to the chain so far and appended to the
+// headers file in order.
+//
+// A chunk that a helper peer fails to fetch, that does not link or that is
+// short is fetched again from the leader. Only the leader decides where the
+// chain ends.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// Max number of peers other than the leader fetching headers on sync
+	SYNC_HELPERS = 3
+	// Pause between rounds of chunk requests so no server is hammered
+	syncRoundInterval = 250 * time.Millisecond
+)
+
+// hdrsSource is a server headers can be fetched from.
+type hdrsSource struct {
+	name string
+	// context of the source's node
+	ctx   context.Context
+	fetch func(ctx context.Context, startHeight int64, count int) (*getBlockHeadersResult, error)
+}
+
+// newHdrsSource makes a headers source from a running peer.
+func newHdrsSource(peer *peerNode) *hdrsSource {
+	return &hdrsSource{
+		name:  peer.netAddr.String(),
+		ctx:   peer.nodeCtx,
+		fetch: peer.node.blockHeaders,
+	}
+}
+
+// hdrsChunk is a chunk of raw headers fetched from a source.
+type hdrsChunk struct {
+	startHeight int64
+	count       int
+	b           []byte
+	from        string
+	err         error
+}
+
+// fetchChunk fetches up to count headers from startHeight. The request is
+// canceled if either ctx or the source's node context is done.
+func (src *hdrsSource) fetchChunk(ctx context.Context, startHeight int64, count int) *hdrsChunk {
+	chunk := &hdrsChunk{startHeight: startHeight, from: src.name}
+	reqCtx, cancel := context.WithCancel(src.ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	res, err := src.fetch(reqCtx, startHeight, count)
+	if err != nil {
+		chunk.err = err
+		return chunk
+	}
+	b, err := hex.DecodeString(res.HexConcat)
+	if err != nil {
+		chunk.err = err
+		return chunk
+	}
+	chunk.count = res.Count
+	chunk.b = b
+	return chunk
+}
+
+// linkChunk checks the chunk's headers link to the header with prevHash and to
+// each other and returns the hash of it's last header. If havePrev is false
+// the chunk starts the headers file.
+func (h *headers) linkChunk(chunk *hdrsChunk, prevHash WireHash, havePrev bool) (WireHash, error) {
+	if chunk.err != nil {
+		return WireHash{}, chunk.err
+	}
+	if len(chunk.b) != chunk.count*h.headerSize {
+		return WireHash{}, errors.New("headers chunk size does not match count")
+	}
+	rdr := bytes.NewReader(chunk.b)
+	for i := 0; i < chunk.count; i++ {
+		blkHdr, err := h.headerDeserialzer.Deserialize(rdr)
+		if err != nil {
+			return WireHash{}, err
+		}
+		if havePrev && blkHdr.Prev != prevHash {
+			return WireHash{}, fmt.Errorf("headers from %s do not link at height %d",
+				chunk.from, chunk.startHeight+int64(i))
+		}
+		prevHash = blkHdr.Hash
+		havePrev = true
+	}
+	return prevHash, nil
+}
+
+// fetchHeaders fetches headers from the end of the headers file, which has
+// numHeaders headers, to the leader's tip. helpers returns other sources that
+// may be used and can be nil. Returns the number of headers appended to the
+// headers file.
+func (h *headers) fetchHeaders(ctx context.Context, numHeaders int64, leader *hdrsSource, helpers func() []*hdrsSource) (int64, error) {
+	var prevHash WireHash
+	havePrev := numHeaders > 0
+	if havePrev {
+		blkHdrs, err := h.readHdrsFromFile(numHeaders-1, 1)
+		if err != nil {
+			return 0, err
+		}
+		prevHash = blkHdrs[0].Hash
+	}
+
+	// Do not make requested block count too big or electrumX may throttle response
+	// as an anti ddos measure. ElectrumX Doc.: "Recommended to be at least one
+	// Bitcoin difficulty retarget period, i.e. 2016."
+	const blockCount = ELECTRUM_MAGIC_NUMHDR
+	startHeight := h.startPoint + numHeaders
+	var appended int64
+	for {
+		sources := []*hdrsSource{leader}
+		if helpers != nil {
+			for _, src := range helpers() {
+				if len(sources) > SYNC_HELPERS {
+					break
+				}
+				sources = append(sources, src)
+			}
+		}
+
+		// one chunk from each source
+		chunks := make([]*hdrsChunk, len(sources))
+		var wg sync.WaitGroup
+		for i, src := range sources {
+			wg.Add(1)
+			go func(i int, src *hdrsSource) {
+				defer wg.Done()
+				chunks[i] = src.fetchChunk(ctx, startHeight+int64(i*blockCount), blockCount)
+			}(i, src)
+		}
+		wg.Wait()
+
+		// append in order
+		for i, chunk := range chunks {
+			lastHash, err := h.linkChunk(chunk, prevHash, havePrev)
+			if i > 0 && (err != nil || chunk.count < blockCount) {
+				if err == nil {
+					err = errors.New("short chunk")
+				}
+				fmt.Printf("headers at %d from %s - %v - fetching from leader\n",
+					chunk.startHeight, chunk.from, err)
+				chunk = leader.fetchChunk(ctx, chunk.startHeight, blockCount)
+				lastHash, err = h.linkChunk(chunk, prevHash, havePrev)
+			}
+			if err != nil {
+				return appended, err
+			}
+			if chunk.count > 0 {
+				_, err = h.appendHeadersFile(chunk.b)
+				if err != nil {
+					return appended, err
+				}
+				appended += int64(chunk.count)
+				prevHash = lastHash
+				havePrev = true
+				fmt.Printf(" appended: %d headers at %d from %s\n", chunk.count, chunk.startHeight, chunk.from)
+			}
+			if chunk.count < blockCount {
+				return appended, nil
+			}
+		}
+		startHeight += int64(len(chunks) * blockCount)
+
+		select {
+		case <-ctx.Done():
+			return appended, ctx.Err()
+		case <-time.After(syncRoundInterval):
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package electrumx
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// mkHdrsSource serves headers from the raw chain b starting at startPoint.
+func mkHdrsSource(name string, b []byte, startPoint int64, calls *atomic.Int32) *hdrsSource {
+	numHdrs := int64(len(b) / BTC_HEADER_SIZE)
+	return &hdrsSource{
+		name: name,
+		ctx:  context.Background(),
+		fetch: func(ctx context.Context, startHeight int64, count int) (*getBlockHeadersResult, error) {
+			if calls != nil {
+				calls.Add(1)
+			}
+			from := min(max(startHeight-startPoint, 0), numHdrs)
+			to := min(from+int64(count), numHdrs)
+			return &getBlockHeadersResult{
+				Count:     int(to - from),
+				HexConcat: hex.EncodeToString(b[from*BTC_HEADER_SIZE : to*BTC_HEADER_SIZE]),
+				Max:       ELECTRUM_MAGIC_NUMHDR,
+			}, nil
+		},
+	}
+}
+
+func TestFetchHeaders(t *testing.T) {
+	const startPoint = 1000
+	const numHdrs = 4*ELECTRUM_MAGIC_NUMHDR + 100
+	b, hashes := mkHdrChain(t, numHdrs)
+	// a chain that forks from the start
+	forkB, _ := mkHdrChain(t, numHdrs+1)
+	forkB = forkB[BTC_HEADER_SIZE:]
+
+	failing := &hdrsSource{
+		name: "failing",
+		ctx:  context.Background(),
+		fetch: func(ctx context.Context, startHeight int64, count int) (*getBlockHeadersResult, error) {
+			return nil, errors.New("no headers for you")
+		},
+	}
+
+	tests := []struct {
+		name    string
+		helpers []*hdrsSource
+		// headers already in the file
+		numHeaders int64
+	}{
+		{
+			name: "leader only",
+		},
+		{
+			name: "good helpers",
+			helpers: []*hdrsSource{
+				mkHdrsSource("helper1", b, startPoint, nil),
+				mkHdrsSource("helper2", b, startPoint, nil),
+			},
+		},
+		{
+			name: "bad helpers",
+			helpers: []*hdrsSource{
+				mkHdrsSource("fork", forkB, startPoint, nil),
+				// behind by a chunk and a bit
+				mkHdrsSource("behind", b[:(numHdrs-ELECTRUM_MAGIC_NUMHDR-50)*BTC_HEADER_SIZE], startPoint, nil),
+				failing,
+			},
+		},
+		{
+			name:       "continue file",
+			numHeaders: 10,
+			helpers: []*hdrsSource{
+				mkHdrsSource("helper1", b, startPoint, nil),
+				mkHdrsSource("fork", forkB, startPoint, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := mkStoreHeaders(t.TempDir(), startPoint)
+			if tt.numHeaders > 0 {
+				_, err := h.appendHeadersFile(b[:tt.numHeaders*BTC_HEADER_SIZE])
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			var leaderCalls atomic.Int32
+			leader := mkHdrsSource("leader", b, startPoint, &leaderCalls)
+			helpers := func() []*hdrsSource { return tt.helpers }
+			appended, err := h.fetchHeaders(context.Background(), tt.numHeaders, leader, helpers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if appended != numHdrs-tt.numHeaders {
+				t.Fatalf("appended %d headers", appended)
+			}
+			err = h.openStore(numHdrs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.index.close()
+			err = h.verifyAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pos := range []int64{0, ELECTRUM_MAGIC_NUMHDR, numHdrs - 1} {
+				if h.getHeaderAt(startPoint+pos).Hash != hashes[pos] {
+					t.Fatalf("wrong header at position %d", pos)
+				}
+			}
+			// 5 chunks: rounds of 3 then 2 and the short last chunk fetched
+			// again from the leader
+			if tt.name == "good helpers" && leaderCalls.Load() != 3 {
+				t.Fatalf("leader fetched %d chunks", leaderCalls.Load())
+			}
+		})
+	}
+}
+
+func TestFetchHeadersLeaderDoesNotLink(t *testing.T) {
+	const startPoint = 0
+	b, _ := mkHdrChain(t, 20)
+	forkB, _ := mkHdrChain(t, 21)
+	h := mkStoreHeaders(t.TempDir(), startPoint)
+	_, err := h.appendHeadersFile(b[:10*BTC_HEADER_SIZE])
+	if err != nil {
+		t.Fatal(err)
+	}
+	leader := mkHdrsSource("leader", forkB[BTC_HEADER_SIZE:], startPoint, nil)
+	_, err = h.fetchHeaders(context.Background(), 10, leader, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	n, _ := h.numHeadersInFile()
+	if n != 10 {
+		t.Fatalf("headers file changed: %d headers", n)
+	}
+}
@@ -86,6 +86,9 @@ func (d headerDeserialzer) Deserialize(r io.Reader) (*BlockHeader, error) {
 	blockHeader.Hash = WireHash(chainHash)
 	blockHeader.Prev = WireHash(wireHdr.PrevBlock)
 	blockHeader.Merkle = WireHash(wireHdr.MerkleRoot)
+	blockHeader.Timestamp = uint32(wireHdr.Timestamp.Unix())
+	blockHeader.Bits = wireHdr.Bits
+	blockHeader.Nonce = uint64(wireHdr.Nonce)
 	return blockHeader, nil
 }
 
@@ -98,16 +101,16 @@ func TestAppendHeaders(t *testing.T) {
 	}
 	defer f.Close()
 	defer os.RemoveAll(f.Name())
+	defer os.RemoveAll(f.Name() + ".meta")
 
 	h := headers{
 		headerSize:        BTC_HEADER_SIZE,
 		headerDeserialzer: btcDeserializer,
 		hdrFilePath:       f.Name(),
 		startPoint:        0, // regtest
-		hdrs:              make(map[int64]*BlockHeader),
-		blkHdrs:           make(map[WireHash]int64),
 		synced:            false,
 	}
+	h.clearMem()
 	h.setTip(0)
 
 	numHdrs, err := h.appendHeadersFile(hdrFileReg)
@@ -126,21 +129,16 @@ func TestAppendHeaders(t *testing.T) {
 		log.Fatal("total headers wrong")
 	}
 
-	// 'finished' appending - now grab the bytes back
-	maybeTip := numHdrs - 1
-
-	// read back bytes from file
-	b, err := h.readAllBytesFromFile()
+	// 'finished' appending - now index the file and load the recent headers
+	h.idxFilePath = f.Name() + "_index"
+	defer os.RemoveAll(h.idxFilePath)
+	err = h.openStore(numHdrs)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	// store all bytes into the map
-	err = h.store(b, 0)
-	if err != nil {
-		log.Fatal(err)
+	if h.getTip() != numHdrs-1 {
+		log.Fatalf("bad tip %d", h.getTip())
 	}
-	h.setTip(maybeTip)
 
 	// verify chain
 	fmt.Println("verifying back from tip at height", h.getTip())
@@ -158,16 +156,16 @@ func TestTruncateHeadersFile(t *testing.T) {
 	}
 	defer f.Close()
 	defer os.RemoveAll(f.Name())
+	defer os.RemoveAll(f.Name() + ".meta")
 
 	h := headers{
 		headerSize:        BTC_HEADER_SIZE,
 		headerDeserialzer: btcDeserializer,
 		hdrFilePath:       f.Name(),
 		startPoint:        0, // regtest
-		hdrs:              make(map[int64]*BlockHeader),
-		blkHdrs:           make(map[WireHash]int64),
 		synced:            false,
 	}
+	h.clearMem()
 	h.setTip(0)
 
 	numHdrs, err := h.appendHeadersFile(hdrFileReg)
@@ -276,10 +274,9 @@ func TestStore(t *testing.T) {
 		headerDeserialzer: btcDeserializer,
 		hdrFilePath:       "<no file>",
 		startPoint:        0, // regtest
-		hdrs:              make(map[int64]*BlockHeader),
-		blkHdrs:           make(map[WireHash]int64),
 		synced:            false,
 	}
+	h.clearMem()
 	h.setTip(0)
 
 	err := h.store(hdr, 0)
@@ -327,10 +324,9 @@ func TestStoreHashes(t *testing.T) {
 		headerDeserialzer: btcDeserializer,
 		hdrFilePath:       "<no file>",
 		startPoint:        0, // regtest
-		hdrs:              make(map[int64]*BlockHeader),
-		blkHdrs:           make(map[WireHash]int64),
 		synced:            false,
 	}
+	h.clearMem()
 	h.setTip(0)
 
 	err := h.store(hdrFileReg, 0)
@@ -346,13 +342,13 @@ func TestStoreHashes(t *testing.T) {
 	h.setTip(numHeaders - 1)
 	var i int64
 	for i = 0; i <= h.getTip(); i++ {
-		hdr := h.hdrs[i]
+		hdr := h.getHeaderAt(i)
 		if hdr == nil {
 			log.Fatalf("nil header returned from map at %d", i)
 		}
 		blkHash := hdr.Hash
-		height := h.blkHdrs[blkHash]
-		if i != height {
+		height, ok := h.getHeightForHash(blkHash)
+		if !ok || i != height {
 			t.Errorf("height mismatch: wanted %d got %d", i, height)
 		}
 	}
@@ -373,10 +369,9 @@ func TestDeserializeHeader(t *testing.T) {
 		headerDeserialzer: btcDeserializer,
 		hdrFilePath:       "<no file>",
 		startPoint:        0, // regtest
-		hdrs:              make(map[int64]*BlockHeader),
-		blkHdrs:           make(map[WireHash]int64),
 		synced:            false,
 	}
+	h.clearMem()
 	h.tip.Store(0)
 
 	r := bytes.NewBuffer(hdrSerialized)
@@ -385,7 +380,10 @@ func TestDeserializeHeader(t *testing.T) {
 		log.Fatal(err)
 	}
 	h.tip.Store(-1)
-	h.storeOneHdr(blkHdr) // {tip++}
+	err = h.storeOneHdr(blkHdr) // {tip++}
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.dumpAll()
 }
 
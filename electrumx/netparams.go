@@ -0,0 +1,221 @@
+package electrumx
+
+// Network parameters registry.
+//
+// The ElectrumX network parameters for each network of a coin - start point,
+// genesis, peer limits, strategy flags, checkpoints and seed servers - are
+// kept in a JSON file embedded in the coin's elx package. An operator can
+// supply their own JSON file in the same format with ElectrumXConfig
+// NetParamsFile to roll a start point or checkpoints forward without a new
+// release. Only the fields present in the user file replace the embedded ones.
+//
+// The start_hash is the block hash at the start point. A headers snapshot must
+// start with it. A start point moved by a user file without it's start_hash
+// has none and a snapshot's first header is then trusted on it's signature.
+//
+//	{
+//	  "coin": "btc",
+//	  "nets": {
+//	    "mainnet": {
+//	      "start_point": 823000,
+//	      "start_hash": "...",
+//	      "genesis": "000000000019d6...",
+//	      "max_online_peers": 10,
+//	      "max_onion": 2,
+//	      "flags": 0,
+//	      "checkpoints": [{"height": 823000, "hash": "..."}],
+//	      "seed_servers": [{"net": "ssl", "addr": "host:50002"}]
+//	    }
+//	  }
+//	}
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CoinParams are the network parameters for each network of a coin.
+type CoinParams struct {
+	Coin string                `json:"coin"`
+	Nets map[string]*NetParams `json:"nets"`
+}
+
+// NetParams are the network parameters for one network of a coin.
+type NetParams struct {
+	// Height of the first header in the headers file
+	StartPoint int64 `json:"start_point"`
+	// Block hash at the start point; the genesis hash for start point 0
+	StartHash string `json:"start_hash,omitempty"`
+	// Genesis block hash
+	Genesis        string `json:"genesis"`
+	MaxOnlinePeers int    `json:"max_online_peers"`
+	MaxOnion       int    `json:"max_onion"`
+	// Strategy flags: Default or NoDeleteKnownPeers
+	Flags       uint8        `json:"flags"`
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
+	// Servers added to the known servers when there are none
+	SeedServers []*SeedServer `json:"seed_servers,omitempty"`
+	// Free text such as where the start point came from
+	Note string `json:"note,omitempty"`
+}
+
+// SeedServer is a known ElectrumX server for a network.
+type SeedServer struct {
+	Net     string `json:"net"`
+	Addr    string `json:"addr"`
+	IsOnion bool   `json:"is_onion,omitempty"`
+}
+
+// nodeServerAddr returns the seed server as a NodeServerAddr.
+func (s *SeedServer) nodeServerAddr() *NodeServerAddr {
+	return &NodeServerAddr{Net: s.Net, Addr: s.Addr, Onion: s.IsOnion}
+}
+
+// validate checks the parameters for a network make sense.
+func (p *NetParams) validate() error {
+	if p.StartPoint < 0 {
+		return errors.New("negative start point")
+	}
+	if len(p.Genesis) != 2*HashSize {
+		return errors.New("bad genesis hash")
+	}
+	if p.StartHash != "" && len(p.StartHash) != 2*HashSize {
+		return errors.New("bad start point hash")
+	}
+	if p.StartPoint == 0 && p.StartHash != "" && p.StartHash != p.Genesis {
+		return errors.New("start point hash is not the genesis hash")
+	}
+	if p.MaxOnlinePeers < 0 || p.MaxOnion < 0 {
+		return errors.New("negative peer limit")
+	}
+	if p.Flags&^NoDeleteKnownPeers != 0 {
+		return fmt.Errorf("unknown strategy flags %#x", p.Flags)
+	}
+	for _, cp := range p.Checkpoints {
+		if cp.Height < 0 || len(cp.Hash) != 2*HashSize {
+			return fmt.Errorf("bad checkpoint at height %d", cp.Height)
+		}
+	}
+	for _, seed := range p.SeedServers {
+		_, err := newServerAddr(seed.nodeServerAddr())
+		if err != nil {
+			return fmt.Errorf("bad seed server %s: %w", seed.Addr, err)
+		}
+	}
+	return nil
+}
+
+// ParseCoinParams parses and validates network parameters for a coin.
+func ParseCoinParams(b []byte) (*CoinParams, error) {
+	params := &CoinParams{}
+	err := json.Unmarshal(b, params)
+	if err != nil {
+		return nil, err
+	}
+	for netType, netParams := range params.Nets {
+		if netParams == nil {
+			return nil, fmt.Errorf("%s %s: no parameters", params.Coin, netType)
+		}
+		err = netParams.validate()
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", params.Coin, netType, err)
+		}
+	}
+	return params, nil
+}
+
+// LoadNetParams returns the parameters for network netType of a coin from the
+// coin's embedded JSON. If userFile is not "" it's fields for the network
+// replace the embedded ones.
+func LoadNetParams(embedded []byte, coin, netType, userFile string) (*NetParams, error) {
+	params, err := ParseCoinParams(embedded)
+	if err != nil {
+		return nil, err
+	}
+	if params.Coin != coin {
+		return nil, fmt.Errorf("embedded network parameters are for %s", params.Coin)
+	}
+	netParams := params.Nets[netType]
+	if netParams == nil {
+		return nil, fmt.Errorf("no %s parameters for %s", netType, coin)
+	}
+	if userFile == "" {
+		return netParams, nil
+	}
+
+	b, err := os.ReadFile(userFile)
+	if err != nil {
+		return nil, err
+	}
+	var user struct {
+		Coin string                     `json:"coin"`
+		Nets map[string]json.RawMessage `json:"nets"`
+	}
+	err = json.Unmarshal(b, &user)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", userFile, err)
+	}
+	if user.Coin != coin {
+		return nil, fmt.Errorf("%s: parameters are for %s", userFile, user.Coin)
+	}
+	raw, ok := user.Nets[netType]
+	if !ok {
+		return netParams, nil
+	}
+	// unmarshal over the embedded parameters so missing fields are kept
+	merged := *netParams
+	err = json.Unmarshal(raw, &merged)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", userFile, err)
+	}
+	// the embedded start hash is for the embedded start point
+	if merged.StartPoint != netParams.StartPoint && merged.StartHash == netParams.StartHash {
+		merged.StartHash = ""
+	}
+	err = merged.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s %s: %w", userFile, coin, netType, err)
+	}
+	return &merged, nil
+}
+
+// Apply fills in the network parameters in an ElectrumX config.
+func (p *NetParams) Apply(cfg *ElectrumXConfig) {
+	cfg.StartPoint = p.StartPoint
+	cfg.StartHash = p.StartHash
+	if cfg.StartHash == "" && p.StartPoint == 0 {
+		cfg.StartHash = p.Genesis
+	}
+	cfg.Genesis = p.Genesis
+	cfg.MaxOnlinePeers = p.MaxOnlinePeers
+	cfg.MaxOnion = p.MaxOnion
+	cfg.Flags = p.Flags
+	cfg.Checkpoints = append([]Checkpoint{{Height: 0, Hash: p.Genesis}}, p.Checkpoints...)
+	cfg.SeedServers = nil
+	for _, seed := range p.SeedServers {
+		cfg.SeedServers = append(cfg.SeedServers, seed.nodeServerAddr())
+	}
+}
+
+// addSeedServers adds the configured seed servers to the known servers when
+// none are known - called before the network starts
+func (net *Network) addSeedServers() error {
+	var servers []*serverAddr
+	for _, seed := range net.config.SeedServers {
+		server, err := newServerAddr(seed)
+		if err != nil {
+			return err
+		}
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 {
+		return nil
+	}
+	err := net.updateNetworkServers(servers)
+	if err != nil {
+		return err
+	}
+	return net.updateStoredServers(servers)
+}
@@ -0,0 +1,105 @@
+package electrumx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testNetParamsJSON = `{
+  "coin": "btc",
+  "nets": {
+    "regtest": {
+      "start_point": 0,
+      "start_hash": "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206",
+      "genesis": "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206",
+      "max_online_peers": 0,
+      "max_onion": 2,
+      "flags": 1
+    },
+    "mainnet": {
+      "start_point": 823000,
+      "start_hash": "0000000000000000000000000000000000000000000000000000000000823000",
+      "genesis": "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+      "max_online_peers": 10,
+      "max_onion": 2,
+      "flags": 0,
+      "seed_servers": [{"net": "ssl", "addr": "elx.example.com:50002"}]
+    }
+  }
+}`
+
+func TestLoadNetParams(t *testing.T) {
+	embedded := []byte(testNetParamsJSON)
+	p, err := LoadNetParams(embedded, "btc", Mainnet, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.StartPoint != 823000 || p.MaxOnlinePeers != 10 || len(p.SeedServers) != 1 ||
+		p.StartHash != "0000000000000000000000000000000000000000000000000000000000823000" {
+		t.Fatalf("bad params %+v", p)
+	}
+	_, err = LoadNetParams(embedded, "dash", Mainnet, "")
+	if err == nil {
+		t.Fatal("expected error for another coin")
+	}
+	_, err = LoadNetParams(embedded, "btc", Testnet, "")
+	if err == nil {
+		t.Fatal("expected error for missing net")
+	}
+
+	// roll the start point forward
+	userFile := filepath.Join(t.TempDir(), "netparams.json")
+	user := `{"coin": "btc", "nets": {"mainnet": {"start_point": 900000,
+		"checkpoints": [{"height": 900000, "hash": "000000000000000000010538edbfd2d5b809a33dd83f284aeea41c6d0d96968a"}]}}}`
+	err = os.WriteFile(userFile, []byte(user), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = LoadNetParams(embedded, "btc", Mainnet, userFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.StartPoint != 900000 || len(p.Checkpoints) != 1 || p.MaxOnlinePeers != 10 ||
+		p.Genesis != "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f" {
+		t.Fatalf("bad merged params %+v", p)
+	}
+	// the embedded start hash is not for the new start point
+	if p.StartHash != "" {
+		t.Fatalf("start hash %s kept for a moved start point", p.StartHash)
+	}
+	// other nets are untouched
+	p, err = LoadNetParams(embedded, "btc", Regtest, userFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Flags != NoDeleteKnownPeers {
+		t.Fatalf("bad regtest params %+v", p)
+	}
+
+	cfg := &ElectrumXConfig{}
+	p.Apply(cfg)
+	if cfg.Genesis != p.Genesis || len(cfg.Checkpoints) != 1 || cfg.Checkpoints[0].Height != 0 ||
+		cfg.StartHash != p.Genesis {
+		t.Fatalf("bad applied config %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		`{"coin": "btc", "nets": {"mainnet": {"start_point": -1}}}`,
+		`{"coin": "btc", "nets": {"mainnet": {"genesis": "00"}}}`,
+		`{"coin": "btc", "nets": {"mainnet": {"flags": 2}}}`,
+		`{"coin": "btc", "nets": {"mainnet": {"start_hash": "00"}}}`,
+		`{"coin": "btc", "nets": {"mainnet": {"start_point": 0, "start_hash": "0000000000000000000000000000000000000000000000000000000000000001"}}}`,
+		`{"coin": "btc", "nets": {"mainnet": {"seed_servers": [{"net": "udp", "addr": "a.b:1"}]}}}`,
+		`{"coin": "dash", "nets": {}}`,
+	} {
+		err = os.WriteFile(userFile, []byte(bad), 0664)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadNetParams(embedded, "btc", Mainnet, userFile)
+		if err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
@@ -92,9 +92,27 @@ type Network struct {
 	proxyAddr       string // socks5
 	onlineOnions    int
 	headers         *headers
-	// static channels to client for the lifetime of the main goele context
-	clientTipChangeNotify  chan int64
-	clientScripthashNotify chan *ScripthashStatusResult
+	lagTracker      *headerLagTracker
+	// leader switch requested by api - under peersMtx
+	switchLeader bool
+	// wake up peersMonitor after an api change
+	monitorKick chan struct{}
+	// events to any number of client subscribers for the lifetime of the main
+	// goele context
+	events *EventBroker
+	// running leader - for network state events
+	connected atomic.Bool
+	// snapshot of peers for a leader's headers sync; read without peersMtx as
+	// a promoted leader syncs under peersMtx
+	syncPeers atomic.Pointer[[]*peerNode]
+	// checked merkle root of block hashes up to the start point
+	cpRoot    *WireHash
+	cpRootMtx sync.Mutex
+	// deprecated notify channels fed from events
+	tipNotify            chan int64
+	tipNotifyOnce        sync.Once
+	scripthashNotify     chan *ScripthashStatusResult
+	scripthashNotifyOnce sync.Once
 }
 
 func NewNetwork(config *ElectrumXConfig) *Network {
@@ -104,37 +122,79 @@ func NewNetwork(config *ElectrumXConfig) *Network {
 	}
 	h := newHeaders(config)
 	network := &Network{
-		config:                 config,
-		started:                false,
-		leader:                 nil,
-		peers:                  make([]*peerNode, 0, 10),
-		knownServers:           make([]*serverAddr, 0, 30),
-		proxyAddr:              proxyAddr,
-		onlineOnions:           0,
-		headers:                h,
-		clientTipChangeNotify:  make(chan int64), // unbuffered
-		clientScripthashNotify: make(chan *ScripthashStatusResult),
+		config:       config,
+		started:      false,
+		leader:       nil,
+		peers:        make([]*peerNode, 0, 10),
+		knownServers: make([]*serverAddr, 0, 30),
+		proxyAddr:    proxyAddr,
+		onlineOnions: 0,
+		headers:      h,
+		lagTracker:   newHeaderLagTracker(),
+		monitorKick:  make(chan struct{}, 1),
+		events:       newEventBroker(),
 	}
 	return network
 }
 
-// GetTipChangeNotify returns a channel to client to receive tip change
-// notifications from the current leader node
+// SubscribeEvents returns a new subscription to network events with a buffer
+// of bufSize events. If no types are given all event types are received. Each
+// subscriber is independent; a slow subscriber loses events according to
+// policy but never holds up the network or other subscribers. The events
+// channel is closed when the network shuts down.
+func (net *Network) SubscribeEvents(bufSize int, policy OverflowPolicy, types ...EventType) *EventSubscription {
+	return net.events.subscribe(bufSize, policy, types...)
+}
+
+// GetTipChangeNotify returns a channel to receive the new tip height on each
+// new tip or reorg. Every call returns the same channel. The channel is closed
+// when the network shuts down.
+//
+// Deprecated: use SubscribeEvents for EventNewTip and EventReorg.
 func (net *Network) GetTipChangeNotify() <-chan int64 {
-	return net.clientTipChangeNotify
+	net.tipNotifyOnce.Do(func() {
+		net.tipNotify = make(chan int64)
+		sub := net.events.subscribe(1, Coalesce, EventNewTip, EventReorg)
+		go func() {
+			defer close(net.tipNotify)
+			for ev := range sub.Events() {
+				net.tipNotify <- ev.Height
+			}
+		}()
+	})
+	return net.tipNotify
 }
 
-// GetScripthashNotify returns a channel to client to receive scripthash
-// notifications from the current leader node
+// GetScripthashNotify returns a channel to receive the status changes of
+// subscribed scripthashes. Every call returns the same channel. None are
+// dropped. The channel is closed when the network shuts down.
+//
+// Deprecated: use SubscribeEvents for EventScripthashStatus.
 func (net *Network) GetScripthashNotify() <-chan *ScripthashStatusResult {
-	return net.clientScripthashNotify
+	net.scripthashNotifyOnce.Do(func() {
+		net.scripthashNotify = make(chan *ScripthashStatusResult)
+		sub := net.events.subscribe(1, Unbounded, EventScripthashStatus)
+		go func() {
+			defer close(net.scripthashNotify)
+			for ev := range sub.Events() {
+				net.scripthashNotify <- ev.Scripthash
+			}
+		}()
+	})
+	return net.scripthashNotify
 }
 
 func (net *Network) Start(ctx context.Context) error {
-	_, err := net.loadKnownServers()
+	numKnown, err := net.loadKnownServers()
 	if err != nil {
 		return err
 	}
+	if numKnown == 0 {
+		err = net.addSeedServers()
+		if err != nil {
+			return err
+		}
+	}
 	if net.config.TrustedPeer == nil {
 		// TODO: start a stored server if no trusted peer
 		return errors.New("a trusted peer is required in config")
@@ -145,25 +205,65 @@ func (net *Network) Start(ctx context.Context) error {
 	if net.started {
 		return errors.New("network already started")
 	}
+	net.importHeadersSnapshot()
 	return net.start(ctx, serverAddress)
 }
 
 // start starts the network with one leader peer - locked under startMtx
 func (net *Network) start(ctx context.Context, startServer *NodeServerAddr) error {
+	// some peers to help the leader's initial headers sync
+	net.startSyncHelpers(ctx, startServer)
 	// start from our trusted node as leader
-	err := net.startNewPeer(ctx, startServer, true, true)
+	leader, err := net.newStartedPeer(ctx, startServer, true, true)
 	if err != nil {
 		return err
 	}
+	// the sync helpers use peersMtx
+	net.peersMtx.Lock()
+	net.setLeader(leader)
+	net.peersMtx.Unlock()
 	// leader up and headers synced
 	net.started = true
 	// ask leader for it's own current known peers
-	net.getServerPeers(ctx)
+	net.getServerPeers(ctx, leader)
 	// bootstrap peers loop with leader's connection
 	go net.peersMonitor(ctx)
 	return nil
 }
 
+// startSyncHelpers starts up to SYNC_HELPERS peers in the background while the
+// first leader starts so they can help fetch headers on the initial sync.
+// They then stay on as ordinary peers - called before the leader is started
+func (net *Network) startSyncHelpers(ctx context.Context, leaderAddr *NodeServerAddr) {
+	numHelpers := min(SYNC_HELPERS, net.config.MaxOnlinePeers)
+	if numHelpers <= 0 {
+		return
+	}
+	var helpers []*serverAddr
+	for _, server := range net.availableServers(false) {
+		if server.IsOnion || toNetAddr(server).IsEqual(leaderAddr) {
+			continue
+		}
+		helpers = append(helpers, server)
+	}
+	sortServersByQuality(helpers)
+	if len(helpers) > numHelpers {
+		helpers = helpers[:numHelpers]
+	}
+	for _, server := range helpers {
+		go func(server *serverAddr) {
+			peer, err := net.newStartedPeer(ctx, toNetAddr(server), false, false)
+			net.peersMtx.Lock()
+			defer net.peersMtx.Unlock()
+			if err != nil {
+				net.removeServer(server)
+				return
+			}
+			net.addPeer(peer)
+		}(server)
+	}
+}
+
 // startNewPeer starts up a new peer and adds to peersList - not locked
 func (net *Network) startNewPeer(
 	ctx context.Context,
@@ -171,6 +271,27 @@ func (net *Network) startNewPeer(
 	isLeader,
 	isTrusted bool) error {
 
+	peer, err := net.newStartedPeer(ctx, netAddr, isLeader, isTrusted)
+	if err != nil {
+		return err
+	}
+	// node is up, add to peerNodes if not leader
+	if isLeader {
+		net.setLeader(peer)
+	} else {
+		net.addPeer(peer)
+	}
+	net.getServerPeers(ctx, net.getLeader())
+	return nil
+}
+
+// newStartedPeer makes and starts up a new peer - not locked
+func (net *Network) newStartedPeer(
+	ctx context.Context,
+	netAddr *NodeServerAddr,
+	isLeader,
+	isTrusted bool) (*peerNode, error) {
+
 	proxy := ""
 	if netAddr.IsOnion() {
 		proxy = net.proxyAddr
@@ -181,11 +302,12 @@ func (net *Network) startNewPeer(
 		proxy,
 		isLeader,
 		net.headers,
-		net.clientTipChangeNotify,
-		net.clientScripthashNotify)
+		net.lagTracker,
+		net.events)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	node.syncPeers = net.getSyncPeers
 	network := net.config.Coin
 	nettype := net.config.NetType
 	genesis := net.config.Genesis
@@ -195,22 +317,14 @@ func (net *Network) startNewPeer(
 	err = node.start(nodeCtx, nodeCancel, network, nettype, genesis)
 	if err != nil {
 		nodeCancel(errNetworkCanceled)
-		return err
-	}
-	// node is up, add to peerNodes if not leader
-	peer := newPeerNodeWithId(isLeader, isTrusted, netAddr, node, nodeCtx, nodeCancel)
-	if isLeader {
-		net.leader = peer
-	} else {
-		net.addPeer(peer)
+		return nil, err
 	}
-	net.getServerPeers(ctx)
-	return nil
+	return newPeerNodeWithId(isLeader, isTrusted, netAddr, node, nodeCtx, nodeCancel), nil
 }
 
-// get and update a list of a known servers - not locked
-func (net *Network) getServerPeers(ctx context.Context) {
-	err := net.getServers(ctx)
+// get and update a list of a known servers from the leader - not locked
+func (net *Network) getServerPeers(ctx context.Context, leader *peerNode) {
+	err := net.getServers(ctx, leader)
 	if err != nil {
 		fmt.Printf("getServerPeers: ignoring error - %v\n", err)
 	}
@@ -219,6 +333,7 @@ func (net *Network) getServerPeers(ctx context.Context) {
 // add a started peer to running peers - not locked
 func (net *Network) addPeer(newPeer *peerNode) {
 	net.peers = append(net.peers, newPeer)
+	net.updateSyncPeers()
 }
 
 // remove a running peer - not locked
@@ -233,6 +348,24 @@ func (net *Network) removePeer(oldPeer *peerNode) {
 		}
 	}
 	net.peers = newPeers
+	net.updateSyncPeers()
+}
+
+// updateSyncPeers updates the snapshot of peers for headers sync - not locked
+func (net *Network) updateSyncPeers() {
+	syncPeers := make([]*peerNode, len(net.peers))
+	copy(syncPeers, net.peers)
+	net.syncPeers.Store(&syncPeers)
+}
+
+// getSyncPeers returns the running peers that can help a leader's headers sync
+// - not locked
+func (net *Network) getSyncPeers() []*peerNode {
+	syncPeers := net.syncPeers.Load()
+	if syncPeers == nil {
+		return nil
+	}
+	return *syncPeers
 }
 
 // getNumPeers gets the number of current peer nodes - not locked
@@ -245,6 +378,33 @@ func (net *Network) getLeader() *peerNode {
 	return net.leader
 }
 
+// setLeader sets a new leader node and tells subscribers - not locked
+func (net *Network) setLeader(peer *peerNode) {
+	net.leader = peer
+	net.setConnected(true, peer.netAddr.String())
+	net.events.publish(&Event{Type: EventLeaderChange, Leader: peer.netAddr.String()})
+}
+
+// setConnected tells subscribers when the network gains or loses it's leader
+func (net *Network) setConnected(connected bool, leader string) {
+	if !net.connected.CompareAndSwap(!connected, connected) {
+		return
+	}
+	if connected {
+		net.events.publish(&Event{Type: EventConnected, Leader: leader})
+		return
+	}
+	net.events.publish(&Event{Type: EventDisconnected})
+}
+
+// updateConnected checks we still have a running leader - not locked
+func (net *Network) updateConnected() {
+	leader := net.getLeader()
+	if leader == nil || leader.nodeCtx.Err() != nil {
+		net.setConnected(false, "")
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Peer nodes monitor
 // -----------------------------------------------------------------------------
@@ -253,40 +413,93 @@ func (net *Network) getLeader() *peerNode {
 func (net *Network) peersMonitor(ctx context.Context) {
 	t := time.NewTicker(5 * time.Second)
 	defer t.Stop()
+	ticks := 0
 	for {
 		select {
 		case <-ctx.Done():
 			for _, peer := range net.peers {
 				peer.nodeCancel(errNetworkCanceled)
 			}
+			net.setConnected(false, "")
+			net.events.close()
 			return
+		case <-net.monitorKick:
+			net.checkLeader(ctx)
+			net.reapDeadPeers()
+			net.startNewPeerMaybe(ctx)
 		case <-t.C:
 			net.checkLeader(ctx)
 			net.reapDeadPeers()
 			net.startNewPeerMaybe(ctx)
+			ticks++
+			if ticks%statsPersistTicks == 0 {
+				net.persistServerStats()
+			}
 		}
 	}
 }
 
 func (net *Network) checkLeader(ctx context.Context) {
+	preferred, oldLeaderAddr := net.replaceLeader(ctx, true, nil)
+	if preferred == nil {
+		return
+	}
+	// the preferred leader is dialed without peersMtx
+	if net.startPreferredLeader(ctx, preferred) {
+		return
+	}
+	net.replaceLeader(ctx, false, oldLeaderAddr)
+}
+
+// replaceLeader finds a new leader if the leader is gone or a switch was asked
+// for. If tryPreferred and the preferred leader is not a running peer it
+// returns the preferred leader's address to be dialed by the caller and the
+// address of the old leader. Otherwise a running peer is promoted or another
+// server not oldLeaderAddr is started as leader - locked under peersMtx
+func (net *Network) replaceLeader(ctx context.Context, tryPreferred bool,
+	oldLeaderAddr *NodeServerAddr) (*NodeServerAddr, *NodeServerAddr) {
+
 	net.peersMtx.Lock()
 	defer net.peersMtx.Unlock()
+	// no leader after all our attempts?
+	defer net.updateConnected()
 
 	leader := net.getLeader()
+	if net.switchLeader {
+		net.switchLeader = false
+		if leader != nil {
+			oldLeaderAddr = leader.netAddr
+			if leader.nodeCtx.Err() == nil {
+				fmt.Printf("switching leader from %s\n", leader.netAddr)
+				leader.nodeCancel(errNetworkCanceled)
+			}
+		}
+	}
 	if leader != nil {
 		if leader.nodeCtx.Err() == nil {
 			// fast path
-			return
+			return nil, nil
 		}
 	}
 
 	// we need a new leader
 
+	// a preferred leader set by the api user?
+	if tryPreferred {
+		promoted, dial := net.promotePreferredLeader()
+		if promoted {
+			return nil, nil
+		}
+		if dial != nil {
+			return dial, oldLeaderAddr
+		}
+	}
+
 	// any running peers we can promote?
 	numPeers := net.getNumPeers()
 	if numPeers > 0 {
-		// promote one from the list.
-		for _, peer := range net.peers {
+		// promote one from the list - most up to date & fastest first.
+		for _, peer := range net.rankPeers() {
 			if peer.nodeCtx.Err() != nil {
 				continue
 			}
@@ -295,13 +508,79 @@ func (net *Network) checkLeader(ctx context.Context) {
 				peer.nodeCancel(errNetworkCanceled)
 				continue
 			}
-			net.leader = peer
+			net.setLeader(peer)
 			fmt.Printf("promoted and started new leader %s\n", peer.netAddr)
-			return
+			return nil, nil
 		}
 	}
 	// no available running peers so make new leader
-	net.startNewLeader(ctx)
+	net.startNewLeader(ctx, oldLeaderAddr)
+	return nil, nil
+}
+
+// promotePreferredLeader promotes the preferred leader if it is a running
+// peer. Returns true if it became leader or the address to start it on if it
+// is not running - locked under peersMtx
+func (net *Network) promotePreferredLeader() (bool, *NodeServerAddr) {
+	net.knownServersMtx.Lock()
+	preferred := net.getPreferredLeader()
+	net.knownServersMtx.Unlock()
+	if preferred == nil {
+		return false, nil
+	}
+	addr := toNetAddr(preferred)
+	for _, peer := range net.peers {
+		if peer.nodeCtx.Err() != nil || !addr.IsEqual(peer.netAddr) {
+			continue
+		}
+		err := peer.node.promoteToLeader(peer.nodeCtx)
+		if err != nil {
+			fmt.Printf("cannot promote preferred leader %s - %v\n", addr, err)
+			peer.nodeCancel(errNetworkCanceled)
+			return false, nil
+		}
+		net.setLeader(peer)
+		fmt.Printf("promoted and started preferred leader %s\n", peer.netAddr)
+		return true, nil
+	}
+	if preferred.IsOnion {
+		return false, nil
+	}
+	return false, addr
+}
+
+// startPreferredLeader starts the preferred leader at addr. It dials without
+// peersMtx and takes it only to make the new peer leader. Returns true if
+// there is a running leader - not locked
+func (net *Network) startPreferredLeader(ctx context.Context, addr *NodeServerAddr) bool {
+	peer, err := net.newStartedPeer(ctx, addr, true, false) // dialerCtx time limited to 10s
+	if err != nil {
+		fmt.Printf("cannot start preferred leader %s - %v\n", addr, err)
+		return false
+	}
+	net.peersMtx.Lock()
+	leader := net.getLeader()
+	if leader != nil && leader.nodeCtx.Err() == nil {
+		// not expected as only the peersMonitor replaces a dead leader
+		net.peersMtx.Unlock()
+		peer.nodeCancel(errNetworkCanceled)
+		return true
+	}
+	net.setLeader(peer)
+	net.peersMtx.Unlock()
+	net.getServerPeers(ctx, peer)
+	fmt.Printf("started preferred leader %s\n", addr)
+	return true
+}
+
+func (net *Network) persistServerStats() {
+	net.peersMtx.RLock()
+	defer net.peersMtx.RUnlock()
+
+	err := net.updateServerStats()
+	if err != nil {
+		fmt.Printf("persistServerStats: ignoring error - %v\n", err)
+	}
 }
 
 func (net *Network) reapDeadPeers() {
@@ -334,7 +613,8 @@ func (net *Network) startNewPeerMaybe(ctx context.Context) {
 	if len(available) == 0 {
 		return
 	}
-	// start one new peer .. from the pseudo-randomized list
+	// start one new peer .. from the pseudo-randomized list, best known first
+	sortServersByQuality(available)
 	addr := toNetAddr(available[0])
 	err := net.startNewPeer(ctx, addr, false, false) // dialerCtx time limited to 10s
 	if err != nil {
@@ -353,15 +633,26 @@ func toNetAddr(saddr *serverAddr) *NodeServerAddr {
 }
 
 // build a pseudo randomized list of known servers that have not yet been started
+// and are not banned
 func (net *Network) availableServers(forLeader bool) []*serverAddr {
 	var available = make([]*serverAddr, 0)
-	servers := net.knownServers
+	net.knownServersMtx.Lock()
+	servers := make([]*serverAddr, len(net.knownServers))
+	copy(servers, net.knownServers)
+	net.knownServersMtx.Unlock()
+	leader := net.getLeader()
 	for _, server := range servers {
+		if server.Banned {
+			continue
+		}
 		if server.IsOnion {
 			if forLeader || net.proxyAddr == "" {
 				continue
 			}
 		}
+		if leader != nil && leader.nodeCtx.Err() == nil && toNetAddr(server).IsEqual(leader.netAddr) {
+			continue
+		}
 		matchedAnyNetAddr := false
 		for _, peer := range net.peers {
 			if peer.nodeCtx.Err() != nil {
@@ -383,17 +674,30 @@ func (net *Network) availableServers(forLeader bool) []*serverAddr {
 	return available
 }
 
-func (net *Network) startNewLeader(ctx context.Context) {
+func (net *Network) startNewLeader(ctx context.Context, exclude *NodeServerAddr) {
 	// get a free known server
 	if len(net.knownServers) == 0 {
 		return
 	}
 	available := net.availableServers(true)
+	if exclude != nil {
+		var notExcluded = make([]*serverAddr, 0, len(available))
+		for _, server := range available {
+			if toNetAddr(server).IsEqual(exclude) {
+				continue
+			}
+			notExcluded = append(notExcluded, server)
+		}
+		available = notExcluded
+	}
 	if len(available) == 0 {
 		return
 	}
 	// TODO: filter servers again by reputation, capabilities and banlist
 
+	// prefer the fastest and most up to date servers we have seen before
+	sortServersByQuality(available)
+
 	// start one node up as new leader
 	addr := toNetAddr(available[0])
 	err := net.startNewPeer(ctx, addr, true, false) // dialerCtx time limited to 10s
@@ -435,6 +739,14 @@ func (net *Network) Synced() bool {
 	return net.headers.getClientSynced()
 }
 
+// Connected returns true if the network has a running leader.
+func (net *Network) Connected() bool {
+	if !net.started {
+		return false
+	}
+	return net.connected.Load()
+}
+
 func (net *Network) BlockHeader(height int64) (*ClientBlockHeader, error) {
 	if !net.started {
 		return nil, errNoNetwork
@@ -449,6 +761,29 @@ func (net *Network) BlockHeaders(startHeight int64, blockCount int64) ([]*Client
 	return net.headers.getBlockHeaders(startHeight, blockCount)
 }
 
+func (net *Network) BlockHeaderByHash(hash string) (*ClientBlockHeader, error) {
+	if !net.started {
+		return nil, errNoNetwork
+	}
+	return net.headers.getBlockHeaderByHash(hash)
+}
+
+func (net *Network) HeightForBlockHash(hash string) (int64, error) {
+	if !net.started {
+		return 0, errNoNetwork
+	}
+	return net.headers.getHeightForBlockHash(hash)
+}
+
+// MedianTimePast returns the median time past of the block at height; see
+// BIP113.
+func (net *Network) MedianTimePast(height int64) (time.Time, error) {
+	if !net.started {
+		return time.Time{}, errNoNetwork
+	}
+	return net.headers.medianTimePast(height)
+}
+
 // -----------------------------------------------------------------------------
 // API Pass thru from Client
 // -----------------------------------------------------------------------------
@@ -0,0 +1,377 @@
+package electrumx
+
+// Manual server management. These let an api user change the servers the
+// running network knows about and choose the leader without a restart. Changes
+// are made to the knownServers slice and 'network_servers.json' file and the
+// peersMonitor acts on them at it's next check, which the api kicks off
+// immediately.
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+var errUnknownServer = errors.New("unknown server")
+
+// KnownServer describes a server known to the network. It is exported to
+// Client.
+type KnownServer struct {
+	Net       string
+	Address   string
+	Host      string
+	IsOnion   bool
+	Version   string
+	RTT       time.Duration // smoothed server.ping round trip time; 0 if never measured
+	Lag       time.Duration // smoothed header notification lag behind other peers
+	Banned    bool
+	Preferred bool // preferred leader
+	Connected bool // running as leader or peer
+	Leader    bool
+}
+
+// newServerAddr makes a serverAddr from an api user's NodeServerAddr after
+// checking it is usable.
+func newServerAddr(addr *NodeServerAddr) (*serverAddr, error) {
+	if addr == nil {
+		return nil, errors.New("nil server address")
+	}
+	switch addr.Net {
+	case "tcp", "ssl":
+	default:
+		return nil, fmt.Errorf("unknown protocol: %s", addr.Net)
+	}
+	host, port, err := net.SplitHostPort(addr.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidPort(port) {
+		return nil, fmt.Errorf("invalid port: %s", port)
+	}
+	isOnion := addr.Onion || strings.HasSuffix(host, ".onion")
+	var ip string
+	if parsed := net.ParseIP(host); parsed != nil {
+		ip = parsed.String()
+		host = ip
+	} else if !isOnion && !isValidHostname(host) {
+		return nil, fmt.Errorf("invalid host: %s", host)
+	}
+	return &serverAddr{
+		Net:     addr.Net,
+		Address: net.JoinHostPort(host, port),
+		Host:    host,
+		IP:      ip,
+		IsOnion: isOnion,
+	}, nil
+}
+
+// kickMonitor wakes up peersMonitor to act on a change now rather than at the
+// next tick.
+func (net *Network) kickMonitor() {
+	select {
+	case net.monitorKick <- struct{}{}:
+	default:
+	}
+}
+
+// updateServerFlags finds a known server and applies update to both the
+// in memory and the stored copy. The stored copy is added if missing -
+// locked under knownServersMtx
+func (net *Network) updateServerFlags(server *serverAddr, update func(sa *serverAddr)) error {
+	found := false
+	for _, known := range net.knownServers {
+		if known.isSameServer(server) {
+			update(known)
+			found = true
+		}
+	}
+	if !found {
+		return errUnknownServer
+	}
+	stored, _, err := net.readServerAddrFile()
+	if err != nil {
+		return err
+	}
+	found = false
+	for _, got := range stored {
+		if got.isSameServer(server) {
+			update(got)
+			found = true
+		}
+	}
+	if !found {
+		for _, known := range net.knownServers {
+			if known.isSameServer(server) {
+				stored = append(stored, known)
+			}
+		}
+	}
+	return net.writeServerAddrFile(stored)
+}
+
+// disconnectServer stops a running leader or peer for a server - locked under
+// peersMtx
+func (net *Network) disconnectServer(server *serverAddr) {
+	leader := net.getLeader()
+	if leader != nil && toNetAddr(server).IsEqual(leader.netAddr) {
+		leader.nodeCancel(errNetworkCanceled)
+	}
+	for _, peer := range net.peers {
+		if toNetAddr(server).IsEqual(peer.netAddr) {
+			peer.nodeCancel(errNetworkCanceled)
+		}
+	}
+}
+
+// getPreferredLeader returns the preferred leader server if any - locked
+// under knownServersMtx
+func (net *Network) getPreferredLeader() *serverAddr {
+	for _, known := range net.knownServers {
+		if known.Preferred && !known.Banned {
+			return known
+		}
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// API Server management
+// -----------------------------------------------------------------------------
+
+// AddServer adds a server to the known servers. Adding a banned server lifts
+// the ban.
+func (net *Network) AddServer(addr *NodeServerAddr) error {
+	server, err := newServerAddr(addr)
+	if err != nil {
+		return err
+	}
+	net.knownServersMtx.Lock()
+	err = net.updateServerFlags(server, func(sa *serverAddr) {
+		sa.Banned = false
+	})
+	net.knownServersMtx.Unlock()
+	if err == nil {
+		// already known
+		net.kickMonitor()
+		return nil
+	}
+	if !errors.Is(err, errUnknownServer) {
+		return err
+	}
+	servers := []*serverAddr{server}
+	err = net.updateNetworkServers(servers)
+	if err != nil {
+		return err
+	}
+	err = net.updateStoredServers(servers)
+	if err != nil {
+		return err
+	}
+	net.kickMonitor()
+	return nil
+}
+
+// RemoveServer removes a server from the known servers and disconnects it if
+// running. This is done regardless of the NoDeleteKnownPeers strategy. The
+// server can be found again in peer discovery; use BanServer to stop that.
+func (net *Network) RemoveServer(addr *NodeServerAddr) error {
+	server, err := newServerAddr(addr)
+	if err != nil {
+		return err
+	}
+	net.peersMtx.Lock()
+	defer net.peersMtx.Unlock()
+	net.knownServersMtx.Lock()
+	defer net.knownServersMtx.Unlock()
+
+	var lessKnown = make([]*serverAddr, 0, len(net.knownServers))
+	for _, known := range net.knownServers {
+		if known.isSameServer(server) {
+			continue
+		}
+		lessKnown = append(lessKnown, known)
+	}
+	if len(lessKnown) == len(net.knownServers) {
+		return errUnknownServer
+	}
+	net.knownServers = lessKnown
+
+	stored, _, err := net.readServerAddrFile()
+	if err != nil {
+		return err
+	}
+	var lessStored = make([]*serverAddr, 0, len(stored))
+	for _, got := range stored {
+		if got.isSameServer(server) {
+			continue
+		}
+		lessStored = append(lessStored, got)
+	}
+	err = net.writeServerAddrFile(lessStored)
+	if err != nil {
+		return err
+	}
+	net.disconnectServer(server)
+	net.kickMonitor()
+	return nil
+}
+
+// BanServer disconnects a server if running and stops it being used again. The
+// server is kept in the known servers, marked as banned, so that it is not
+// added back by peer discovery.
+func (net *Network) BanServer(addr *NodeServerAddr) error {
+	server, err := newServerAddr(addr)
+	if err != nil {
+		return err
+	}
+	net.peersMtx.Lock()
+	defer net.peersMtx.Unlock()
+	net.knownServersMtx.Lock()
+	defer net.knownServersMtx.Unlock()
+
+	if !net.isKnownServer(server) {
+		net.knownServers = append(net.knownServers, server)
+	}
+	err = net.updateServerFlags(server, func(sa *serverAddr) {
+		sa.Banned = true
+		sa.Preferred = false
+	})
+	if err != nil {
+		return err
+	}
+	net.disconnectServer(server)
+	net.kickMonitor()
+	return nil
+}
+
+// ListKnownServers returns all the servers known to the network.
+func (net *Network) ListKnownServers() []*KnownServer {
+	net.peersMtx.RLock()
+	defer net.peersMtx.RUnlock()
+	net.knownServersMtx.Lock()
+	defer net.knownServersMtx.Unlock()
+
+	leader := net.getLeader()
+	list := make([]*KnownServer, 0, len(net.knownServers))
+	for _, known := range net.knownServers {
+		ks := &KnownServer{
+			Net:       known.Net,
+			Address:   known.Address,
+			Host:      known.Host,
+			IsOnion:   known.IsOnion,
+			Version:   known.Version,
+			RTT:       time.Duration(known.RTT) * time.Millisecond,
+			Lag:       time.Duration(known.Lag) * time.Millisecond,
+			Banned:    known.Banned,
+			Preferred: known.Preferred,
+		}
+		netAddr := toNetAddr(known)
+		if leader != nil && leader.nodeCtx.Err() == nil && netAddr.IsEqual(leader.netAddr) {
+			ks.Connected = true
+			ks.Leader = true
+		}
+		for _, peer := range net.peers {
+			if peer.nodeCtx.Err() == nil && netAddr.IsEqual(peer.netAddr) {
+				ks.Connected = true
+			}
+		}
+		list = append(list, ks)
+	}
+	return list
+}
+
+// SetPreferredLeader makes a server the preferred leader. The server is added
+// to the known servers if needed and the network switches leader to it. Only
+// one server can be preferred; nil clears the preference.
+func (net *Network) SetPreferredLeader(addr *NodeServerAddr) error {
+	if addr == nil {
+		net.knownServersMtx.Lock()
+		defer net.knownServersMtx.Unlock()
+		preferred := net.getPreferredLeader()
+		if preferred == nil {
+			return nil
+		}
+		return net.updateServerFlags(preferred, func(sa *serverAddr) {
+			sa.Preferred = false
+		})
+	}
+	server, err := newServerAddr(addr)
+	if err != nil {
+		return err
+	}
+	net.knownServersMtx.Lock()
+	if !net.isKnownServer(server) {
+		net.knownServers = append(net.knownServers, server)
+	}
+	for _, known := range net.knownServers {
+		if known.Preferred && !known.isSameServer(server) {
+			err = net.updateServerFlags(known, func(sa *serverAddr) {
+				sa.Preferred = false
+			})
+			if err != nil {
+				net.knownServersMtx.Unlock()
+				return err
+			}
+		}
+	}
+	err = net.updateServerFlags(server, func(sa *serverAddr) {
+		sa.Preferred = true
+		sa.Banned = false
+	})
+	net.knownServersMtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// switch now unless already leading
+	net.peersMtx.Lock()
+	defer net.peersMtx.Unlock()
+	leader := net.getLeader()
+	if leader != nil && leader.nodeCtx.Err() == nil && toNetAddr(server).IsEqual(leader.netAddr) {
+		return nil
+	}
+	net.switchLeader = true
+	net.kickMonitor()
+	return nil
+}
+
+// ForceLeaderSwitch drops the current leader and the peersMonitor chooses a
+// new one; the preferred leader if any, otherwise the best running peer or
+// known server.
+func (net *Network) ForceLeaderSwitch() error {
+	if !net.started {
+		return errNoNetwork
+	}
+	net.peersMtx.Lock()
+	defer net.peersMtx.Unlock()
+	leader := net.getLeader()
+	if leader == nil {
+		return errNoLeader
+	}
+	// is there somewhere to go?
+	hasOther := false
+	for _, peer := range net.peers {
+		if peer.nodeCtx.Err() == nil {
+			hasOther = true
+			break
+		}
+	}
+	if !hasOther && len(net.availableServers(true)) == 0 {
+		return errors.New("no other server to switch leader to")
+	}
+	net.switchLeader = true
+	net.kickMonitor()
+	return nil
+}
+
+// isKnownServer - locked under knownServersMtx
+func (net *Network) isKnownServer(server *serverAddr) bool {
+	for _, known := range net.knownServers {
+		if known.isSameServer(server) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package electrumx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func findKnown(list []*KnownServer, addr string) *KnownServer {
+	for _, ks := range list {
+		if ks.Address == addr {
+			return ks
+		}
+	}
+	return nil
+}
+
+func TestManageServers(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "tms_")
+	defer os.RemoveAll(tmpDir)
+	net := mkNetwork(tmpDir)
+
+	err := net.addIncomingServers(peerResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// discovery does not store servers, store them as a previous run would
+	err = net.updateStoredServers(net.knownServers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numKnown := len(net.knownServers)
+
+	// bad addresses
+	badAddrs := []*NodeServerAddr{
+		nil,
+		{Net: "udp", Addr: "electrum.example.com:50002"},
+		{Net: "ssl", Addr: "electrum.example.com"},
+		{Net: "ssl", Addr: "electrum.example.com:0"},
+		{Net: "ssl", Addr: "bad_host:50002"},
+	}
+	for _, addr := range badAddrs {
+		if err := net.AddServer(addr); err == nil {
+			t.Fatalf("expected error adding %v", addr)
+		}
+	}
+
+	// add
+	newAddr := &NodeServerAddr{Net: "ssl", Addr: "electrum.example.com:50002"}
+	err = net.AddServer(newAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(net.knownServers) != numKnown+1 {
+		t.Fatalf("got %d known servers, want %d", len(net.knownServers), numKnown+1)
+	}
+	// adding again is not an error and does not duplicate
+	err = net.AddServer(newAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(net.knownServers) != numKnown+1 {
+		t.Fatalf("got %d known servers, want %d", len(net.knownServers), numKnown+1)
+	}
+	_, n, err := net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != numKnown+1 {
+		t.Fatalf("got %d stored servers, want %d", n, numKnown+1)
+	}
+
+	// ban
+	bannedAddr := &NodeServerAddr{Net: "ssl", Addr: "testnet.aranguren.org:51002"}
+	err = net.BanServer(bannedAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := findKnown(net.ListKnownServers(), "testnet.aranguren.org:51002")
+	if ks == nil || !ks.Banned {
+		t.Fatal("expected banned server in list")
+	}
+	for _, server := range net.availableServers(false) {
+		if server.Banned {
+			t.Fatalf("banned server %s is available", server.Address)
+		}
+	}
+	// seen again by ip in peer discovery - still banned & not duplicated
+	err = net.addIncomingServers([]*peersResult{
+		{Addr: "203.132.94.196", Host: "203.132.94.196", Feats: []string{"s51002"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(net.knownServers) != numKnown+1 {
+		t.Fatalf("got %d known servers, want %d", len(net.knownServers), numKnown+1)
+	}
+	// ban survives a restart
+	_, err = net.loadKnownServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks = findKnown(net.ListKnownServers(), "testnet.aranguren.org:51002")
+	if ks == nil || !ks.Banned {
+		t.Fatal("expected banned server after reload")
+	}
+	// add lifts the ban
+	err = net.AddServer(bannedAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks = findKnown(net.ListKnownServers(), "testnet.aranguren.org:51002")
+	if ks == nil || ks.Banned {
+		t.Fatal("expected ban lifted")
+	}
+
+	// preferred leader
+	err = net.SetPreferredLeader(newAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !net.switchLeader {
+		t.Fatal("expected leader switch requested")
+	}
+	net.knownServersMtx.Lock()
+	preferred := net.getPreferredLeader()
+	net.knownServersMtx.Unlock()
+	if preferred == nil || preferred.Address != "electrum.example.com:50002" {
+		t.Fatal("expected preferred leader")
+	}
+	// only one preferred
+	err = net.SetPreferredLeader(bannedAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numPreferred := 0
+	for _, ks := range net.ListKnownServers() {
+		if ks.Preferred {
+			numPreferred++
+		}
+	}
+	if numPreferred != 1 {
+		t.Fatalf("got %d preferred servers", numPreferred)
+	}
+	// preferred is not removed automatically
+	net.knownServersMtx.Lock()
+	preferred = net.getPreferredLeader()
+	net.knownServersMtx.Unlock()
+	err = net.removeServer(preferred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findKnown(net.ListKnownServers(), preferred.Address) == nil {
+		t.Fatal("preferred server removed")
+	}
+	// clear
+	err = net.SetPreferredLeader(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net.knownServersMtx.Lock()
+	preferred = net.getPreferredLeader()
+	net.knownServersMtx.Unlock()
+	if preferred != nil {
+		t.Fatal("expected no preferred leader")
+	}
+
+	// remove
+	err = net.RemoveServer(newAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findKnown(net.ListKnownServers(), "electrum.example.com:50002") != nil {
+		t.Fatal("server not removed")
+	}
+	err = net.RemoveServer(newAddr)
+	if err == nil {
+		t.Fatal("expected error removing unknown server")
+	}
+	_, n, err = net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != numKnown {
+		t.Fatalf("got %d stored servers, want %d", n, numKnown)
+	}
+
+	// not started
+	err = net.ForceLeaderSwitch()
+	if err == nil {
+		t.Fatal("expected error - network not started")
+	}
+}
+
+func TestManageServersDisconnect(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "tmd_")
+	defer os.RemoveAll(tmpDir)
+	net := mkNetwork(tmpDir)
+	net.started = true
+
+	err := net.addIncomingServers(peerResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no leader to switch from
+	err = net.ForceLeaderSwitch()
+	if !errors.Is(err, errNoLeader) {
+		t.Fatalf("expected no leader error, got %v", err)
+	}
+	leaderCtx, leaderCancel := context.WithCancelCause(context.Background())
+	defer leaderCancel(nil)
+	leader := mkPeer(leaderCtx, 1, "testnet.aranguren.org:51002", 100, 0)
+	leader.nodeCancel = leaderCancel
+	net.leader = leader
+	peerCtx, peerCancel := context.WithCancelCause(context.Background())
+	defer peerCancel(nil)
+	peer := mkPeer(peerCtx, 2, "[2600:1900:40b0:3af2:0:5::]:50002", 100, 0)
+	peer.nodeCancel = peerCancel
+	net.peers = append(net.peers, peer)
+
+	ks := findKnown(net.ListKnownServers(), "testnet.aranguren.org:51002")
+	if ks == nil || !ks.Leader || !ks.Connected {
+		t.Fatal("expected connected leader")
+	}
+
+	err = net.ForceLeaderSwitch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !net.switchLeader {
+		t.Fatal("expected leader switch requested")
+	}
+
+	err = net.BanServer(&NodeServerAddr{Net: "ssl", Addr: "[2600:1900:40b0:3af2:0:5::]:50002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peerCtx.Err() == nil {
+		t.Fatal("banned peer not disconnected")
+	}
+	err = net.RemoveServer(&NodeServerAddr{Net: "ssl", Addr: "testnet.aranguren.org:51002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaderCtx.Err() == nil {
+		t.Fatal("removed leader not disconnected")
+	}
+}
@@ -0,0 +1,270 @@
+package electrumx
+
+// Peer quality tracking for leader selection.
+//
+// For each running peer we measure:
+//   - the round trip time of the 'server.ping' requests sent from keepAlive
+//   - how far behind the first peer to report a new tip this peer's header
+//     notification arrived; and the last tip height it reported.
+//
+// Leader selection and promotion prefer the most up-to-date and then the
+// fastest peer. The smoothed figures are persisted to 'network_servers.json'
+// so that they can be used to pick good servers after a restart.
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Smoothing factor for the exponential moving averages: new = old +
+	// (sample - old) / qualitySmoothing
+	qualitySmoothing = 4
+	// Used in place of a round trip time for servers we never measured so that
+	// they are tried before known slow servers but after known fast ones.
+	defaultServerRTT = time.Second
+	// Penalty per block a peer is behind the best tip any peer reported.
+	blockBehindPenalty = 10 * time.Second
+	// How many tip heights we remember the first-seen time for.
+	maxTrackedHeights = 100
+	// Persist server stats every statsPersistTicks peersMonitor ticks.
+	statsPersistTicks = 12
+)
+
+// smooth returns the exponential moving average of old and sample. A zero old
+// value means no previous samples.
+func smooth(old, sample time.Duration) time.Duration {
+	if old == 0 {
+		return sample
+	}
+	return old + (sample-old)/qualitySmoothing
+}
+
+// headerLagTracker records when each tip height was first reported by any
+// peer. It is shared by all the network's nodes.
+type headerLagTracker struct {
+	firstSeen map[int64]time.Time
+	mtx       sync.Mutex
+}
+
+func newHeaderLagTracker() *headerLagTracker {
+	return &headerLagTracker{
+		firstSeen: make(map[int64]time.Time, maxTrackedHeights),
+	}
+}
+
+// seen records a peer reporting height at time 'at' and returns how long after
+// the first peer that reported this height it arrived.
+func (t *headerLagTracker) seen(height int64, at time.Time) time.Duration {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	first, ok := t.firstSeen[height]
+	if !ok {
+		t.firstSeen[height] = at
+		// forget old heights
+		for h := range t.firstSeen {
+			if h <= height-maxTrackedHeights {
+				delete(t.firstSeen, h)
+			}
+		}
+		return 0
+	}
+	if at.Before(first) {
+		return 0
+	}
+	return at.Sub(first)
+}
+
+// peerQuality holds the measured quality of a running peer's server.
+type peerQuality struct {
+	tipHeight int64
+	tipLag    time.Duration // smoothed
+	mtx       sync.Mutex
+}
+
+func newPeerQuality() *peerQuality {
+	return &peerQuality{}
+}
+
+// recordTip records the tip height reported by the peer and, if this was a
+// header notification rather than a subscription reply, the lag behind the
+// first peer to report it.
+func (q *peerQuality) recordTip(height int64, lag time.Duration, isNotification bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if height > q.tipHeight {
+		q.tipHeight = height
+	}
+	if isNotification {
+		q.tipLag = smooth(q.tipLag, lag)
+	}
+}
+
+// peerStats is a snapshot of a peer's quality.
+type peerStats struct {
+	rtt       time.Duration
+	tipHeight int64
+	tipLag    time.Duration
+}
+
+// stats returns a snapshot of the node's quality measurements.
+func (n *Node) stats() peerStats {
+	var st peerStats
+	if n.quality != nil {
+		n.quality.mtx.Lock()
+		st.tipHeight = n.quality.tipHeight
+		st.tipLag = n.quality.tipLag
+		n.quality.mtx.Unlock()
+	}
+	if n.server.conn != nil {
+		st.rtt = n.server.conn.pingRTT()
+	}
+	return st
+}
+
+// recordTip records a tip height reported by this node's server.
+func (n *Node) recordTip(height int64, isNotification bool) {
+	if n.quality == nil {
+		return
+	}
+	var lag time.Duration
+	if n.lagTracker != nil {
+		lag = n.lagTracker.seen(height, time.Now())
+	}
+	n.quality.recordTip(height, lag, isNotification)
+}
+
+// score returns a peer ranking where lower is better. Peers that are behind
+// the best known tip are heavily penalized.
+func (st peerStats) score(bestHeight int64) time.Duration {
+	rtt := st.rtt
+	if rtt == 0 {
+		rtt = defaultServerRTT
+	}
+	score := rtt + st.tipLag
+	if behind := bestHeight - st.tipHeight; behind > 0 {
+		score += time.Duration(behind) * blockBehindPenalty
+	}
+	return score
+}
+
+// bestPeerHeight returns the best tip height reported by the leader or any
+// running peer - not locked
+func (net *Network) bestPeerHeight() int64 {
+	var best int64
+	leader := net.getLeader()
+	if leader != nil && leader.nodeCtx.Err() == nil {
+		best = leader.node.stats().tipHeight
+	}
+	for _, peer := range net.peers {
+		if peer.nodeCtx.Err() != nil {
+			continue
+		}
+		if h := peer.node.stats().tipHeight; h > best {
+			best = h
+		}
+	}
+	return best
+}
+
+// rankPeers returns the running peers ordered best first - not locked
+func (net *Network) rankPeers() []*peerNode {
+	bestHeight := net.bestPeerHeight()
+	ranked := make([]*peerNode, 0, len(net.peers))
+	for _, peer := range net.peers {
+		if peer.nodeCtx.Err() != nil {
+			continue
+		}
+		ranked = append(ranked, peer)
+	}
+	scores := make(map[uint32]time.Duration, len(ranked))
+	for _, peer := range ranked {
+		scores[peer.id] = peer.node.stats().score(bestHeight)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].id] < scores[ranked[j].id]
+	})
+	return ranked
+}
+
+// serverScore ranks a known server from it's persisted stats. Lower is better.
+func (sa *serverAddr) serverScore() time.Duration {
+	rtt := time.Duration(sa.RTT) * time.Millisecond
+	if rtt == 0 {
+		rtt = defaultServerRTT
+	}
+	return rtt + time.Duration(sa.Lag)*time.Millisecond
+}
+
+// sortServersByQuality orders servers best first. The sort is stable so
+// servers with equal scores keep their pseudo random order.
+func sortServersByQuality(servers []*serverAddr) {
+	sort.SliceStable(servers, func(i, j int) bool {
+		return servers[i].serverScore() < servers[j].serverScore()
+	})
+}
+
+// updateServerStats copies the running peers' measurements into the matching
+// known servers and persists them to 'network_servers.json'. A discovered
+// server not yet stored is stored once it has stats - not locked under
+// peersMtx
+func (net *Network) updateServerStats() error {
+	running := make([]*peerNode, 0, len(net.peers)+1)
+	if leader := net.getLeader(); leader != nil {
+		running = append(running, leader)
+	}
+	running = append(running, net.peers...)
+
+	net.knownServersMtx.Lock()
+	defer net.knownServersMtx.Unlock()
+
+	stored, _, err := net.readServerAddrFile()
+	if err != nil {
+		return err
+	}
+	updated := false
+	for _, peer := range running {
+		if peer.nodeCtx.Err() != nil {
+			continue
+		}
+		st := peer.node.stats()
+		if st.rtt == 0 && st.tipLag == 0 {
+			continue
+		}
+		rtt := st.rtt.Milliseconds()
+		lag := st.tipLag.Milliseconds()
+		for _, servers := range [][]*serverAddr{net.knownServers, stored} {
+			for _, server := range servers {
+				if !toNetAddr(server).IsEqual(peer.netAddr) {
+					continue
+				}
+				server.RTT = rtt
+				server.Lag = lag
+				updated = true
+			}
+		}
+	}
+	// store known servers with stats that are not stored yet
+	for _, server := range net.knownServers {
+		if server.RTT == 0 && server.Lag == 0 {
+			continue
+		}
+		isStored := false
+		for _, got := range stored {
+			if got.isSameServer(server) {
+				isStored = true
+				break
+			}
+		}
+		if !isStored {
+			storedServer := *server
+			stored = append(stored, &storedServer)
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	return net.writeServerAddrFile(stored)
+}
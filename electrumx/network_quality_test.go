@@ -0,0 +1,192 @@
+package electrumx
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHeaderLagTracker(t *testing.T) {
+	tracker := newHeaderLagTracker()
+	now := time.Now()
+
+	lag := tracker.seen(100, now)
+	if lag != 0 {
+		t.Fatalf("first peer to report should have no lag, got %v", lag)
+	}
+	lag = tracker.seen(100, now.Add(3*time.Second))
+	if lag != 3*time.Second {
+		t.Fatalf("expected 3s lag, got %v", lag)
+	}
+	// clock went backwards
+	lag = tracker.seen(100, now.Add(-time.Second))
+	if lag != 0 {
+		t.Fatalf("expected no lag, got %v", lag)
+	}
+	// old heights are forgotten
+	tracker.seen(100+maxTrackedHeights, now)
+	if _, ok := tracker.firstSeen[100]; ok {
+		t.Fatal("height 100 should have been forgotten")
+	}
+}
+
+func TestPeerQuality(t *testing.T) {
+	q := newPeerQuality()
+	q.recordTip(100, 10*time.Second, false)
+	if q.tipHeight != 100 || q.tipLag != 0 {
+		t.Fatalf("subscription reply should not count as lag: %d %v", q.tipHeight, q.tipLag)
+	}
+	q.recordTip(101, 4*time.Second, true)
+	if q.tipHeight != 101 || q.tipLag != 4*time.Second {
+		t.Fatalf("got %d %v", q.tipHeight, q.tipLag)
+	}
+	q.recordTip(102, 0, true)
+	if q.tipLag != 3*time.Second {
+		t.Fatalf("expected smoothed lag 3s, got %v", q.tipLag)
+	}
+	// lower height does not wind back the tip
+	q.recordTip(99, 0, false)
+	if q.tipHeight != 102 {
+		t.Fatalf("expected tip 102, got %d", q.tipHeight)
+	}
+}
+
+func mkPeer(ctx context.Context, id uint32, addr string, tipHeight int64, tipLag time.Duration) *peerNode {
+	q := newPeerQuality()
+	q.tipHeight = tipHeight
+	q.tipLag = tipLag
+	return &peerNode{
+		id:      id,
+		netAddr: &NodeServerAddr{Net: "ssl", Addr: addr},
+		node: &Node{
+			server:  &Server{},
+			quality: q,
+		},
+		nodeCtx: ctx,
+	}
+}
+
+func TestRankPeers(t *testing.T) {
+	ctx := context.Background()
+	deadCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	net := mkNetwork("")
+	behind := mkPeer(ctx, 1, "behind.example.com:50002", 99, 0)
+	slow := mkPeer(ctx, 2, "slow.example.com:50002", 100, 5*time.Second)
+	fast := mkPeer(ctx, 3, "fast.example.com:50002", 100, 100*time.Millisecond)
+	dead := mkPeer(deadCtx, 4, "dead.example.com:50002", 101, 0)
+	net.peers = []*peerNode{behind, slow, dead, fast}
+
+	ranked := net.rankPeers()
+	if len(ranked) != 3 {
+		t.Fatalf("expected 3 live peers, got %d", len(ranked))
+	}
+	if ranked[0] != fast || ranked[1] != slow || ranked[2] != behind {
+		t.Fatalf("bad ranking: %d %d %d", ranked[0].id, ranked[1].id, ranked[2].id)
+	}
+}
+
+func TestSortServersByQuality(t *testing.T) {
+	servers := []*serverAddr{
+		{Address: "slow:50002", RTT: 2000},
+		{Address: "unknown:50002"},
+		{Address: "laggy:50002", RTT: 50, Lag: 30000},
+		{Address: "fast:50002", RTT: 50},
+	}
+	sortServersByQuality(servers)
+	want := []string{"fast:50002", "unknown:50002", "slow:50002", "laggy:50002"}
+	for i, server := range servers {
+		if server.Address != want[i] {
+			t.Fatalf("position %d: got %s want %s", i, server.Address, want[i])
+		}
+	}
+}
+
+func TestUpdateServerStats(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "tqs_")
+	defer os.RemoveAll(tmpDir)
+	net := mkNetwork(tmpDir)
+
+	err := net.addIncomingServers(peerResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// discovery does not store servers, store them as a previous run would
+	err = net.updateStoredServers(net.knownServers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	net.leader = mkPeer(ctx, 1, "testnet.aranguren.org:51002", 100, 2*time.Second)
+
+	err = net.updateServerStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _, err := net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, server := range stored {
+		if server.Net == "ssl" && server.Address == "testnet.aranguren.org:51002" {
+			found = true
+			if server.Lag != 2000 {
+				t.Fatalf("expected persisted lag 2000ms, got %d", server.Lag)
+			}
+			continue
+		}
+		if server.Lag != 0 || server.RTT != 0 {
+			t.Fatalf("unexpected stats for %s", server.Address)
+		}
+	}
+	if !found {
+		t.Fatal("leader server not stored")
+	}
+
+	// stats survive a restart
+	_, err = net.loadKnownServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range net.knownServers {
+		if server.Net == "ssl" && server.Address == "testnet.aranguren.org:51002" && server.Lag != 2000 {
+			t.Fatalf("expected loaded lag 2000ms, got %d", server.Lag)
+		}
+	}
+}
+
+func TestUpdateServerStatsDiscovered(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "tqs_")
+	defer os.RemoveAll(tmpDir)
+	net := mkNetwork(tmpDir)
+
+	// discovered, never stored
+	err := net.addIncomingServers(peerResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, n, err := net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("got %d stored servers", n)
+	}
+	ctx := context.Background()
+	net.leader = mkPeer(ctx, 1, "testnet.aranguren.org:51002", 100, 2*time.Second)
+
+	err = net.updateServerStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _, err := net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Address != "testnet.aranguren.org:51002" || stored[0].Lag != 2000 {
+		t.Fatalf("expected only the leader stored with it's stats, got %d servers", len(stored))
+	}
+}
@@ -11,19 +11,25 @@ import (
 	"net"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 )
 
 const ServerAddrFileName = "network_servers.json"
 
 type serverAddr struct {
-	Net     string `json:"net"`
-	Address string `json:"addr"`
-	Host    string `json:"host"`
-	IsOnion bool   `json:"is_onion"`
-	Version string `json:"version"`
-	Caps    string `json:"caps"` // comma separated string eg. "cannot_lead,no_blks,..."
-	Rep     int    `json:"rep"`  // reputation score - not fully implemented as yet
+	Net       string `json:"net"`
+	Address   string `json:"addr"`
+	Host      string `json:"host"`
+	IP        string `json:"ip,omitempty"` // IP address the server was seen on, if any
+	IsOnion   bool   `json:"is_onion"`
+	Version   string `json:"version"`
+	Caps      string `json:"caps"`                // comma separated string eg. "cannot_lead,no_blks,..."
+	Rep       int    `json:"rep"`                 // reputation score - not fully implemented as yet
+	RTT       int64  `json:"rtt_ms,omitempty"`    // smoothed server.ping round trip time
+	Lag       int64  `json:"lag_ms,omitempty"`    // smoothed header notification lag behind other peers
+	Banned    bool   `json:"banned,omitempty"`    // banned by api user - never started
+	Preferred bool   `json:"preferred,omitempty"` // preferred leader set by api user
 }
 
 // Incoming list from server_connection.go - constructed using reflection
@@ -40,14 +46,14 @@ type serverAddr struct {
 // getServers gets server peers (from the peerNode's server) which that server
 // currently knows; which can be different than known persisted servers held by
 // goele.
-func (net *Network) getServers(ctx context.Context) error {
+func (net *Network) getServers(ctx context.Context, leader *peerNode) error {
 	if !net.started {
 		return errNoNetwork
 	}
-	if net.getLeader() == nil {
+	if leader == nil {
 		return errNoLeader
 	}
-	peerResults, err := net.getLeader().node.getServerPeers(ctx)
+	peerResults, err := leader.node.getServerPeers(ctx)
 	if err != nil {
 		return err
 	}
@@ -74,19 +80,10 @@ func makeIncomingServerAddrs(in []*peersResult) []*serverAddr {
 	var badAddresses = 0
 
 	for _, pres := range in {
-		isOnion := false
-		if strings.HasSuffix(pres.Addr, ".onion") {
-			onionAddr := strings.Split(pres.Host, ".")
-			isOnion = true
-			if len(onionAddr[0]) != 56 { // no V2
-				badAddresses++
-				continue
-			}
-		} else {
-			if net.ParseIP(pres.Addr) == nil {
-				badAddresses++
-				continue
-			}
+		host, ip, isOnion, ok := peerHostAndIP(pres)
+		if !ok {
+			badAddresses++
+			continue
 		}
 
 		var isTcp bool
@@ -97,6 +94,9 @@ func makeIncomingServerAddrs(in []*peersResult) []*serverAddr {
 		var sslPort string
 		feats := pres.Feats
 		for _, feat := range feats {
+			if len(feat) == 0 {
+				continue
+			}
 			switch []rune(feat)[0] {
 			case 'v':
 				version = feat[1:]
@@ -112,35 +112,207 @@ func makeIncomingServerAddrs(in []*peersResult) []*serverAddr {
 			if len(tcpPort) == 0 {
 				tcpPort = "51001" // default if no explicit port after 't'
 			}
+			isTcp = isValidPort(tcpPort)
+		}
+		if isSsl {
+			if len(sslPort) == 0 {
+				sslPort = "51002" // default if no explicit port after 's'
+			}
+			isSsl = isValidPort(sslPort)
+		}
+		if !isTcp && !isSsl {
+			badAddresses++
+			continue
+		}
+		if isTcp {
 			saddr := &serverAddr{
 				Net:     "tcp",
-				Address: net.JoinHostPort(pres.Addr, tcpPort),
-				Host:    pres.Host,
+				Address: net.JoinHostPort(host, tcpPort),
+				Host:    host,
+				IP:      ip,
 				IsOnion: isOnion,
 				Version: version,
 				Caps:    "",
 			}
-			servers = append(servers, saddr)
+			servers = appendUniqueServer(servers, saddr)
 		}
 		if isSsl {
-			if len(sslPort) == 0 {
-				sslPort = "51002" // default if no explicit port after 's'
-			}
 			saddr := &serverAddr{
 				Net:     "ssl",
-				Address: net.JoinHostPort(pres.Addr, sslPort),
-				Host:    pres.Host,
+				Address: net.JoinHostPort(host, sslPort),
+				Host:    host,
+				IP:      ip,
 				IsOnion: isOnion,
 				Version: version,
 				Caps:    "",
 			}
-			servers = append(servers, saddr)
+			servers = appendUniqueServer(servers, saddr)
 		}
 		goodAddresses++
 	}
 	return servers
 }
 
+// peerHostAndIP decides the host we dial for an incoming peer and the literal
+// IP address (if any) that the peer was seen on.
+//
+// ElectrumX sends [ip, host, feats] for each peer where ip is an IP address or
+// an onion name. We prefer a DNS hostname from the host field when the server
+// advertises one as many servers (e.g. Fulcrum) have certificates issued for
+// the hostname only. Otherwise we fall back to the IP. IPv6 addresses are
+// returned without brackets; net.JoinHostPort adds them back.
+func peerHostAndIP(pres *peersResult) (string, string, bool, bool) {
+	addr := trimIPv6Brackets(strings.TrimSpace(pres.Addr))
+	host := trimIPv6Brackets(strings.TrimSpace(pres.Host))
+
+	if strings.HasSuffix(addr, ".onion") {
+		onionAddr := strings.Split(addr, ".")
+		if len(onionAddr) != 2 || len(onionAddr[0]) != 56 { // no V2
+			return "", "", false, false
+		}
+		return addr, "", true, true
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		// ip field is neither an IP address nor an onion name
+		return "", "", false, false
+	}
+	ipStr := ip.String()
+	if isValidHostname(host) {
+		return strings.ToLower(host), ipStr, false, true
+	}
+	return ipStr, ipStr, false, true
+}
+
+func trimIPv6Brackets(s string) string {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// isValidHostname checks for a fully qualified DNS name such as
+// "testnet.aranguren.org". Literal IPs, bare names like "localhost" and
+// onion names are not accepted.
+func isValidHostname(host string) bool {
+	if len(host) == 0 || len(host) > 253 {
+		return false
+	}
+	if net.ParseIP(host) != nil {
+		return false
+	}
+	host = strings.TrimSuffix(host, ".")
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			switch {
+			case c >= 'a' && c <= 'z':
+			case c >= 'A' && c <= 'Z':
+			case c >= '0' && c <= '9':
+			case c == '-':
+			default:
+				return false
+			}
+		}
+	}
+	// top level domain cannot be all numeric
+	tld := labels[len(labels)-1]
+	if _, err := strconv.Atoi(tld); err == nil {
+		return false
+	}
+	return true
+}
+
+// isValidPort checks for a decimal port number in the range 1-65535.
+func isValidPort(port string) bool {
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return false
+	}
+	return p > 0
+}
+
+// isSameServer returns true if both server addresses are the same server for
+// the same protocol. A server can be seen by it's IP address from one peer and
+// by it's hostname from another so we match on port plus either host or IP.
+// Two different hostnames are never the same server.
+func (sa *serverAddr) isSameServer(other *serverAddr) bool {
+	if sa.Net != other.Net {
+		return false
+	}
+	if sa.Address == other.Address {
+		return true
+	}
+	saHost, saPort, err := net.SplitHostPort(sa.Address)
+	if err != nil {
+		return false
+	}
+	otherHost, otherPort, err := net.SplitHostPort(other.Address)
+	if err != nil {
+		return false
+	}
+	if saPort != otherPort {
+		return false
+	}
+	// different hostnames are different servers even if they share an IP
+	// address, e.g. virtual hosts behind one load balancer
+	saHost, otherHost = strings.ToLower(saHost), strings.ToLower(otherHost)
+	if net.ParseIP(saHost) == nil && net.ParseIP(otherHost) == nil {
+		return saHost == otherHost
+	}
+	saNames := []string{saHost, sa.IP}
+	otherNames := []string{otherHost, other.IP}
+	for _, saName := range saNames {
+		if saName == "" {
+			continue
+		}
+		for _, otherName := range otherNames {
+			if saName == otherName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// preferHostname gives a server seen only by it's IP address the hostname of
+// other, the same server seen by it's hostname. Many servers have certificates
+// for their hostname only. Stats, ban and preferred settings are kept.
+func (sa *serverAddr) preferHostname(other *serverAddr) {
+	saHost, _, err := net.SplitHostPort(sa.Address)
+	if err != nil || net.ParseIP(saHost) == nil {
+		return
+	}
+	otherHost, _, err := net.SplitHostPort(other.Address)
+	if err != nil || net.ParseIP(otherHost) != nil || other.IsOnion {
+		return
+	}
+	sa.Address = other.Address
+	sa.Host = other.Host
+	sa.IP = net.ParseIP(saHost).String()
+}
+
+// appendUniqueServer appends a server if it is not already in the list.
+func appendUniqueServer(servers []*serverAddr, server *serverAddr) []*serverAddr {
+	for _, got := range servers {
+		if got.isSameServer(server) {
+			got.preferHostname(server)
+			return servers
+		}
+	}
+	return append(servers, server)
+}
+
 func (net *Network) updateNetworkServers(servers []*serverAddr) error {
 	net.knownServersMtx.Lock()
 	defer net.knownServersMtx.Unlock()
@@ -155,7 +327,8 @@ func (net *Network) updateNetworkServers(servers []*serverAddr) error {
 		matchedKnown := false
 		for _, got := range net.knownServers { // loop through what we have already
 			// already got?
-			if got.Address == new.Address {
+			if got.isSameServer(new) {
+				got.preferHostname(new)
 				matchedKnown = true
 			}
 		}
@@ -184,7 +357,8 @@ func (net *Network) updateStoredServers(servers []*serverAddr) error {
 	for _, new := range servers {
 		matchedKnown := false
 		for _, got := range stored {
-			if got.Address == new.Address {
+			if got.isSameServer(new) {
+				got.preferHostname(new)
 				matchedKnown = true
 			}
 		}
@@ -197,6 +371,9 @@ func (net *Network) updateStoredServers(servers []*serverAddr) error {
 }
 
 func (net *Network) removeServer(server *serverAddr) error {
+	if server == nil {
+		return nil
+	}
 	if net.config.Flags&NoDeleteKnownPeers == NoDeleteKnownPeers {
 		fmt.Printf("removeServer: not removing %s - Strategy: NoDeleteStoredPeers\n", server.Address)
 		return nil
@@ -205,6 +382,13 @@ func (net *Network) removeServer(server *serverAddr) error {
 	net.knownServersMtx.Lock()
 	defer net.knownServersMtx.Unlock()
 
+	for _, known := range net.knownServers {
+		if known.Preferred && known.isSameServer(server) {
+			fmt.Printf("removeServer: not removing %s - preferred leader\n", server.Address)
+			return nil
+		}
+	}
+
 	// remove from memory first
 	var lessKnown = make([]*serverAddr, 0)
 	for _, known := range net.knownServers {
@@ -1,7 +1,6 @@
 package electrumx
 
 import (
-	"fmt"
 	netIp "net"
 	"os"
 	"testing"
@@ -136,8 +135,8 @@ func TestNetworkServers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(net.knownServers) != 10 {
-		t.Fatal("net.knownServers should be 8")
+	if len(net.knownServers) != 8 {
+		t.Fatalf("got %d net.knownServers should be 8", len(net.knownServers))
 	}
 
 	// update but all the same servers
@@ -145,7 +144,7 @@ func TestNetworkServers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(net.knownServers) != 10 {
+	if len(net.knownServers) != 8 {
 		t.Fatalf("got %d net.knownServers should be 8", len(net.knownServers))
 	}
 
@@ -154,15 +153,27 @@ func TestNetworkServers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(net.knownServers) != 10 {
-		t.Fatalf("got %d net.knownServers should be 10", len(net.knownServers))
+	if len(net.knownServers) != 8 {
+		t.Fatalf("got %d net.knownServers should be 8", len(net.knownServers))
+	}
+	// discovery does not store servers
+	_, n, err := net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("got %d stored servers should be 0", n)
+	}
+	err = net.updateStoredServers(net.knownServers)
+	if err != nil {
+		t.Fatal(err)
 	}
 	storedServers, n, err := net.readServerAddrFile()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if n != 10 {
-		t.Fatalf("got %d stored servers should be 12", len(storedServers))
+	if n != 8 {
+		t.Fatalf("got %d stored servers should be 8", len(storedServers))
 	}
 
 	// remove one by one
@@ -183,17 +194,220 @@ func TestNetworkServers(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	n = 0
+	if len(net.knownServers) != 4 {
+		t.Fatalf("got %d net.knownServers should be 4", len(net.knownServers))
+	}
 	for _, ks := range net.knownServers {
-		h, _, _ := netIp.SplitHostPort(ks.Address)
-		if nil == netIp.ParseIP(h) {
-			fmt.Printf("bad ip addr: %s\n", ks.Address)
-			continue
+		if nil == netIp.ParseIP(ks.IP) {
+			t.Fatalf("bad ip addr: %s for %s", ks.IP, ks.Address)
+		}
+		h, _, err := netIp.SplitHostPort(ks.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h != ks.Host {
+			t.Fatalf("address host %s is not server host %s", h, ks.Host)
 		}
-		n++
 	}
-	if len(net.knownServers) != n {
-		t.Fatal()
+}
+
+func TestMakeIncomingServerAddrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    []*peersResult
+		addrs []string
+	}{
+		{
+			name: "hostname preferred over ip",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "testnet.aranguren.org", Feats: []string{"v1.5", "s51002"}},
+			},
+			addrs: []string{"testnet.aranguren.org:51002"},
+		},
+		{
+			name: "ip used when host is also the ip",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "203.132.94.196", Feats: []string{"v1.5", "t51001"}},
+			},
+			addrs: []string{"203.132.94.196:51001"},
+		},
+		{
+			name: "ipv6 bracketed",
+			in: []*peersResult{
+				{Addr: "2600:1901:81c0:6a5:0:3::", Host: "2600:1901:81c0:6a5:0:3::", Feats: []string{"v1.5", "s50002", "t50001"}},
+			},
+			addrs: []string{"[2600:1901:81c0:6a5:0:3::]:50001", "[2600:1901:81c0:6a5:0:3::]:50002"},
+		},
+		{
+			name: "ipv6 already bracketed",
+			in: []*peersResult{
+				{Addr: "[2600:1900:40f0:964b::]", Host: "", Feats: []string{"s50002"}},
+			},
+			addrs: []string{"[2600:1900:40f0:964b::]:50002"},
+		},
+		{
+			name: "ipv6 with hostname",
+			in: []*peersResult{
+				{Addr: "2600:1900:40f0:964b::", Host: "testIPv6.test.org", Feats: []string{"s50002"}},
+			},
+			addrs: []string{"testipv6.test.org:50002"},
+		},
+		{
+			name: "same server seen by ip and hostname",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "testnet.aranguren.org", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.196", Host: "203.132.94.196", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.196", Host: "TESTNET.aranguren.org", Feats: []string{"s51002"}},
+			},
+			addrs: []string{"testnet.aranguren.org:51002"},
+		},
+		{
+			name: "hostname preferred when the ip is seen first",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "203.132.94.196", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.196", Host: "testnet.aranguren.org", Feats: []string{"s51002"}},
+			},
+			addrs: []string{"testnet.aranguren.org:51002"},
+		},
+		{
+			name: "same ip different ports are different servers",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "testnet.aranguren.org", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.196", Host: "203.132.94.196", Feats: []string{"s52002"}},
+			},
+			addrs: []string{"testnet.aranguren.org:51002", "203.132.94.196:52002"},
+		},
+		{
+			name: "default ports",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "", Feats: []string{"v1.4", "s", "t"}},
+			},
+			addrs: []string{"203.132.94.196:51001", "203.132.94.196:51002"},
+		},
+		{
+			name: "bad ports",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "testnet.aranguren.org", Feats: []string{"s0", "t65536"}},
+				{Addr: "203.132.94.197", Host: "", Feats: []string{"sabc", "t50001"}},
+			},
+			addrs: []string{"203.132.94.197:50001"},
+		},
+		{
+			name: "bad addresses",
+			in: []*peersResult{
+				{Addr: "new IP:PORT", Host: "testnet.aranguren.org", Feats: []string{"s51002"}},
+				{Addr: "203.132.94", Host: "", Feats: []string{"s51002"}},
+				{Addr: "v2onionaddress.onion", Host: "v2onionaddress.onion", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.196", Host: "", Feats: []string{"v1.4"}},
+			},
+			addrs: []string{},
+		},
+		{
+			name: "bad hostname falls back to ip",
+			in: []*peersResult{
+				{Addr: "203.132.94.196", Host: "localhost", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.197", Host: "-bad-.example.com", Feats: []string{"s51002"}},
+				{Addr: "203.132.94.198", Host: "bad_host.example.com", Feats: []string{"s51002"}},
+			},
+			addrs: []string{"203.132.94.196:51002", "203.132.94.197:51002", "203.132.94.198:51002"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := makeIncomingServerAddrs(tt.in)
+			if len(servers) != len(tt.addrs) {
+				t.Fatalf("got %d servers, want %d", len(servers), len(tt.addrs))
+			}
+			for _, want := range tt.addrs {
+				found := false
+				for _, server := range servers {
+					if server.Address == want {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Fatalf("missing server address %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsSameServer(t *testing.T) {
+	byHost := &serverAddr{Net: "ssl", Address: "testnet.aranguren.org:51002", Host: "testnet.aranguren.org", IP: "203.132.94.196"}
+	// stored before we kept hostnames
+	byIP := &serverAddr{Net: "ssl", Address: "203.132.94.196:51002", Host: "testnet.aranguren.org"}
+	tcp := &serverAddr{Net: "tcp", Address: "203.132.94.196:51002", Host: "testnet.aranguren.org"}
+	other := &serverAddr{Net: "ssl", Address: "testnet.qtornado.com:51002", Host: "testnet.qtornado.com", IP: "203.132.94.197"}
+
+	if !byHost.isSameServer(byIP) || !byIP.isSameServer(byHost) {
+		t.Fatal("expected same server")
+	}
+	if byIP.isSameServer(tcp) {
+		t.Fatal("expected different protocol")
+	}
+	if byHost.isSameServer(other) {
+		t.Fatal("expected different server")
+	}
+	// virtual hosts on one IP address
+	vhost := &serverAddr{Net: "ssl", Address: "electrum.aranguren.org:51002", Host: "electrum.aranguren.org", IP: "203.132.94.196"}
+	if byHost.isSameServer(vhost) || vhost.isSameServer(byHost) {
+		t.Fatal("expected different hostnames to be different servers")
+	}
+	if !vhost.isSameServer(byIP) {
+		t.Fatal("expected a hostname to match its IP address")
+	}
+}
+
+func TestPreferHostname(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "tph_")
+	defer os.RemoveAll(tmpDir)
+	net := mkNetwork(tmpDir)
+
+	// first seen by ip only, with stats and stored
+	byIP := []*peersResult{
+		{Addr: "203.132.94.196", Host: "203.132.94.196", Feats: []string{"s51002"}},
+	}
+	err := net.addIncomingServers(byIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net.knownServers[0].RTT = 120
+	net.knownServers[0].Preferred = true
+	err = net.updateStoredServers(net.knownServers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// then seen by hostname
+	byHost := []*peersResult{
+		{Addr: "203.132.94.196", Host: "testnet.aranguren.org", Feats: []string{"s51002"}},
+	}
+	err = net.addIncomingServers(byHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(net.knownServers) != 1 {
+		t.Fatalf("got %d known servers, want 1", len(net.knownServers))
+	}
+	known := net.knownServers[0]
+	if known.Address != "testnet.aranguren.org:51002" || known.IP != "203.132.94.196" {
+		t.Fatalf("expected the hostname entry, got %s (%s)", known.Address, known.IP)
+	}
+	if known.RTT != 120 || !known.Preferred {
+		t.Fatal("stats and settings lost on merge")
+	}
+	err = net.updateStoredServers(makeIncomingServerAddrs(byHost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, n, err := net.readServerAddrFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || stored[0].Address != "testnet.aranguren.org:51002" || stored[0].RTT != 120 {
+		t.Fatalf("expected the stored hostname entry, got %d %s", n, stored[0].Address)
 	}
 }
 
@@ -12,15 +12,21 @@ import (
 var ErrNotConnected = errors.New("node not connected")
 
 type Node struct {
-	serverAddr             string
-	netProto               string
-	connectOpts            *connectOpts
-	server                 *Server
-	leader                 bool
-	networkHeaders         *headers
-	clientTipChangeNotify  chan int64
-	clientScriptHashNotify chan *ScripthashStatusResult
-	session                *session
+	serverAddr     string
+	netProto       string
+	connectOpts    *connectOpts
+	server         *Server
+	leader         bool
+	networkHeaders *headers
+	events         *EventBroker
+	session        *session
+	quality        *peerQuality
+	lagTracker     *headerLagTracker
+	// other running peers that can help fetch headers on sync; nil for none
+	syncPeers func() []*peerNode
+	// non-leader header notifications watcher
+	hdrsWatchStop chan struct{}
+	hdrsWatchDone chan struct{}
 }
 
 func newNode(
@@ -28,8 +34,8 @@ func newNode(
 	proxyAddr string,
 	isLeader bool,
 	networkHeaders *headers,
-	clientTipChangeNotify chan int64,
-	clientScriptHashNotify chan *ScripthashStatusResult) (*Node, error) {
+	lagTracker *headerLagTracker,
+	events *EventBroker) (*Node, error) {
 
 	netProto := netAddr.Network()
 	addr := netAddr.String()
@@ -59,15 +65,16 @@ func newNode(
 	}
 
 	n := &Node{
-		serverAddr:             addr,
-		netProto:               netProto,
-		connectOpts:            connectOpts,
-		server:                 &Server{},
-		leader:                 isLeader,
-		networkHeaders:         networkHeaders,
-		clientTipChangeNotify:  clientTipChangeNotify,
-		clientScriptHashNotify: clientScriptHashNotify,
-		session:                nil,
+		serverAddr:     addr,
+		netProto:       netProto,
+		connectOpts:    connectOpts,
+		server:         &Server{},
+		leader:         isLeader,
+		networkHeaders: networkHeaders,
+		events:         events,
+		session:        nil,
+		quality:        newPeerQuality(),
+		lagTracker:     lagTracker,
 	}
 	return n, nil
 }
@@ -106,9 +113,10 @@ func (n *Node) start(nodeCtx context.Context, nodeCancel context.CancelCauseFunc
 	n.session = newSession()
 	n.session.start(nodeCtx)
 
-	// Node is up and ready - if not leader then we exit here
+	// Node is up and ready - if not leader then we only watch for header
+	// notifications to measure how up to date the server is.
 	if !n.leader {
-		return nil
+		return n.headersWatch(nodeCtx)
 	}
 
 	// leader sync headers
@@ -129,6 +137,8 @@ func (n *Node) start(nodeCtx context.Context, nodeCancel context.CancelCauseFunc
 // synced & receiving incoming notifications
 func (n *Node) promoteToLeader(nodeCtx context.Context) error {
 	h := n.networkHeaders
+	// leader takes over reading header notifications from the server
+	n.stopHeadersWatch()
 	// start sync if not synced
 	if !h.synced {
 		err := n.syncHeaders(nodeCtx)
@@ -210,6 +220,19 @@ func (n *Node) blockHeader(nodeCtx context.Context, height int64) (string, error
 	return blkHdr, err
 }
 
+func (n *Node) blockHeaderProof(nodeCtx context.Context, height, cpHeight int64) (*blockHeaderProofResult, error) {
+	if !n.server.connected {
+		return nil, ErrNotConnected
+	}
+	proof, err := n.server.conn.blockHeaderProof(nodeCtx, uint32(height), uint32(cpHeight))
+	if err == nil {
+		n.session.bumpCostString(proof.Header)
+	} else {
+		n.session.bumpCostError()
+	}
+	return proof, err
+}
+
 func (n *Node) blockHeaders(nodeCtx context.Context, startHeight int64, blockCount int) (*getBlockHeadersResult, error) {
 	if !n.server.connected {
 		return nil, ErrNotConnected
@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 )
 
@@ -16,119 +15,53 @@ func (n *Node) syncHeaders(nodeCtx context.Context) error {
 	return n.syncNetworkHeaders(nodeCtx)
 }
 
-// syncNetworkHeaders reads blockchain_headers file, then gets any missing block
-// from end of file to current tip from server. The current set of headers is
-// also stored in headers map and the chain verified by checking previous block
-// hashes backwards from local Tip.
+// syncNetworkHeaders counts the headers in blockchain_headers file, then gets
+// any missing block from end of file to current tip from server and any other
+// running peers. The most
+// recent headers are loaded into memory and verified by checking previous block
+// hashes backwards from local Tip. Older headers stay on disk.
 func (n *Node) syncNetworkHeaders(nodeCtx context.Context) error {
 	h := n.networkHeaders
+	n.events.publish(&Event{Type: EventSyncing})
 
 	// we start from a recent height for testnet/mainnet
 	startPointHeight := h.startPoint
 
-	// 1. Read last stored blockchain_headers file for this network
+	// 1. Check and repair the last stored blockchain_headers file for this
+	//    network and count the good headers
 
-	b, err := h.readAllBytesFromFile()
-	if err != nil {
-		return err
-	}
-	lenb := int64(len(b))
-	fmt.Println("read:", lenb, " bytes from header file")
-	numHeaders, err := h.bytesToNumHdrs(lenb)
+	numHeaders, err := h.repairHeadersFile()
 	if err != nil {
 		return err
 	}
-
-	var maybeTip int64 = startPointHeight + numHeaders - 1
+	fmt.Println("found:", numHeaders, " headers in header file")
 
 	// 2. Gather new block headers we did not have in file up to current tip
+	//    from the leader and other running peers in parallel
 
-	// Do not make requested block count too big or electrumX may throttle response
-	// as an anti ddos measure. ElectrumX Doc.: "Recommended to be at least one
-	// Bitcoin difficulty retarget period, i.e. 2016."
-	var blockCount = 2016
-	var doneGathering = false
-	var startHeight = startPointHeight + numHeaders
-
-	hdrsRes, err := n.blockHeaders(nodeCtx, startHeight, blockCount)
-	if err != nil {
-		return err
-	}
-	count := hdrsRes.Count
-
-	fmt.Printf("read: %d from server at height %d max chunk size %d\n", count, startHeight, hdrsRes.Max)
-
-	if count > 0 {
-		b, err := hex.DecodeString(hdrsRes.HexConcat)
-		if err != nil {
-			log.Fatal(err)
-		}
-		nh, err := h.appendHeadersFile(b)
-		if err != nil {
-			log.Fatal(err)
-		}
-		maybeTip += int64(count)
-
-		fmt.Println(" appended: ", nh, " headers at ", startHeight, " maybeTip ", maybeTip)
-	}
-
-	if count < blockCount {
-		doneGathering = true
-	}
-
-	for !doneGathering {
-
-		startHeight += int64(blockCount)
-
-		select {
-
-		case <-nodeCtx.Done():
-			<-n.server.conn.Done()
-			return nil
-
-		case <-time.After(time.Second):
-			hdrsRes, err := n.blockHeaders(nodeCtx, startHeight, blockCount)
-			if err != nil {
-				return err
-			}
-			count = hdrsRes.Count
-
-			if count > 0 {
-				b, err := hex.DecodeString(hdrsRes.HexConcat)
-				if err != nil {
-					return err
-				}
-				nh, err := h.appendHeadersFile(b)
-				if err != nil {
-					return err
-				}
-				maybeTip += int64(count)
-
-				fmt.Println(" Appended: ", nh, " headers at ", startHeight, " maybeTip ", maybeTip)
-			}
-
-			if count < blockCount {
-				doneGathering = true
-			}
-		}
+	leader := &hdrsSource{name: n.serverAddr, ctx: nodeCtx, fetch: n.blockHeaders}
+	appended, err := h.fetchHeaders(nodeCtx, numHeaders, leader, n.syncHelpers)
+	if nodeCtx.Err() != nil {
+		<-n.server.conn.Done()
+		return nil
 	}
-
-	// 3. Read up to date blockchain_headers file
-	b2, err := h.readAllBytesFromFile()
 	if err != nil {
 		return err
 	}
+	maybeTip := startPointHeight + numHeaders + appended - 1
+	fmt.Printf("fetched: %d headers from server maybeTip %d\n", appended, maybeTip)
 
-	// 4. Store all headers in the hdrs map
-	err = h.store(b2, startPointHeight)
+	// 3. Index any new headers and load the most recent into memory
+	err = h.openStore(maybeTip - startPointHeight + 1)
 	if err != nil {
 		return err
 	}
-	h.setTip(maybeTip)
 
-	// 5. Verify headers in headers map
-	fmt.Printf("starting verify at height %d\n", h.getTip())
-	err = h.verifyAll()
+	// 4. Verify the new headers and the in memory headers. Older headers were
+	//    verified when first synced.
+	verifyFrom := min(numHeaders-1, h.hdrPos(h.getTip())-RECENT_HDRS_IN_MEM)
+	fmt.Printf("starting verify at height %d\n", h.startPoint+max(verifyFrom, 0))
+	err = h.verifyFromPos(verifyFrom)
 	if err != nil {
 		return err
 	}
@@ -136,9 +69,26 @@ func (n *Node) syncNetworkHeaders(nodeCtx context.Context) error {
 
 	h.synced = true
 	fmt.Println("headers synced up to tip ", h.getTip())
+	n.events.publish(&Event{Type: EventSyncComplete, Height: h.getTip()})
 	return nil
 }
 
+// syncHelpers returns sources for the other running peers that can help fetch
+// headers on sync.
+func (n *Node) syncHelpers() []*hdrsSource {
+	if n.syncPeers == nil {
+		return nil
+	}
+	var sources []*hdrsSource
+	for _, peer := range n.syncPeers() {
+		if peer.node == n || peer.nodeCtx.Err() != nil {
+			continue
+		}
+		sources = append(sources, newHdrsSource(peer))
+	}
+	return sources
+}
+
 // headersNotify subscribes to new block tip notifications from the
 // electrumx server and queues them as they arrive.
 //
@@ -161,6 +111,7 @@ func (n *Node) headersNotify(nodeCtx context.Context) error {
 	if err != nil {
 		return err
 	}
+	n.recordTip(hdrRes.Height, false)
 	ourTip := h.getTip()
 	diff := hdrRes.Height - ourTip
 
@@ -185,6 +136,9 @@ func (n *Node) headersNotify(nodeCtx context.Context) error {
 			}
 			// from server into queue
 			hdrs := <-hdrsNotifyChan
+			if hdrs != nil {
+				n.recordTip(hdrs.Height, true)
+			}
 			qchan <- hdrs
 		}
 	}()
@@ -192,6 +146,53 @@ func (n *Node) headersNotify(nodeCtx context.Context) error {
 	return nil
 }
 
+// headersWatch subscribes a non-leader node to new block tip notifications
+// from the electrumx server. The notifications are only used to measure how up
+// to date the server is compared with our other peers.
+func (n *Node) headersWatch(nodeCtx context.Context) error {
+	hdrsNotifyChan := n.getHeadersNotify()
+	if hdrsNotifyChan == nil {
+		return errors.New("server headers notify channel is nil")
+	}
+	n.hdrsWatchStop = make(chan struct{})
+	n.hdrsWatchDone = make(chan struct{})
+	hdrRes, err := n.subscribeHeaders(nodeCtx)
+	if err != nil {
+		return err
+	}
+	n.recordTip(hdrRes.Height, false)
+
+	go func() {
+		defer close(n.hdrsWatchDone)
+		for {
+			select {
+			case <-nodeCtx.Done():
+				return
+			case <-n.hdrsWatchStop:
+				return
+			case hdrRes, ok := <-hdrsNotifyChan:
+				if !ok || hdrRes == nil {
+					return
+				}
+				n.recordTip(hdrRes.Height, true)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stopHeadersWatch stops the non-leader header notifications watcher, if any,
+// and waits for it to exit.
+func (n *Node) stopHeadersWatch() {
+	if n.hdrsWatchStop == nil {
+		return
+	}
+	close(n.hdrsWatchStop)
+	<-n.hdrsWatchDone
+	n.hdrsWatchStop = nil
+}
+
 // headerQueue receives incoming headers notify results from qchan
 // - run as a goroutine.
 // The client local 'blockhain_headers' file is appended and the headers map updated and verified.
@@ -235,7 +236,7 @@ func (n *Node) headerQueue(nodeCtx context.Context, qchan <-chan *headersNotifyR
 				// connected the block & updated our headers tip
 				fmt.Printf(" - updated 1 header - our new tip is %d\n\n", h.getTip())
 				// notify client
-				n.clientTipChangeNotify <- h.getTip()
+				n.events.publish(&Event{Type: EventNewTip, Height: h.getTip()})
 				continue
 			}
 			// two or more headers that we do not have yet
@@ -243,7 +244,7 @@ func (n *Node) headerQueue(nodeCtx context.Context, qchan <-chan *headersNotifyR
 			// updating less hdrs than requested is not an error - we hope to get them next time
 			fmt.Printf(" - updated %d headers - our new tip is %d\n\n", numHdrs, h.getTip())
 			if numHdrs > 0 {
-				n.clientTipChangeNotify <- h.getTip()
+				n.events.publish(&Event{Type: EventNewTip, Height: h.getTip()})
 			}
 		}
 	}
@@ -343,8 +344,20 @@ func (n *Node) connectTip(serverHeader string) bool {
 	if err != nil {
 		return false
 	}
-	h.storeOneHdr(incomingHdr) // (sets tip++)
-	h.recovery = false
+	err = h.storeOneHdr(incomingHdr) // (sets tip++)
+	if err != nil {
+		fmt.Printf("connectTip - cannot store header: %v\n", err)
+		// take the header back out of the file so it matches the tip
+		_, err = h.truncateHeadersFile(1)
+		if err != nil {
+			fmt.Printf("connectTip - %v\n", err)
+		}
+		return false
+	}
+	if h.recovery {
+		h.recovery = false
+		n.events.publish(&Event{Type: EventRecoveryEnded, Height: h.getTip()})
+	}
 	return true
 }
 
@@ -377,8 +390,12 @@ func (n *Node) reorgRecovery() {
 		h.removeOneHdrFromTip() // (sets tip--)
 	}
 
-	h.recoveryTip = tip // what we  send back to users in getTip() during recovery
-	h.recovery = true
+	n.events.publish(&Event{Type: EventReorg, Height: h.getTip(), OldHeight: tip})
+	if !h.recovery {
+		h.recoveryTip = tip // what we  send back to users in getTip() during recovery
+		h.recovery = true
+		n.events.publish(&Event{Type: EventRecoveryStarted, Height: h.getTip(), OldHeight: tip})
+	}
 }
 
 // ----------------------------------------------------------------------------
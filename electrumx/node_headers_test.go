@@ -4,13 +4,12 @@ import "testing"
 
 func TestNode_connectTip(t *testing.T) {
 	type fields struct {
-		serverAddr          string
-		connectOpts         *connectOpts
-		server              *Server
-		leader              bool
-		networkHeaders      *headers
-		rcvTipChangeNotify  chan int64
-		rcvScriptHashNotify chan *ScripthashStatusResult
+		serverAddr     string
+		connectOpts    *connectOpts
+		server         *Server
+		leader         bool
+		networkHeaders *headers
+		events         *EventBroker
 	}
 	type args struct {
 		serverHeader string
@@ -26,13 +25,12 @@ func TestNode_connectTip(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			n := &Node{
-				serverAddr:             tt.fields.serverAddr,
-				connectOpts:            tt.fields.connectOpts,
-				server:                 tt.fields.server,
-				leader:                 tt.fields.leader,
-				networkHeaders:         tt.fields.networkHeaders,
-				clientTipChangeNotify:  tt.fields.rcvTipChangeNotify,
-				clientScriptHashNotify: tt.fields.rcvScriptHashNotify,
+				serverAddr:     tt.fields.serverAddr,
+				connectOpts:    tt.fields.connectOpts,
+				server:         tt.fields.server,
+				leader:         tt.fields.leader,
+				networkHeaders: tt.fields.networkHeaders,
+				events:         tt.fields.events,
 			}
 			if got := n.connectTip(tt.args.serverHeader); got != tt.want {
 				t.Errorf("Node.connectTip() = %v, want %v", got, tt.want)
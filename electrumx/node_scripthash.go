@@ -45,7 +45,7 @@ func (n *Node) scriptHashQueue(nodeCtx context.Context, qchan <-chan *Scripthash
 			if ntfn == nil {
 				return
 			}
-			n.clientScriptHashNotify <- ntfn
+			n.events.publish(&Event{Type: EventScripthashStatus, Scripthash: ntfn})
 		}
 	}
 }
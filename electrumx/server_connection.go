@@ -46,6 +46,9 @@ type serverConn struct {
 
 	reqID uint64
 
+	// Smoothed round trip time of 'server.ping' requests in nanoseconds.
+	rtt atomic.Int64
+
 	// Response handlers per request with id. Closed in the 'listen' func below.
 	respHandlers    map[uint64]chan *response // reqID => requestor
 	respHandlersMtx sync.Mutex
@@ -151,9 +154,11 @@ func (sc *serverConn) keepAlive(nodeCtx context.Context) {
 		if err != nil {
 			return
 		}
+		start := time.Now()
 		if err = sc.ping(nodeCtx); err != nil {
 			return
 		}
+		sc.updatePingRTT(time.Since(start))
 
 		select {
 		case <-nodeCtx.Done():
@@ -389,6 +394,18 @@ func (sc *serverConn) ping(nodeCtx context.Context) error {
 	return sc.request(nodeCtx, "server.ping", nil, nil)
 }
 
+// updatePingRTT adds a measured ping round trip time to the smoothed rtt. Only
+// called from keepAlive.
+func (sc *serverConn) updatePingRTT(sample time.Duration) {
+	old := time.Duration(sc.rtt.Load())
+	sc.rtt.Store(int64(smooth(old, sample)))
+}
+
+// pingRTT returns the smoothed ping round trip time or 0 if not yet measured.
+func (sc *serverConn) pingRTT() time.Duration {
+	return time.Duration(sc.rtt.Load())
+}
+
 // serverVersion returns the server's software version and electrumx protocol
 // of the connected server
 func (sc *serverConn) serverVersion(nodeCtx context.Context, client, proto string) ([]string, error) {
@@ -582,6 +599,26 @@ func (sc *serverConn) blockHeader(nodeCtx context.Context, height uint32) (strin
 	return resp, nil
 }
 
+// blockHeaderProofResult is the result of a block.header request with a
+// checkpoint height. Branch is the merkle branch of the header's block hash up
+// to Root, the merkle root of all the block hashes up to the checkpoint height.
+type blockHeaderProofResult struct {
+	Branch []string `json:"branch"`
+	Header string   `json:"header"`
+	Root   string   `json:"root"`
+}
+
+// blockHeaderProof requests the block header at the given height with a
+// merkle proof to the checkpoint height cpHeight.
+func (sc *serverConn) blockHeaderProof(nodeCtx context.Context, height, cpHeight uint32) (*blockHeaderProofResult, error) {
+	var resp blockHeaderProofResult
+	err := sc.request(nodeCtx, "blockchain.block.header", positional{height, cpHeight}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // getBlockHeadersResult represents the result of a batch request for block
 // headers via the block.headers method. The serialized block headers are
 // concatenated in the HexConcat field, which contains Count headers.
@@ -1,6 +1,7 @@
 package bdb
 
 import (
+	"encoding/binary"
 	"sync"
 	"time"
 
@@ -12,7 +13,11 @@ type CfgDB struct {
 	lock *sync.RWMutex
 }
 
-var creationKey = []byte("creationDate")
+var (
+	creationKey    = []byte("creationDate")
+	dataVersionKey = []byte("dataVersion")
+	migrationKey   = []byte("migrationCursor")
+)
 
 func (c *CfgDB) PutCreationDate(creationDate time.Time) error {
 	c.lock.Lock()
@@ -46,3 +51,61 @@ func (c *CfgDB) GetCreationDate() (time.Time, error) {
 	})
 	return t, e
 }
+
+func (c *CfgDB) PutDataVersion(version int) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	versionValue := binary.LittleEndian.AppendUint32(nil, uint32(version))
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(configBkt)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+		return b.Put(dataVersionKey, versionValue)
+	})
+}
+
+func (c *CfgDB) GetDataVersion() (int, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	version := 0
+	e := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(configBkt)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+		versionValue := b.Get(dataVersionKey)
+		if len(versionValue) == 4 {
+			version = int(binary.LittleEndian.Uint32(versionValue))
+		}
+		return nil
+	})
+	return version, e
+}
+
+func (c *CfgDB) PutMigrationCursor(txid string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(configBkt)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+		return b.Put(migrationKey, []byte(txid))
+	})
+}
+
+func (c *CfgDB) GetMigrationCursor() (string, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var txid string
+	e := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(configBkt)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+		txid = string(b.Get(migrationKey))
+		return nil
+	})
+	return txid, e
+}
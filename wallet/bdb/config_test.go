@@ -53,3 +53,53 @@ func TestConfig(t *testing.T) {
 	}
 	fmt.Println(time2.String())
 }
+
+func TestDataVersion(t *testing.T) {
+	if err := setupCfg(); err != nil {
+		t.Fatal(err)
+	}
+	defer teardownCfg()
+	version, err := config.GetDataVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Fatalf("expected version 0 when not stored, got %d", version)
+	}
+	err = config.PutDataVersion(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err = config.GetDataVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Fatalf("got version %d", version)
+	}
+}
+
+func TestMigrationCursor(t *testing.T) {
+	if err := setupCfg(); err != nil {
+		t.Fatal(err)
+	}
+	defer teardownCfg()
+	txid, err := config.GetMigrationCursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txid != "" {
+		t.Fatalf("expected no cursor when not stored, got %s", txid)
+	}
+	err = config.PutMigrationCursor("abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid, err = config.GetMigrationCursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txid != "abcd" {
+		t.Fatalf("got cursor %s", txid)
+	}
+}
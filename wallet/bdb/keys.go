@@ -24,6 +24,7 @@ type KeysDB struct {
 func (k *KeysDB) Put(scriptAddress []byte, keyPath wallet.KeyPath) error {
 	krec := &keyRec{
 		ScriptAddress: scriptAddress,
+		Account:       keyPath.Account,
 		Purpose:       int(keyPath.Change),
 		KeyIndex:      keyPath.Index,
 		Used:          false,
@@ -40,9 +41,9 @@ func (k *KeysDB) MarkKeyAsUsed(scriptAddress []byte) error {
 	return k.put(krec)
 }
 
-// GetLastKeyIndex gets the last (highest) key index stored and whether it has been used.
-// If error or no records it will return -1 and error.
-func (k *KeysDB) GetLastKeyIndex(purpose wallet.KeyChange) (int, bool, error) {
+// GetLastKeyIndex gets the last (highest) key index stored for the account and
+// whether it has been used. If error or no records it will return -1 and error.
+func (k *KeysDB) GetLastKeyIndex(account uint32, purpose wallet.KeyChange) (int, bool, error) {
 	krecList, err := k.getAllSorted()
 	if err != nil {
 		return -1, false, err
@@ -52,7 +53,7 @@ func (k *KeysDB) GetLastKeyIndex(purpose wallet.KeyChange) (int, bool, error) {
 	}
 	var krecListPurpose = make([]keyRec, 0)
 	for _, krec := range krecList {
-		if krec.Purpose == int(purpose) {
+		if krec.Account == account && krec.Purpose == int(purpose) {
 			krecListPurpose = append(krecListPurpose, krec)
 		}
 	}
@@ -69,19 +70,20 @@ func (k *KeysDB) GetPathForKey(scriptAddress []byte) (wallet.KeyPath, error) {
 	if err != nil {
 		return keyPath, err
 	}
+	keyPath.Account = krec.Account
 	keyPath.Change = wallet.KeyChange(krec.Purpose)
 	keyPath.Index = krec.KeyIndex
 	return keyPath, nil
 }
 
-func (k *KeysDB) GetUnused(purpose wallet.KeyChange) ([]int, error) {
+func (k *KeysDB) GetUnused(account uint32, purpose wallet.KeyChange) ([]int, error) {
 	var ret []int
 	krecList, err := k.getAllSorted()
 	if err != nil {
 		return nil, err
 	}
 	for _, krec := range krecList {
-		if purpose == wallet.KeyChange(krec.Purpose) && !krec.Used {
+		if account == krec.Account && purpose == wallet.KeyChange(krec.Purpose) && !krec.Used {
 			ret = append(ret, krec.KeyIndex)
 		}
 	}
@@ -96,8 +98,9 @@ func (k *KeysDB) GetAll() ([]wallet.KeyPath, error) {
 	}
 	for _, krec := range krecList {
 		keyPath := wallet.KeyPath{
-			Change: wallet.KeyChange(krec.Purpose),
-			Index:  krec.KeyIndex,
+			Account: krec.Account,
+			Change:  wallet.KeyChange(krec.Purpose),
+			Index:   krec.KeyIndex,
 		}
 		ret = append(ret, keyPath)
 	}
@@ -127,6 +130,7 @@ func (k *KeysDB) GetDbg() string {
 		} else {
 			purpose = "INTERNAL"
 		}
+		account := strconv.FormatUint(uint64(krec.Account), 10)
 		keyIndex := strconv.Itoa(krec.KeyIndex)
 		var used string
 		if krec.Used {
@@ -138,6 +142,9 @@ func (k *KeysDB) GetDbg() string {
 		sb.WriteString("  ")
 		sb.WriteString(segwitAddrStr)
 		sb.WriteString("\n")
+		sb.WriteString(" Key Account:    ")
+		sb.WriteString(account)
+		sb.WriteString("\n")
 		sb.WriteString(" Key Purpose:    ")
 		sb.WriteString(purpose)
 		sb.WriteString("\n")
@@ -151,7 +158,7 @@ func (k *KeysDB) GetDbg() string {
 	return ret
 }
 
-func (k *KeysDB) GetLookaheadWindows() map[wallet.KeyChange]int {
+func (k *KeysDB) GetLookaheadWindows(account uint32) map[wallet.KeyChange]int {
 	windows := make(map[wallet.KeyChange]int)
 	krecList, err := k.getAllSorted()
 	if err != nil || len(krecList) == 0 {
@@ -162,7 +169,7 @@ func (k *KeysDB) GetLookaheadWindows() map[wallet.KeyChange]int {
 	var unusedCountExternal int = 0
 	var unusedCountInternal int = 0
 	for _, krec := range krecList {
-		if krec.Used {
+		if krec.Used || krec.Account != account {
 			continue
 		}
 		if krec.Purpose == int(wallet.EXTERNAL) {
@@ -177,13 +184,21 @@ func (k *KeysDB) GetLookaheadWindows() map[wallet.KeyChange]int {
 	return windows
 }
 
+// validKeyLength checks a script address key is a 20 byte hash or a 32 byte
+// taproot output key.
+func validKeyLength(key []byte) bool {
+	return len(key) == 20 || len(key) == 32
+}
+
 // DB access record
 type keyRec struct {
 	// Unique key - Used as K & V[ScriptAddress]
 	ScriptAddress []byte `json:"script_address"`
-	Purpose       int    `json:"purpose"`
-	KeyIndex      int    `json:"key_index"`
-	Used          bool   `json:"used"`
+	// Account is missing, so 0, in records stored before HD accounts
+	Account  uint32 `json:"account"`
+	Purpose  int    `json:"purpose"`
+	KeyIndex int    `json:"key_index"`
+	Used     bool   `json:"used"`
 }
 
 func (k *KeysDB) put(krec *keyRec) error {
@@ -191,7 +206,7 @@ func (k *KeysDB) put(krec *keyRec) error {
 	defer k.lock.Unlock()
 
 	key := krec.ScriptAddress
-	if len(key) != 20 {
+	if !validKeyLength(key) {
 		return errors.New("bad key length")
 	}
 	value, err := json.Marshal(krec)
@@ -216,7 +231,7 @@ func (k *KeysDB) get(scriptAddress []byte) (*keyRec, error) {
 	defer k.lock.RUnlock()
 
 	key := []byte(scriptAddress)
-	if len(key) != 20 {
+	if !validKeyLength(key) {
 		return nil, errors.New("bad key length")
 	}
 
@@ -270,7 +285,7 @@ func (k *KeysDB) delete(scriptAddress []byte) error {
 	k.lock.Lock()
 	defer k.lock.Unlock()
 	key := scriptAddress
-	if len(key) != 20 {
+	if !validKeyLength(key) {
 		return errors.New("bad key length")
 	}
 
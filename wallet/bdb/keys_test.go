@@ -105,6 +105,33 @@ func TestPutKey(t *testing.T) {
 	}
 }
 
+func TestPutTaprootKey(t *testing.T) {
+	if err := setupKdb(); err != nil {
+		t.Fatal(err)
+	}
+	defer teardownKdb()
+	b := make([]byte, 32)
+	rand.Read(b)
+	err := kdb.Put(b, wallet.KeyPath{
+		Change: wallet.EXTERNAL,
+		Index:  0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := kdb.GetPathForKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kp.Change != wallet.EXTERNAL || kp.Index != 0 {
+		t.Errorf("unexpected key path %v", kp)
+	}
+	err = kdb.Put(make([]byte, 33), wallet.KeyPath{})
+	if err == nil {
+		t.Error("expected bad key length error")
+	}
+}
+
 func TestPutDuplicateKey(t *testing.T) {
 	if err := setupKdb(); err != nil {
 		t.Fatal(err)
@@ -184,22 +211,22 @@ func TestGetLastKeyIndex(t *testing.T) {
 		}
 		lastInternal = b
 	}
-	idx, used, err := kdb.GetLastKeyIndex(wallet.EXTERNAL)
+	idx, used, err := kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil || idx != 49 || used != false {
 		t.Error("Failed to fetch correct last index")
 	}
 	kdb.MarkKeyAsUsed(lastExternal)
-	_, used, err = kdb.GetLastKeyIndex(wallet.EXTERNAL)
+	_, used, err = kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil || used != true {
 		t.Error("Failed to fetch correct last index")
 	}
 
-	idx, used, err = kdb.GetLastKeyIndex(wallet.INTERNAL)
+	idx, used, err = kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.INTERNAL)
 	if err != nil || idx != 49 || used != false {
 		t.Error("Failed to fetch correct last index")
 	}
 	kdb.MarkKeyAsUsed(lastInternal)
-	_, used, err = kdb.GetLastKeyIndex(wallet.INTERNAL)
+	_, used, err = kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.INTERNAL)
 	if err != nil || used != true {
 		t.Error("Failed to fetch correct last index")
 	}
@@ -261,7 +288,7 @@ func TestGetUnsed(t *testing.T) {
 			tenth = b
 		}
 	}
-	i, err := kdb.GetUnused(wallet.INTERNAL)
+	i, err := kdb.GetUnused(wallet.DefaultAccount, wallet.INTERNAL)
 	if err != nil {
 		t.Error(err)
 	}
@@ -272,7 +299,7 @@ func TestGetUnsed(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	i, err = kdb.GetUnused(wallet.INTERNAL)
+	i, err = kdb.GetUnused(wallet.DefaultAccount, wallet.INTERNAL)
 	if err != nil {
 		t.Error(err)
 	}
@@ -293,7 +320,7 @@ func TestGetLookaheadWindows(t *testing.T) {
 
 	// test zero keys
 	var winZero = make(map[wallet.KeyChange]int)
-	winZero = kdb.GetLookaheadWindows()
+	winZero = kdb.GetLookaheadWindows(wallet.DefaultAccount)
 	if winZero[wallet.EXTERNAL] != 0 || winZero[wallet.INTERNAL] != 0 {
 		t.Fatal("no records failed - should return an un-empty map")
 	}
@@ -326,7 +353,7 @@ func TestGetLookaheadWindows(t *testing.T) {
 			kdb.MarkKeyAsUsed(b)
 		}
 	}
-	windows = kdb.GetLookaheadWindows()
+	windows = kdb.GetLookaheadWindows(wallet.DefaultAccount)
 	if windows[wallet.EXTERNAL] != 100-33 || windows[wallet.INTERNAL] != 100-81 {
 		t.Error("Fetched incorrect lookahead windows")
 	}
@@ -355,3 +382,57 @@ func TestDeleteKey(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestAccountKeys(t *testing.T) {
+	if err := setupKdb(); err != nil {
+		t.Fatal(err)
+	}
+	defer teardownKdb()
+	for i := 0; i < 10; i++ {
+		b := make([]byte, 20)
+		rand.Read(b)
+		err := kdb.Put(b, wallet.KeyPath{
+			Change: wallet.EXTERNAL,
+			Index:  i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var last []byte
+	for i := 0; i < 3; i++ {
+		b := make([]byte, 20)
+		rand.Read(b)
+		err := kdb.Put(b, wallet.KeyPath{
+			Account: 2,
+			Change:  wallet.EXTERNAL,
+			Index:   i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = b
+	}
+	path, err := kdb.GetPathForKey(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Account != 2 || path.Index != 2 {
+		t.Errorf("Returned incorrect key path %v", path)
+	}
+	idx, _, err := kdb.GetLastKeyIndex(2, wallet.EXTERNAL)
+	if err != nil || idx != 2 {
+		t.Errorf("Failed to fetch correct last account index %d", idx)
+	}
+	idx, _, err = kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.EXTERNAL)
+	if err != nil || idx != 9 {
+		t.Errorf("Failed to fetch correct last default account index %d", idx)
+	}
+	unused, err := kdb.GetUnused(2, wallet.EXTERNAL)
+	if err != nil || len(unused) != 3 {
+		t.Errorf("Failed to fetch correct unused account keys %v", unused)
+	}
+	if kdb.GetLookaheadWindows(2)[wallet.EXTERNAL] != 3 {
+		t.Error("Fetched incorrect account lookahead windows")
+	}
+}
@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/coinset"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Coin control. SpendOptions steer the coin selection of a spend without
+// freezing the other coins of the wallet, and may set its fee.
+
+var (
+	// ErrCoinNotSpendable is returned for an included coin that is not a
+	// confirmed, unfrozen coin of the spending account
+	ErrCoinNotSpendable = errors.New("coin is not spendable by the account")
+
+	// ErrCoinIncludedAndAvoided is returned for a coin that is both included
+	// and avoided
+	ErrCoinIncludedAndAvoided = errors.New("coin is both included and avoided")
+
+	// ErrChosenCoinsInsufficient is returned when the IncludeOnly coins do
+	// not cover the amount plus fee
+	ErrChosenCoinsInsufficient = errors.New("chosen coins do not cover the amount plus fee")
+)
+
+// SpendOptions are the coin control and fee of a spend. A nil *SpendOptions
+// selects from every spendable coin of the account and pays the rate of the
+// spend's FeeLevel.
+type SpendOptions struct {
+	// Include are coins the spend must use. Other coins are added if they
+	// do not cover the amount plus fee.
+	Include []wire.OutPoint
+
+	// IncludeOnly makes the spend use the Include coins and no others.
+	IncludeOnly bool
+
+	// Avoid are coins the spend must not use.
+	Avoid []wire.OutPoint
+
+	// FeeRate is a fee rate in sat/vB that overrides the spend's FeeLevel.
+	// It may not be over the MaxFee of the wallet's FeeProvider.
+	FeeRate int64
+
+	// Fee is an absolute fee in sats that overrides the spend's FeeLevel.
+	// It must pay the minimum relay fee for the transaction's size. The fee
+	// may grow by a change amount too small to pay out.
+	Fee int64
+}
+
+func (o *SpendOptions) includes(op wire.OutPoint) bool {
+	for _, include := range o.Include {
+		if include == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *SpendOptions) avoids(op wire.OutPoint) bool {
+	for _, avoid := range o.Avoid {
+		if avoid == op {
+			return true
+		}
+	}
+	return false
+}
+
+// CoinControl splits the spendable coins of an account into the coins a spend
+// must use and the coins the coin selector may add.
+func CoinControl(coins []coinset.Coin, opts *SpendOptions) ([]coinset.Coin, []coinset.Coin, error) {
+	if opts == nil {
+		return nil, coins, nil
+	}
+	var include, rest []coinset.Coin
+	seen := make(map[wire.OutPoint]bool)
+	for _, op := range opts.Include {
+		if opts.avoids(op) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrCoinIncludedAndAvoided, op)
+		}
+		if seen[op] {
+			continue
+		}
+		seen[op] = true
+		found := false
+		for _, c := range coins {
+			if *c.Hash() == op.Hash && c.Index() == op.Index {
+				include = append(include, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%w: %s", ErrCoinNotSpendable, op)
+		}
+	}
+	if opts.IncludeOnly {
+		return include, nil, nil
+	}
+	for _, c := range coins {
+		op := wire.OutPoint{Hash: *c.Hash(), Index: c.Index()}
+		if opts.includes(op) || opts.avoids(op) {
+			continue
+		}
+		rest = append(rest, c)
+	}
+	return include, rest, nil
+}
@@ -105,6 +105,13 @@ type Datastore interface {
 type Cfg interface {
 	PutCreationDate(date time.Time) error
 	GetCreationDate() (time.Time, error)
+	// The version of the wallet data; 0 if never stored
+	PutDataVersion(version int) error
+	GetDataVersion() (int, error)
+	// The txid of the last transaction migrated to the next data version;
+	// "" if none
+	PutMigrationCursor(txid string) error
+	GetMigrationCursor() (string, error)
 }
 
 type Enc interface {
@@ -173,22 +180,22 @@ type Keys interface {
 	// Mark the key as used
 	MarkKeyAsUsed(scriptAddress []byte) error
 
-	// Fetch the last index for the given key purpose
+	// Fetch the last index for the given account and key purpose
 	// The bool should state whether the key has been used or not
-	GetLastKeyIndex(purpose KeyChange) (int, bool, error)
+	GetLastKeyIndex(account uint32, purpose KeyChange) (int, bool, error)
 
 	// Returns the path for the given key
 	GetPathForKey(scriptAddress []byte) (KeyPath, error)
 
-	// Get a list of unused key indexes for the given purpose
-	GetUnused(purpose KeyChange) ([]int, error)
+	// Get a list of unused key indexes for the given account and purpose
+	GetUnused(account uint32, purpose KeyChange) ([]int, error)
 
 	// Fetch all key paths
 	GetAll() ([]KeyPath, error)
 
 	// Get the number of unused keys following the last used key
-	// for each key purpose.
-	GetLookaheadWindows() map[KeyChange]int
+	// for each key purpose of the account.
+	GetLookaheadWindows(account uint32) map[KeyChange]int
 
 	// Debug dump
 	GetDbg() string
@@ -337,7 +344,8 @@ type Txn struct {
 	// The height at which it was mined
 	Height int64
 
-	// The time the transaction was first seen
+	// The block time for a confirmed transaction or the time an unconfirmed
+	// transaction was first seen
 	Timestamp time.Time
 
 	// This transaction only involves a watch only address
@@ -376,6 +384,8 @@ const (
 )
 
 type KeyPath struct {
-	Change KeyChange
-	Index  int
+	// HD account, 0 is the DefaultAccount
+	Account uint32
+	Change  KeyChange
+	Index   int
 }
@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -48,3 +50,83 @@ func (s *CfgDB) PutCreationDate(creationDate time.Time) error {
 	tx.Commit()
 	return nil
 }
+
+func (s *CfgDB) GetDataVersion() (int, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	stmt, err := s.db.Prepare("select value from config where key=?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	var version []byte
+	err = stmt.QueryRow("dataVersion").Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(string(version))
+}
+
+func (s *CfgDB) PutDataVersion(version int) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("insert or replace into config(key, value) values(?,?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec("dataVersion", strconv.Itoa(version))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+func (s *CfgDB) GetMigrationCursor() (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	stmt, err := s.db.Prepare("select value from config where key=?")
+	if err != nil {
+		return "", err
+	}
+	defer stmt.Close()
+	var txid []byte
+	err = stmt.QueryRow("migrationCursor").Scan(&txid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(txid), nil
+}
+
+func (s *CfgDB) PutMigrationCursor(txid string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("insert or replace into config(key, value) values(?,?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec("migrationCursor", txid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+	return nil
+}
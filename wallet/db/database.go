@@ -92,7 +92,7 @@ func (db *SQLiteDatastore) Subscriptions() wallet.Subscriptions {
 func initDatabaseTables(db *sql.DB) error {
 	var sqlStmt string
 	sqlStmt = sqlStmt + `
-	create table if not exists keys (scriptAddress text primary key not null, purpose integer, keyIndex integer, used integer);
+	create table if not exists keys (scriptAddress text primary key not null, purpose integer, keyIndex integer, used integer, account integer not null default 0);
 	create table if not exists utxos (outpoint text primary key not null, value integer, height integer, scriptPubKey text, watchOnly integer, frozen integer);
 	create table if not exists stxos (outpoint text primary key not null, value integer, height integer, scriptPubKey text, watchOnly integer, spendHeight integer, spendTxid text);
 	create table if not exists txns (txid text primary key not null, value integer, height integer, timestamp integer, watchOnly integer, tx blob);
@@ -101,5 +101,34 @@ func initDatabaseTables(db *sql.DB) error {
 	create table if not exists enc(key text primary key not null, value blob);
 	`
 	_, err := db.Exec(sqlStmt)
+	if err != nil {
+		return err
+	}
+	return addKeysAccountColumn(db)
+}
+
+// addKeysAccountColumn adds the account column to a keys table made before HD
+// accounts. Its keys are all in the default account.
+func addKeysAccountColumn(db *sql.DB) error {
+	rows, err := db.Query("pragma table_info(keys)")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == "account" {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec("alter table keys add column account integer not null default 0")
 	return err
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"strconv"
 	"sync"
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
@@ -23,9 +22,9 @@ func (k *KeysDB) Put(scriptAddress []byte, keyPath wallet.KeyPath) error {
 	if err != nil {
 		return err
 	}
-	stmt, _ := tx.Prepare("insert into keys(scriptAddress, purpose, keyIndex, used) values(?,?,?,?)")
+	stmt, _ := tx.Prepare("insert into keys(scriptAddress, account, purpose, keyIndex, used) values(?,?,?,?,?)")
 	defer stmt.Close()
-	_, err = stmt.Exec(hex.EncodeToString(scriptAddress), int(keyPath.Change), keyPath.Index, 0)
+	_, err = stmt.Exec(hex.EncodeToString(scriptAddress), keyPath.Account, int(keyPath.Change), keyPath.Index, 0)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -56,11 +55,11 @@ func (k *KeysDB) MarkKeyAsUsed(scriptAddress []byte) error {
 	return nil
 }
 
-func (k *KeysDB) GetLastKeyIndex(purpose wallet.KeyChange) (int, bool, error) {
+func (k *KeysDB) GetLastKeyIndex(account uint32, purpose wallet.KeyChange) (int, bool, error) {
 	k.lock.RLock()
 	defer k.lock.RUnlock()
 
-	stm := "select keyIndex, used from keys where purpose=" + strconv.Itoa(int(purpose)) + " order by rowid desc limit 1"
+	stm := "select keyIndex, used from keys where account=? and purpose=? order by rowid desc limit 1"
 	stmt, err := k.db.Prepare(stm)
 	if err != nil {
 		return 0, false, err
@@ -68,7 +67,7 @@ func (k *KeysDB) GetLastKeyIndex(purpose wallet.KeyChange) (int, bool, error) {
 	defer stmt.Close()
 	var index int
 	var usedInt int
-	err = stmt.QueryRow().Scan(&index, &usedInt)
+	err = stmt.QueryRow(account, int(purpose)).Scan(&index, &usedInt)
 	if err != nil {
 		return 0, false, err
 	}
@@ -85,30 +84,32 @@ func (k *KeysDB) GetPathForKey(scriptAddress []byte) (wallet.KeyPath, error) {
 	k.lock.RLock()
 	defer k.lock.RUnlock()
 
-	stmt, err := k.db.Prepare("select purpose, keyIndex from keys where scriptAddress=? and purpose!=-1")
+	stmt, err := k.db.Prepare("select account, purpose, keyIndex from keys where scriptAddress=? and purpose!=-1")
 	if err != nil {
 		return wallet.KeyPath{}, err
 	}
 	defer stmt.Close()
+	var account uint32
 	var purpose int
 	var index int
-	err = stmt.QueryRow(hex.EncodeToString(scriptAddress)).Scan(&purpose, &index)
+	err = stmt.QueryRow(hex.EncodeToString(scriptAddress)).Scan(&account, &purpose, &index)
 	if err != nil {
 		return wallet.KeyPath{}, errors.New("key not found")
 	}
 	p := wallet.KeyPath{
-		Change: wallet.KeyChange(purpose),
-		Index:  index,
+		Account: account,
+		Change:  wallet.KeyChange(purpose),
+		Index:   index,
 	}
 	return p, nil
 }
 
-func (k *KeysDB) GetUnused(purpose wallet.KeyChange) ([]int, error) {
+func (k *KeysDB) GetUnused(account uint32, purpose wallet.KeyChange) ([]int, error) {
 	k.lock.RLock()
 	defer k.lock.RUnlock()
 	var ret []int
-	stm := "select keyIndex from keys where purpose=" + strconv.Itoa(int(purpose)) + " and used=0 order by rowid asc"
-	rows, err := k.db.Query(stm)
+	stm := "select keyIndex from keys where account=? and purpose=? and used=0 order by rowid asc"
+	rows, err := k.db.Query(stm, account, int(purpose))
 	if err != nil {
 		return ret, err
 	}
@@ -128,7 +129,7 @@ func (k *KeysDB) GetAll() ([]wallet.KeyPath, error) {
 	k.lock.RLock()
 	defer k.lock.RUnlock()
 	var ret []wallet.KeyPath
-	stm := "select purpose, keyIndex from keys"
+	stm := "select account, purpose, keyIndex from keys"
 	rows, err := k.db.Query(stm)
 	if err != nil {
 		fmt.Println(err)
@@ -136,14 +137,16 @@ func (k *KeysDB) GetAll() ([]wallet.KeyPath, error) {
 	}
 	defer rows.Close()
 	for rows.Next() {
+		var account uint32
 		var purpose int
 		var index int
-		if err := rows.Scan(&purpose, &index); err != nil {
+		if err := rows.Scan(&account, &purpose, &index); err != nil {
 			fmt.Println(err)
 		}
 		p := wallet.KeyPath{
-			Change: wallet.KeyChange(purpose),
-			Index:  index,
+			Account: account,
+			Change:  wallet.KeyChange(purpose),
+			Index:   index,
 		}
 		ret = append(ret, p)
 	}
@@ -158,13 +161,13 @@ func (k *KeysDB) GetDbg() string {
 	return ret
 }
 
-func (k *KeysDB) GetLookaheadWindows() map[wallet.KeyChange]int {
+func (k *KeysDB) GetLookaheadWindows(account uint32) map[wallet.KeyChange]int {
 	k.lock.RLock()
 	defer k.lock.RUnlock()
 	windows := make(map[wallet.KeyChange]int)
 	for i := 0; i < 2; i++ {
-		stm := "select used from keys where purpose=" + strconv.Itoa(i) + " order by rowid desc"
-		rows, err := k.db.Query(stm)
+		stm := "select used from keys where account=? and purpose=? order by rowid desc"
+		rows, err := k.db.Query(stm, account, i)
 		if err != nil {
 			continue
 		}
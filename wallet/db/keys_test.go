@@ -132,12 +132,12 @@ func TestGetLastKeyIndex(t *testing.T) {
 		}
 		last = b
 	}
-	idx, used, err := kdb.GetLastKeyIndex(wallet.EXTERNAL)
+	idx, used, err := kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil || idx != 99 || used != false {
 		t.Error("Failed to fetch correct last index")
 	}
 	kdb.MarkKeyAsUsed(last)
-	_, used, err = kdb.GetLastKeyIndex(wallet.EXTERNAL)
+	_, used, err = kdb.GetLastKeyIndex(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil || used != true {
 		t.Error("Failed to fetch correct last index")
 	}
@@ -183,7 +183,7 @@ func TestGetUnsed(t *testing.T) {
 			t.Error(err)
 		}
 	}
-	idx, err := kdb.GetUnused(wallet.INTERNAL)
+	idx, err := kdb.GetUnused(wallet.DefaultAccount, wallet.INTERNAL)
 	if err != nil {
 		t.Error("Failed to fetch correct unused")
 	}
@@ -219,9 +219,70 @@ func TestGetLookaheadWindows(t *testing.T) {
 			kdb.MarkKeyAsUsed(b)
 		}
 	}
-	windows := kdb.GetLookaheadWindows()
+	windows := kdb.GetLookaheadWindows(wallet.DefaultAccount)
 	if windows[wallet.EXTERNAL] != 50 || windows[wallet.INTERNAL] != 50 {
 		t.Error("Fetched incorrect lookahead windows")
 	}
 
 }
+
+func TestAccountKeys(t *testing.T) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	err := kdb.Put(b, wallet.KeyPath{
+		Account: 3,
+		Change:  wallet.EXTERNAL,
+		Index:   7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := kdb.GetPathForKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Account != 3 || path.Index != 7 || path.Change != wallet.EXTERNAL {
+		t.Errorf("Returned incorrect key path %v", path)
+	}
+	idx, used, err := kdb.GetLastKeyIndex(3, wallet.EXTERNAL)
+	if err != nil || idx != 7 || used {
+		t.Error("Failed to fetch correct last account index")
+	}
+	unused, err := kdb.GetUnused(3, wallet.EXTERNAL)
+	if err != nil || len(unused) != 1 || unused[0] != 7 {
+		t.Errorf("Failed to fetch correct unused account keys %v", unused)
+	}
+	if _, _, err := kdb.GetLastKeyIndex(4, wallet.EXTERNAL); err == nil {
+		t.Error("Expected no keys for an empty account")
+	}
+}
+
+func TestAddKeysAccountColumn(t *testing.T) {
+	conn, _ := sql.Open("sqlite3", ":memory:")
+	_, err := conn.Exec("create table keys (scriptAddress text primary key not null, purpose integer, keyIndex integer, used integer);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec("insert into keys(scriptAddress, purpose, keyIndex, used) values('00', 0, 5, 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := initDatabaseTables(conn); err != nil {
+		t.Fatal(err)
+	}
+	// a second open must not try to add the column again
+	if err := initDatabaseTables(conn); err != nil {
+		t.Fatal(err)
+	}
+	oldKeys := KeysDB{
+		db:   conn,
+		lock: new(sync.RWMutex),
+	}
+	path, err := oldKeys.GetPathForKey([]byte{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Account != wallet.DefaultAccount || path.Index != 5 {
+		t.Errorf("Returned incorrect key path %v", path)
+	}
+}
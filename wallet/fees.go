@@ -19,9 +19,10 @@ type feeCache struct {
 }
 
 type Fees struct {
-	Priority int64 `json:"priority"`
-	Normal   int64 `json:"normal"`
-	Economic int64 `json:"economic"`
+	Priority      int64 `json:"priority"`
+	Normal        int64 `json:"normal"`
+	Economic      int64 `json:"economic"`
+	SuperEconomic int64 `json:"supereconomic"`
 }
 
 type FeeProvider struct {
@@ -29,7 +30,9 @@ type FeeProvider struct {
 	PriorityFee int64
 	NormalFee   int64
 	EconomicFee int64
-	FeeAPI      string
+	// SuperEconomicFee defaults to half the EconomicFee
+	SuperEconomicFee int64
+	FeeAPI           string
 
 	HttpClient HttpClient
 
@@ -38,12 +41,13 @@ type FeeProvider struct {
 
 func NewFeeProvider(maxFee, priorityFee, normalFee, economicFee int64, feeAPI string, proxy proxy.Dialer) *FeeProvider {
 	fp := FeeProvider{
-		MaxFee:      maxFee,
-		PriorityFee: priorityFee,
-		NormalFee:   normalFee,
-		EconomicFee: economicFee,
-		FeeAPI:      feeAPI,
-		cache:       new(feeCache),
+		MaxFee:           maxFee,
+		PriorityFee:      priorityFee,
+		NormalFee:        normalFee,
+		EconomicFee:      economicFee,
+		SuperEconomicFee: max(economicFee/2, 1),
+		FeeAPI:           feeAPI,
+		cache:            new(feeCache),
 	}
 	dial := net.Dial
 	if proxy != nil {
@@ -81,11 +85,18 @@ func (fp *FeeProvider) GetFeePerByte(feeLevel FeeLevel) int64 {
 	case PRIORITY:
 		return fp.selectFee(fees.Priority, PRIORITY)
 	case NORMAL:
-		return fp.selectFee(fees.Normal, PRIORITY)
+		return fp.selectFee(fees.Normal, NORMAL)
 	case ECONOMIC:
-		return fp.selectFee(fees.Economic, PRIORITY)
+		return fp.selectFee(fees.Economic, ECONOMIC)
 	case FEE_BUMP:
-		return fp.selectFee(fees.Priority, PRIORITY)
+		return fp.selectFee(fees.Priority, FEE_BUMP)
+	case SUPER_ECONOMIC:
+		superEconomic := fees.SuperEconomic
+		if superEconomic == 0 && fees.Economic != 0 {
+			// the API has no super economic fee so half it's economic fee
+			superEconomic = max(fees.Economic/2, 1)
+		}
+		return fp.selectFee(superEconomic, SUPER_ECONOMIC)
 	default:
 		return fp.NormalFee
 	}
@@ -111,6 +122,8 @@ func (fp *FeeProvider) defaultFee(feeLevel FeeLevel) int64 {
 		return fp.EconomicFee
 	case FEE_BUMP:
 		return fp.PriorityFee
+	case SUPER_ECONOMIC:
+		return fp.SuperEconomicFee
 	default:
 		return fp.NormalFee
 	}
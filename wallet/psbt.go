@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BIP174 partially signed transactions. A wallet makes and signs PSBTs with
+// CreatePsbt and SignPsbt. Combining, finalizing and extracting need no wallet.
+
+var (
+	// ErrPsbtMismatch is returned when combining PSBTs of different
+	// transactions
+	ErrPsbtMismatch = errors.New("psbts are not for the same transaction")
+
+	// ErrPsbtIncomplete is returned when a PSBT has inputs that cannot be
+	// finalized yet
+	ErrPsbtIncomplete = errors.New("psbt is not fully signed")
+
+	// ErrPsbtSighash is returned when a PSBT input asks for a sighash type
+	// the signer did not allow
+	ErrPsbtSighash = errors.New("psbt sighash type not allowed")
+)
+
+// PsbtSignOptions are options for SignPsbt. A nil *PsbtSignOptions signs with
+// the defaults.
+type PsbtSignOptions struct {
+	// AllowAnySighash signs inputs with any sighash type the PSBT asks for.
+	// By default only SIGHASH_ALL, or SIGHASH_DEFAULT for taproot inputs, is
+	// signed; the other types let the transaction be changed after signing.
+	AllowAnySighash bool
+}
+
+// CombinePsbt merges the signatures, scripts and derivations of PSBTs of the
+// same unsigned transaction into one, the BIP174 Combiner. The packets are not
+// changed.
+func CombinePsbt(packets ...*psbt.Packet) (*psbt.Packet, error) {
+	if len(packets) == 0 {
+		return nil, errors.New("no psbts to combine")
+	}
+	txHash := packets[0].UnsignedTx.TxHash()
+	for _, p := range packets[1:] {
+		if p.UnsignedTx.TxHash() != txHash {
+			return nil, ErrPsbtMismatch
+		}
+	}
+	combined, err := copyPsbt(packets[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packets[1:] {
+		for i := range combined.Inputs {
+			combineInput(&combined.Inputs[i], &p.Inputs[i])
+		}
+		for i := range combined.Outputs {
+			combineOutput(&combined.Outputs[i], &p.Outputs[i])
+		}
+		combined.Unknowns = combineUnknowns(combined.Unknowns, p.Unknowns)
+	}
+	return combined, nil
+}
+
+// FinalizePsbt finalizes every input of a fully signed PSBT, the BIP174 Input
+// Finalizer. It returns ErrPsbtIncomplete if an input lacks signatures.
+func FinalizePsbt(packet *psbt.Packet) error {
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		if errors.Is(err, psbt.ErrNotFinalizable) {
+			return ErrPsbtIncomplete
+		}
+		return err
+	}
+	return nil
+}
+
+// ExtractPsbtTx returns the network transaction of a finalized PSBT, the
+// BIP174 Transaction Extractor.
+func ExtractPsbtTx(packet *psbt.Packet) (*wire.MsgTx, error) {
+	if !packet.IsComplete() {
+		return nil, ErrPsbtIncomplete
+	}
+	return psbt.Extract(packet)
+}
+
+// copyPsbt deep copies a PSBT by round tripping its serialization.
+func copyPsbt(packet *psbt.Packet) (*psbt.Packet, error) {
+	var b bytes.Buffer
+	if err := packet.Serialize(&b); err != nil {
+		return nil, err
+	}
+	return psbt.NewFromRawBytes(&b, false)
+}
+
+func combineInput(dst, src *psbt.PInput) {
+	if dst.NonWitnessUtxo == nil {
+		dst.NonWitnessUtxo = src.NonWitnessUtxo
+	}
+	if dst.WitnessUtxo == nil {
+		dst.WitnessUtxo = src.WitnessUtxo
+	}
+	for _, sig := range src.PartialSigs {
+		found := false
+		for _, have := range dst.PartialSigs {
+			if bytes.Equal(have.PubKey, sig.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.PartialSigs = append(dst.PartialSigs, sig)
+		}
+	}
+	if dst.SighashType == 0 {
+		dst.SighashType = src.SighashType
+	}
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	dst.Bip32Derivation = combineDerivations(dst.Bip32Derivation, src.Bip32Derivation)
+	if dst.FinalScriptSig == nil {
+		dst.FinalScriptSig = src.FinalScriptSig
+	}
+	if dst.FinalScriptWitness == nil {
+		dst.FinalScriptWitness = src.FinalScriptWitness
+	}
+	if dst.TaprootKeySpendSig == nil {
+		dst.TaprootKeySpendSig = src.TaprootKeySpendSig
+	}
+	for _, sig := range src.TaprootScriptSpendSig {
+		found := false
+		for _, have := range dst.TaprootScriptSpendSig {
+			if have.EqualKey(sig) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.TaprootScriptSpendSig = append(dst.TaprootScriptSpendSig, sig)
+		}
+	}
+	for _, leaf := range src.TaprootLeafScript {
+		found := false
+		for _, have := range dst.TaprootLeafScript {
+			if bytes.Equal(have.ControlBlock, leaf.ControlBlock) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.TaprootLeafScript = append(dst.TaprootLeafScript, leaf)
+		}
+	}
+	dst.TaprootBip32Derivation = combineTaprootDerivations(dst.TaprootBip32Derivation, src.TaprootBip32Derivation)
+	if dst.TaprootInternalKey == nil {
+		dst.TaprootInternalKey = src.TaprootInternalKey
+	}
+	if dst.TaprootMerkleRoot == nil {
+		dst.TaprootMerkleRoot = src.TaprootMerkleRoot
+	}
+	dst.Unknowns = combineUnknowns(dst.Unknowns, src.Unknowns)
+}
+
+func combineOutput(dst, src *psbt.POutput) {
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	dst.Bip32Derivation = combineDerivations(dst.Bip32Derivation, src.Bip32Derivation)
+	if dst.TaprootInternalKey == nil {
+		dst.TaprootInternalKey = src.TaprootInternalKey
+	}
+	if dst.TaprootTapTree == nil {
+		dst.TaprootTapTree = src.TaprootTapTree
+	}
+	dst.TaprootBip32Derivation = combineTaprootDerivations(dst.TaprootBip32Derivation, src.TaprootBip32Derivation)
+	dst.Unknowns = combineUnknowns(dst.Unknowns, src.Unknowns)
+}
+
+func combineDerivations(dst, src []*psbt.Bip32Derivation) []*psbt.Bip32Derivation {
+	for _, d := range src {
+		found := false
+		for _, have := range dst {
+			if bytes.Equal(have.PubKey, d.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+func combineTaprootDerivations(dst, src []*psbt.TaprootBip32Derivation) []*psbt.TaprootBip32Derivation {
+	for _, d := range src {
+		found := false
+		for _, have := range dst {
+			if bytes.Equal(have.XOnlyPubKey, d.XOnlyPubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+func combineUnknowns(dst, src []*psbt.Unknown) []*psbt.Unknown {
+	for _, u := range src {
+		found := false
+		for _, have := range dst {
+			if bytes.Equal(have.Key, u.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, u)
+		}
+	}
+	return dst
+}
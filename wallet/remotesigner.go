@@ -0,0 +1,246 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Remote signing protocol. A RemoteSigner connects to a signing process over
+// a local socket, unix or tcp to localhost, and writes a SignRequest as one
+// line of JSON. The request has the unsigned transaction, the input to sign
+// and the outputs spent by every input so the signing process can check the
+// payments and change before it makes the sighash and signs. The signing
+// process answers with a SignResponse line:
+//
+//	-> {"id":1,"method":"sign_ecdsa","account":0,"change":1,"index":4,
+//	    "tx":"<hex>","input":0,"prevouts":[{"value":100000,"pkscript":"<hex>"}],
+//	    "sighashtype":1}
+//	<- {"id":1,"signature":"<hex>"}
+//
+// or {"id":1,"error":"<reason>"}. Prevouts of other wallets may be null. A
+// request that cannot be read gets an error with id 0 and the connection is
+// closed. ServeSigner is a signing process for any Signer.
+
+const (
+	SignMethodECDSA   = "sign_ecdsa"
+	SignMethodSchnorr = "sign_schnorr"
+)
+
+// DefaultRemoteSignerTimeout is the time a RemoteSigner waits for a signature.
+// A hardware wallet may need its user to confirm.
+const DefaultRemoteSignerTimeout = 2 * time.Minute
+
+// SignRequest asks a signing process to sign an input of a transaction with
+// the key at a key path of the wallet's address scheme and coin type.
+type SignRequest struct {
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Account uint32 `json:"account"`
+	Change  int    `json:"change"`
+	Index   int    `json:"index"`
+	// Tx is the unsigned transaction
+	Tx string `json:"tx"`
+	// Input is the index of the input to sign
+	Input        int          `json:"input"`
+	PrevOuts     []*SignTxOut `json:"prevouts"`
+	RedeemScript string       `json:"redeemscript,omitempty"`
+	SigHashType  uint32       `json:"sighashtype"`
+}
+
+// SignTxOut is an output spent by the transaction of a SignRequest.
+type SignTxOut struct {
+	Value    int64  `json:"value"`
+	PkScript string `json:"pkscript"`
+}
+
+// SignResponse is the answer to the SignRequest with the same ID.
+type SignResponse struct {
+	ID        uint64 `json:"id"`
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RemoteSigner is a Signer that asks a signing process listening on a local
+// socket.
+type RemoteSigner struct {
+	network string
+	address string
+	Timeout time.Duration
+	nextID  atomic.Uint64
+}
+
+var _ = Signer(&RemoteSigner{})
+
+// NewRemoteSigner makes a RemoteSigner for the signing process listening at
+// address on network, "unix" or "tcp".
+func NewRemoteSigner(network, address string) *RemoteSigner {
+	return &RemoteSigner{
+		network: network,
+		address: address,
+		Timeout: DefaultRemoteSignerTimeout,
+	}
+}
+
+func (rs *RemoteSigner) SignECDSA(keyPath KeyPath, input *SigningInput) ([]byte, error) {
+	return rs.sign(SignMethodECDSA, keyPath, input)
+}
+
+func (rs *RemoteSigner) SignSchnorr(keyPath KeyPath, input *SigningInput) ([]byte, error) {
+	return rs.sign(SignMethodSchnorr, keyPath, input)
+}
+
+func (rs *RemoteSigner) sign(method string, keyPath KeyPath, input *SigningInput) ([]byte, error) {
+	var b bytes.Buffer
+	if err := input.Tx.Serialize(&b); err != nil {
+		return nil, err
+	}
+	req := SignRequest{
+		ID:           rs.nextID.Add(1),
+		Method:       method,
+		Account:      keyPath.Account,
+		Change:       int(keyPath.Change),
+		Index:        keyPath.Index,
+		Tx:           hex.EncodeToString(b.Bytes()),
+		Input:        input.Index,
+		PrevOuts:     make([]*SignTxOut, len(input.PrevOuts)),
+		RedeemScript: hex.EncodeToString(input.RedeemScript),
+		SigHashType:  uint32(input.HashType),
+	}
+	for i, prevOut := range input.PrevOuts {
+		if prevOut != nil {
+			req.PrevOuts[i] = &SignTxOut{
+				Value:    prevOut.Value,
+				PkScript: hex.EncodeToString(prevOut.PkScript),
+			}
+		}
+	}
+	conn, err := net.DialTimeout(rs.network, rs.address, rs.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(rs.Timeout)); err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(conn).Encode(&req); err != nil {
+		return nil, err
+	}
+	var resp SignResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.ID == 0 && resp.Error != "" {
+		return nil, fmt.Errorf("remote signer: %s", resp.Error)
+	}
+	if resp.ID != req.ID {
+		return nil, fmt.Errorf("remote signer: response id %d for request %d", resp.ID, req.ID)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("remote signer: %s", resp.Error)
+	}
+	return hex.DecodeString(resp.Signature)
+}
+
+// ServeSigner answers the SignRequests of RemoteSigners connecting to l with
+// signer. It returns when l is closed.
+func ServeSigner(l net.Listener, signer Signer) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveSignerConn(conn, signer)
+	}
+}
+
+func serveSignerConn(conn net.Conn, signer Signer) {
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req SignRequest
+		if err := dec.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Printf("sign request: %v\n", err)
+				enc.Encode(&SignResponse{Error: fmt.Sprintf("bad sign request: %v", err)})
+			}
+			return
+		}
+		resp := SignResponse{ID: req.ID}
+		sig, err := signRequest(signer, &req)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Signature = hex.EncodeToString(sig)
+		}
+		if err := enc.Encode(&resp); err != nil {
+			return
+		}
+	}
+}
+
+func signRequest(signer Signer, req *SignRequest) ([]byte, error) {
+	input, err := req.signingInput()
+	if err != nil {
+		return nil, err
+	}
+	keyPath := KeyPath{
+		Account: req.Account,
+		Change:  KeyChange(req.Change),
+		Index:   req.Index,
+	}
+	switch req.Method {
+	case SignMethodECDSA:
+		return signer.SignECDSA(keyPath, input)
+	case SignMethodSchnorr:
+		return signer.SignSchnorr(keyPath, input)
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
+
+// signingInput decodes the SigningInput of the request.
+func (req *SignRequest) signingInput() (*SigningInput, error) {
+	b, err := hex.DecodeString(req.Tx)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	redeemScript, err := hex.DecodeString(req.RedeemScript)
+	if err != nil {
+		return nil, err
+	}
+	input := &SigningInput{
+		Tx:           tx,
+		Index:        req.Input,
+		PrevOuts:     make([]*wire.TxOut, len(req.PrevOuts)),
+		RedeemScript: redeemScript,
+		HashType:     txscript.SigHashType(req.SigHashType),
+	}
+	for i, prevOut := range req.PrevOuts {
+		if prevOut == nil {
+			continue
+		}
+		pkScript, err := hex.DecodeString(prevOut.PkScript)
+		if err != nil {
+			return nil, err
+		}
+		input.PrevOuts[i] = wire.NewTxOut(prevOut.Value, pkScript)
+	}
+	return input, nil
+}
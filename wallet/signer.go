@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Signer signs the inputs of a wallet's transactions with its HD keys. It is
+// given the whole unsigned transaction and the outputs it spends so it can
+// check what it signs, show it to its user and make the sighash itself. The
+// wallet makes the input scripts and checks the signatures against its own
+// public keys.
+//
+// A wallet made from a seed signs in process by default. A WatchOnly wallet
+// has no Signer until one is set with SetSigner, for example a RemoteSigner
+// for a hardware wallet.
+type Signer interface {
+	// SignECDSA returns the DER encoded ECDSA signature of the input by the
+	// key at keyPath.
+	SignECDSA(keyPath KeyPath, input *SigningInput) ([]byte, error)
+
+	// SignSchnorr returns the BIP340 signature of a taproot input by the
+	// key at keyPath tweaked for a BIP86 key path spend.
+	SignSchnorr(keyPath KeyPath, input *SigningInput) ([]byte, error)
+}
+
+var (
+	// ErrBadSignature is returned when a Signer's signature does not verify
+	// with the wallet's public key.
+	ErrBadSignature = errors.New("signer signature does not verify")
+
+	// ErrSchnorrNotSupported is returned by the signers of coins without
+	// taproot.
+	ErrSchnorrNotSupported = errors.New("schnorr signatures are not supported")
+
+	// ErrBadSigningInput is returned for a SigningInput without the output
+	// its input spends.
+	ErrBadSigningInput = errors.New("signing input has no output to spend")
+)
+
+// SigningInput is an input of an unsigned transaction to be signed.
+type SigningInput struct {
+	// Tx is the unsigned transaction
+	Tx *wire.MsgTx
+	// Index is the index of the input to sign
+	Index int
+	// PrevOuts are the outputs spent by the inputs of Tx in input order.
+	// Outputs of other wallets may be nil, except for taproot inputs whose
+	// sighash commits to all of them.
+	PrevOuts []*wire.TxOut
+	// RedeemScript is the redeem script of a P2SH input
+	RedeemScript []byte
+	// HashType is the sighash type
+	HashType txscript.SigHashType
+}
+
+// NewSigningInput makes the SigningInput of input idx of tx with the outputs
+// known to prevOutFetcher.
+func NewSigningInput(tx *wire.MsgTx, idx int, prevOutFetcher txscript.PrevOutputFetcher,
+	redeemScript []byte, hashType txscript.SigHashType) *SigningInput {
+
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		prevOuts[i] = prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+	}
+	return &SigningInput{
+		Tx:           tx,
+		Index:        idx,
+		PrevOuts:     prevOuts,
+		RedeemScript: redeemScript,
+		HashType:     hashType,
+	}
+}
+
+// SigHash returns the sighash of the input. P2SH inputs are nested P2WPKH
+// signed for the RedeemScript.
+func (in *SigningInput) SigHash() ([]byte, error) {
+	if in.Tx == nil || in.Index < 0 || in.Index >= len(in.Tx.TxIn) ||
+		len(in.PrevOuts) != len(in.Tx.TxIn) || in.PrevOuts[in.Index] == nil {
+		return nil, ErrBadSigningInput
+	}
+	prevOut := in.PrevOuts[in.Index]
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range in.Tx.TxIn {
+		if in.PrevOuts[i] != nil {
+			prevOutFetcher.AddPrevOut(txIn.PreviousOutPoint, in.PrevOuts[i])
+		}
+	}
+	switch class := txscript.GetScriptClass(prevOut.PkScript); class {
+	case txscript.WitnessV0PubKeyHashTy:
+		sigHashes := txscript.NewTxSigHashes(in.Tx, prevOutFetcher)
+		return txscript.CalcWitnessSigHash(prevOut.PkScript, sigHashes, in.HashType, in.Tx, in.Index, prevOut.Value)
+	case txscript.ScriptHashTy:
+		if !bytes.Equal(prevOut.PkScript[2:22], btcutil.Hash160(in.RedeemScript)) {
+			return nil, errors.New("P2SH output is not for the redeem script")
+		}
+		sigHashes := txscript.NewTxSigHashes(in.Tx, prevOutFetcher)
+		return txscript.CalcWitnessSigHash(in.RedeemScript, sigHashes, in.HashType, in.Tx, in.Index, prevOut.Value)
+	case txscript.PubKeyHashTy:
+		return txscript.CalcSignatureHash(prevOut.PkScript, in.HashType, in.Tx, in.Index)
+	case txscript.WitnessV1TaprootTy:
+		for _, p := range in.PrevOuts {
+			if p == nil {
+				return nil, errors.New("taproot signing needs the prevouts of every input")
+			}
+		}
+		sigHashes := txscript.NewTxSigHashes(in.Tx, prevOutFetcher)
+		return txscript.CalcTaprootSignatureHash(sigHashes, in.HashType, in.Tx, in.Index, prevOutFetcher)
+	default:
+		return nil, fmt.Errorf("signing for script type %v unsupported", class)
+	}
+}
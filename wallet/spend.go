@@ -0,0 +1,132 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+)
+
+var (
+	// ErrFeeRateAndFee is returned for SpendOptions with both a FeeRate and
+	// an absolute Fee
+	ErrFeeRateAndFee = errors.New("spend has both a fee rate and a fee")
+
+	// ErrNegativeFee is returned for a negative FeeRate or Fee
+	ErrNegativeFee = errors.New("negative fee")
+
+	// ErrFeeRateTooHigh is returned for a FeeRate over the MaxFee of the
+	// FeeProvider
+	ErrFeeRateTooHigh = errors.New("fee rate is above the maximum fee rate")
+
+	// ErrFeeTooLow is returned for an absolute Fee below the minimum relay
+	// fee of the transaction
+	ErrFeeTooLow = errors.New("fee is below the minimum relay fee")
+)
+
+// SpendInput is a coin spent by a spend.
+type SpendInput struct {
+	Outpoint wire.OutPoint
+	Value    int64
+}
+
+// SpendEstimate is the preview of a spend before it is signed.
+type SpendEstimate struct {
+	// Inputs are the coins the spend would use
+	Inputs []SpendInput
+	// Outputs are the outputs paid, BIP69 sorted, with the change
+	Outputs []*wire.TxOut
+	// ChangeIndex is the index of the change output or -1 if there is no
+	// change
+	ChangeIndex int
+	// Change is the change amount
+	Change int64
+	// VSize is the estimated virtual size of the signed transaction
+	VSize int64
+	// Fee is the fee paid, the inputs less the outputs
+	Fee int64
+}
+
+// SpendFeePerKB returns the fee rate in sat/kB of a spend with the options:
+// the options FeeRate if set, 0 for an absolute Fee and otherwise the fee rate
+// of the spend's FeeLevel. A FeeRate may not be over the MaxFee.
+func (fp *FeeProvider) SpendFeePerKB(feeLevel FeeLevel, opts *SpendOptions) (int64, error) {
+	if opts == nil {
+		return fp.GetFeePerByte(feeLevel) * 1000, nil
+	}
+	if opts.FeeRate < 0 || opts.Fee < 0 {
+		return 0, ErrNegativeFee
+	}
+	if opts.FeeRate > 0 && opts.Fee > 0 {
+		return 0, ErrFeeRateAndFee
+	}
+	if opts.Fee > 0 {
+		return 0, nil
+	}
+	if opts.FeeRate > 0 {
+		if opts.FeeRate > fp.MaxFee {
+			return 0, fmt.Errorf("%w: %d > %d sat/vB", ErrFeeRateTooHigh, opts.FeeRate, fp.MaxFee)
+		}
+		return opts.FeeRate * 1000, nil
+	}
+	return fp.GetFeePerByte(feeLevel) * 1000, nil
+}
+
+// CheckRelayFee checks that an absolute fee pays at least the minimum relay
+// fee for a transaction of vsize.
+func CheckRelayFee(fee, vsize int64) error {
+	minFee := int64(txrules.FeeForSerializeSize(txrules.DefaultRelayFeePerKb, int(vsize)))
+	if fee < minFee {
+		return fmt.Errorf("%w: %d < %d", ErrFeeTooLow, fee, minFee)
+	}
+	return nil
+}
+
+// EstimateVirtualSize estimates the virtual size of the signed transaction
+// from the outputs it spends.
+func EstimateVirtualSize(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) int64 {
+	var nested, p2wpkh, p2tr, p2pkh int
+	for _, txIn := range tx.TxIn {
+		switch txscript.GetScriptClass(prevOuts[txIn.PreviousOutPoint].PkScript) {
+		case txscript.ScriptHashTy:
+			nested++
+		case txscript.WitnessV0PubKeyHashTy:
+			p2wpkh++
+		case txscript.WitnessV1TaprootTy:
+			p2tr++
+		default:
+			p2pkh++
+		}
+	}
+	return int64(txsizes.EstimateVirtualSize(p2pkh, p2tr, p2wpkh, nested, tx.TxOut, 0))
+}
+
+// NewSpendEstimate makes the SpendEstimate of an unsigned transaction with the
+// outputs it spends.
+func NewSpendEstimate(tx *wire.MsgTx, changeIndex int, prevOuts map[wire.OutPoint]*wire.TxOut) *SpendEstimate {
+	estimate := &SpendEstimate{
+		Outputs:     tx.TxOut,
+		ChangeIndex: changeIndex,
+	}
+	var inputTotal, outputTotal int64
+	for _, txIn := range tx.TxIn {
+		prevOut := prevOuts[txIn.PreviousOutPoint]
+		estimate.Inputs = append(estimate.Inputs, SpendInput{
+			Outpoint: txIn.PreviousOutPoint,
+			Value:    prevOut.Value,
+		})
+		inputTotal += prevOut.Value
+	}
+	for _, txOut := range tx.TxOut {
+		outputTotal += txOut.Value
+	}
+	if changeIndex >= 0 {
+		estimate.Change = tx.TxOut[changeIndex].Value
+	}
+	estimate.VSize = EstimateVirtualSize(tx, prevOuts)
+	estimate.Fee = inputTotal - outputTotal
+	return estimate
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
@@ -20,6 +21,13 @@ type WalletConfig struct {
 	// The blockchain, btc, dash, etc
 	CoinType CoinType
 
+	// New wallets derive keys on the legacy bitcoin coin type path
+	LegacyHDPath bool
+
+	// Derivation and address scheme of new segwit coin wallets. Empty is
+	// SchemeBip84. Coins without segwit always use SchemeBip44.
+	AddressScheme AddressScheme
+
 	// mainnet, testnet or regtest
 	NetType string
 
@@ -49,6 +57,85 @@ type WalletConfig struct {
 	Testing bool
 }
 
+// SLIP-44 coin types for the BIP44 key derivation path.
+const (
+	// every test network
+	HDCoinTypeTestnet uint32 = 1
+	// wallets made before the coin type was taken from the config derive
+	// keys for all coins and networks with the bitcoin coin type
+	HDCoinTypeLegacy uint32 = 0
+)
+
+// HDCoinType returns the SLIP-44 coin type for new wallet key derivation:
+// CoinType on mainnet and HDCoinTypeTestnet on all other networks, or
+// HDCoinTypeLegacy if LegacyHDPath is set.
+func (wc *WalletConfig) HDCoinType() uint32 {
+	if wc.LegacyHDPath {
+		return HDCoinTypeLegacy
+	}
+	if wc.NetType != "mainnet" || wc.CoinType >= TestnetBitcoin {
+		return HDCoinTypeTestnet
+	}
+	return uint32(wc.CoinType)
+}
+
+// AddressScheme is the HD derivation scheme of a wallet: the purpose level of
+// the key path and the kind of address made from each key.
+type AddressScheme string
+
+const (
+	// BIP44 m/44' P2PKH addresses
+	SchemeBip44 AddressScheme = "bip44"
+	// BIP49 m/49' P2SH-P2WPKH addresses
+	SchemeBip49 AddressScheme = "bip49"
+	// BIP84 m/84' P2WPKH addresses
+	SchemeBip84 AddressScheme = "bip84"
+	// BIP86 m/86' P2TR key-path only addresses
+	SchemeBip86 AddressScheme = "bip86"
+	// m/44' P2WPKH addresses as made by wallets before the scheme was
+	// selectable
+	SchemeLegacy AddressScheme = "legacy"
+)
+
+var ErrUnknownAddressScheme = errors.New("unknown address scheme")
+
+// ParseAddressScheme returns the AddressScheme named s.
+func ParseAddressScheme(s string) (AddressScheme, error) {
+	scheme := AddressScheme(strings.ToLower(s))
+	if _, err := scheme.Purpose(); err != nil {
+		return "", err
+	}
+	return scheme, nil
+}
+
+// Purpose returns the purpose level of the scheme's key derivation path.
+func (s AddressScheme) Purpose() (uint32, error) {
+	switch s {
+	case SchemeBip44, SchemeLegacy:
+		return 44, nil
+	case SchemeBip49:
+		return 49, nil
+	case SchemeBip84:
+		return 84, nil
+	case SchemeBip86:
+		return 86, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownAddressScheme, string(s))
+}
+
+// Scheme returns the address scheme for new segwit coin wallets:
+// AddressScheme or SchemeBip84 if not set, or SchemeLegacy if LegacyHDPath is
+// set.
+func (wc *WalletConfig) Scheme() AddressScheme {
+	if wc.LegacyHDPath {
+		return SchemeLegacy
+	}
+	if wc.AddressScheme == "" {
+		return SchemeBip84
+	}
+	return wc.AddressScheme
+}
+
 type ElectrumWallet interface {
 	// Start the wallet
 	Start()
@@ -178,9 +265,65 @@ type ElectrumWallet interface {
 	// Set the utxo as spendable again
 	UnFreezeUTXO(op *wire.OutPoint) error
 
-	// Make a new spending transaction
+	// WatchOnly returns whether the wallet was made from an extended public
+	// key. A watch-only wallet has no private keys and cannot sign until a
+	// Signer is set.
+	WatchOnly() bool
+
+	// SetSigner sets the Signer of the wallet's spends, transactions and
+	// PSBTs in place of its in process signer, e.g. a RemoteSigner.
+	SetSigner(signer Signer)
+
+	// Make a new spending transaction from the coins of the DefaultAccount
 	Spend(pw string, amount int64, toAddress btcutil.Address, feeLevel FeeLevel) (int, *wire.MsgTx, error)
 
+	// CreateAccount adds a named HD account to the wallet and returns its
+	// account number. Accounts are numbered in order from 1.
+	CreateAccount(pw, name string) (uint32, error)
+
+	// ListAccounts returns the wallet accounts in account number order,
+	// starting with the DefaultAccount.
+	ListAccounts() []Account
+
+	// GetUnusedAccountAddress is GetUnusedAddress for an account.
+	GetUnusedAccountAddress(account uint32, purpose KeyChange) (btcutil.Address, error)
+
+	// AccountBalance is Balance for the coins of an account.
+	AccountBalance(account uint32) (int64, int64, int64, error)
+
+	// ListAccountUnspent is ListUnspent for the coins of an account.
+	ListAccountUnspent(account uint32) ([]Utxo, error)
+
+	// AccountSpend makes a new spending transaction from the coins of an
+	// account. Any change goes to the account.
+	AccountSpend(pw string, account uint32, amount int64, toAddress btcutil.Address, feeLevel FeeLevel) (int, *wire.MsgTx, error)
+
+	// SpendMany makes a new spending transaction paying every output, e.g. a
+	// batch of withdrawals, from the coins of an account. Any change goes to
+	// the account. The options, if not nil, choose the coins to spend or
+	// avoid and may set a fee rate or fee in place of the feeLevel. It
+	// returns the change output index or -1 if there is no change.
+	SpendMany(pw string, account uint32, outputs []TransactionOutput, feeLevel FeeLevel, opts *SpendOptions) (int, *wire.MsgTx, error)
+
+	// EstimateSpend previews SpendMany without signing: the coins it would
+	// spend, its outputs and change, and its estimated vsize and fee.
+	EstimateSpend(account uint32, outputs []TransactionOutput, feeLevel FeeLevel, opts *SpendOptions) (*SpendEstimate, error)
+
+	// BuildUnsignedTx is AccountSpend without signing. It needs no password
+	// and works for a WatchOnly wallet.
+	BuildUnsignedTx(account uint32, amount int64, toAddress btcutil.Address, feeLevel FeeLevel) (int, *wire.MsgTx, error)
+
+	// CreatePsbt funds a BIP174 PSBT paying the outputs from the coins of an
+	// account. The inputs carry their prevouts and the wallet's inputs and
+	// change output their BIP32 derivations. It returns the change output
+	// index. It works for a WatchOnly wallet.
+	CreatePsbt(account uint32, outputs []TransactionOutput, feeLevel FeeLevel) (*psbt.Packet, int, error)
+
+	// SignPsbt adds signatures for the PSBT inputs that spend wallet coins
+	// and returns how many it signed. Other inputs are left for other
+	// signers. opts may be nil.
+	SignPsbt(pw string, packet *psbt.Packet, opts *PsbtSignOptions) (int, error)
+
 	// Calculates the estimated size of the transaction and returns the total fee for the given feePerByte
 	EstimateFee(ins []InputInfo, outs []TransactionOutput, feePerByte int64) int64
 
@@ -192,23 +335,59 @@ type ElectrumWallet interface {
 
 	// Update the height of the tip from the blockchain headers.
 	UpdateTip(newTip int64)
+
+	// Migrate wallet data from an older DataVersion. blockTime returns the
+	// time of the block at a height.
+	MigrateTxTimes(blockTime func(height int64) (time.Time, error)) error
 }
 
+// Wallet data versions. A new wallet is at DataVersionCurrent. An older wallet
+// is migrated by the client when it is loaded.
+const (
+	// transaction times were the time the wallet first saw a transaction
+	DataVersionInitial = 0
+	// confirmed transaction times are block times
+	DataVersionBlockTimes = 1
+
+	DataVersionCurrent = DataVersionBlockTimes
+)
+
 // Errors
 var (
 	// ErrDustAmount is returned if an output amount is below the dust threshold
 	ErrDustAmount error = errors.New("amount is below network dust threshold")
 
+	// ErrNoOutputs is returned for a spend without outputs
+	ErrNoOutputs = errors.New("no outputs to pay")
+
 	// ErrInsufficientFunds is returned when the wallet is unable to send the
 	// amount specified due to the balance being too low
 	ErrInsufficientFunds = errors.New("ERROR_INSUFFICIENT_FUNDS")
 
+	// ErrUnknownAccount is returned for an account the wallet does not have
+	ErrUnknownAccount = errors.New("unknown account")
+
+	// ErrWatchOnly is returned when a WatchOnly wallet is asked for private
+	// keys or signatures
+	ErrWatchOnly = errors.New("watch-only wallet has no private keys")
+
 	// ErrWalletFnNotImplemented is returned from some unimplemented functions.
 	// This is due to a concrete wallet not implementing the functionality or
 	// temporarily during development.
 	ErrWalletFnNotImplemented = errors.New("wallet function is not implemented")
 )
 
+// DefaultAccount is the HD account of every wallet. Spend, GetUnusedAddress
+// and address lookups without an account use it.
+const DefaultAccount uint32 = 0
+
+// Account is a named HD account of the wallet.
+type Account struct {
+	// account' level of the key derivation path
+	Number uint32 `json:"number"`
+	Name   string `json:"name"`
+}
+
 type FeeLevel int
 
 const (
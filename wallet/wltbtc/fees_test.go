@@ -16,10 +16,15 @@ func (cb *ClosingBuffer) Close() (err error) {
 	return
 }
 
-type mockHttpClient struct{}
+type mockHttpClient struct {
+	data string
+}
 
 func (m *mockHttpClient) Get(url string) (*http.Response, error) {
-	data := `{"priority":450,"normal":420,"economic":390}`
+	data := m.data
+	if data == "" {
+		data = `{"priority":450,"normal":420,"economic":390}`
+	}
 	cb := &ClosingBuffer{bytes.NewBufferString(data)}
 	resp := &http.Response{
 		Body: cb,
@@ -45,6 +50,10 @@ func TestFeeProvider_GetFeePerByte(t *testing.T) {
 	if fp.GetFeePerByte(wallet.FEE_BUMP) != 450 {
 		t.Error("Returned incorrect fee per byte")
 	}
+	// the API has no super economic fee
+	if fp.GetFeePerByte(wallet.SUPER_ECONOMIC) != 195 {
+		t.Error("Returned incorrect fee per byte")
+	}
 
 	// Test return over max
 	fp.MaxFee = 100
@@ -75,4 +84,22 @@ func TestFeeProvider_GetFeePerByte(t *testing.T) {
 	if fp.GetFeePerByte(wallet.FEE_BUMP) != 360 {
 		t.Error("Returned incorrect fee per byte")
 	}
+	if fp.GetFeePerByte(wallet.SUPER_ECONOMIC) != 140 {
+		t.Error("Returned incorrect fee per byte")
+	}
+}
+
+func TestFeeProvider_GetFeePerByteBelowStatic(t *testing.T) {
+	fp := wallet.NewFeeProvider(2000, 360, 320, 280, "https://mempool.space/api/v1/fees/recommended", nil)
+	// an empty mempool - the API rates are below the static fees
+	fp.HttpClient = &mockHttpClient{data: `{"priority":4,"normal":3,"economic":2}`}
+
+	if fp.GetFeePerByte(wallet.ECONOMIC) != 2 {
+		t.Error("Returned incorrect fee per byte")
+	}
+	// the API has no super economic fee - half the API economic fee, not the
+	// static one
+	if fp.GetFeePerByte(wallet.SUPER_ECONOMIC) != 1 {
+		t.Error("Returned incorrect fee per byte")
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"sort"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -23,7 +24,7 @@ func makeRegtestSeed() []byte {
 	return bip39.NewSeed(test_mnemonic, "")
 }
 
-func createTxStore() (*TxStore, *StorageManager) {
+func createTxStore(scheme wallet.AddressScheme) (*TxStore, *StorageManager) {
 	mockDb := MockDatastore{
 		&mockConfig{creationDate: time.Now()},
 		&mockStorage{blob: make([]byte, 10)},
@@ -36,7 +37,7 @@ func createTxStore() (*TxStore, *StorageManager) {
 
 	seed := makeRegtestSeed()
 	key, _ := hdkeychain.NewMaster(seed, &chaincfg.RegressionNetParams)
-	km, _ := NewKeyManager(mockDb.Keys(), &chaincfg.RegressionNetParams, key)
+	km, _ := NewKeyManager(mockDb.Keys(), &chaincfg.RegressionNetParams, key, wallet.HDCoinTypeLegacy, scheme)
 	sm := NewStorageManager(mockDb.Enc(), &chaincfg.RegressionNetParams)
 	txStore, _ := NewTxStore(&chaincfg.RegressionNetParams, &mockDb, km)
 	return txStore, sm
@@ -44,19 +45,28 @@ func createTxStore() (*TxStore, *StorageManager) {
 
 // A 'regtest' wallet
 func MockWallet(pw string) *BtcElectrumWallet {
-	txstore, storageMgr := createTxStore()
+	// the test vectors were made on the legacy derivation path
+	return MockSchemeWallet(pw, wallet.SchemeLegacy)
+}
+
+// A 'regtest' wallet with keys and addresses of the address scheme
+func MockSchemeWallet(pw string, scheme wallet.AddressScheme) *BtcElectrumWallet {
+	txstore, storageMgr := createTxStore(scheme)
 
 	storageMgr.store.Xprv = "tprv8ZgxMBicQKsPfJU6JyiVdmFAtAzmWmTeEv85nTAHjLQyL35tdP2fAPWDSBBnFqGhhfTHVQMcnZhZDFkzFmCjm1bgf5UDwMAeFUWhJ9Dr8c4"
 	storageMgr.store.Xpub = "tpubD6NzVbkrYhZ4YmVtCdP63AuHTCWhg6eYpDis4yCb9cDNAXLfFmrFLt85cLFTwHiDJ9855NiE7cgQdiTGt5mb2RS9RfaxgVDkwBybJWm54Gh"
 	storageMgr.store.ShaPw = chainhash.HashB([]byte(pw))
 	storageMgr.store.Seed = []byte{0x01, 0x02, 0x03}
+	storageMgr.store.Scheme = scheme
 
 	wallet := &BtcElectrumWallet{
 		txstore:        txstore,
 		keyManager:     txstore.keyManager,
+		signer:         &hdSigner{km: txstore.keyManager},
 		storageManager: storageMgr,
 		params:         &chaincfg.RegressionNetParams,
 		feeProvider:    wallet.DefaultFeeProvider(),
+		mutex:          new(sync.RWMutex),
 	}
 
 	// fundWallet(wallet)
@@ -103,7 +113,9 @@ func (m *MockDatastore) Subscriptions() wallet.Subscriptions {
 }
 
 type mockConfig struct {
-	creationDate time.Time
+	creationDate    time.Time
+	dataVersion     int
+	migrationCursor string
 }
 
 func (mc *mockConfig) PutCreationDate(date time.Time) error {
@@ -115,6 +127,24 @@ func (mc *mockConfig) GetCreationDate() (time.Time, error) {
 	return mc.creationDate, nil
 }
 
+func (mc *mockConfig) PutDataVersion(version int) error {
+	mc.dataVersion = version
+	return nil
+}
+
+func (mc *mockConfig) GetDataVersion() (int, error) {
+	return mc.dataVersion, nil
+}
+
+func (mc *mockConfig) PutMigrationCursor(txid string) error {
+	mc.migrationCursor = txid
+	return nil
+}
+
+func (mc *mockConfig) GetMigrationCursor() (string, error) {
+	return mc.migrationCursor, nil
+}
+
 // encrypted blob
 type mockStorage struct {
 	blob []byte
@@ -180,11 +210,11 @@ func (m *mockKeyStore) MarkKeyAsUsed(scriptAddress []byte) error {
 	return nil
 }
 
-func (m *mockKeyStore) GetLastKeyIndex(purpose wallet.KeyChange) (int, bool, error) {
+func (m *mockKeyStore) GetLastKeyIndex(account uint32, purpose wallet.KeyChange) (int, bool, error) {
 	i := -1
 	used := false
 	for _, key := range m.keys {
-		if key.path.Change == purpose && key.path.Index > i {
+		if key.path.Account == account && key.path.Change == purpose && key.path.Index > i {
 			i = key.path.Index
 			used = key.used
 		}
@@ -203,10 +233,10 @@ func (m *mockKeyStore) GetPathForKey(scriptAddress []byte) (wallet.KeyPath, erro
 	return key.path, nil
 }
 
-func (m *mockKeyStore) GetUnused(purpose wallet.KeyChange) ([]int, error) {
+func (m *mockKeyStore) GetUnused(account uint32, purpose wallet.KeyChange) ([]int, error) {
 	var i []int
 	for _, key := range m.keys {
-		if !key.used && key.path.Change == purpose {
+		if !key.used && key.path.Account == account && key.path.Change == purpose {
 			i = append(i, key.path.Index)
 		}
 	}
@@ -228,10 +258,13 @@ func (m *mockKeyStore) GetDbg() string {
 	return ret
 }
 
-func (m *mockKeyStore) GetLookaheadWindows() map[wallet.KeyChange]int {
+func (m *mockKeyStore) GetLookaheadWindows(account uint32) map[wallet.KeyChange]int {
 	internalLastUsed := -1
 	externalLastUsed := -1
 	for _, key := range m.keys {
+		if key.path.Account != account {
+			continue
+		}
 		if key.path.Change == wallet.INTERNAL && key.used && key.path.Index > internalLastUsed {
 			internalLastUsed = key.path.Index
 		}
@@ -242,6 +275,9 @@ func (m *mockKeyStore) GetLookaheadWindows() map[wallet.KeyChange]int {
 	internalUnused := 0
 	externalUnused := 0
 	for _, key := range m.keys {
+		if key.path.Account != account {
+			continue
+		}
 		if key.path.Change == wallet.INTERNAL && !key.used && key.path.Index > internalLastUsed {
 			internalUnused++
 		}
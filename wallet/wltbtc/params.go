@@ -0,0 +1,87 @@
+package wltbtc
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestNet4Params are the chain parameters for Bitcoin testnet4 (BIP94) which
+// btcd does not have yet. Addresses and extended keys are encoded as for
+// testnet3.
+var TestNet4Params = newTestNet4Params()
+
+// SigNetParams are the chain parameters for the default public signet.
+// Addresses and extended keys are encoded as for testnet3.
+var SigNetParams = &chaincfg.SigNetParams
+
+// testNet4GenesisBlock is the testnet4 genesis block from Bitcoin Core.
+var testNet4GenesisBlock = wire.MsgBlock{
+	Header: wire.BlockHeader{
+		Version:    1,
+		PrevBlock:  chainhash.Hash{},
+		MerkleRoot: testNet4GenesisMerkleRoot,
+		Timestamp:  time.Unix(1714777860, 0),
+		Bits:       0x1d00ffff,
+		Nonce:      393743547,
+	},
+	Transactions: []*wire.MsgTx{&testNet4GenesisCoinbase},
+}
+
+var testNet4GenesisCoinbase = wire.MsgTx{
+	Version: 1,
+	TxIn: []*wire.TxIn{{
+		PreviousOutPoint: wire.OutPoint{Index: 0xffffffff},
+		// push 486604799, push 4, push the message
+		SignatureScript: append([]byte{0x04, 0xff, 0xff, 0x00, 0x1d, 0x01, 0x04, 0x4c, 0x4c},
+			"03/May/2024 000000000000000000001ebd58c244970b3aa9d783bb001011fbe8ea8e98e00e"...),
+		Sequence: 0xffffffff,
+	}},
+	TxOut: []*wire.TxOut{{
+		Value: 50 * 1e8,
+		// 33 zero bytes OP_CHECKSIG
+		PkScript: mustDecodeHex("21" +
+			"000000000000000000000000000000000000000000000000000000000000000000" + "ac"),
+	}},
+}
+
+var testNet4GenesisMerkleRoot = mustHashFromStr("7aa0a7ae1e223414cb807e40cd57e667b718e42aaf9306db9102fe28912b7b4e")
+
+var testNet4GenesisHash = mustHashFromStr("00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043")
+
+func newTestNet4Params() *chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "testnet4"
+	params.Net = wire.BitcoinNet(0x283f161c)
+	params.DefaultPort = "48333"
+	params.DNSSeeds = []chaincfg.DNSSeed{
+		{Host: "seed.testnet4.bitcoin.sprovoost.nl", HasFiltering: true},
+		{Host: "seed.testnet4.wiz.biz", HasFiltering: true},
+	}
+	params.GenesisBlock = &testNet4GenesisBlock
+	params.GenesisHash = &testNet4GenesisHash
+	params.BIP0034Height = 1
+	params.BIP0065Height = 1
+	params.BIP0066Height = 1
+	params.Checkpoints = nil
+	return &params
+}
+
+func mustHashFromStr(s string) chainhash.Hash {
+	hash, err := chainhash.NewHashFromStr(s)
+	if err != nil {
+		panic(err)
+	}
+	return *hash
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,44 @@
+package wltbtc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestTestNet4Params(t *testing.T) {
+	genesis := TestNet4Params.GenesisBlock
+	if genesis.Transactions[0].TxHash() != genesis.Header.MerkleRoot {
+		t.Fatal("bad genesis merkle root")
+	}
+	if genesis.BlockHash() != *TestNet4Params.GenesisHash {
+		t.Fatalf("bad genesis hash %s", genesis.BlockHash())
+	}
+}
+
+func TestTestNetAddresses(t *testing.T) {
+	seed := make([]byte, 32)
+	for _, params := range []*chaincfg.Params{TestNet4Params, SigNetParams} {
+		master, err := hdkeychain.NewMaster(seed, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := master.ECPubKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pub.SerializeCompressed()), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := btcutil.DecodeAddress(addr.String(), params)
+		if err != nil {
+			t.Fatalf("%s: %v", params.Name, err)
+		}
+		if !decoded.IsForNet(params) || addr.String()[:3] != "tb1" {
+			t.Fatalf("%s: bad address %s", params.Name, addr)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package wltbtc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BIP174 PSBT creation and signing. The PSBTs carry the prevouts, redeem
+// scripts and BIP32 derivations that Bitcoin Core and hardware wallets need to
+// sign; BIP371 taproot fields for a SchemeBip86 wallet.
+
+// CreatePsbt funds a PSBT paying the outputs from the coins of an account.
+// Change goes to an address of the account. It returns the change output index
+// or -1 if there is no change.
+func (w *BtcElectrumWallet) CreatePsbt(
+	account uint32,
+	outputs []wallet.TransactionOutput,
+	feeLevel wallet.FeeLevel) (*psbt.Packet, int, error) {
+
+	if !w.keyManager.hasAccount(account) {
+		return nil, 0, wallet.ErrUnknownAccount
+	}
+	authoredTx, prevOuts, err := w.authorTx(account, outputs, feeLevel, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	packet, err := psbt.NewFromUnsignedTx(authoredTx.Tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		op := txIn.PreviousOutPoint
+		prevOut := prevOuts[op]
+		pInput := &packet.Inputs[i]
+		// Segwit v0 signers also want the whole previous tx to check the
+		// input value, see bitcoin/bitcoin#19215.
+		txn, err := w.txstore.Txns().Get(op.Hash.String())
+		if err == nil && len(txn.Bytes) > 0 {
+			prevTx := wire.NewMsgTx(wire.TxVersion)
+			if err := prevTx.Deserialize(bytes.NewReader(txn.Bytes)); err == nil {
+				pInput.NonWitnessUtxo = prevTx
+			}
+		}
+		if txscript.GetScriptClass(prevOut.PkScript) != txscript.PubKeyHashTy {
+			pInput.WitnessUtxo = prevOut
+		}
+		if pInput.NonWitnessUtxo == nil && pInput.WitnessUtxo == nil {
+			return nil, 0, fmt.Errorf("no previous tx for input %s", op)
+		}
+		k, ok := w.walletKey(prevOut.PkScript)
+		if !ok {
+			return nil, 0, fmt.Errorf("input %s is not a wallet coin", op)
+		}
+		err = w.updatePsbtInput(pInput, prevOut.PkScript, k)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	// The change output derivation lets a signer check the change is its
+	// own.
+	for i, txOut := range packet.UnsignedTx.TxOut {
+		k, ok := w.walletKey(txOut.PkScript)
+		if !ok {
+			continue
+		}
+		err = w.updatePsbtOutput(&packet.Outputs[i], txOut.PkScript, k)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return packet, authoredTx.ChangeIndex, nil
+}
+
+// SignPsbt signs the inputs of the PSBT that spend wallet coins and adds their
+// BIP32 derivations. Finalized inputs and inputs of other wallets are skipped.
+// Inputs asking for a sighash type other than SIGHASH_ALL, or SIGHASH_DEFAULT
+// for taproot, are refused unless opts allow any sighash. It returns the number
+// of inputs signed.
+func (w *BtcElectrumWallet) SignPsbt(pw string, packet *psbt.Packet, opts *wallet.PsbtSignOptions) (int, error) {
+	if ok := w.storageManager.IsValidPw(pw); !ok {
+		return 0, errors.New("invalid password")
+	}
+	if w.getSigner() == nil {
+		return 0, wallet.ErrWatchOnly
+	}
+	tx := packet.UnsignedTx
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	allPrevOuts := true
+	for i, txIn := range tx.TxIn {
+		prevOuts[i] = psbtPrevOut(packet, i)
+		if prevOuts[i] == nil {
+			allPrevOuts = false
+			continue
+		}
+		prevOutFetcher.AddPrevOut(txIn.PreviousOutPoint, prevOuts[i])
+	}
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return 0, err
+	}
+
+	var signed int
+	for i := range tx.TxIn {
+		pInput := &packet.Inputs[i]
+		prevOut := prevOuts[i]
+		if prevOut == nil || pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil {
+			continue
+		}
+		k, ok := w.walletKey(prevOut.PkScript)
+		if !ok {
+			continue
+		}
+		scriptClass := txscript.GetScriptClass(prevOut.PkScript)
+		hashType := txscript.SigHashAll
+		if scriptClass == txscript.WitnessV1TaprootTy {
+			hashType = txscript.SigHashDefault
+		}
+		if pInput.SighashType != 0 {
+			hashType = pInput.SighashType
+		}
+		if !sighashAllowed(hashType, scriptClass, opts) {
+			return 0, fmt.Errorf("%w: input %d sighash %v", wallet.ErrPsbtSighash, i, hashType)
+		}
+		if err := w.updatePsbtInput(pInput, prevOut.PkScript, k); err != nil {
+			return 0, err
+		}
+		pubKey := k.pubKey.SerializeCompressed()
+		switch scriptClass {
+		case txscript.WitnessV0PubKeyHashTy, txscript.PubKeyHashTy:
+			sig, err := w.inputSignature(tx, i, k, nil, hashType, prevOutFetcher)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := updater.Sign(i, sig, pubKey, nil, nil); err != nil {
+				return 0, err
+			}
+		case txscript.ScriptHashTy:
+			// BIP49 P2SH-P2WPKH
+			redeemScript := pInput.RedeemScript
+			sig, err := w.inputSignature(tx, i, k, redeemScript, hashType, prevOutFetcher)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := updater.Sign(i, sig, pubKey, redeemScript, nil); err != nil {
+				return 0, err
+			}
+		case txscript.WitnessV1TaprootTy:
+			// BIP86 key path spend. The sighash commits to every
+			// prevout.
+			if !allPrevOuts {
+				return 0, errors.New("taproot signing needs the prevouts of every input")
+			}
+			sig, err := w.inputSignature(tx, i, k, nil, hashType, prevOutFetcher)
+			if err != nil {
+				return 0, err
+			}
+			pInput.TaprootKeySpendSig = sig
+		default:
+			continue
+		}
+		signed++
+	}
+	return signed, nil
+}
+
+// sighashAllowed returns true if an input may be signed with hashType. Only
+// SIGHASH_ALL, or SIGHASH_DEFAULT for taproot, commit to the whole transaction.
+func sighashAllowed(hashType txscript.SigHashType, scriptClass txscript.ScriptClass, opts *wallet.PsbtSignOptions) bool {
+	if opts != nil && opts.AllowAnySighash {
+		return true
+	}
+	switch hashType {
+	case txscript.SigHashAll:
+		return true
+	case txscript.SigHashDefault:
+		return scriptClass == txscript.WitnessV1TaprootTy
+	}
+	return false
+}
+
+// psbtPrevOut returns the output spent by a PSBT input or nil if the input has
+// no utxo.
+func psbtPrevOut(packet *psbt.Packet, idx int) *wire.TxOut {
+	pInput := packet.Inputs[idx]
+	if pInput.WitnessUtxo != nil {
+		return pInput.WitnessUtxo
+	}
+	if pInput.NonWitnessUtxo != nil {
+		op := packet.UnsignedTx.TxIn[idx].PreviousOutPoint
+		if pInput.NonWitnessUtxo.TxHash() != op.Hash ||
+			op.Index >= uint32(len(pInput.NonWitnessUtxo.TxOut)) {
+			return nil
+		}
+		return pInput.NonWitnessUtxo.TxOut[op.Index]
+	}
+	return nil
+}
+
+// keyDerivation returns the BIP32 master key fingerprint and the full key path
+// of a wallet key. ok is false for a WatchOnly wallet made without a key
+// origin.
+func (w *BtcElectrumWallet) keyDerivation(keyPath wallet.KeyPath) (uint32, []uint32, bool, error) {
+	store := w.storageManager.store
+	var fingerprint uint32
+	var accountPath []uint32
+	if store.WatchOnly {
+		if len(store.AccountPath) == 0 {
+			return 0, nil, false, nil
+		}
+		fingerprint = store.MasterFingerprint
+		accountPath = store.AccountPath
+	} else {
+		masterPubKey, err := hdkeychain.NewKeyFromString(store.Xpub)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		pubKey, err := masterPubKey.ECPubKey()
+		if err != nil {
+			return 0, nil, false, err
+		}
+		fingerprint = binary.LittleEndian.Uint32(btcutil.Hash160(pubKey.SerializeCompressed())[:4])
+		purpose, err := store.Scheme.Purpose()
+		if err != nil {
+			return 0, nil, false, err
+		}
+		accountPath = []uint32{
+			hdkeychain.HardenedKeyStart + purpose,
+			hdkeychain.HardenedKeyStart + store.HDCoinType,
+			hdkeychain.HardenedKeyStart + keyPath.Account,
+		}
+	}
+	path := append(append([]uint32{}, accountPath...), uint32(keyPath.Change), uint32(keyPath.Index))
+	return fingerprint, path, true, nil
+}
+
+// updatePsbtInput adds the redeem script, taproot internal key and BIP32
+// derivation of a wallet input.
+func (w *BtcElectrumWallet) updatePsbtInput(pInput *psbt.PInput, pkScript []byte, k *walletKey) error {
+	fingerprint, path, ok, err := w.keyDerivation(k.keyPath)
+	if err != nil {
+		return err
+	}
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.WitnessV1TaprootTy:
+		internalKey := schnorr.SerializePubKey(k.pubKey)
+		pInput.TaprootInternalKey = internalKey
+		if ok && !hasTaprootDerivation(pInput.TaprootBip32Derivation, internalKey) {
+			pInput.TaprootBip32Derivation = append(pInput.TaprootBip32Derivation,
+				&psbt.TaprootBip32Derivation{
+					XOnlyPubKey:          internalKey,
+					MasterKeyFingerprint: fingerprint,
+					Bip32Path:            path,
+				})
+		}
+		return nil
+	case txscript.ScriptHashTy:
+		redeemScript, err := p2wpkhScript(btcutil.Hash160(k.pubKey.SerializeCompressed()))
+		if err != nil {
+			return err
+		}
+		pInput.RedeemScript = redeemScript
+	}
+	pubKey := k.pubKey.SerializeCompressed()
+	if ok && !hasDerivation(pInput.Bip32Derivation, pubKey) {
+		pInput.Bip32Derivation = append(pInput.Bip32Derivation,
+			&psbt.Bip32Derivation{
+				PubKey:               pubKey,
+				MasterKeyFingerprint: fingerprint,
+				Bip32Path:            path,
+			})
+	}
+	return nil
+}
+
+// updatePsbtOutput adds the redeem script, taproot internal key and BIP32
+// derivation of an output paying the wallet.
+func (w *BtcElectrumWallet) updatePsbtOutput(pOutput *psbt.POutput, pkScript []byte, k *walletKey) error {
+	fingerprint, path, ok, err := w.keyDerivation(k.keyPath)
+	if err != nil {
+		return err
+	}
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.WitnessV1TaprootTy:
+		internalKey := schnorr.SerializePubKey(k.pubKey)
+		pOutput.TaprootInternalKey = internalKey
+		if ok && !hasTaprootDerivation(pOutput.TaprootBip32Derivation, internalKey) {
+			pOutput.TaprootBip32Derivation = append(pOutput.TaprootBip32Derivation,
+				&psbt.TaprootBip32Derivation{
+					XOnlyPubKey:          internalKey,
+					MasterKeyFingerprint: fingerprint,
+					Bip32Path:            path,
+				})
+		}
+		return nil
+	case txscript.ScriptHashTy:
+		redeemScript, err := p2wpkhScript(btcutil.Hash160(k.pubKey.SerializeCompressed()))
+		if err != nil {
+			return err
+		}
+		pOutput.RedeemScript = redeemScript
+	}
+	pubKey := k.pubKey.SerializeCompressed()
+	if ok && !hasDerivation(pOutput.Bip32Derivation, pubKey) {
+		pOutput.Bip32Derivation = append(pOutput.Bip32Derivation,
+			&psbt.Bip32Derivation{
+				PubKey:               pubKey,
+				MasterKeyFingerprint: fingerprint,
+				Bip32Path:            path,
+			})
+	}
+	return nil
+}
+
+func hasDerivation(derivations []*psbt.Bip32Derivation, pubKey []byte) bool {
+	for _, d := range derivations {
+		if bytes.Equal(d.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTaprootDerivation(derivations []*psbt.TaprootBip32Derivation, xOnlyPubKey []byte) bool {
+	for _, d := range derivations {
+		if bytes.Equal(d.XOnlyPubKey, xOnlyPubKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,360 @@
+package wltbtc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// putPrevTx stores a confirmed tx paying value to an unused address of the
+// default account and its utxo.
+func putPrevTx(t *testing.T, w *BtcElectrumWallet, value int64) wallet.Utxo {
+	t.Helper()
+	address, err := w.GetUnusedAddress(wallet.RECEIVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.MarkAddressUsed(address); err != nil {
+		t.Fatal(err)
+	}
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(value, script))
+	var b bytes.Buffer
+	if err := prevTx.Serialize(&b); err != nil {
+		t.Fatal(err)
+	}
+	txid := prevTx.TxHash()
+	if err := w.txstore.Txns().Put(b.Bytes(), txid.String(), value, 400, time.Now(), false); err != nil {
+		t.Fatal(err)
+	}
+	utxo := wallet.Utxo{
+		Op:           *wire.NewOutPoint(&txid, 0),
+		ScriptPubkey: script,
+		AtHeight:     400,
+		Value:        value,
+	}
+	if err := w.txstore.Utxos().Put(utxo); err != nil {
+		t.Fatal(err)
+	}
+	return utxo
+}
+
+// verifyTx runs the script of every input of tx.
+func verifyTx(t *testing.T, tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) {
+	t.Helper()
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+	for i, txIn := range tx.TxIn {
+		prevOut := prevOuts[txIn.PreviousOutPoint]
+		e, err := txscript.NewEngine(prevOut.PkScript, tx, i, txscript.StandardVerifyFlags,
+			nil, sigHashes, prevOut.Value, prevOutFetcher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Execute(); err != nil {
+			t.Fatalf("input %d: %v", i, err)
+		}
+	}
+}
+
+// masterFingerprint is the BIP32 fingerprint of a master key.
+func masterFingerprint(t *testing.T, master *hdkeychain.ExtendedKey) uint32 {
+	t.Helper()
+	pubKey, err := master.ECPubKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return binary.LittleEndian.Uint32(btcutil.Hash160(pubKey.SerializeCompressed())[:4])
+}
+
+func TestPsbtCreateSignFinalize(t *testing.T) {
+	payee, err := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scheme := range []wallet.AddressScheme{wallet.SchemeBip44, wallet.SchemeBip49,
+		wallet.SchemeBip84, wallet.SchemeBip86} {
+
+		w := MockSchemeWallet(pw, scheme)
+		masterPubKey, err := hdkeychain.NewKeyFromString(w.storageManager.store.Xpub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fingerprint := masterFingerprint(t, masterPubKey)
+		w.blockchainTip = 500
+		utxo := putPrevTx(t, w, 100_000_000)
+		outputs := []wallet.TransactionOutput{{Address: payee, Value: 50_000_000}}
+		packet, changeIndex, err := w.CreatePsbt(wallet.DefaultAccount, outputs, wallet.NORMAL)
+		if err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		if len(packet.Inputs) != 1 || len(packet.Outputs) != 2 || changeIndex < 0 {
+			t.Fatalf("%s: unexpected psbt", scheme)
+		}
+		pInput := packet.Inputs[0]
+		if pInput.NonWitnessUtxo == nil {
+			t.Fatalf("%s: no previous tx", scheme)
+		}
+		if scheme != wallet.SchemeBip44 && pInput.WitnessUtxo == nil {
+			t.Fatalf("%s: no witness utxo", scheme)
+		}
+		purpose, _ := scheme.Purpose()
+		wantPath := []uint32{
+			hdkeychain.HardenedKeyStart + purpose,
+			hdkeychain.HardenedKeyStart + wallet.HDCoinTypeLegacy,
+			hdkeychain.HardenedKeyStart + wallet.DefaultAccount,
+			uint32(wallet.EXTERNAL), 0,
+		}
+		var gotFingerprint uint32
+		var gotPath []uint32
+		switch scheme {
+		case wallet.SchemeBip86:
+			if len(pInput.TaprootBip32Derivation) != 1 || len(pInput.TaprootInternalKey) != 32 {
+				t.Fatalf("%s: no taproot derivation", scheme)
+			}
+			gotFingerprint = pInput.TaprootBip32Derivation[0].MasterKeyFingerprint
+			gotPath = pInput.TaprootBip32Derivation[0].Bip32Path
+			if len(packet.Outputs[changeIndex].TaprootBip32Derivation) != 1 {
+				t.Fatalf("%s: no change derivation", scheme)
+			}
+		default:
+			if len(pInput.Bip32Derivation) != 1 {
+				t.Fatalf("%s: no derivation", scheme)
+			}
+			gotFingerprint = pInput.Bip32Derivation[0].MasterKeyFingerprint
+			gotPath = pInput.Bip32Derivation[0].Bip32Path
+			if len(packet.Outputs[changeIndex].Bip32Derivation) != 1 {
+				t.Fatalf("%s: no change derivation", scheme)
+			}
+		}
+		if scheme == wallet.SchemeBip49 && pInput.RedeemScript == nil {
+			t.Fatalf("%s: no redeem script", scheme)
+		}
+		if gotFingerprint != fingerprint {
+			t.Fatalf("%s: got fingerprint %08x, want %08x", scheme, gotFingerprint, fingerprint)
+		}
+		if fmt.Sprint(gotPath) != fmt.Sprint(wantPath) {
+			t.Fatalf("%s: got path %v, want %v", scheme, gotPath, wantPath)
+		}
+
+		// round trip as base64 like another signer would
+		b64, err := packet.B64Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		packet, err = psbt.NewFromRawBytes(bytes.NewReader([]byte(b64)), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wallet.FinalizePsbt(packet); !errors.Is(err, wallet.ErrPsbtIncomplete) {
+			t.Fatalf("%s: expected %v got %v", scheme, wallet.ErrPsbtIncomplete, err)
+		}
+		if _, err := w.SignPsbt("bad", packet, nil); err == nil {
+			t.Fatalf("%s: expected invalid password error", scheme)
+		}
+		signed, err := w.SignPsbt(pw, packet, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		if signed != 1 {
+			t.Fatalf("%s: signed %d inputs", scheme, signed)
+		}
+		if err := wallet.FinalizePsbt(packet); err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		tx, err := wallet.ExtractPsbtTx(packet)
+		if err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		verifyTx(t, tx, map[wire.OutPoint]*wire.TxOut{
+			utxo.Op: wire.NewTxOut(utxo.Value, utxo.ScriptPubkey),
+		})
+	}
+}
+
+func TestPsbtWatchOnlyOfflineSigner(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	signer := MockSchemeWallet(pw, wallet.SchemeBip84)
+
+	// the watch-only wallet has the signer's account key and key origin
+	master, err := hdkeychain.NewMaster(makeRegtestSeed(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountKey := master
+	for _, i := range []uint32{84, wallet.HDCoinTypeLegacy, wallet.DefaultAccount} {
+		accountKey, err = accountKey.Derive(hdkeychain.HardenedKeyStart + i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	accountPubKey, err := accountKey.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountPubKey, err = accountPubKey.CloneWithVersion(vpubVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := masterFingerprint(t, master)
+	var fp [4]byte
+	binary.LittleEndian.PutUint32(fp[:], fingerprint)
+	xpub := fmt.Sprintf("[%s/84'/0h/0']%s", hex.EncodeToString(fp[:]), accountPubKey)
+
+	if _, err := NewWatchOnlyElectrumWallet(watchOnlyConfig(), pw, "[00/84'/0'/0']"+accountPubKey.String()); !errors.Is(err, ErrKeyOrigin) {
+		t.Fatalf("expected %v got %v", ErrKeyOrigin, err)
+	}
+	watcher, err := NewWatchOnlyElectrumWallet(watchOnlyConfig(), pw, xpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher.blockchainTip = 500
+	utxo := putPrevTx(t, watcher, 100_000_000)
+
+	payee, err := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := []wallet.TransactionOutput{{Address: payee, Value: 50_000_000}}
+	packet, _, err := watcher.CreatePsbt(wallet.DefaultAccount, outputs, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	derivation := packet.Inputs[0].Bip32Derivation
+	if len(derivation) != 1 || derivation[0].MasterKeyFingerprint != fingerprint {
+		t.Fatal("no key origin derivation")
+	}
+	if _, err := watcher.SignPsbt(pw, packet, nil); !errors.Is(err, wallet.ErrWatchOnly) {
+		t.Fatalf("expected %v got %v", wallet.ErrWatchOnly, err)
+	}
+
+	// the offline signer signs a copy and the copies are combined
+	var b bytes.Buffer
+	if err := packet.Serialize(&b); err != nil {
+		t.Fatal(err)
+	}
+	signerPacket, err := psbt.NewFromRawBytes(&b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.SignPsbt(pw, signerPacket, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed != 1 {
+		t.Fatalf("signed %d inputs", signed)
+	}
+	combined, err := wallet.CombinePsbt(packet, signerPacket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packet.Inputs[0].PartialSigs) != 0 {
+		t.Fatal("combine changed a packet")
+	}
+	if len(combined.Inputs[0].PartialSigs) != 1 {
+		t.Fatal("signature not combined")
+	}
+	if err := wallet.FinalizePsbt(combined); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := wallet.ExtractPsbtTx(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, tx, map[wire.OutPoint]*wire.TxOut{
+		utxo.Op: wire.NewTxOut(utxo.Value, utxo.ScriptPubkey),
+	})
+
+	// a psbt of another tx
+	other, _, err := watcher.CreatePsbt(wallet.DefaultAccount,
+		[]wallet.TransactionOutput{{Address: payee, Value: 40_000_000}}, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wallet.CombinePsbt(packet, other); !errors.Is(err, wallet.ErrPsbtMismatch) {
+		t.Fatalf("expected %v got %v", wallet.ErrPsbtMismatch, err)
+	}
+}
+
+// The taproot internal key of a BIP86 input is the untweaked wallet key.
+func TestPsbtTaprootInternalKey(t *testing.T) {
+	w := MockSchemeWallet(pw, wallet.SchemeBip86)
+	w.blockchainTip = 500
+	utxo := putPrevTx(t, w, 100_000_000)
+	payee, _ := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", &chaincfg.RegressionNetParams)
+	packet, _, err := w.CreatePsbt(wallet.DefaultAccount,
+		[]wallet.TransactionOutput{{Address: payee, Value: 50_000_000}}, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internalKey, err := schnorr.ParsePubKey(packet.Inputs[0].TaprootInternalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputKey := txscript.ComputeTaprootKeyNoScript(internalKey)
+	if !bytes.Equal(utxo.ScriptPubkey[2:], schnorr.SerializePubKey(outputKey)) {
+		t.Fatal("internal key does not tweak to the output key")
+	}
+}
+
+// Inputs asking for a sighash type that does not commit to the whole tx are
+// only signed if the caller allows it.
+func TestPsbtSighashType(t *testing.T) {
+	payee, _ := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", &chaincfg.RegressionNetParams)
+	tests := []struct {
+		scheme   wallet.AddressScheme
+		hashType txscript.SigHashType
+		allowed  bool
+	}{
+		{wallet.SchemeBip84, txscript.SigHashAll, true},
+		{wallet.SchemeBip84, txscript.SigHashNone, false},
+		{wallet.SchemeBip84, txscript.SigHashSingle | txscript.SigHashAnyOneCanPay, false},
+		{wallet.SchemeBip86, txscript.SigHashDefault, true},
+		{wallet.SchemeBip86, txscript.SigHashAll, true},
+		{wallet.SchemeBip86, txscript.SigHashNone, false},
+	}
+	for _, tt := range tests {
+		w := MockSchemeWallet(pw, tt.scheme)
+		w.blockchainTip = 500
+		putPrevTx(t, w, 100_000_000)
+		outputs := []wallet.TransactionOutput{{Address: payee, Value: 50_000_000}}
+		packet, _, err := w.CreatePsbt(wallet.DefaultAccount, outputs, wallet.NORMAL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packet.Inputs[0].SighashType = tt.hashType
+		signed, err := w.SignPsbt(pw, packet, nil)
+		if tt.allowed {
+			if err != nil || signed != 1 {
+				t.Fatalf("%s %v: signed %d: %v", tt.scheme, tt.hashType, signed, err)
+			}
+			continue
+		}
+		if !errors.Is(err, wallet.ErrPsbtSighash) {
+			t.Fatalf("%s %v: expected %v got %v", tt.scheme, tt.hashType, wallet.ErrPsbtSighash, err)
+		}
+		signed, err = w.SignPsbt(pw, packet, &wallet.PsbtSignOptions{AllowAnySighash: true})
+		if err != nil || signed != 1 {
+			t.Fatalf("%s %v: signed %d: %v", tt.scheme, tt.hashType, signed, err)
+		}
+	}
+}
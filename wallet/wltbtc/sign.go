@@ -8,6 +8,8 @@ import (
 	"os"
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
@@ -19,6 +21,9 @@ func (w *BtcElectrumWallet) SignTx(pw string, info *wallet.SigningInfo) ([]byte,
 	if ok := w.storageManager.IsValidPw(pw); !ok {
 		return nil, errors.New("invalid password")
 	}
+	if w.getSigner() == nil {
+		return nil, wallet.ErrWatchOnly
+	}
 	// Note: maybe change this for future CPFP logic, tricky!
 	confirmedUtxos, err := w.ListConfirmedUnspent()
 	validConfirmedUtxo := func(op wire.OutPoint) (*wallet.Utxo, bool) {
@@ -33,69 +38,41 @@ func (w *BtcElectrumWallet) SignTx(pw string, info *wallet.SigningInfo) ([]byte,
 		return nil, err
 	}
 	tx := info.UnsignedTx
-	prevOutFetcher := new(txscript.CannedPrevOutputFetcher)
+	// The taproot sighash commits to every prevout of the tx so all must be
+	// known before signing.
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	utxos := make([]*wallet.Utxo, len(tx.TxIn))
 	for idx, input := range tx.TxIn {
 		op := input.PreviousOutPoint
 		utxo, valid := validConfirmedUtxo(op)
 		if !valid {
 			return nil, fmt.Errorf("outpoint %s is not valid (maybe not confirmed?)", op.String())
 		}
-		pkScript, err := txscript.ParsePkScript(utxo.ScriptPubkey)
-		if err != nil {
-			return nil, err
-		}
-		address, err := pkScript.Address(w.params)
-		if err != nil {
-			return nil, err
-		}
-		key, err := w.txstore.keyManager.GetKeyForScript(address.ScriptAddress())
-		if err != nil {
-			return nil, err
+		prevOutFetcher.AddPrevOut(op, wire.NewTxOut(utxo.Value, utxo.ScriptPubkey))
+		utxos[idx] = utxo
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+	for idx := range tx.TxIn {
+		utxo := utxos[idx]
+		prevOut := wire.NewTxOut(utxo.Value, utxo.ScriptPubkey)
+		if txscript.GetScriptClass(prevOut.PkScript) == txscript.WitnessV0ScriptHashTy {
+			return nil, errors.New("signing P2WSH not (yet) supported")
 		}
-		defer key.Zero()
-		privKey, err := key.ECPrivKey()
+		err = w.signInput(tx, idx, prevOut, prevOutFetcher)
 		if err != nil {
 			return nil, err
 		}
-		sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
-		prevOutScriptTy := txscript.GetScriptClass(pkScript.Script())
-		switch prevOutScriptTy {
-		case txscript.WitnessV0ScriptHashTy:
-			return nil, errors.New("signing P2WSH not (yet) supported")
-		case txscript.WitnessV0PubKeyHashTy:
-			sig, err := txscript.WitnessSignature(tx, sigHashes, idx, utxo.Value,
-				pkScript.Script(), txscript.SigHashAll, privKey, true)
-			if err != nil {
-				return nil, err
-			}
-			// add witness
-			input.SignatureScript = nil
-			input.Witness = append(input.Witness, sig...)
-		case txscript.PubKeyHashTy:
-			// note we do not really support P2PK for outbound txs
-			sig, err := txscript.SignatureScript(tx, idx,
-				pkScript.Script(), txscript.SigHashAll, privKey, true)
-			if err != nil {
-				return nil, err
-			}
-			// add script sig
-			input.SignatureScript = append(input.SignatureScript, sig...)
-			input.Witness = nil
-		default:
-			return nil, fmt.Errorf("signing for script type %v unsupported",
-				prevOutScriptTy)
-		}
 		if info.VerifyTx {
 			e, err := txscript.NewDebugEngine(
 				// pubkey script
-				pkScript.Script(),
+				utxo.ScriptPubkey,
 				// refund transaction
 				tx,
 				// transaction input index
 				idx,
 				txscript.StandardVerifyFlags,
 				txscript.NewSigCache(10),
-				txscript.NewTxSigHashes(tx, prevOutFetcher),
+				sigHashes,
 				utxo.Value,
 				prevOutFetcher,
 				nil)
@@ -120,6 +97,31 @@ func (w *BtcElectrumWallet) SignTx(pw string, info *wallet.SigningInfo) ([]byte,
 	return txBytes, nil
 }
 
+// signNestedP2WPKH signs input idx spending a P2SH-P2WPKH output of pkScript.
+// It returns the signature script pushing the redeem script and the witness.
+func signNestedP2WPKH(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, idx int,
+	value int64, pkScript []byte, privKey *btcec.PrivateKey) ([]byte, wire.TxWitness, error) {
+
+	pkHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	redeemScript, err := p2wpkhScript(pkHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(pkScript[2:22], btcutil.Hash160(redeemScript)) {
+		return nil, nil, errors.New("P2SH output is not nested P2WPKH for the key")
+	}
+	witness, err := txscript.WitnessSignature(tx, sigHashes, idx, value,
+		redeemScript, txscript.SigHashAll, privKey, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sigScript, witness, nil
+}
+
 func stepDebugScript(e *txscript.Engine) {
 	fmt.Println("Script 0")
 	fmt.Println(e.DisasmScript(0))
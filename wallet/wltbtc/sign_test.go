@@ -3,9 +3,11 @@ package wltbtc
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"testing"
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
 
@@ -127,3 +129,43 @@ func TestSignTx(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestSignTxTaproot(t *testing.T) {
+	w := MockSchemeWallet("abc", wallet.SchemeBip86)
+	w.blockchainTip = 500
+	ours, err := w.GetAddress(&wallet.KeyPath{Change: wallet.EXTERNAL, Index: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.PayToAddrScript(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !txscript.IsPayToTaproot(script) {
+		t.Fatalf("not a taproot address %s", ours)
+	}
+
+	// two inputs so the sighash commits to more than one prevout
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i, value := range []int64{200000000, 100000000} {
+		op, _ := wire.NewOutPointFromString(fmt.Sprintf("01d36086d9851e7ebfdc4bb08be7870145cfb3e1272d89ad2cd0301990533af8:%d", i))
+		err = w.txstore.Utxos().Put(wallet.Utxo{Op: *op, Value: value, AtHeight: 208, ScriptPubkey: script})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.AddTxIn(wire.NewTxIn(op, nil, nil))
+	}
+	payTo, _ := hex.DecodeString("00148a94b43c8ab88812884b1f9aa5b9b8fdc0839fb3")
+	tx.AddTxOut(wire.NewTxOut(299990000, payTo))
+
+	// VerifyTx panics for a bad signature
+	_, err = w.SignTx("abc", &wallet.SigningInfo{UnsignedTx: tx, VerifyTx: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range tx.TxIn {
+		if len(in.SignatureScript) != 0 || len(in.Witness) != 1 || len(in.Witness[0]) != 64 {
+			t.Fatal("not a key path spend")
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package wltbtc
+
+import (
+	"fmt"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// The wallet signs through a wallet.Signer. It makes the sighashes and input
+// scripts itself and only asks the Signer for signatures, which it checks
+// against its own public keys before using them.
+
+// hdSigner is the in process wallet.Signer of a wallet made from a seed. It
+// signs with the private keys of the KeyManager.
+type hdSigner struct {
+	km *KeyManager
+}
+
+var _ = wallet.Signer(&hdSigner{})
+
+func (s *hdSigner) SignECDSA(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	hash, err := input.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := s.privKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer privKey.Zero()
+	return ecdsa.Sign(privKey, hash).Serialize(), nil
+}
+
+func (s *hdSigner) SignSchnorr(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	hash, err := input.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := s.privKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer privKey.Zero()
+	tweakedKey := txscript.TweakTaprootPrivKey(*privKey, nil)
+	defer tweakedKey.Zero()
+	sig, err := schnorr.Sign(tweakedKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+func (s *hdSigner) privKey(keyPath wallet.KeyPath) (*btcec.PrivateKey, error) {
+	key, err := s.km.generateChildKey(keyPath.Account, keyPath.Change, uint32(keyPath.Index))
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	return key.ECPrivKey()
+}
+
+// SetSigner sets the signer of the wallet's transactions and PSBTs, for
+// example a wallet.RemoteSigner for a WatchOnly wallet. A nil signer makes the
+// wallet unable to sign.
+func (w *BtcElectrumWallet) SetSigner(signer wallet.Signer) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.signer = signer
+}
+
+// getSigner returns the signer of the wallet or nil if it cannot sign.
+func (w *BtcElectrumWallet) getSigner() wallet.Signer {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.signer
+}
+
+// walletKey is the key path and public key of a wallet script.
+type walletKey struct {
+	keyPath wallet.KeyPath
+	pubKey  *btcec.PublicKey
+}
+
+// walletKey returns the wallet key paying to pkScript. ok is false if the
+// script is not the wallet's.
+func (w *BtcElectrumWallet) walletKey(pkScript []byte) (*walletKey, bool) {
+	address, err := w.ScriptToAddress(pkScript)
+	if err != nil {
+		return nil, false
+	}
+	keyPath, err := w.keyManager.GetPathForScript(address.ScriptAddress())
+	if err != nil {
+		return nil, false
+	}
+	key, err := w.keyManager.generateChildKey(keyPath.Account, keyPath.Change, uint32(keyPath.Index))
+	if err != nil {
+		return nil, false
+	}
+	pubKey, err := key.ECPubKey()
+	key.Zero()
+	if err != nil {
+		return nil, false
+	}
+	return &walletKey{keyPath: keyPath, pubKey: pubKey}, true
+}
+
+// signInput signs input idx of tx spending prevOut, a wallet output, and sets
+// its signature script and witness.
+func (w *BtcElectrumWallet) signInput(tx *wire.MsgTx, idx int, prevOut *wire.TxOut,
+	prevOutFetcher txscript.PrevOutputFetcher) error {
+
+	k, ok := w.walletKey(prevOut.PkScript)
+	if !ok {
+		return fmt.Errorf("input %s is not a wallet coin", tx.TxIn[idx].PreviousOutPoint)
+	}
+	txIn := tx.TxIn[idx]
+	pubKey := k.pubKey.SerializeCompressed()
+	switch class := txscript.GetScriptClass(prevOut.PkScript); class {
+	case txscript.WitnessV0PubKeyHashTy:
+		sig, err := w.inputSignature(tx, idx, k, nil, txscript.SigHashAll, prevOutFetcher)
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = nil
+		txIn.Witness = wire.TxWitness{sig, pubKey}
+	case txscript.ScriptHashTy:
+		// only BIP49 P2SH-P2WPKH, the redeem script is the witness
+		// program
+		redeemScript, err := p2wpkhScript(btcutil.Hash160(pubKey))
+		if err != nil {
+			return err
+		}
+		sig, err := w.inputSignature(tx, idx, k, redeemScript, txscript.SigHashAll, prevOutFetcher)
+		if err != nil {
+			return err
+		}
+		sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = sigScript
+		txIn.Witness = wire.TxWitness{sig, pubKey}
+	case txscript.PubKeyHashTy:
+		sig, err := w.inputSignature(tx, idx, k, nil, txscript.SigHashAll, prevOutFetcher)
+		if err != nil {
+			return err
+		}
+		sigScript, err := txscript.NewScriptBuilder().AddData(sig).AddData(pubKey).Script()
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = sigScript
+		txIn.Witness = nil
+	case txscript.WitnessV1TaprootTy:
+		// BIP86 key path spend
+		sig, err := w.inputSignature(tx, idx, k, nil, txscript.SigHashDefault, prevOutFetcher)
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = nil
+		txIn.Witness = wire.TxWitness{sig}
+	default:
+		return fmt.Errorf("signing for script type %v unsupported", class)
+	}
+	return nil
+}
+
+// inputSignature has the wallet's Signer sign input idx of tx with the key k
+// and checks the signature. P2SH inputs are signed for the redeemScript. It
+// returns the signature with its sighash type as it goes in the input
+// scripts.
+func (w *BtcElectrumWallet) inputSignature(tx *wire.MsgTx, idx int, k *walletKey,
+	redeemScript []byte, hashType txscript.SigHashType,
+	prevOutFetcher txscript.PrevOutputFetcher) ([]byte, error) {
+
+	signer := w.getSigner()
+	if signer == nil {
+		return nil, wallet.ErrWatchOnly
+	}
+	input := wallet.NewSigningInput(tx, idx, prevOutFetcher, redeemScript, hashType)
+	hash, err := input.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	if txscript.GetScriptClass(input.PrevOuts[idx].PkScript) == txscript.WitnessV1TaprootTy {
+		return schnorrSignature(signer, k, input, hash)
+	}
+	return ecdsaSignature(signer, k, input, hash)
+}
+
+// ecdsaSignature has the signer sign the input and checks the signature of
+// hash.
+func ecdsaSignature(signer wallet.Signer, k *walletKey, input *wallet.SigningInput, hash []byte) ([]byte, error) {
+	sig, err := signer.SignECDSA(k.keyPath, input)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ecdsa.ParseDERSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.Verify(hash, k.pubKey) {
+		return nil, wallet.ErrBadSignature
+	}
+	return append(sig, byte(input.HashType)), nil
+}
+
+// schnorrSignature has the signer sign the taproot input and checks the
+// signature of hash.
+func schnorrSignature(signer wallet.Signer, k *walletKey, input *wallet.SigningInput, hash []byte) ([]byte, error) {
+	sig, err := signer.SignSchnorr(k.keyPath, input)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := schnorr.ParseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.Verify(hash, txscript.ComputeTaprootKeyNoScript(k.pubKey)) {
+		return nil, wallet.ErrBadSignature
+	}
+	// SigHashDefault signatures have no sighash byte
+	if input.HashType != txscript.SigHashDefault {
+		sig = append(sig, byte(input.HashType))
+	}
+	return sig, nil
+}
@@ -0,0 +1,237 @@
+package wltbtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// serveSigner serves the signer on a unix socket for the test and returns a
+// RemoteSigner for it.
+func serveSigner(t *testing.T, signer wallet.Signer) *wallet.RemoteSigner {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go wallet.ServeSigner(l, signer)
+	return wallet.NewRemoteSigner("unix", path)
+}
+
+// wrongKeySigner signs with a key that is not the wallet's.
+type wrongKeySigner struct {
+	privKey *btcec.PrivateKey
+}
+
+func (s *wrongKeySigner) SignECDSA(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	hash, err := input.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.Sign(s.privKey, hash).Serialize(), nil
+}
+
+func (s *wrongKeySigner) SignSchnorr(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	hash, err := input.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := schnorr.Sign(s.privKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+// payeeSigner signs only inputs of transactions that pay the payee with
+// every spent output known, as a hardware wallet showing the payment would.
+type payeeSigner struct {
+	wallet.Signer
+	payee []byte
+}
+
+func (s *payeeSigner) check(input *wallet.SigningInput) error {
+	for _, prevOut := range input.PrevOuts {
+		if prevOut == nil || prevOut.Value <= 0 {
+			return errors.New("unknown spent output")
+		}
+	}
+	for _, txOut := range input.Tx.TxOut {
+		if bytes.Equal(txOut.PkScript, s.payee) {
+			return nil
+		}
+	}
+	return errors.New("tx does not pay the payee")
+}
+
+func (s *payeeSigner) SignECDSA(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	if err := s.check(input); err != nil {
+		return nil, err
+	}
+	return s.Signer.SignECDSA(keyPath, input)
+}
+
+func (s *payeeSigner) SignSchnorr(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	if err := s.check(input); err != nil {
+		return nil, err
+	}
+	return s.Signer.SignSchnorr(keyPath, input)
+}
+
+func TestRemoteSignerSpend(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	payee, err := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scheme := range []wallet.AddressScheme{wallet.SchemeBip44, wallet.SchemeBip49, wallet.SchemeBip84, wallet.SchemeBip86} {
+		t.Run(string(scheme), func(t *testing.T) {
+			// the signing process has the seed of the mock wallet
+			seedWallet := MockSchemeWallet(pw, scheme)
+			payeeScript, err := txscript.PayToAddrScript(payee)
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote := serveSigner(t, &payeeSigner{seedWallet.signer, payeeScript})
+
+			purpose, err := scheme.Purpose()
+			if err != nil {
+				t.Fatal(err)
+			}
+			accountKey, err := hdkeychain.NewMaster(makeRegtestSeed(), params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, i := range []uint32{purpose, wallet.HDCoinTypeLegacy, wallet.DefaultAccount} {
+				accountKey, err = accountKey.Derive(hdkeychain.HardenedKeyStart + i)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			accountPubKey, err := accountKey.Neuter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := watchOnlyConfig()
+			config.AddressScheme = scheme
+			watcher, err := NewWatchOnlyElectrumWallet(config, pw, accountPubKey.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			watcher.blockchainTip = 500
+			utxo := putPrevTx(t, watcher, 100_000_000)
+			prevOuts := map[wire.OutPoint]*wire.TxOut{
+				utxo.Op: wire.NewTxOut(utxo.Value, utxo.ScriptPubkey),
+			}
+
+			if _, _, err := watcher.Spend(pw, 50_000_000, payee, wallet.NORMAL); !errors.Is(err, wallet.ErrWatchOnly) {
+				t.Fatalf("expected %v got %v", wallet.ErrWatchOnly, err)
+			}
+
+			watcher.SetSigner(remote)
+			_, tx, err := watcher.Spend(pw, 50_000_000, payee, wallet.NORMAL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifyTx(t, tx, prevOuts)
+
+			unsigned := tx.Copy()
+			for _, txIn := range unsigned.TxIn {
+				txIn.SignatureScript = nil
+				txIn.Witness = nil
+			}
+			b, err := watcher.SignTx(pw, &wallet.SigningInfo{UnsignedTx: unsigned})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(b) == 0 {
+				t.Fatal("no signed tx")
+			}
+			verifyTx(t, unsigned, prevOuts)
+
+			// the wallet checks the signatures of the signer
+			watcher.SetSigner(serveSigner(t, &wrongKeySigner{wrongKey}))
+			if _, _, err := watcher.Spend(pw, 50_000_000, payee, wallet.NORMAL); !errors.Is(err, wallet.ErrBadSignature) {
+				t.Fatalf("expected %v got %v", wallet.ErrBadSignature, err)
+			}
+		})
+	}
+}
+
+func TestRemoteSignerError(t *testing.T) {
+	w := MockSchemeWallet(pw, wallet.SchemeBip84)
+	remote := serveSigner(t, w.signer)
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(50_000, make([]byte, 22)))
+	input := &wallet.SigningInput{
+		Tx:       tx,
+		PrevOuts: []*wire.TxOut{wire.NewTxOut(100_000, append([]byte{txscript.OP_0, txscript.OP_DATA_20}, make([]byte, 20)...))},
+		HashType: txscript.SigHashAll,
+	}
+	if _, err := remote.SignECDSA(wallet.KeyPath{Account: 7}, input); err == nil ||
+		err.Error() != "remote signer: "+wallet.ErrUnknownAccount.Error() {
+		t.Fatalf("expected the remote error got %v", err)
+	}
+	// the signing process makes the sighash, it needs the spent output
+	input.PrevOuts = []*wire.TxOut{nil}
+	if _, err := remote.SignECDSA(wallet.KeyPath{}, input); err == nil ||
+		err.Error() != "remote signer: "+wallet.ErrBadSigningInput.Error() {
+		t.Fatalf("expected the remote error got %v", err)
+	}
+	remote = wallet.NewRemoteSigner("unix", filepath.Join(t.TempDir(), "none.sock"))
+	if _, err := remote.SignECDSA(wallet.KeyPath{}, input); err == nil {
+		t.Fatal("expected a dial error")
+	}
+}
+
+// A malformed request gets an error response and the connection is closed.
+func TestServeSignerBadRequest(t *testing.T) {
+	w := MockSchemeWallet(pw, wallet.SchemeBip44)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go wallet.ServeSigner(l, w.signer)
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("{\"id\":1,\"method\":}\n")); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(conn)
+	var resp wallet.SignResponse
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 0 || resp.Error == "" {
+		t.Fatalf("expected an error response got %+v", resp)
+	}
+	if err := dec.Decode(&resp); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the connection closed got %v", err)
+	}
+}
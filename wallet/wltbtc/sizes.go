@@ -1,7 +1,5 @@
 package wltbtc
 
-import "github.com/btcsuite/btcd/wire"
-
 const (
 	// PubKeyLength is the length of a serialized compressed public key.
 	PubKeyLength = 33
@@ -91,6 +89,34 @@ const (
 	RedeemP2WPKHInputTotalSize = RedeemP2WPKHInputSize +
 		(RedeemP2WPKHInputWitnessWeight+(witnessWeight-1))/witnessWeight
 
+	// RedeemNestedP2WPKHSigScriptSize is the size of a transaction input
+	// script that redeems a BIP49 P2SH-P2WPKH output. It is calculated as:
+	//
+	//   - OP_DATA_22
+	//   - 22 bytes P2WPKH redeem script
+	RedeemNestedP2WPKHSigScriptSize = 1 + P2WPKHPkScriptSize // 23
+
+	// RedeemNestedP2WPKHInputSize is the size of a transaction input
+	// redeeming a P2SH-P2WPKH output without the witness data. It is
+	// calculated as:
+	//
+	//   - 32 bytes previous tx
+	//   - 4 bytes output index
+	//   - 4 bytes sequence
+	//   - 1 byte compact int encoding value 23
+	//   - 23 bytes signature script
+	RedeemNestedP2WPKHInputSize = TxInOverhead + 1 + RedeemNestedP2WPKHSigScriptSize // 64
+
+	// RedeemNestedP2WPKHInputTotalSize is the worst case size of a
+	// transaction input redeeming a P2SH-P2WPKH output and the corresponding
+	// witness data.
+	//
+	// 64 vbytes base tx input
+	// 109wu witness = 28 vbytes
+	// total = 92 vbytes
+	RedeemNestedP2WPKHInputTotalSize = RedeemNestedP2WPKHInputSize +
+		(RedeemP2WPKHInputWitnessWeight+(witnessWeight-1))/witnessWeight
+
 	// SigwitMarkerAndFlagWeight is the 2 bytes of overhead witness data
 	// added to every segwit transaction.
 	SegwitMarkerAndFlagWeight = 2
@@ -116,6 +142,40 @@ const (
 	//   - 22 bytes P2PKH output script
 	P2WPKHOutputSize = TxOutOverhead + P2WPKHPkScriptSize // 31
 
+	// P2TRPkScriptSize is the size of a transaction output script that
+	// pays to a taproot output key. It is calculated as:
+	//
+	//   - OP_1
+	//   - OP_DATA_32
+	//   - 32 bytes x-only output key
+	P2TRPkScriptSize = 1 + 1 + 32
+
+	// P2TROutputSize is the serialize size of a transaction output with a
+	// P2TR output script.
+	P2TROutputSize = TxOutOverhead + P2TRPkScriptSize // 43
+
+	// RedeemP2TRInputSize is the size of a transaction input redeeming a
+	// P2TR output on the key path without the witness data. The signature
+	// script is empty.
+	RedeemP2TRInputSize = TxInOverhead + 1 // 41
+
+	// RedeemP2TRInputWitnessWeight is the weight of the witness of a key
+	// path spend with the default sighash type. It is calculated as:
+	//
+	//   - 1 wu compact int encoding value 1 (number of items)
+	//   - 1 wu compact int encoding value 64
+	//   - 64 wu schnorr signature
+	RedeemP2TRInputWitnessWeight = 1 + 1 + 64 // 66
+
+	// RedeemP2TRInputTotalSize is the size of a transaction input redeeming
+	// a P2TR output on the key path and the witness data.
+	//
+	// 41 vbytes base tx input
+	// 66wu witness = 17 vbytes
+	// total = 58 vbytes
+	RedeemP2TRInputTotalSize = RedeemP2TRInputSize +
+		(RedeemP2TRInputWitnessWeight+(witnessWeight-1))/witnessWeight
+
 	// MinimumTxOverhead is the size of an empty transaction.
 	// 4 bytes version + 4 bytes locktime + 2 bytes of varints for the number of
 	// transaction inputs and outputs
@@ -148,22 +208,3 @@ const (
 
 	witnessWeight = 4 // github.com/btcsuite/btcd/blockchain.WitnessScaleFactor
 )
-
-// msgTxVBytes retuns vbytes. Call with MsgTx + the input(s) defined but no output yet
-func msgTxVBytes(msgTx *wire.MsgTx) uint64 {
-	baseSize := msgTx.SerializeSizeStripped()
-	totalSize := msgTx.SerializeSize()
-	txWeight := baseSize*(witnessWeight-1) + totalSize
-	// vbytes is ceil(tx_weight/4)
-	return uint64(txWeight+(witnessWeight-1)) / witnessWeight // +3 before / 4 to round up
-}
-
-// Sweep worst case serial size estimates for fees - segwit
-func txSerialSizeEst(msgTx *wire.MsgTx) int {
-	size := msgTxVBytes(msgTx)
-	// include marker and flag weight.
-	witnessVBytes := uint64(((DefaultSigScriptSize + 2 + 3) / 4))
-	// witnessVBytes := uint64(((DefaultSigScriptSize + 2 + 3) / 4) * len(msgTx.TxIn))
-	size += witnessVBytes + P2WPKHOutputSize
-	return int(size)
-}
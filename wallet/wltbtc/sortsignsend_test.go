@@ -99,7 +99,7 @@ func Test_gatherCoins(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	coins := w.gatherCoins(false)
+	coins := w.gatherCoins(wallet.DefaultAccount, false)
 	for _, coin := range coins {
 		fmt.Println(coin.Hash().String(), coin.Index(), coin.NumConfs(), coin.Value(), coin.PkScript())
 	}
@@ -113,7 +113,7 @@ func Test_gatherCoins(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	coins = w.gatherCoins(false)
+	coins = w.gatherCoins(wallet.DefaultAccount, false)
 	if len(coins) > 0 {
 		t.Fatal("should be no unfrozen coin in map")
 	}
@@ -302,3 +302,98 @@ func Test_newLegacyTransaction(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// A BIP49 wallet spends its P2SH-P2WPKH outputs and its change is P2SH-P2WPKH.
+func Test_newNestedSegwitTransaction(t *testing.T) {
+	w := MockSchemeWallet("abc", wallet.SchemeBip49)
+	w.blockchainTip = 500
+
+	ours, err := w.GetAddress(&wallet.KeyPath{Change: wallet.EXTERNAL, Index: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ours.(*btcutil.AddressScriptHash); !ok {
+		t.Fatalf("unexpected address type %T", ours)
+	}
+	script, err := txscript.PayToAddrScript(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := chainhash.NewHashFromStr("50b636d971e7d4d918d92876d6d53a22ccc960e051f540108056ca4ad6ec080c")
+	err = w.txstore.Utxos().Put(wallet.Utxo{
+		Op:           wire.OutPoint{Hash: *h, Index: 0},
+		ScriptPubkey: script,
+		AtHeight:     421,
+		Value:        10030000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address, err := btcutil.DecodeAddress("bcrt1q322tg0y2hzyp9zztr7d2twdclhqg88anvzxwwr", &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeIndex, tx, err := w.Spend("abc", 3000000, address, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(script, 10030000)
+	e, err := txscript.NewEngine(script, tx, 0, txscript.StandardVerifyFlags,
+		nil, txscript.NewTxSigHashes(tx, prevOutFetcher), 10030000, prevOutFetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Execute(); err != nil {
+		t.Fatalf("bad signature: %v", err)
+	}
+	if changeIndex < 0 || !txscript.IsPayToScriptHash(tx.TxOut[changeIndex].PkScript) {
+		t.Fatal("change is not P2SH-P2WPKH")
+	}
+}
+
+// A BIP86 wallet spends its P2TR outputs and its change is P2TR.
+func Test_newTaprootTransaction(t *testing.T) {
+	w := MockSchemeWallet("abc", wallet.SchemeBip86)
+	w.blockchainTip = 500
+
+	ours, err := w.GetAddress(&wallet.KeyPath{Change: wallet.EXTERNAL, Index: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.PayToAddrScript(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := chainhash.NewHashFromStr("50b636d971e7d4d918d92876d6d53a22ccc960e051f540108056ca4ad6ec080c")
+	err = w.txstore.Utxos().Put(wallet.Utxo{
+		Op:           wire.OutPoint{Hash: *h, Index: 0},
+		ScriptPubkey: script,
+		AtHeight:     421,
+		Value:        10030000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address, err := btcutil.DecodeAddress("bcrt1q322tg0y2hzyp9zztr7d2twdclhqg88anvzxwwr", &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeIndex, tx, err := w.Spend("abc", 3000000, address, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(script, 10030000)
+	e, err := txscript.NewEngine(script, tx, 0, txscript.StandardVerifyFlags,
+		nil, txscript.NewTxSigHashes(tx, prevOutFetcher), 10030000, prevOutFetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Execute(); err != nil {
+		t.Fatalf("bad signature: %v", err)
+	}
+	if changeIndex < 0 || !txscript.IsPayToTaproot(tx.TxOut[changeIndex].PkScript) {
+		t.Fatal("change is not P2TR")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/bisoncraft/go-electrum-client/wallet"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
@@ -91,3 +92,53 @@ func TestValidPw(t *testing.T) {
 	}
 	fmt.Println("valid pw")
 }
+
+func TestMigrateLegacyPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		// storage from before the coin type was recorded
+		{"legacy path", fmt.Sprintf(`{"version":"0.1","xprv":"%s","xpub":"%s"}`, xprv, xpub)},
+		// storage from before the address scheme was recorded
+		{"coin type", fmt.Sprintf(`{"version":"0.2","xprv":"%s","xpub":"%s","hdcointype":0}`, xprv, xpub)},
+	}
+	for _, tt := range tests {
+		sm := createStorageManager()
+		err := sm.datastore.PutEncrypted([]byte(tt.stored), pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sm.Get(pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sm.migrateLegacyPath(pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// reload from the datastore
+		migrated := NewStorageManager(sm.datastore, sm.params)
+		err = migrated.Get(pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migrated.store.Version != StorageVersionCurrent ||
+			migrated.store.HDCoinType != wallet.HDCoinTypeLegacy ||
+			migrated.store.Scheme != wallet.SchemeLegacy {
+			t.Fatalf("%s: not migrated: %s", tt.name, migrated.store)
+		}
+
+		// newer storage is left alone
+		migrated.store.HDCoinType = 1
+		migrated.store.Scheme = wallet.SchemeBip49
+		err = migrated.migrateLegacyPath(pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migrated.store.HDCoinType != 1 || migrated.store.Scheme != wallet.SchemeBip49 {
+			t.Fatalf("%s: storage changed", tt.name)
+		}
+	}
+}
@@ -16,7 +16,7 @@ var (
 	verify = true
 )
 
-// Build (one or more) segwit transaction that sweeps all coins owned by a
+// Build (one or more) transaction that sweeps all coins owned by a
 // private key external to our wallet .. into our wallet.
 // Each tx has many inputs but only one output. There are no change outputs.
 
@@ -34,13 +34,13 @@ func (w *BtcElectrumWallet) SweepCoins(
 	var prevOutScripts map[int][]byte
 	var prevOutValues map[int]int64
 
-	// segwit output which makes this always a segwit transaction
-	walletAddressSegwit, err := w.GetUnusedAddress(wallet.RECEIVING)
+	// output to a wallet address of the wallet's address scheme
+	walletAddress, err := w.GetUnusedAddress(wallet.RECEIVING)
 	if err != nil {
 		return nil, err
 	}
 	// make an output script that can spend the inputs to our wallet
-	p2wpkhScript, err := txscript.PayToAddrScript(walletAddressSegwit)
+	walletScript, err := txscript.PayToAddrScript(walletAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +52,7 @@ func (w *BtcElectrumWallet) SweepCoins(
 	privKeyToSignOutputs = make(map[int]*secp256k1.PrivateKey)
 	prevOutScripts = make(map[int][]byte)
 	prevOutValues = make(map[int]int64)
+	inputTypes := make([]InputType, 0, len(coins))
 
 	for i, coin := range coins {
 		scriptForInput, err := txscript.PayToAddrScript(coin.LinkedAddress)
@@ -59,6 +60,11 @@ func (w *BtcElectrumWallet) SweepCoins(
 			// we could change policy to just ignore .. see how
 			return nil, err
 		}
+		inputType, err := scriptInputType(scriptForInput)
+		if err != nil {
+			return nil, err
+		}
+		inputTypes = append(inputTypes, inputType)
 		totalValue += coin.Value
 		prevOutValues[i] = coin.Value
 		prevOutScripts[i] = scriptForInput
@@ -68,16 +74,17 @@ func (w *BtcElectrumWallet) SweepCoins(
 		sweepTx.TxIn = append(sweepTx.TxIn, input)
 	}
 
-	// get vsize estimate before adding the output
-	sweepSize := txSerialSizeEst(sweepTx)
+	// get vsize estimate with the single output
+	sweepOut := wire.NewTxOut(0, walletScript)
+	sweepSize := EstimateMixedSerializeSize(inputTypes, []*wire.TxOut{sweepOut}, 0)
 
 	// get the fee
 	feePerKB := btcutil.Amount(w.GetFeePerByte(feeLevel)) * 1000
 	fee := txrules.FeeForSerializeSize(feePerKB, sweepSize)
 
 	// add single output
-	outValue := totalValue - int64(fee)
-	sweepTx.AddTxOut(wire.NewTxOut(outValue, p2wpkhScript))
+	sweepOut.Value = totalValue - int64(fee)
+	sweepTx.AddTxOut(sweepOut)
 	err = txrules.CheckOutput(sweepTx.TxOut[0], feePerKB)
 	if err != nil {
 		return nil, err
@@ -85,12 +92,15 @@ func (w *BtcElectrumWallet) SweepCoins(
 
 	// sign
 
-	// NewTxSigHashes uses the PrevOutFetcher only for detecting a taproot
-	// output, so we can provide a dummy.
-	prevOutFetcher := new(txscript.CannedPrevOutputFetcher)
+	// The taproot sighash commits to every prevout of the tx.
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for idx, input := range sweepTx.TxIn {
+		prevOutFetcher.AddPrevOut(input.PreviousOutPoint,
+			wire.NewTxOut(prevOutValues[idx], prevOutScripts[idx]))
+	}
+	sigHashes := txscript.NewTxSigHashes(sweepTx, prevOutFetcher)
 
 	for idx, input := range sweepTx.TxIn {
-		sigHashes := txscript.NewTxSigHashes(sweepTx, prevOutFetcher)
 		prevOutScriptTy := txscript.GetScriptClass(prevOutScripts[idx])
 		switch prevOutScriptTy {
 		case txscript.WitnessV0PubKeyHashTy:
@@ -102,6 +112,23 @@ func (w *BtcElectrumWallet) SweepCoins(
 			// add witness
 			input.SignatureScript = nil
 			input.Witness = append(input.Witness, sig...)
+		case txscript.WitnessV1TaprootTy:
+			witness, err := txscript.TaprootWitnessSignature(sweepTx, sigHashes, idx,
+				prevOutValues[idx], prevOutScripts[idx], txscript.SigHashDefault,
+				privKeyToSignOutputs[idx])
+			if err != nil {
+				return nil, err
+			}
+			input.SignatureScript = nil
+			input.Witness = witness
+		case txscript.ScriptHashTy:
+			sigScript, witness, err := signNestedP2WPKH(sweepTx, sigHashes, idx,
+				prevOutValues[idx], prevOutScripts[idx], privKeyToSignOutputs[idx])
+			if err != nil {
+				return nil, err
+			}
+			input.SignatureScript = sigScript
+			input.Witness = witness
 		case txscript.PubKeyHashTy:
 			sig, err := txscript.SignatureScript(sweepTx, idx,
 				prevOutScripts[idx], txscript.SigHashAll, privKeyToSignOutputs[idx], true)
@@ -130,7 +157,7 @@ func (w *BtcElectrumWallet) SweepCoins(
 				idx,
 				txscript.StandardVerifyFlags,
 				nil, //txscript.NewSigCache(10),
-				nil, //txscript.NewTxSigHashes(sweepTx, prevOutFetcher),
+				sigHashes,
 				prevOutValues[idx],
 				prevOutFetcher)
 			if err != nil {
@@ -10,6 +10,10 @@ package wltbtc
 /* Copied here from a btcd internal package*/
 
 import (
+	"fmt"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
 
@@ -207,6 +211,10 @@ const (
 	P2SH_2of3_Multisig
 	P2SH_Multisig_Timelock_1Sig
 	P2SH_Multisig_Timelock_2Sigs
+	// segwit inputs are sized in vbytes
+	P2WPKH
+	NestedP2WPKH
+	P2TR
 )
 
 // EstimateSerializeSize returns a worst case serialize size estimate for a
@@ -214,33 +222,106 @@ const (
 // and contains each transaction output from txOuts.  The estimated size is
 // incremented for an additional P2PKH change output if addChangeOutput is true.
 func EstimateSerializeSize(inputCount int, txOuts []*wire.TxOut, addChangeOutput bool, inputType InputType) int {
+	inputTypes := make([]InputType, inputCount)
+	for i := range inputTypes {
+		inputTypes[i] = inputType
+	}
+	changeScriptSize := 0
+	if addChangeOutput {
+		changeScriptSize = P2PKHPkScriptSize
+	}
+	return EstimateMixedSerializeSize(inputTypes, txOuts, changeScriptSize)
+}
+
+// EstimateMixedSerializeSize is EstimateSerializeSize for a transaction that
+// spends one output of each of inputTypes. A change output with a script of
+// changeScriptSize is added if changeScriptSize is not zero.
+func EstimateMixedSerializeSize(inputTypes []InputType, txOuts []*wire.TxOut, changeScriptSize int) int {
 	changeSize := 0
 	outputCount := len(txOuts)
-	if addChangeOutput {
-		changeSize = P2PKHOutputSize
+	if changeScriptSize > 0 {
+		changeSize = TxOutOverhead + changeScriptSize
 		outputCount++
 	}
 
 	var redeemScriptSize int
+	for _, inputType := range inputTypes {
+		redeemScriptSize += redeemInputSize(inputType)
+	}
+
+	// 10 additional bytes are for version, locktime, and segwit flags
+	return 10 + wire.VarIntSerializeSize(uint64(len(inputTypes))) +
+		wire.VarIntSerializeSize(uint64(outputCount)) +
+		redeemScriptSize +
+		SumOutputSerializeSizes(txOuts) +
+		changeSize
+}
+
+func redeemInputSize(inputType InputType) int {
 	switch inputType {
 	case P2PKH:
-		redeemScriptSize = RedeemP2PKHInputSize
+		return RedeemP2PKHInputSize
 	case P2SH_1of2_Multisig:
-		redeemScriptSize = RedeemP2SH1of2MultisigInputSize
+		return RedeemP2SH1of2MultisigInputSize
 	case P2SH_2of3_Multisig:
-		redeemScriptSize = RedeemP2SH2of3MultisigInputSize
+		return RedeemP2SH2of3MultisigInputSize
 	case P2SH_Multisig_Timelock_1Sig:
-		redeemScriptSize = RedeemP2SHMultisigTimelock1InputSize
+		return RedeemP2SHMultisigTimelock1InputSize
 	case P2SH_Multisig_Timelock_2Sigs:
-		redeemScriptSize = RedeemP2SHMultisigTimelock2InputSize
+		return RedeemP2SHMultisigTimelock2InputSize
+	case P2WPKH:
+		return RedeemP2WPKHInputTotalSize
+	case NestedP2WPKH:
+		return RedeemNestedP2WPKHInputTotalSize
+	case P2TR:
+		return RedeemP2TRInputTotalSize
 	}
+	return 0
+}
 
-	// 10 additional bytes are for version, locktime, and segwit flags
-	return 10 + wire.VarIntSerializeSize(uint64(inputCount)) +
-		wire.VarIntSerializeSize(uint64(outputCount)) +
-		inputCount*redeemScriptSize +
-		SumOutputSerializeSizes(txOuts) +
-		changeSize
+// schemeInputType returns the InputType of an output paying to an address of
+// the scheme.
+func schemeInputType(scheme wallet.AddressScheme) InputType {
+	switch scheme {
+	case wallet.SchemeBip44:
+		return P2PKH
+	case wallet.SchemeBip49:
+		return NestedP2WPKH
+	case wallet.SchemeBip86:
+		return P2TR
+	}
+	return P2WPKH
+}
+
+// schemePkScriptSize returns the size of an output script paying to an address
+// of the scheme.
+func schemePkScriptSize(scheme wallet.AddressScheme) int {
+	switch scheme {
+	case wallet.SchemeBip44:
+		return P2PKHPkScriptSize
+	case wallet.SchemeBip49:
+		return P2SHPkScriptSize
+	case wallet.SchemeBip86:
+		return P2TRPkScriptSize
+	}
+	return P2WPKHPkScriptSize
+}
+
+// scriptInputType returns the InputType of an output script the wallet can
+// sign for. P2SH is taken to be nested P2WPKH.
+func scriptInputType(pkScript []byte) (InputType, error) {
+	switch class := txscript.GetScriptClass(pkScript); class {
+	case txscript.PubKeyHashTy:
+		return P2PKH, nil
+	case txscript.ScriptHashTy:
+		return NestedP2WPKH, nil
+	case txscript.WitnessV0PubKeyHashTy:
+		return P2WPKH, nil
+	case txscript.WitnessV1TaprootTy:
+		return P2TR, nil
+	default:
+		return 0, fmt.Errorf("script type %v unsupported", class)
+	}
 }
 
 // SumOutputSerializeSizes sums up the serialized size of the supplied outputs.
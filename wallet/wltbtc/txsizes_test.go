@@ -68,6 +68,26 @@ func TestEstimateSerializeSize(t *testing.T) {
 	}
 }
 
+func TestEstimateMixedSerializeSize(t *testing.T) {
+	tests := []struct {
+		inputTypes           []InputType
+		changeScriptSize     int
+		expectedSizeEstimate int
+	}{
+		0: {[]InputType{P2WPKH}, 0, 10 + 1 + 1 + 69},
+		1: {[]InputType{NestedP2WPKH}, 0, 10 + 1 + 1 + 92},
+		2: {[]InputType{P2TR}, 0, 10 + 1 + 1 + 58},
+		3: {[]InputType{P2PKH, P2WPKH, NestedP2WPKH}, P2PKHPkScriptSize, 10 + 1 + 1 + 149 + 69 + 92 + P2PKHOutputSize},
+		4: {[]InputType{P2TR, P2TR}, P2TRPkScriptSize, 10 + 1 + 1 + 2*58 + P2TROutputSize},
+	}
+	for i, test := range tests {
+		actualEstimate := EstimateMixedSerializeSize(test.inputTypes, nil, test.changeScriptSize)
+		if actualEstimate != test.expectedSizeEstimate {
+			t.Errorf("Test %d: Got %v: Expected %v", i, actualEstimate, test.expectedSizeEstimate)
+		}
+	}
+}
+
 func TestSumOutputSerializeSizes(t *testing.T) {
 	testTx := "0100000001066b78efa7d66d271cae6d6eb799e1d10953fb1a4a760226cc93186d52b55613010000006a47304402204e6c32cc214c496546c3277191ca734494fe49fed0af1d800db92fed2021e61802206a14d063b67f2f1c8fc18f9e9a5963fe33e18c549e56e3045e88b4fc6219be11012103f72d0a11727219bff66b8838c3c5e1c74a5257a325b0c84247bd10bdb9069e88ffffffff0200c2eb0b000000001976a914426e80ad778792e3e19c20977fb93ec0591e1a3988ac35b7cb59000000001976a914e5b6dc0b297acdd99d1a89937474df77db5743c788ac00000000"
 	txBytes, err := hex.DecodeString(testTx)
@@ -52,18 +52,13 @@ func (ts *TxStore) PopulateAdrs() {
 	ts.addrMutex.Lock()
 	ts.adrs = []btcutil.Address{}
 	for _, k := range keys {
-		address, err := k.Address(ts.params)
+		address, err := ts.keyManager.Address(k)
+		k.Zero()
 		if err != nil {
+			fmt.Println(err)
 			continue
 		}
-		script := address.ScriptAddress()
-		segwitAddress, swerr := btcutil.NewAddressWitnessPubKeyHash(script, ts.params)
-		if swerr != nil {
-			fmt.Println(swerr)
-			continue
-		}
-		ts.adrs = append(ts.adrs, segwitAddress)
-		k.Zero()
+		ts.adrs = append(ts.adrs, address)
 	}
 	ts.addrMutex.Unlock()
 
@@ -137,6 +132,10 @@ func (ts *TxStore) AddTransaction(tx *wire.MsgTx, height int64, timestamp time.T
 					Hash:  thisTxHash,
 					Index: uint32(i),
 				}
+				// WatchOnly marks a watched script that is not a wallet
+				// key; balances, coin selection and the tx list skip it.
+				// The coins of a WatchOnly wallet are its own so it is
+				// not set for them.
 				newu := wallet.Utxo{
 					AtHeight:     height,
 					Value:        txout.Value,
@@ -218,6 +217,10 @@ func (ts *TxStore) AddTransaction(tx *wire.MsgTx, height int64, timestamp time.T
 		// check the height before committing so we don't allow rogue electrumX servers
 		// to send us a loose tx that resets our height to zero.
 		if err == nil && txn.Height <= 0 {
+			// once confirmed the block time replaces the first seen time
+			if height > 0 {
+				txn.Timestamp = timestamp
+			}
 			ts.Txns().UpdateHeight(tx.TxHash().String(), int(height), txn.Timestamp)
 			ts.txids[tx.TxHash().String()] = height
 		}
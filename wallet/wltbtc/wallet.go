@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -28,6 +29,8 @@ const WalletVersion = "0.1.0"
 
 var ErrEmptyPassword = errors.New("empty password")
 
+var ErrNoLegacyAddress = errors.New("address scheme has no legacy address")
+
 type BtcElectrumWallet struct {
 	params *chaincfg.Params
 
@@ -40,6 +43,10 @@ type BtcElectrumWallet struct {
 	keyManager          *KeyManager
 	subscriptionManager *SubscriptionManager
 
+	// signer signs with the HD keys, nil for a WatchOnly wallet until
+	// SetSigner
+	signer wallet.Signer
+
 	mutex *sync.RWMutex
 
 	creationDate time.Time
@@ -103,7 +110,9 @@ func makeBtcElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	}
 
 	sm := NewStorageManager(config.DB.Enc(), config.Params)
-	sm.store.Version = "0.1"
+	sm.store.Version = StorageVersionCurrent
+	sm.store.HDCoinType = config.HDCoinType()
+	sm.store.Scheme = config.Scheme()
 	sm.store.Xprv = mPrivKey.String()
 	sm.store.Xpub = mPubKey.String()
 	sm.store.ShaPw = chainhash.HashB([]byte(pw))
@@ -116,12 +125,13 @@ func makeBtcElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	}
 	w.storageManager = sm
 
-	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey)
+	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey, sm.store.HDCoinType, sm.store.Scheme)
 	mPrivKey.Zero()
 	mPubKey.Zero()
 	if err != nil {
 		return nil, err
 	}
+	w.signer = &hdSigner{km: w.keyManager}
 
 	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
 	if err != nil {
@@ -134,6 +144,10 @@ func makeBtcElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	if err != nil {
 		return nil, err
 	}
+	err = config.DB.Cfg().PutDataVersion(wallet.DataVersionCurrent)
+	if err != nil {
+		return nil, err
+	}
 
 	return w, nil
 }
@@ -154,8 +168,7 @@ func loadBtcElectrumWallet(config *wallet.WalletConfig, pw string) (*BtcElectrum
 	if err != nil {
 		return nil, err
 	}
-
-	mPrivKey, err := hdkeychain.NewKeyFromString(sm.store.Xprv)
+	err = sm.migrateLegacyPath(pw)
 	if err != nil {
 		return nil, err
 	}
@@ -168,10 +181,26 @@ func loadBtcElectrumWallet(config *wallet.WalletConfig, pw string) (*BtcElectrum
 		mutex:          new(sync.RWMutex),
 	}
 
-	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey)
-	mPrivKey.Zero()
-	if err != nil {
-		return nil, err
+	if sm.store.WatchOnly {
+		accountPubKey, err := hdkeychain.NewKeyFromString(sm.store.Xpub)
+		if err != nil {
+			return nil, err
+		}
+		w.keyManager, err = NewWatchOnlyKeyManager(config.DB.Keys(), w.params, accountPubKey, sm.store.Scheme)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		mPrivKey, err := hdkeychain.NewKeyFromString(sm.store.Xprv)
+		if err != nil {
+			return nil, err
+		}
+		w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey, sm.store.HDCoinType, sm.store.Scheme, sm.store.accountNumbers()...)
+		mPrivKey.Zero()
+		if err != nil {
+			return nil, err
+		}
+		w.signer = &hdSigner{km: w.keyManager}
 	}
 
 	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
@@ -218,6 +247,12 @@ func (w *BtcElectrumWallet) CurrencyCode() string {
 	}
 }
 
+// WatchOnly returns whether the wallet was made from an extended public key
+// with NewWatchOnlyElectrumWallet.
+func (w *BtcElectrumWallet) WatchOnly() bool {
+	return w.storageManager.store.WatchOnly
+}
+
 func (w *BtcElectrumWallet) IsDust(amount int64) bool {
 	// This is a per mempool policy thing .. < 1000 sats for now
 	return btcutil.Amount(amount) < txrules.DefaultRelayFeePerKb
@@ -227,51 +262,47 @@ func (w *BtcElectrumWallet) IsDust(amount int64) bool {
 // It is used for Rescan and has no concept of gap-limit. It is expected that
 // keys made here are just temporarily used to generate addresses for rescan.
 func (w *BtcElectrumWallet) GetAddress(kp *wallet.KeyPath /*, addressType*/) (btcutil.Address, error) {
-	key, err := w.keyManager.generateChildKey(kp.Change, uint32(kp.Index))
+	key, err := w.keyManager.generateChildKey(kp.Account, kp.Change, uint32(kp.Index))
 	if err != nil {
 		return nil, err
 	}
-	address, err := key.Address(w.params)
+	address, err := w.keyManager.Address(key)
 	key.Zero()
 	if err != nil {
 		return nil, err
 	}
-	script := address.ScriptAddress()
-	segwitAddress, err := btcutil.NewAddressWitnessPubKeyHash(script, w.params)
-	if err != nil {
-		return nil, err
-	}
-	return segwitAddress, nil
+	return address, nil
 }
 
 func (w *BtcElectrumWallet) GetUnusedAddress(purpose wallet.KeyChange) (btcutil.Address, error) {
-	key, err := w.keyManager.GetUnusedKey(purpose)
+	key, err := w.keyManager.GetUnusedKey(wallet.DefaultAccount, purpose)
 	if err != nil {
 		return nil, nil
 	}
-	address, err := key.Address(w.params)
+	address, err := w.keyManager.Address(key)
 	key.Zero()
 	if err != nil {
-		return nil, nil
-	}
-	script := address.ScriptAddress()
-	segwitAddress, swerr := btcutil.NewAddressWitnessPubKeyHash(script, w.params)
-	if swerr != nil {
-		return nil, swerr
+		return nil, err
 	}
-	return segwitAddress, nil
+	return address, nil
 }
 
-// For receiving simple payments from legacy wallets only!
+// For receiving simple payments from legacy wallets only! Only BIP44 and older
+// goele wallets have P2PKH keys; a BIP49, BIP84 or BIP86 wallet would never see
+// a payment to the P2PKH address of it's keys.
 func (w *BtcElectrumWallet) GetUnusedLegacyAddress() (btcutil.Address, error) {
-	key, err := w.keyManager.GetUnusedKey(wallet.RECEIVING)
+	scheme := w.keyManager.Scheme()
+	if scheme != wallet.SchemeBip44 && scheme != wallet.SchemeLegacy {
+		return nil, fmt.Errorf("%w: %s", ErrNoLegacyAddress, scheme)
+	}
+	key, err := w.keyManager.GetUnusedKey(wallet.DefaultAccount, wallet.RECEIVING)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 	addrP2PKH, err := key.Address(w.params)
 	key.Zero()
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 	return addrP2PKH, nil
 }
@@ -280,6 +311,9 @@ func (w *BtcElectrumWallet) GetPrivKeyForAddress(pw string, address btcutil.Addr
 	if ok := w.storageManager.IsValidPw(pw); !ok {
 		return "", errors.New("invalid password")
 	}
+	if w.WatchOnly() {
+		return "", wallet.ErrWatchOnly
+	}
 	hdKey, err := w.keyManager.GetKeyForScript(address.ScriptAddress())
 	if err != nil {
 		return "", err
@@ -348,7 +382,7 @@ func (w *BtcElectrumWallet) ListAddresses() []btcutil.Address {
 	keys := w.keyManager.GetKeys()
 	addresses := []btcutil.Address{}
 	for _, k := range keys {
-		address, err := k.Address(w.params)
+		address, err := w.keyManager.Address(k)
 		if err != nil {
 			continue
 		}
@@ -368,6 +402,15 @@ func (w *BtcElectrumWallet) IsMine(queryAddress btcutil.Address) bool {
 }
 
 func (w *BtcElectrumWallet) Balance() (int64, int64, int64, error) {
+	utxos, err := w.txstore.Utxos().GetAll()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return w.balance(utxos)
+}
+
+// balance returns the confirmed, unconfirmed and locked amounts of utxos.
+func (w *BtcElectrumWallet) balance(utxos []wallet.Utxo) (int64, int64, int64, error) {
 
 	isStxoConfirmed := func(utxo wallet.Utxo, stxos []wallet.Stxo) bool {
 		for _, stxo := range stxos {
@@ -389,10 +432,6 @@ func (w *BtcElectrumWallet) Balance() (int64, int64, int64, error) {
 	confirmed := int64(0)
 	unconfirmed := int64(0)
 	locked := int64(0)
-	utxos, err := w.txstore.Utxos().GetAll()
-	if err != nil {
-		return 0, 0, 0, err
-	}
 	stxos, err := w.txstore.Stxos().GetAll()
 	if err != nil {
 		return 0, 0, 0, err
@@ -522,6 +561,52 @@ func (w *BtcElectrumWallet) UpdateTip(newTip int64) {
 	w.blockchainTip = newTip
 }
 
+// MigrateTxTimes sets the times of confirmed transactions stored before
+// DataVersionBlockTimes to their block times. Does nothing for newer wallet
+// data. Progress is saved after each transaction so if blockTime fails the
+// migration carries on from there on the next load.
+func (w *BtcElectrumWallet) MigrateTxTimes(blockTime func(height int64) (time.Time, error)) error {
+	version, err := w.txstore.Cfg().GetDataVersion()
+	if err != nil {
+		return err
+	}
+	if version >= wallet.DataVersionBlockTimes {
+		return nil
+	}
+	cursor, err := w.txstore.Cfg().GetMigrationCursor()
+	if err != nil {
+		return err
+	}
+	txns, err := w.txstore.Txns().GetAll(true)
+	if err != nil {
+		return err
+	}
+	// in txid order; the cursor is the last one done
+	sort.Slice(txns, func(i, j int) bool { return txns[i].Txid < txns[j].Txid })
+	for _, txn := range txns {
+		if txn.Height <= 0 || txn.Txid <= cursor {
+			continue
+		}
+		timestamp, err := blockTime(txn.Height)
+		if err != nil {
+			return err
+		}
+		err = w.txstore.Txns().UpdateHeight(txn.Txid, int(txn.Height), timestamp)
+		if err != nil {
+			return err
+		}
+		err = w.txstore.Cfg().PutMigrationCursor(txn.Txid)
+		if err != nil {
+			return err
+		}
+	}
+	err = w.txstore.Cfg().PutDataVersion(wallet.DataVersionBlockTimes)
+	if err != nil {
+		return err
+	}
+	return w.txstore.Cfg().PutMigrationCursor("")
+}
+
 /////////////////////////////
 // implementations in send.go
 
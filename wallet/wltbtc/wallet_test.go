@@ -5,6 +5,9 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
 )
 
 type rawTx struct {
@@ -152,3 +155,144 @@ func TestBalance(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestTxTimes(t *testing.T) {
+	w := MockWallet("abc")
+	firstSeen := time.Unix(1_700_000_000, 0)
+	err := fundWallet(w, 0, firstSeen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := makeTxList()[0].txid
+	txn, _ := w.GetTransaction(txid)
+	if !txn.Timestamp.Equal(firstSeen) {
+		t.Fatalf("unconfirmed: got time %v", txn.Timestamp)
+	}
+	// seen again unconfirmed keeps the first seen time
+	err = fundWallet(w, 0, firstSeen.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, _ = w.GetTransaction(txid)
+	if !txn.Timestamp.Equal(firstSeen) {
+		t.Fatalf("unconfirmed again: got time %v", txn.Timestamp)
+	}
+	// confirmed takes the block time
+	blockTime := firstSeen.Add(10 * time.Minute)
+	err = fundWallet(w, 100, blockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, _ = w.GetTransaction(txid)
+	if !txn.Timestamp.Equal(blockTime) {
+		t.Fatalf("confirmed: got time %v", txn.Timestamp)
+	}
+}
+
+func TestMigrateTxTimes(t *testing.T) {
+	w := MockWallet("abc")
+	err := fundWallet(w, 100, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockTime := func(height int64) (time.Time, error) {
+		return time.Unix(height*600, 0), nil
+	}
+	err = w.MigrateTxTimes(blockTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := w.ListTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, txn := range txns {
+		if txn.Timestamp.Unix() != 100*600 {
+			t.Fatalf("%s: got time %v", txn.Txid, txn.Timestamp)
+		}
+	}
+	version, _ := w.txstore.Cfg().GetDataVersion()
+	if version != wallet.DataVersionBlockTimes {
+		t.Fatalf("data version %d", version)
+	}
+	// done once
+	err = w.MigrateTxTimes(func(height int64) (time.Time, error) {
+		return time.Time{}, errors.New("migrated twice")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateTxTimesResume(t *testing.T) {
+	w := MockWallet("abc")
+	err := fundWallet(w, 100, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := w.ListTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) < 2 {
+		t.Fatalf("need more than one tx, got %d", len(txns))
+	}
+	// fail after the first tx
+	var calls int
+	err = w.MigrateTxTimes(func(height int64) (time.Time, error) {
+		calls++
+		if calls > 1 {
+			return time.Time{}, errors.New("no block time")
+		}
+		return time.Unix(height*600, 0), nil
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	cursor, _ := w.txstore.Cfg().GetMigrationCursor()
+	if cursor == "" {
+		t.Fatal("no progress saved")
+	}
+	// carries on after the first tx
+	calls = 0
+	err = w.MigrateTxTimes(func(height int64) (time.Time, error) {
+		calls++
+		return time.Unix(height*600, 0), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != len(txns)-1 {
+		t.Fatalf("expected %d block times, got %d", len(txns)-1, calls)
+	}
+	txns, _ = w.ListTransactions()
+	for _, txn := range txns {
+		if txn.Timestamp.Unix() != 100*600 {
+			t.Fatalf("%s: got time %v", txn.Txid, txn.Timestamp)
+		}
+	}
+	cursor, _ = w.txstore.Cfg().GetMigrationCursor()
+	if cursor != "" {
+		t.Fatalf("cursor %s left after migration", cursor)
+	}
+}
+
+func TestGetUnusedLegacyAddress(t *testing.T) {
+	for _, scheme := range []wallet.AddressScheme{wallet.SchemeBip44, wallet.SchemeLegacy} {
+		w := MockSchemeWallet("abc", scheme)
+		addr, err := w.GetUnusedLegacyAddress()
+		if err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		if _, ok := addr.(*btcutil.AddressPubKeyHash); !ok {
+			t.Fatalf("%s: expected P2PKH address, got %s", scheme, addr)
+		}
+	}
+	for _, scheme := range []wallet.AddressScheme{wallet.SchemeBip49, wallet.SchemeBip84, wallet.SchemeBip86} {
+		w := MockSchemeWallet("abc", scheme)
+		_, err := w.GetUnusedLegacyAddress()
+		if !errors.Is(err, ErrNoLegacyAddress) {
+			t.Fatalf("%s: expected no legacy address error, got %v", scheme, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package wltbtc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+var (
+	ErrPrivateExtendedKey = errors.New("extended key is private - use an extended public key")
+	ErrNotAccountKey      = errors.New("extended key is not an account level key")
+	ErrExtendedKeyVersion = errors.New("extended key version is not for this network")
+	ErrKeyOrigin          = errors.New("malformed key origin - expected [fingerprint/purpose'/coin_type'/account']")
+)
+
+// SLIP-132 extended public key versions
+var (
+	xpubVersion = []byte{0x04, 0x88, 0xb2, 0x1e}
+	ypubVersion = []byte{0x04, 0x9d, 0x7c, 0xb2}
+	zpubVersion = []byte{0x04, 0xb2, 0x47, 0x46}
+	tpubVersion = []byte{0x04, 0x35, 0x87, 0xcf}
+	upubVersion = []byte{0x04, 0x4a, 0x52, 0x62}
+	vpubVersion = []byte{0x04, 0x5f, 0x1c, 0xf6}
+)
+
+// accountKeyDepth is the depth of m / purpose' / coin_type' / account'
+const accountKeyDepth = 3
+
+// ParseAccountXpub parses an account level extended public key and returns it
+// with the network's xpub version along with the address scheme its SLIP-132
+// version implies: xpub/tpub SchemeBip44, ypub/upub SchemeBip49 and zpub/vpub
+// SchemeBip84. A BIP86 account key is an xpub/tpub so its scheme has to be
+// given in the wallet config.
+func ParseAccountXpub(xpub string, params *chaincfg.Params) (*hdkeychain.ExtendedKey, wallet.AddressScheme, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.IsPrivate() {
+		return nil, "", ErrPrivateExtendedKey
+	}
+	if key.Depth() != accountKeyDepth {
+		return nil, "", ErrNotAccountKey
+	}
+	mainnet := bytes.Equal(params.HDPublicKeyID[:], xpubVersion)
+	var scheme wallet.AddressScheme
+	version := key.Version()
+	switch {
+	case bytes.Equal(version, xpubVersion) && mainnet,
+		bytes.Equal(version, tpubVersion) && !mainnet:
+		scheme = wallet.SchemeBip44
+	case bytes.Equal(version, ypubVersion) && mainnet,
+		bytes.Equal(version, upubVersion) && !mainnet:
+		scheme = wallet.SchemeBip49
+	case bytes.Equal(version, zpubVersion) && mainnet,
+		bytes.Equal(version, vpubVersion) && !mainnet:
+		scheme = wallet.SchemeBip84
+	default:
+		return nil, "", ErrExtendedKeyVersion
+	}
+	key, err = key.CloneWithVersion(params.HDPublicKeyID[:])
+	if err != nil {
+		return nil, "", err
+	}
+	return key, scheme, nil
+}
+
+// parseKeyOrigin splits an optional descriptor style key origin from an account
+// extended public key, "[d34db33f/84'/0'/0']xpub..". It returns the master key
+// fingerprint in the psbt package byte order and the account key path, or a
+// nil path if there is no key origin.
+func parseKeyOrigin(xpub string) (string, uint32, []uint32, error) {
+	if !strings.HasPrefix(xpub, "[") {
+		return xpub, 0, nil, nil
+	}
+	end := strings.Index(xpub, "]")
+	if end < 0 {
+		return "", 0, nil, ErrKeyOrigin
+	}
+	parts := strings.Split(xpub[1:end], "/")
+	if len(parts) != accountKeyDepth+1 {
+		return "", 0, nil, ErrKeyOrigin
+	}
+	fp, err := hex.DecodeString(parts[0])
+	if err != nil || len(fp) != 4 {
+		return "", 0, nil, ErrKeyOrigin
+	}
+	var path []uint32
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if !hardened {
+			return "", 0, nil, ErrKeyOrigin
+		}
+		index, err := strconv.ParseUint(part[:len(part)-1], 10, 31)
+		if err != nil {
+			return "", 0, nil, ErrKeyOrigin
+		}
+		path = append(path, hdkeychain.HardenedKeyStart+uint32(index))
+	}
+	return xpub[end+1:], binary.LittleEndian.Uint32(fp), path, nil
+}
+
+// NewWatchOnlyElectrumWallet makes a new wallet from an account extended public
+// key. The wallet derives and tracks the account's addresses and can build
+// unsigned transactions and PSBTs but has no private keys so cannot sign. The
+// address scheme is config.AddressScheme if set, otherwise the scheme of the
+// key's SLIP-132 version. The key may have a key origin prefix,
+// "[fingerprint/84'/0'/0']", for the BIP32 derivations of PSBTs that hardware
+// wallets sign. The password encrypts the stored xpub.
+func NewWatchOnlyElectrumWallet(config *wallet.WalletConfig, pw, xpub string) (*BtcElectrumWallet, error) {
+	if pw == "" {
+		return nil, ErrEmptyPassword
+	}
+	xpub, fingerprint, accountPath, err := parseKeyOrigin(xpub)
+	if err != nil {
+		return nil, err
+	}
+	accountPubKey, scheme, err := ParseAccountXpub(xpub, config.Params)
+	if err != nil {
+		return nil, err
+	}
+	if config.AddressScheme != "" {
+		scheme = config.Scheme()
+	}
+
+	w := &BtcElectrumWallet{
+		repoPath:     config.DataDir,
+		params:       config.Params,
+		creationDate: time.Now(),
+		feeProvider:  wallet.DefaultFeeProvider(),
+		mutex:        new(sync.RWMutex),
+	}
+
+	sm := NewStorageManager(config.DB.Enc(), config.Params)
+	sm.store.Version = StorageVersionCurrent
+	sm.store.HDCoinType = config.HDCoinType()
+	sm.store.Scheme = scheme
+	sm.store.Xpub = accountPubKey.String()
+	sm.store.WatchOnly = true
+	sm.store.MasterFingerprint = fingerprint
+	sm.store.AccountPath = accountPath
+	sm.store.ShaPw = chainhash.HashB([]byte(pw))
+	err = sm.Put(pw)
+	if err != nil {
+		return nil, err
+	}
+	w.storageManager = sm
+
+	w.keyManager, err = NewWatchOnlyKeyManager(config.DB.Keys(), w.params, accountPubKey, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
+	if err != nil {
+		return nil, err
+	}
+
+	w.subscriptionManager = NewSubscriptionManager(config.DB.Subscriptions(), w.params)
+
+	err = config.DB.Cfg().PutCreationDate(w.creationDate)
+	if err != nil {
+		return nil, err
+	}
+	err = config.DB.Cfg().PutDataVersion(wallet.DataVersionCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
@@ -0,0 +1,276 @@
+package wltbtc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// accountExtendedKey derives the account key m / purpose' / coin_type' / 0'
+// of the 'abandon .. about' test seed.
+func accountExtendedKey(t *testing.T, params *chaincfg.Params, purpose, coinType uint32) *hdkeychain.ExtendedKey {
+	t.Helper()
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	key, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []uint32{purpose, coinType, 0} {
+		key, err = key.Derive(hdkeychain.HardenedKeyStart + i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return key
+}
+
+// accountXpub returns the neutered account key with a SLIP-132 version.
+func accountXpub(t *testing.T, params *chaincfg.Params, purpose, coinType uint32, version []byte) string {
+	t.Helper()
+	pub, err := accountExtendedKey(t, params, purpose, coinType).Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err = pub.CloneWithVersion(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub.String()
+}
+
+func TestParseAccountXpub(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose uint32
+		version []byte
+		scheme  wallet.AddressScheme
+		want    string
+	}{
+		{"xpub", 44, xpubVersion, wallet.SchemeBip44, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{"ypub", 49, ypubVersion, wallet.SchemeBip49, "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{"zpub", 84, zpubVersion, wallet.SchemeBip84, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+	}
+	for _, tt := range tests {
+		xpub := accountXpub(t, &chaincfg.MainNetParams, tt.purpose, 0, tt.version)
+		key, scheme, err := ParseAccountXpub(xpub, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if scheme != tt.scheme {
+			t.Fatalf("%s: got scheme %s, want %s", tt.name, scheme, tt.scheme)
+		}
+		km, err := NewWatchOnlyKeyManager(&mockKeyStore{make(map[string]*keyStoreEntry)}, &chaincfg.MainNetParams, key, scheme)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		child, err := km.generateChildKey(wallet.DefaultAccount, wallet.EXTERNAL, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		addr, err := km.Address(child)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if addr.String() != tt.want {
+			t.Fatalf("%s: got %s, want %s", tt.name, addr, tt.want)
+		}
+	}
+
+	// private account key
+	xprv := accountExtendedKey(t, &chaincfg.MainNetParams, 84, 0).String()
+	if _, _, err := ParseAccountXpub(xprv, &chaincfg.MainNetParams); !errors.Is(err, ErrPrivateExtendedKey) {
+		t.Fatalf("expected %v got %v", ErrPrivateExtendedKey, err)
+	}
+	// mainnet zpub on testnet
+	zpub := accountXpub(t, &chaincfg.MainNetParams, 84, 0, zpubVersion)
+	if _, _, err := ParseAccountXpub(zpub, &chaincfg.TestNet3Params); !errors.Is(err, ErrExtendedKeyVersion) {
+		t.Fatalf("expected %v got %v", ErrExtendedKeyVersion, err)
+	}
+	// a change level key is not an account key
+	external, err := accountExtendedKey(t, &chaincfg.MainNetParams, 84, 0).Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	externalPub, _ := external.Neuter()
+	if _, _, err := ParseAccountXpub(externalPub.String(), &chaincfg.MainNetParams); !errors.Is(err, ErrNotAccountKey) {
+		t.Fatalf("expected %v got %v", ErrNotAccountKey, err)
+	}
+}
+
+// watchOnlyConfig returns a regtest wallet config with an empty mock datastore.
+func watchOnlyConfig() *wallet.WalletConfig {
+	mockDb := &MockDatastore{
+		&mockConfig{creationDate: time.Now()},
+		&mockStorage{blob: make([]byte, 10)},
+		&mockKeyStore{make(map[string]*keyStoreEntry)},
+		&mockUtxoStore{make(map[string]*wallet.Utxo)},
+		&mockStxoStore{make(map[string]*wallet.Stxo)},
+		&mockTxnStore{make(map[string]*wallet.Txn)},
+		&mockSubscriptionsStore{make(map[string]*wallet.Subscription)},
+	}
+	return &wallet.WalletConfig{
+		NetType: "regtest",
+		Params:  &chaincfg.RegressionNetParams,
+		DB:      mockDb,
+	}
+}
+
+func TestWatchOnlyWallet(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	config := watchOnlyConfig()
+	// the vpub sets SchemeBip84
+	vpub := accountXpub(t, params, 84, wallet.HDCoinTypeTestnet, vpubVersion)
+	w, err := NewWatchOnlyElectrumWallet(config, pw, vpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.WatchOnly() {
+		t.Fatal("expected a watch-only wallet")
+	}
+
+	// the addresses are those of the seed wallet
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	masterPrivKey, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	km, err := NewKeyManager(&mockKeyStore{make(map[string]*keyStoreEntry)}, params, masterPrivKey, wallet.HDCoinTypeTestnet, wallet.SchemeBip84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := km.GetUnusedKey(wallet.DefaultAccount, wallet.EXTERNAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := km.Address(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := w.GetUnusedAddress(wallet.RECEIVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != want.String() {
+		t.Fatalf("got address %s, want %s", addr, want)
+	}
+
+	// no signing
+	payee, err := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := w.Spend(pw, 50_000_000, payee, wallet.NORMAL); !errors.Is(err, wallet.ErrWatchOnly) {
+		t.Fatalf("Spend: expected %v got %v", wallet.ErrWatchOnly, err)
+	}
+	if _, err := w.SignTx(pw, &wallet.SigningInfo{}); !errors.Is(err, wallet.ErrWatchOnly) {
+		t.Fatalf("SignTx: expected %v got %v", wallet.ErrWatchOnly, err)
+	}
+	if _, err := w.GetPrivKeyForAddress(pw, addr); !errors.Is(err, wallet.ErrWatchOnly) {
+		t.Fatalf("GetPrivKeyForAddress: expected %v got %v", wallet.ErrWatchOnly, err)
+	}
+	if _, err := w.CreateAccount(pw, "savings"); !errors.Is(err, wallet.ErrWatchOnly) {
+		t.Fatalf("CreateAccount: expected %v got %v", wallet.ErrWatchOnly, err)
+	}
+
+	// unsigned txs for signing elsewhere
+	w.blockchainTip = 500
+	utxo := putAccountUtxo(t, w, wallet.DefaultAccount, 0x01, 100_000_000)
+	c, _, _, err := w.Balance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 100_000_000 {
+		t.Fatalf("expected balance 100000000 got %d", c)
+	}
+	changeIndex, tx, err := w.BuildUnsignedTx(wallet.DefaultAccount, 50_000_000, payee, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn) != 1 || tx.TxIn[0].PreviousOutPoint != utxo.Op {
+		t.Fatal("unexpected inputs")
+	}
+	if len(tx.TxIn[0].SignatureScript) != 0 || len(tx.TxIn[0].Witness) != 0 {
+		t.Fatal("tx is signed")
+	}
+	if changeIndex < 0 || tx.TxOut[changeIndex].Value == 50_000_000 {
+		t.Fatalf("bad change index %d", changeIndex)
+	}
+
+	// synced coins are the wallet's own, not WatchOnly coins
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.MarkAddressUsed(addr); err != nil {
+		t.Fatal(err)
+	}
+	payment := wire.NewMsgTx(wire.TxVersion)
+	payment.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x02}, 0), nil, nil))
+	payment.AddTxOut(wire.NewTxOut(20_000_000, script))
+	if err := w.AddTransaction(payment, 450, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	utxos, err := w.ListUnspent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range utxos {
+		if u.WatchOnly {
+			t.Fatalf("utxo %s is WatchOnly", u.Op)
+		}
+	}
+	c, _, _, err = w.Balance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 120_000_000 {
+		t.Fatalf("expected balance 120000000 got %d", c)
+	}
+	txns, err := w.ListTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, txn := range txns {
+		found = found || txn.Txid == payment.TxHash().String()
+	}
+	if !found {
+		t.Fatal("synced tx not listed")
+	}
+
+	// reload
+	w2, err := LoadBtcElectrumWallet(config, pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w2.WatchOnly() {
+		t.Fatal("expected a watch-only wallet after load")
+	}
+	if w2.storageManager.store.Xprv != "" {
+		t.Fatal("watch-only wallet stored a private key")
+	}
+	key, err = km.GetUnusedKey(wallet.DefaultAccount, wallet.INTERNAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err = km.Address(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err = w2.GetUnusedAddress(wallet.CHANGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != want.String() {
+		t.Fatalf("got change address %s, want %s", addr, want)
+	}
+}
@@ -0,0 +1,107 @@
+package wltdash
+
+import (
+	"errors"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+// defaultAccountName names the wallet.DefaultAccount
+const defaultAccountName = "default"
+
+var (
+	ErrEmptyAccountName = errors.New("empty account name")
+	ErrDupAccountName   = errors.New("account name already used")
+)
+
+// CreateAccount derives the keys of a new HD account and records its name in
+// the encrypted storage. Accounts are numbered in order from 1.
+func (w *DashElectrumWallet) CreateAccount(pw, name string) (uint32, error) {
+	if ok := w.storageManager.IsValidPw(pw); !ok {
+		return 0, errors.New("invalid password")
+	}
+	if name == "" {
+		return 0, ErrEmptyAccountName
+	}
+	// record the account before deriving any keys so a failure here leaves
+	// an account that is derived again on the next load
+	number, err := w.storageManager.addAccount(pw, name)
+	if err != nil {
+		return 0, err
+	}
+	mPrivKey, err := hdkeychain.NewKeyFromString(w.storageManager.store.Xprv)
+	if err != nil {
+		return 0, err
+	}
+	err = w.keyManager.AddAccount(mPrivKey, number)
+	mPrivKey.Zero()
+	if err != nil {
+		return 0, err
+	}
+	return number, nil
+}
+
+// ListAccounts returns the wallet.DefaultAccount then the created accounts.
+func (w *DashElectrumWallet) ListAccounts() []wallet.Account {
+	accounts := []wallet.Account{{
+		Number: wallet.DefaultAccount,
+		Name:   defaultAccountName,
+	}}
+	return append(accounts, w.storageManager.accounts()...)
+}
+
+// GetUnusedAccountAddress gets an unused address of the account.
+func (w *DashElectrumWallet) GetUnusedAccountAddress(account uint32, purpose wallet.KeyChange) (btcutil.Address, error) {
+	key, err := w.keyManager.GetUnusedKey(account, purpose)
+	if err != nil {
+		return nil, err
+	}
+	// P2PKH only
+	address, err := key.Address(w.params)
+	key.Zero()
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// AccountBalance returns the confirmed, unconfirmed and locked amounts of the
+// account.
+func (w *DashElectrumWallet) AccountBalance(account uint32) (int64, int64, int64, error) {
+	utxos, err := w.accountUtxos(account)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return w.balance(utxos)
+}
+
+// ListAccountUnspent lists the unspent outputs of the account.
+func (w *DashElectrumWallet) ListAccountUnspent(account uint32) ([]wallet.Utxo, error) {
+	return w.accountUtxos(account)
+}
+
+// accountUtxos returns the utxos paying to keys of the account.
+func (w *DashElectrumWallet) accountUtxos(account uint32) ([]wallet.Utxo, error) {
+	if !w.keyManager.hasAccount(account) {
+		return nil, wallet.ErrUnknownAccount
+	}
+	utxos, err := w.txstore.Utxos().GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var accountUtxos = make([]wallet.Utxo, 0)
+	for _, utxo := range utxos {
+		address, err := w.ScriptToAddress(utxo.ScriptPubkey)
+		if err != nil {
+			continue
+		}
+		keyPath, err := w.keyManager.GetPathForScript(address.ScriptAddress())
+		if err != nil || keyPath.Account != account {
+			continue
+		}
+		accountUtxos = append(accountUtxos, utxo)
+	}
+	return accountUtxos, nil
+}
@@ -0,0 +1,208 @@
+package wltdash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// putAccountUtxo stores a confirmed utxo paying to an unused address of the
+// account.
+func putAccountUtxo(t *testing.T, w *DashElectrumWallet, account uint32, txByte byte, value int64) wallet.Utxo {
+	t.Helper()
+	address, err := w.GetUnusedAccountAddress(account, wallet.RECEIVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.MarkAddressUsed(address); err != nil {
+		t.Fatal(err)
+	}
+	utxo := wallet.Utxo{
+		Op:           wire.OutPoint{Hash: chainhash.Hash{txByte}, Index: 0},
+		ScriptPubkey: script,
+		AtHeight:     400,
+		Value:        value,
+	}
+	if err := w.txstore.Utxos().Put(utxo); err != nil {
+		t.Fatal(err)
+	}
+	return utxo
+}
+
+func TestCreateAccount(t *testing.T) {
+	w := MockWallet(pw)
+	if _, err := w.CreateAccount("bad", "savings"); err == nil {
+		t.Fatal("expected invalid password error")
+	}
+	number, err := w.CreateAccount(pw, "savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number != 1 {
+		t.Fatalf("expected account 1 got %d", number)
+	}
+	if _, err := w.CreateAccount(pw, "savings"); !errors.Is(err, ErrDupAccountName) {
+		t.Fatalf("expected %v got %v", ErrDupAccountName, err)
+	}
+	accounts := w.ListAccounts()
+	if len(accounts) != 2 || accounts[0].Number != wallet.DefaultAccount || accounts[1].Name != "savings" {
+		t.Fatalf("unexpected accounts %v", accounts)
+	}
+
+	// the account is in the encrypted storage for the next load
+	sm := NewStorageManager(w.storageManager.datastore, w.params)
+	if err := sm.Get(pw); err != nil {
+		t.Fatal(err)
+	}
+	if len(sm.store.Accounts) != 1 || sm.store.Accounts[0].Number != 1 {
+		t.Fatalf("account not stored %v", sm.store.Accounts)
+	}
+
+	defaultAddr, err := w.GetUnusedAccountAddress(wallet.DefaultAccount, wallet.RECEIVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountAddr, err := w.GetUnusedAccountAddress(number, wallet.RECEIVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaultAddr.String() == accountAddr.String() {
+		t.Fatal("accounts share an address")
+	}
+	keyPath, err := w.keyManager.GetPathForScript(accountAddr.ScriptAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyPath.Account != number {
+		t.Fatalf("expected account %d key got %d", number, keyPath.Account)
+	}
+	addr, err := w.GetAddress(&keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != accountAddr.String() {
+		t.Fatalf("GetAddress expected %s got %s", accountAddr, addr)
+	}
+
+	if _, err := w.GetUnusedAccountAddress(7, wallet.RECEIVING); !errors.Is(err, wallet.ErrUnknownAccount) {
+		t.Fatalf("expected %v got %v", wallet.ErrUnknownAccount, err)
+	}
+}
+
+// TestCreateAccountWhileSyncing creates accounts while the keys and names of
+// the accounts are read, as the sync goroutine does. Run with -race.
+func TestCreateAccountWhileSyncing(t *testing.T) {
+	w := MockWallet(pw)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			if _, err := w.CreateAccount(pw, fmt.Sprintf("account %d", i)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if n := len(w.ListAccounts()); n != 6 {
+				t.Fatalf("expected 6 accounts got %d", n)
+			}
+			return
+		default:
+		}
+		for _, account := range w.ListAccounts() {
+			if !w.keyManager.hasAccount(account.Number) {
+				continue
+			}
+			key, err := w.keyManager.generateChildKey(account.Number, wallet.EXTERNAL, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key.Zero()
+		}
+	}
+}
+
+func TestAccountBalanceAndSpend(t *testing.T) {
+	w := MockWallet(pw)
+	w.blockchainTip = 500
+	number, err := w.CreateAccount(pw, "savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	putAccountUtxo(t, w, wallet.DefaultAccount, 0x01, 300_000_000)
+	accountUtxo := putAccountUtxo(t, w, number, 0x02, 100_000_000)
+
+	c, _, _, err := w.AccountBalance(number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 100_000_000 {
+		t.Fatalf("expected account balance 100000000 got %d", c)
+	}
+	c, _, _, err = w.Balance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 400_000_000 {
+		t.Fatalf("expected wallet balance 400000000 got %d", c)
+	}
+	unspent, err := w.ListAccountUnspent(number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unspent) != 1 || !unspent[0].Op.Hash.IsEqual(&accountUtxo.Op.Hash) {
+		t.Fatalf("unexpected account unspent %v", unspent)
+	}
+
+	address, err := w.DecodeAddress("yLMpV1pHssCVt7R5hqwVGH9VngDj1sKCoR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// more than the account has
+	_, _, err = w.AccountSpend(pw, number, 200_000_000, address, wallet.NORMAL)
+	if !errors.Is(err, wallet.ErrInsufficientFunds) {
+		t.Fatalf("expected %v got %v", wallet.ErrInsufficientFunds, err)
+	}
+	_, tx, err := w.AccountSpend(pw, number, 50_000_000, address, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn) != 1 || tx.TxIn[0].PreviousOutPoint != accountUtxo.Op {
+		t.Fatal("spent coins of another account")
+	}
+	if len(tx.TxOut) != 2 {
+		t.Fatal("expected change")
+	}
+	// outputs are BIP69 sorted
+	change := tx.TxOut[0]
+	if change.Value == 50_000_000 {
+		change = tx.TxOut[1]
+	}
+	changeAddr, err := w.ScriptToAddress(change.PkScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath, err := w.keyManager.GetPathForScript(changeAddr.ScriptAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyPath.Account != number || keyPath.Change != wallet.CHANGE {
+		t.Fatalf("change not to the account: %v", keyPath)
+	}
+
+	if _, _, err := w.AccountSpend(pw, 7, 50_000_000, address, wallet.NORMAL); !errors.Is(err, wallet.ErrUnknownAccount) {
+		t.Fatalf("expected %v got %v", wallet.ErrUnknownAccount, err)
+	}
+}
@@ -2,6 +2,7 @@ package wltdash
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/wallet"
@@ -15,76 +16,130 @@ const GAP_LIMIT = client.GAP_LIMIT
 type KeyManager struct {
 	datastore wallet.Keys
 	params    *chaincfg.Params
+	coinType  uint32
 
+	// mtx guards accounts, CreateAccount adds to them while the wallet
+	// syncs
+	mtx      sync.RWMutex
+	accounts map[uint32]*accountKeys
+}
+
+// accountKeys are the change level keys of an HD account.
+type accountKeys struct {
 	internalKey *hd.ExtendedKey
 	externalKey *hd.ExtendedKey
 }
 
-func NewKeyManager(db wallet.Keys, params *chaincfg.Params, masterPrivKey *hd.ExtendedKey) (*KeyManager, error) {
-	internal, external, err := Bip44Derivation(masterPrivKey)
-	masterPrivKey.Zero()
+// NewKeyManager makes a KeyManager for the wallet.DefaultAccount and any other
+// accounts.
+func NewKeyManager(db wallet.Keys, params *chaincfg.Params, masterPrivKey *hd.ExtendedKey, coinType uint32, accounts ...uint32) (*KeyManager, error) {
+	defer masterPrivKey.Zero()
+	km := &KeyManager{
+		datastore: db,
+		params:    params,
+		coinType:  coinType,
+		accounts:  make(map[uint32]*accountKeys),
+	}
+	for _, account := range append([]uint32{wallet.DefaultAccount}, accounts...) {
+		if err := km.AddAccount(masterPrivKey, account); err != nil {
+			return nil, err
+		}
+	}
+	return km, nil
+}
+
+// hasAccount returns whether the account's keys have been derived.
+func (km *KeyManager) hasAccount(account uint32) bool {
+	km.mtx.RLock()
+	defer km.mtx.RUnlock()
+	_, ok := km.accounts[account]
+	return ok
+}
+
+// AddAccount derives the keys of an HD account and fills its lookahead window.
+// Adding an account that is already there does nothing. The caller zeroes
+// masterPrivKey.
+func (km *KeyManager) AddAccount(masterPrivKey *hd.ExtendedKey, account uint32) error {
+	if km.hasAccount(account) {
+		return nil
+	}
+	internal, external, err := Bip44Derivation(masterPrivKey, km.coinType, account)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	km := &KeyManager{
-		datastore:   db,
-		params:      params,
+	// Derive memoizes the public key of a private parent key, do it now so
+	// the keys are only read once shared
+	for _, key := range []*hd.ExtendedKey{internal, external} {
+		if _, err := key.ECPubKey(); err != nil {
+			return err
+		}
+	}
+	km.mtx.Lock()
+	if _, ok := km.accounts[account]; ok {
+		km.mtx.Unlock()
+		return nil
+	}
+	km.accounts[account] = &accountKeys{
 		internalKey: internal,
 		externalKey: external,
 	}
-	if err := km.lookahead(); err != nil {
-		return nil, err
-	}
-	return km, nil
+	km.mtx.Unlock()
+	return km.lookahead(account)
 }
 
 // m / purpose' / coin_type' / account' / change / address_index
-func Bip44Derivation(masterPrivKey *hd.ExtendedKey) (internal, external *hd.ExtendedKey, err error) {
+//
+// coinType is the SLIP-44 coin type, see wallet.WalletConfig.HDCoinType.
+func Bip44Derivation(masterPrivKey *hd.ExtendedKey, coinType, account uint32) (internal, external *hd.ExtendedKey, err error) {
 	// Purpose = bip44
 	fourtyFour, err := masterPrivKey.Derive(hd.HardenedKeyStart + 44)
 	if err != nil {
 		return nil, nil, err
 	}
-	// Cointype = bitcoin
-	bitcoin, err := fourtyFour.Derive(hd.HardenedKeyStart + 0)
+	// Cointype
+	coin, err := fourtyFour.Derive(hd.HardenedKeyStart + coinType)
 	if err != nil {
 		return nil, nil, err
 	}
-	// Account = 0
-	account, err := bitcoin.Derive(hd.HardenedKeyStart + 0)
+	// Account
+	accountKey, err := coin.Derive(hd.HardenedKeyStart + account)
 	if err != nil {
 		return nil, nil, err
 	}
 	// Change(0) = external
-	external, err = account.Derive(0)
+	external, err = accountKey.Derive(0)
 	if err != nil {
 		return nil, nil, err
 	}
 	// Change(1) = internal
-	internal, err = account.Derive(1)
+	internal, err = accountKey.Derive(1)
 	if err != nil {
 		return nil, nil, err
 	}
 	return internal, external, nil
 }
 
-// GetUnusedKey gets the first unused key for 'purpose'. CAUTION: There may not
-// be any keys within the gap limit. In this case a used key can be utilized or
-// user can wait until the gap is updated with new key(s). This happens when a
-// transaction newly gets client.AGEDTX confirmations.
-func (km *KeyManager) GetUnusedKey(purpose wallet.KeyChange) (*hd.ExtendedKey, error) {
-	i, err := km.datastore.GetUnused(purpose)
+// GetUnusedKey gets the first unused key of the account for 'purpose'.
+// CAUTION: There may not be any keys within the gap limit. In this case a used
+// key can be utilized or user can wait until the gap is updated with new
+// key(s). This happens when a transaction newly gets client.AGEDTX
+// confirmations.
+func (km *KeyManager) GetUnusedKey(account uint32, purpose wallet.KeyChange) (*hd.ExtendedKey, error) {
+	if !km.hasAccount(account) {
+		return nil, wallet.ErrUnknownAccount
+	}
+	i, err := km.datastore.GetUnused(account, purpose)
 	if err != nil {
 		return nil, err
 	}
 	if len(i) == 0 {
 		return nil, errors.New("no unused keys in database")
 	}
-	return km.generateChildKey(purpose, uint32(i[0]))
+	return km.generateChildKey(account, purpose, uint32(i[0]))
 }
 
-func (km *KeyManager) GetFreshKey(purpose wallet.KeyChange) (*hd.ExtendedKey, error) {
-	index, _, err := km.datastore.GetLastKeyIndex(purpose)
+func (km *KeyManager) GetFreshKey(account uint32, purpose wallet.KeyChange) (*hd.ExtendedKey, error) {
+	index, _, err := km.datastore.GetLastKeyIndex(account, purpose)
 	var childKey *hd.ExtendedKey
 	if err != nil {
 		index = 0
@@ -95,10 +150,13 @@ func (km *KeyManager) GetFreshKey(purpose wallet.KeyChange) (*hd.ExtendedKey, er
 		// There is a small possibility bip32 keys can be invalid. The procedure in such cases
 		// is to discard the key and derive the next one. This loop will continue until a valid key
 		// is derived.
-		childKey, err = km.generateChildKey(purpose, uint32(index))
+		childKey, err = km.generateChildKey(account, purpose, uint32(index))
 		if err == nil {
 			break
 		}
+		if errors.Is(err, wallet.ErrUnknownAccount) {
+			return nil, err
+		}
 		index += 1
 	}
 	addr, err := childKey.Address(km.params)
@@ -106,8 +164,9 @@ func (km *KeyManager) GetFreshKey(purpose wallet.KeyChange) (*hd.ExtendedKey, er
 		return nil, err
 	}
 	p := wallet.KeyPath{
-		Change: wallet.KeyChange(purpose),
-		Index:  index,
+		Account: account,
+		Change:  wallet.KeyChange(purpose),
+		Index:   index,
 	}
 	err = km.datastore.Put(addr.ScriptAddress(), p)
 	if err != nil {
@@ -123,7 +182,7 @@ func (km *KeyManager) GetKeys() []*hd.ExtendedKey {
 		return keys
 	}
 	for _, path := range keyPaths {
-		k, err := km.generateChildKey(path.Change, uint32(path.Index))
+		k, err := km.generateChildKey(path.Account, path.Change, uint32(path.Index))
 		if err != nil {
 			continue
 		}
@@ -137,32 +196,54 @@ func (km *KeyManager) GetKeyForScript(scriptAddress []byte) (*hd.ExtendedKey, er
 	if err != nil {
 		return nil, err
 	}
-	return km.generateChildKey(keyPath.Change, uint32(keyPath.Index))
+	return km.generateChildKey(keyPath.Account, keyPath.Change, uint32(keyPath.Index))
 }
 
-// Mark the given key as used and extend the lookahead window
+// GetPathForScript returns the key path of a wallet script address.
+func (km *KeyManager) GetPathForScript(scriptAddress []byte) (wallet.KeyPath, error) {
+	return km.datastore.GetPathForKey(scriptAddress)
+}
+
+// Mark the given key as used and extend the lookahead windows
 func (km *KeyManager) MarkKeyAsUsed(scriptAddress []byte) error {
 	if err := km.datastore.MarkKeyAsUsed(scriptAddress); err != nil {
 		return err
 	}
-	return km.lookahead()
+	km.mtx.RLock()
+	accounts := make([]uint32, 0, len(km.accounts))
+	for account := range km.accounts {
+		accounts = append(accounts, account)
+	}
+	km.mtx.RUnlock()
+	for _, account := range accounts {
+		if err := km.lookahead(account); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (km *KeyManager) generateChildKey(purpose wallet.KeyChange, index uint32) (*hd.ExtendedKey, error) {
+func (km *KeyManager) generateChildKey(account uint32, purpose wallet.KeyChange, index uint32) (*hd.ExtendedKey, error) {
+	km.mtx.RLock()
+	keys, ok := km.accounts[account]
+	km.mtx.RUnlock()
+	if !ok {
+		return nil, wallet.ErrUnknownAccount
+	}
 	if purpose == wallet.EXTERNAL {
-		return km.externalKey.Derive(index)
+		return keys.externalKey.Derive(index)
 	} else if purpose == wallet.INTERNAL {
-		return km.internalKey.Derive(index)
+		return keys.internalKey.Derive(index)
 	}
 	return nil, errors.New("unknown key purpose")
 }
 
-func (km *KeyManager) lookahead() error {
-	lookaheadWindows := km.datastore.GetLookaheadWindows()
+func (km *KeyManager) lookahead(account uint32) error {
+	lookaheadWindows := km.datastore.GetLookaheadWindows(account)
 	for purpose, size := range lookaheadWindows {
 		if size < GAP_LIMIT {
 			for i := 0; i < (GAP_LIMIT - size); i++ {
-				_, err := km.GetFreshKey(purpose)
+				_, err := km.GetFreshKey(account, purpose)
 				if err != nil {
 					return err
 				}
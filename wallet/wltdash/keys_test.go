@@ -2,12 +2,14 @@ package wltdash
 
 import (
 	"encoding/hex"
+	"errors"
 	"testing"
 
 	"github.com/bisoncraft/go-electrum-client/client"
 	"github.com/bisoncraft/go-electrum-client/wallet"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
 )
 
 func createKeyManager() (*KeyManager, error) {
@@ -15,7 +17,7 @@ func createKeyManager() (*KeyManager, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewKeyManager(&mockKeyStore{make(map[string]*keyStoreEntry)}, &chaincfg.MainNetParams, masterPrivKey)
+	return NewKeyManager(&mockKeyStore{make(map[string]*keyStoreEntry)}, &chaincfg.MainNetParams, masterPrivKey, wallet.HDCoinTypeLegacy)
 }
 
 func TestNewKeyManager(t *testing.T) {
@@ -37,7 +39,7 @@ func TestBip44Derivation(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	internal, external, err := Bip44Derivation(masterPrivKey)
+	internal, external, err := Bip44Derivation(masterPrivKey, wallet.HDCoinTypeLegacy, wallet.DefaultAccount)
 	if err != nil {
 		t.Error(err)
 	}
@@ -66,12 +68,44 @@ func TestBip44Derivation(t *testing.T) {
 	}
 }
 
+// SLIP-44 dash path m/44'/5'/0'/0/0 of the all 'abandon' mnemonic
+func TestBip44DerivationCoinType(t *testing.T) {
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	masterPrivKey, err := hdkeychain.NewMaster(seed, DashMainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, external, err := Bip44Derivation(masterPrivKey, uint32(wallet.Dash), wallet.DefaultAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	externalKey, err := external.Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	externalAddr, err := externalKey.Address(DashMainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if externalAddr.String() != "XoJA8qE3N2Y3jMLEtZ3vcN42qseZ8LvFf5" {
+		t.Fatalf("Incorrect Bip44 key derivation %s", externalAddr)
+	}
+	cfg := &wallet.WalletConfig{CoinType: wallet.Dash, NetType: "mainnet"}
+	if cfg.HDCoinType() != 5 {
+		t.Fatal("bad mainnet coin type")
+	}
+	cfg.NetType = "testnet"
+	if cfg.HDCoinType() != wallet.HDCoinTypeTestnet {
+		t.Fatal("bad testnet coin type")
+	}
+}
+
 func TestKeys_generateChildKey(t *testing.T) {
 	km, err := createKeyManager()
 	if err != nil {
 		t.Error(err)
 	}
-	internalKey, err := km.generateChildKey(wallet.INTERNAL, 0)
+	internalKey, err := km.generateChildKey(wallet.DefaultAccount, wallet.INTERNAL, 0)
 	if err != nil {
 		t.Error(err)
 	}
@@ -82,7 +116,7 @@ func TestKeys_generateChildKey(t *testing.T) {
 	if internalAddr.String() != "16wbbYdecq9QzXdxa58q2dYXJRc8sfkE4J" {
 		t.Error("generateChildKey returned incorrect key")
 	}
-	externalKey, err := km.generateChildKey(wallet.EXTERNAL, 0)
+	externalKey, err := km.generateChildKey(wallet.DefaultAccount, wallet.EXTERNAL, 0)
 	if err != nil {
 		t.Error(err)
 	}
@@ -101,7 +135,7 @@ func TestKeyManager_lookahead(t *testing.T) {
 		t.Error(err)
 	}
 	mock := &mockKeyStore{make(map[string]*keyStoreEntry)}
-	km, err := NewKeyManager(mock, &chaincfg.MainNetParams, masterPrivKey)
+	km, err := NewKeyManager(mock, &chaincfg.MainNetParams, masterPrivKey, wallet.HDCoinTypeLegacy)
 	if err != nil {
 		t.Error(err)
 	}
@@ -109,7 +143,7 @@ func TestKeyManager_lookahead(t *testing.T) {
 		key.used = true
 	}
 	n := len(mock.keys)
-	err = km.lookahead()
+	err = km.lookahead(wallet.DefaultAccount)
 	if err != nil {
 		t.Error(err)
 	}
@@ -132,14 +166,14 @@ func TestKeyManager_MarkKeyAsUsed(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	i, err := km.datastore.GetUnused(wallet.EXTERNAL)
+	i, err := km.datastore.GetUnused(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil {
 		t.Error(err)
 	}
 	if len(i) == 0 {
 		t.Error("No unused keys in database")
 	}
-	key, err := km.generateChildKey(wallet.EXTERNAL, uint32(i[0]))
+	key, err := km.generateChildKey(wallet.DefaultAccount, wallet.EXTERNAL, uint32(i[0]))
 	if err != nil {
 		t.Error(err)
 	}
@@ -154,7 +188,7 @@ func TestKeyManager_MarkKeyAsUsed(t *testing.T) {
 	if len(km.GetKeys()) != (client.GAP_LIMIT*2)+1 {
 		t.Error("Failed to extend lookahead window when marking as read")
 	}
-	unused, err := km.datastore.GetUnused(wallet.EXTERNAL)
+	unused, err := km.datastore.GetUnused(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil {
 		t.Error(err)
 	}
@@ -171,7 +205,7 @@ func TestKeyManager_GetUnusedKey(t *testing.T) {
 		t.Error(err)
 	}
 	mock := &mockKeyStore{make(map[string]*keyStoreEntry)}
-	km, err := NewKeyManager(mock, &chaincfg.MainNetParams, masterPrivKey)
+	km, err := NewKeyManager(mock, &chaincfg.MainNetParams, masterPrivKey, wallet.HDCoinTypeLegacy)
 	if err != nil {
 		t.Error(err)
 	}
@@ -182,7 +216,7 @@ func TestKeyManager_GetUnusedKey(t *testing.T) {
 			break
 		}
 	}
-	key, err := km.GetUnusedKey(wallet.EXTERNAL)
+	key, err := km.GetUnusedKey(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil {
 		t.Error(err)
 	}
@@ -200,7 +234,7 @@ func TestKeyManager_GetFreshKey(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	key, err := km.GetFreshKey(wallet.EXTERNAL)
+	key, err := km.GetFreshKey(wallet.DefaultAccount, wallet.EXTERNAL)
 	if err != nil {
 		t.Error(err)
 	}
@@ -208,7 +242,7 @@ func TestKeyManager_GetFreshKey(t *testing.T) {
 		t.Error("Failed to create additional key")
 	}
 	edgeCaseKeyNumber := uint32(client.GAP_LIMIT)
-	key2, err := km.generateChildKey(wallet.EXTERNAL, edgeCaseKeyNumber)
+	key2, err := km.generateChildKey(wallet.DefaultAccount, wallet.EXTERNAL, edgeCaseKeyNumber)
 	if err != nil {
 		t.Error(err)
 	}
@@ -232,3 +266,49 @@ func TestKeyManager_GetKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestKeyManager_AddAccount(t *testing.T) {
+	km, err := createKeyManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	masterPrivKey, err := hdkeychain.NewKeyFromString("xprv9s21ZrQH143K25QhxbucbDDuQ4naNntJRi4KUfWT7xo4EKsHt2QJDu7KXp1A3u7Bi1j8ph3EGsZ9Xvz9dGuVrtHHs7pXeTzjuxBrCmmhgC6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := km.AddAccount(masterPrivKey, 1); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := km.datastore.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != client.GAP_LIMIT*4 {
+		t.Error("Failed to generate lookahead windows for the account")
+	}
+	_, external, err := Bip44Derivation(masterPrivKey, wallet.HDCoinTypeLegacy, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := external.Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := km.GetUnusedKey(1, wallet.EXTERNAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.String() != want.String() {
+		t.Error("GetUnusedKey returned a key not on the account path")
+	}
+	defaultKey, err := km.GetUnusedKey(wallet.DefaultAccount, wallet.EXTERNAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.String() == defaultKey.String() {
+		t.Error("account key is the default account key")
+	}
+	if _, err := km.generateChildKey(2, wallet.EXTERNAL, 0); !errors.Is(err, wallet.ErrUnknownAccount) {
+		t.Errorf("expected %v got %v", wallet.ErrUnknownAccount, err)
+	}
+}
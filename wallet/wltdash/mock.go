@@ -5,13 +5,13 @@ import (
 	"errors"
 	"sort"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
-	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/tyler-smith/go-bip39"
@@ -35,10 +35,11 @@ func createTxStore() (*TxStore, *StorageManager) {
 	}
 
 	seed := makeRegtestSeed()
-	key, _ := hdkeychain.NewMaster(seed, &chaincfg.RegressionNetParams)
-	km, _ := NewKeyManager(mockDb.Keys(), &chaincfg.RegressionNetParams, key)
-	sm := NewStorageManager(mockDb.Enc(), &chaincfg.RegressionNetParams)
-	txStore, _ := NewTxStore(&chaincfg.RegressionNetParams, &mockDb, km)
+	key, _ := hdkeychain.NewMaster(seed, DashRegtestParams)
+	// the test vectors were made on the legacy derivation path
+	km, _ := NewKeyManager(mockDb.Keys(), DashRegtestParams, key, wallet.HDCoinTypeLegacy)
+	sm := NewStorageManager(mockDb.Enc(), DashRegtestParams)
+	txStore, _ := NewTxStore(DashRegtestParams, &mockDb, km)
 	return txStore, sm
 }
 
@@ -54,9 +55,11 @@ func MockWallet(pw string) *DashElectrumWallet {
 	wallet := &DashElectrumWallet{
 		txstore:        txstore,
 		keyManager:     txstore.keyManager,
+		signer:         &hdSigner{km: txstore.keyManager},
 		storageManager: storageMgr,
-		params:         &chaincfg.RegressionNetParams,
+		params:         DashRegtestParams,
 		feeProvider:    wallet.DefaultFeeProvider(),
+		mutex:          new(sync.RWMutex),
 	}
 
 	// fundWallet(wallet)
@@ -103,7 +106,9 @@ func (m *MockDatastore) Subscriptions() wallet.Subscriptions {
 }
 
 type mockConfig struct {
-	creationDate time.Time
+	creationDate    time.Time
+	dataVersion     int
+	migrationCursor string
 }
 
 func (mc *mockConfig) PutCreationDate(date time.Time) error {
@@ -115,6 +120,24 @@ func (mc *mockConfig) GetCreationDate() (time.Time, error) {
 	return mc.creationDate, nil
 }
 
+func (mc *mockConfig) PutDataVersion(version int) error {
+	mc.dataVersion = version
+	return nil
+}
+
+func (mc *mockConfig) GetDataVersion() (int, error) {
+	return mc.dataVersion, nil
+}
+
+func (mc *mockConfig) PutMigrationCursor(txid string) error {
+	mc.migrationCursor = txid
+	return nil
+}
+
+func (mc *mockConfig) GetMigrationCursor() (string, error) {
+	return mc.migrationCursor, nil
+}
+
 // encrypted blob
 type mockStorage struct {
 	blob []byte
@@ -180,11 +203,11 @@ func (m *mockKeyStore) MarkKeyAsUsed(scriptAddress []byte) error {
 	return nil
 }
 
-func (m *mockKeyStore) GetLastKeyIndex(purpose wallet.KeyChange) (int, bool, error) {
+func (m *mockKeyStore) GetLastKeyIndex(account uint32, purpose wallet.KeyChange) (int, bool, error) {
 	i := -1
 	used := false
 	for _, key := range m.keys {
-		if key.path.Change == purpose && key.path.Index > i {
+		if key.path.Account == account && key.path.Change == purpose && key.path.Index > i {
 			i = key.path.Index
 			used = key.used
 		}
@@ -203,10 +226,10 @@ func (m *mockKeyStore) GetPathForKey(scriptAddress []byte) (wallet.KeyPath, erro
 	return key.path, nil
 }
 
-func (m *mockKeyStore) GetUnused(purpose wallet.KeyChange) ([]int, error) {
+func (m *mockKeyStore) GetUnused(account uint32, purpose wallet.KeyChange) ([]int, error) {
 	var i []int
 	for _, key := range m.keys {
-		if !key.used && key.path.Change == purpose {
+		if !key.used && key.path.Account == account && key.path.Change == purpose {
 			i = append(i, key.path.Index)
 		}
 	}
@@ -228,10 +251,13 @@ func (m *mockKeyStore) GetDbg() string {
 	return ret
 }
 
-func (m *mockKeyStore) GetLookaheadWindows() map[wallet.KeyChange]int {
+func (m *mockKeyStore) GetLookaheadWindows(account uint32) map[wallet.KeyChange]int {
 	internalLastUsed := -1
 	externalLastUsed := -1
 	for _, key := range m.keys {
+		if key.path.Account != account {
+			continue
+		}
 		if key.path.Change == wallet.INTERNAL && key.used && key.path.Index > internalLastUsed {
 			internalLastUsed = key.path.Index
 		}
@@ -242,6 +268,9 @@ func (m *mockKeyStore) GetLookaheadWindows() map[wallet.KeyChange]int {
 	internalUnused := 0
 	externalUnused := 0
 	for _, key := range m.keys {
+		if key.path.Account != account {
+			continue
+		}
 		if key.path.Change == wallet.INTERNAL && !key.used && key.path.Index > internalLastUsed {
 			internalUnused++
 		}
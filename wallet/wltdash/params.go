@@ -0,0 +1,104 @@
+package wltdash
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Dash chain parameters. Only the fields used by the wallet are filled in:
+// network magic, address and key encodings and the genesis hash. Dash has no
+// segwit so there is no bech32 prefix and only P2PKH and P2SH addresses.
+//
+// See https://github.com/dashpay/dash/blob/master/src/chainparams.cpp
+
+// DashMainNetParams are the Dash mainnet parameters. Addresses start with X
+// (P2PKH) and 7 (P2SH).
+var DashMainNetParams = &chaincfg.Params{
+	Name:             "mainnet",
+	Net:              wire.BitcoinNet(0xbd6b0cbf),
+	DefaultPort:      "9999",
+	GenesisHash:      newHashFromStr("00000ffd590b1485b3caadc19b22e6379c733355108f107a430458cdf3407ab6"),
+	PowLimit:         dashPowLimit,
+	PowLimitBits:     0x1e0fffff,
+	PubKeyHashAddrID: 0x4c, // X
+	ScriptHashAddrID: 0x10, // 7
+	PrivateKeyID:     0xcc,
+	HDPrivateKeyID:   [4]byte{0x04, 0x88, 0xad, 0xe4}, // xprv
+	HDPublicKeyID:    [4]byte{0x04, 0x88, 0xb2, 0x1e}, // xpub
+	HDCoinType:       5,
+}
+
+// DashTestNetParams are the Dash testnet parameters. Addresses start with y
+// (P2PKH) and 8 or 9 (P2SH).
+var DashTestNetParams = &chaincfg.Params{
+	Name:             "testnet",
+	Net:              wire.BitcoinNet(0xffcae2ce),
+	DefaultPort:      "19999",
+	GenesisHash:      newHashFromStr("00000bafbc94add76cb75e2ec92894837288a481e5c005f6563d91623bf8bc2c"),
+	PowLimit:         dashPowLimit,
+	PowLimitBits:     0x1e0fffff,
+	PubKeyHashAddrID: 0x8c, // y
+	ScriptHashAddrID: 0x13, // 8 or 9
+	PrivateKeyID:     0xef,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94}, // tprv
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf}, // tpub
+	HDCoinType:       1,
+}
+
+// DashRegtestParams are the Dash regtest parameters. Addresses are encoded as
+// for testnet.
+var DashRegtestParams = &chaincfg.Params{
+	Name:             "regtest",
+	Net:              wire.BitcoinNet(0xdcb7c1fc),
+	DefaultPort:      "19899",
+	GenesisHash:      newHashFromStr("000008ca1832a4baf228eb1553c03d3a2c8e02399550dd6ea8d65cec3ef23d2e"),
+	PowLimit:         regtestPowLimit,
+	PowLimitBits:     0x207fffff,
+	PubKeyHashAddrID: 0x8c,
+	ScriptHashAddrID: 0x13,
+	PrivateKeyID:     0xef,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94},
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf},
+	HDCoinType:       1,
+}
+
+var (
+	bigOne = big.NewInt(1)
+	// ~uint256(0) >> 20
+	dashPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 236), bigOne)
+	// ~uint256(0) >> 1
+	regtestPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne)
+)
+
+var ErrNotDashAddress = errors.New("not a dash P2PKH or P2SH address")
+
+// DecodeAddress decodes a Dash address for the network params. Only P2PKH and
+// P2SH addresses exist on Dash so anything else is refused.
+func DecodeAddress(addr string, params *chaincfg.Params) (btcutil.Address, error) {
+	address, err := btcutil.DecodeAddress(addr, params)
+	if err != nil {
+		return nil, err
+	}
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressScriptHash:
+	default:
+		return nil, ErrNotDashAddress
+	}
+	if !address.IsForNet(params) {
+		return nil, ErrNotDashAddress
+	}
+	return address, nil
+}
+
+func newHashFromStr(s string) *chainhash.Hash {
+	hash, err := chainhash.NewHashFromStr(s)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
@@ -0,0 +1,57 @@
+package wltdash
+
+import (
+	"testing"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestDecodeAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		params *chaincfg.Params
+		valid  bool
+	}{
+		{"XoKbDs6Sk2qHV5E65rsoLJhqqtCJqw9Nc7", DashMainNetParams, true},
+		{"7VzMdYyqRWN3X9ybuFwtTiqbT8WtHYYhaM", DashMainNetParams, true},
+		{"yYxCEpAtBaVMpp9deiCCNL8C8AggR37XZ2", DashTestNetParams, true},
+		{"yYxCEpAtBaVMpp9deiCCNL8C8AggR37XZ2", DashRegtestParams, true},
+		// testnet address on mainnet
+		{"yYxCEpAtBaVMpp9deiCCNL8C8AggR37XZ2", DashMainNetParams, false},
+		// bitcoin addresses
+		{"1DZ3eMoaGXjbnCBpHUm1gQEe2VKq8kaRjV", DashMainNetParams, false},
+		{"bc1q322tg0y2hzyp9zztr7d2twdclhqg88anmr2q3h", DashMainNetParams, false},
+		{"bcrt1q322tg0y2hzyp9zztr7d2twdclhqg88anvzxwwr", DashRegtestParams, false},
+	}
+	for _, tt := range tests {
+		addr, err := DecodeAddress(tt.addr, tt.params)
+		if !tt.valid {
+			if err == nil {
+				t.Fatalf("%s decoded for %s", tt.addr, tt.params.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.addr, err)
+		}
+		if addr.EncodeAddress() != tt.addr {
+			t.Fatalf("%s re-encoded as %s", tt.addr, addr.EncodeAddress())
+		}
+	}
+}
+
+func TestWalletAddressesP2PKH(t *testing.T) {
+	w := MockWallet("abc")
+	addr, err := w.GetUnusedAddress(wallet.RECEIVING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := addr.(*btcutil.AddressPubKeyHash); !ok {
+		t.Fatalf("unexpected address type %T", addr)
+	}
+	if addr.EncodeAddress()[0] != 'y' {
+		t.Fatalf("not a dash regtest address %s", addr)
+	}
+}
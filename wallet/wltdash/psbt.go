@@ -0,0 +1,20 @@
+package wltdash
+
+import (
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+)
+
+// PSBTs are a bitcoin format and are not implemented for Dash.
+
+func (w *DashElectrumWallet) CreatePsbt(
+	account uint32,
+	outputs []wallet.TransactionOutput,
+	feeLevel wallet.FeeLevel) (*psbt.Packet, int, error) {
+
+	return nil, 0, wallet.ErrWalletFnNotImplemented
+}
+
+func (w *DashElectrumWallet) SignPsbt(pw string, packet *psbt.Packet, opts *wallet.PsbtSignOptions) (int, error) {
+	return 0, wallet.ErrWalletFnNotImplemented
+}
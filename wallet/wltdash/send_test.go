@@ -0,0 +1,264 @@
+package wltdash
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSpendMany(t *testing.T) {
+	w := MockWallet(pw)
+	w.blockchainTip = 500
+	putAccountUtxo(t, w, wallet.DefaultAccount, 0x01, 100_000_000)
+	putAccountUtxo(t, w, wallet.DefaultAccount, 0x02, 100_000_000)
+
+	payee1, err := w.DecodeAddress("yLMpV1pHssCVt7R5hqwVGH9VngDj1sKCoR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payee2, err := btcutil.NewAddressPubKeyHash(make([]byte, 20), w.params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := []wallet.TransactionOutput{
+		{Address: payee1, Value: 120_000_000},
+		{Address: payee2, Value: 30_000_000},
+	}
+	changeIndex, tx, err := w.SpendMany(pw, wallet.DefaultAccount, outputs, wallet.NORMAL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn) != 2 || len(tx.TxOut) != 3 {
+		t.Fatalf("expected 2 inputs and 3 outputs got %d and %d", len(tx.TxIn), len(tx.TxOut))
+	}
+	paid := make(map[int64]bool)
+	for i, txOut := range tx.TxOut {
+		if i != changeIndex {
+			paid[txOut.Value] = true
+		}
+	}
+	if !paid[120_000_000] || !paid[30_000_000] {
+		t.Fatal("an output is not paid")
+	}
+	changeAddr, err := w.ScriptToAddress(tx.TxOut[changeIndex].PkScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath, err := w.keyManager.GetPathForScript(changeAddr.ScriptAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyPath.Change != wallet.CHANGE {
+		t.Fatalf("change index %d is not change", changeIndex)
+	}
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) == 0 {
+			t.Fatal("input not signed")
+		}
+	}
+
+	// every output is checked for dust
+	dusty := append(outputs, wallet.TransactionOutput{Address: payee2, Value: 100})
+	if _, _, err := w.SpendMany(pw, wallet.DefaultAccount, dusty, wallet.NORMAL, nil); !errors.Is(err, wallet.ErrDustAmount) {
+		t.Fatalf("expected %v got %v", wallet.ErrDustAmount, err)
+	}
+	if _, _, err := w.SpendMany(pw, wallet.DefaultAccount, nil, wallet.NORMAL, nil); !errors.Is(err, wallet.ErrNoOutputs) {
+		t.Fatalf("expected %v got %v", wallet.ErrNoOutputs, err)
+	}
+	outputs[0].Value = 200_000_000
+	if _, _, err := w.SpendMany(pw, wallet.DefaultAccount, outputs, wallet.NORMAL, nil); !errors.Is(err, wallet.ErrInsufficientFunds) {
+		t.Fatalf("expected %v got %v", wallet.ErrInsufficientFunds, err)
+	}
+}
+
+// spends reports whether tx spends op.
+func spends(tx *wire.MsgTx, op wire.OutPoint) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.PreviousOutPoint == op {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSpendManyCoinControl(t *testing.T) {
+	w := MockWallet(pw)
+	w.blockchainTip = 500
+	big := putAccountUtxo(t, w, wallet.DefaultAccount, 0x01, 100_000_000)
+	medium := putAccountUtxo(t, w, wallet.DefaultAccount, 0x02, 50_000_000)
+	small := putAccountUtxo(t, w, wallet.DefaultAccount, 0x03, 20_000_000)
+
+	payee, err := w.DecodeAddress("yLMpV1pHssCVt7R5hqwVGH9VngDj1sKCoR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay := func(value int64) []wallet.TransactionOutput {
+		return []wallet.TransactionOutput{{Address: payee, Value: value}}
+	}
+
+	// only the chosen coins
+	_, tx, err := w.SpendMany(pw, wallet.DefaultAccount, pay(30_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Include: []wire.OutPoint{medium.Op}, IncludeOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn) != 1 || !spends(tx, medium.Op) {
+		t.Fatal("did not spend only the chosen coin")
+	}
+	_, _, err = w.SpendMany(pw, wallet.DefaultAccount, pay(30_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Include: []wire.OutPoint{small.Op}, IncludeOnly: true})
+	if !errors.Is(err, wallet.ErrChosenCoinsInsufficient) {
+		t.Fatalf("expected %v got %v", wallet.ErrChosenCoinsInsufficient, err)
+	}
+
+	// a must include coin topped up by the selector
+	_, tx, err = w.SpendMany(pw, wallet.DefaultAccount, pay(30_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Include: []wire.OutPoint{small.Op, small.Op}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn) != 2 || !spends(tx, small.Op) {
+		t.Fatal("did not spend the included coin")
+	}
+
+	// avoided coins
+	_, tx, err = w.SpendMany(pw, wallet.DefaultAccount, pay(60_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Avoid: []wire.OutPoint{big.Op}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spends(tx, big.Op) || !spends(tx, medium.Op) || !spends(tx, small.Op) {
+		t.Fatal("spent an avoided coin")
+	}
+	_, _, err = w.SpendMany(pw, wallet.DefaultAccount, pay(60_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Avoid: []wire.OutPoint{big.Op, medium.Op}})
+	if !errors.Is(err, wallet.ErrInsufficientFunds) {
+		t.Fatalf("expected %v got %v", wallet.ErrInsufficientFunds, err)
+	}
+
+	_, _, err = w.SpendMany(pw, wallet.DefaultAccount, pay(30_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Include: []wire.OutPoint{big.Op}, Avoid: []wire.OutPoint{big.Op}})
+	if !errors.Is(err, wallet.ErrCoinIncludedAndAvoided) {
+		t.Fatalf("expected %v got %v", wallet.ErrCoinIncludedAndAvoided, err)
+	}
+	unknown := wire.OutPoint{Hash: chainhash.Hash{0x09}}
+	_, _, err = w.SpendMany(pw, wallet.DefaultAccount, pay(30_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Include: []wire.OutPoint{unknown}})
+	if !errors.Is(err, wallet.ErrCoinNotSpendable) {
+		t.Fatalf("expected %v got %v", wallet.ErrCoinNotSpendable, err)
+	}
+	if err := w.FreezeUTXO(&big.Op); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = w.SpendMany(pw, wallet.DefaultAccount, pay(30_000_000), wallet.NORMAL,
+		&wallet.SpendOptions{Include: []wire.OutPoint{big.Op}})
+	if !errors.Is(err, wallet.ErrCoinNotSpendable) {
+		t.Fatalf("expected %v got %v", wallet.ErrCoinNotSpendable, err)
+	}
+}
+
+func TestEstimateSpend(t *testing.T) {
+	w := MockWallet(pw)
+	w.blockchainTip = 500
+	utxo1 := putAccountUtxo(t, w, wallet.DefaultAccount, 0x01, 100_000_000)
+	utxo2 := putAccountUtxo(t, w, wallet.DefaultAccount, 0x02, 100_000_000)
+	prevOuts := map[wire.OutPoint]*wire.TxOut{
+		utxo1.Op: wire.NewTxOut(utxo1.Value, utxo1.ScriptPubkey),
+		utxo2.Op: wire.NewTxOut(utxo2.Value, utxo2.ScriptPubkey),
+	}
+	payee, err := w.DecodeAddress("yLMpV1pHssCVt7R5hqwVGH9VngDj1sKCoR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := []wallet.TransactionOutput{{Address: payee, Value: 150_000_000}}
+
+	// the preview is the spend
+	estimate, err := w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(estimate.Inputs) != 2 || len(estimate.Outputs) != 2 || estimate.ChangeIndex < 0 {
+		t.Fatalf("unexpected estimate %+v", estimate)
+	}
+	if estimate.Fee != estimate.VSize*w.GetFeePerByte(wallet.NORMAL) {
+		t.Fatalf("fee %d for vsize %d", estimate.Fee, estimate.VSize)
+	}
+	if estimate.Change != 200_000_000-150_000_000-estimate.Fee {
+		t.Fatalf("change %d for fee %d", estimate.Change, estimate.Fee)
+	}
+	changeIndex, tx, err := w.SpendMany(pw, wallet.DefaultAccount, outputs, wallet.NORMAL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changeIndex != estimate.ChangeIndex || !reflect.DeepEqual(tx.TxOut, estimate.Outputs) {
+		t.Fatal("spend differs from its estimate")
+	}
+	for i, txIn := range tx.TxIn {
+		if txIn.PreviousOutPoint != estimate.Inputs[i].Outpoint {
+			t.Fatal("spend inputs differ from its estimate")
+		}
+	}
+	if vsize := int64(tx.SerializeSize()); vsize > estimate.VSize {
+		t.Fatalf("signed vsize %d over the estimate %d", vsize, estimate.VSize)
+	}
+
+	// an explicit fee rate
+	estimate, err = w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL,
+		&wallet.SpendOptions{FeeRate: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Fee != estimate.VSize*3 {
+		t.Fatalf("fee %d for vsize %d at 3 sat/vB", estimate.Fee, estimate.VSize)
+	}
+
+	// an absolute fee
+	opts := &wallet.SpendOptions{Fee: 12_345}
+	estimate, err = w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Fee != 12_345 || len(estimate.Outputs) != 2 {
+		t.Fatalf("unexpected estimate %+v", estimate)
+	}
+	_, tx, err = w.SpendMany(pw, wallet.DefaultAccount, outputs, wallet.NORMAL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputTotal int64
+	for _, txOut := range tx.TxOut {
+		outputTotal += txOut.Value
+	}
+	if fee := 200_000_000 - outputTotal; fee != 12_345 {
+		t.Fatalf("expected fee 12345 got %d", fee)
+	}
+	_ = prevOuts
+
+	// an absolute fee must relay and a fee rate is capped
+	_, err = w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL,
+		&wallet.SpendOptions{Fee: 100})
+	if !errors.Is(err, wallet.ErrFeeTooLow) {
+		t.Fatalf("expected %v got %v", wallet.ErrFeeTooLow, err)
+	}
+	_, err = w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL,
+		&wallet.SpendOptions{FeeRate: w.feeProvider.MaxFee + 1})
+	if !errors.Is(err, wallet.ErrFeeRateTooHigh) {
+		t.Fatalf("expected %v got %v", wallet.ErrFeeRateTooHigh, err)
+	}
+
+	_, err = w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL,
+		&wallet.SpendOptions{FeeRate: 3, Fee: 12_345})
+	if !errors.Is(err, wallet.ErrFeeRateAndFee) {
+		t.Fatalf("expected %v got %v", wallet.ErrFeeRateAndFee, err)
+	}
+	_, err = w.EstimateSpend(wallet.DefaultAccount, outputs, wallet.NORMAL,
+		&wallet.SpendOptions{FeeRate: -1})
+	if !errors.Is(err, wallet.ErrNegativeFee) {
+		t.Fatalf("expected %v got %v", wallet.ErrNegativeFee, err)
+	}
+}
@@ -19,6 +19,9 @@ func (w *DashElectrumWallet) SignTx(pw string, info *wallet.SigningInfo) ([]byte
 	if ok := w.storageManager.IsValidPw(pw); !ok {
 		return nil, errors.New("invalid password")
 	}
+	if w.getSigner() == nil {
+		return nil, wallet.ErrWatchOnly
+	}
 	// Note: maybe change this for future CPFP logic, tricky!
 	confirmedUtxos, err := w.ListConfirmedUnspent()
 	validConfirmedUtxo := func(op wire.OutPoint) (*wallet.Utxo, bool) {
@@ -33,62 +36,28 @@ func (w *DashElectrumWallet) SignTx(pw string, info *wallet.SigningInfo) ([]byte
 		return nil, err
 	}
 	tx := info.UnsignedTx
-	prevOutFetcher := new(txscript.CannedPrevOutputFetcher)
+	// the signer is shown the outputs spent by every input
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	utxos := make([]*wallet.Utxo, len(tx.TxIn))
 	for idx, input := range tx.TxIn {
 		op := input.PreviousOutPoint
 		utxo, valid := validConfirmedUtxo(op)
 		if !valid {
 			return nil, fmt.Errorf("outpoint %s is not valid (maybe not confirmed?)", op.String())
 		}
-		pkScript, err := txscript.ParsePkScript(utxo.ScriptPubkey)
-		if err != nil {
-			return nil, err
-		}
-		address, err := pkScript.Address(w.params)
-		if err != nil {
-			return nil, err
-		}
-		key, err := w.txstore.keyManager.GetKeyForScript(address.ScriptAddress())
-		if err != nil {
-			return nil, err
-		}
-		defer key.Zero()
-		privKey, err := key.ECPrivKey()
+		prevOutFetcher.AddPrevOut(op, wire.NewTxOut(utxo.Value, utxo.ScriptPubkey))
+		utxos[idx] = utxo
+	}
+	for idx := range tx.TxIn {
+		utxo := utxos[idx]
+		err = w.signInput(tx, idx, wire.NewTxOut(utxo.Value, utxo.ScriptPubkey), prevOutFetcher)
 		if err != nil {
 			return nil, err
 		}
-		sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
-		prevOutScriptTy := txscript.GetScriptClass(pkScript.Script())
-		switch prevOutScriptTy {
-		case txscript.WitnessV0ScriptHashTy:
-			return nil, errors.New("signing P2WSH not (yet) supported")
-		case txscript.WitnessV0PubKeyHashTy:
-			sig, err := txscript.WitnessSignature(tx, sigHashes, idx, utxo.Value,
-				pkScript.Script(), txscript.SigHashAll, privKey, true)
-			if err != nil {
-				return nil, err
-			}
-			// add witness
-			input.SignatureScript = nil
-			input.Witness = append(input.Witness, sig...)
-		case txscript.PubKeyHashTy:
-			// note we do not really support P2PK for outbound txs
-			sig, err := txscript.SignatureScript(tx, idx,
-				pkScript.Script(), txscript.SigHashAll, privKey, true)
-			if err != nil {
-				return nil, err
-			}
-			// add script sig
-			input.SignatureScript = append(input.SignatureScript, sig...)
-			input.Witness = nil
-		default:
-			return nil, fmt.Errorf("signing for script type %v unsupported",
-				prevOutScriptTy)
-		}
 		if info.VerifyTx {
 			e, err := txscript.NewDebugEngine(
 				// pubkey script
-				pkScript.Script(),
+				utxo.ScriptPubkey,
 				// refund transaction
 				tx,
 				// transaction input index
@@ -10,8 +10,8 @@ import (
 )
 
 var (
-	testUnsignedTx, _ = hex.DecodeString("0100000002a1f14ec5d026a95c9c28909d07480575f1e82587290ab58d238d893c6c4e5e020100000000ffffffffd1376afd51d837578e3f74f1716f95332752f84b9847bc6b7d56190111c49c210100000000ffffffff02463cc32300000000160014dd3c22b42d29ea8ab7ec454e8bce628a07200ccd0027b929000000001600148a94b43c8ab88812884b1f9aa5b9b8fdc0839fb300000000")
-	testSignedTx, _   = hex.DecodeString("01000000000102a1f14ec5d026a95c9c28909d07480575f1e82587290ab58d238d893c6c4e5e020100000000ffffffffd1376afd51d837578e3f74f1716f95332752f84b9847bc6b7d56190111c49c210100000000ffffffff02463cc32300000000160014dd3c22b42d29ea8ab7ec454e8bce628a07200ccd0027b929000000001600148a94b43c8ab88812884b1f9aa5b9b8fdc0839fb30247304402205d8e2935940dbaa4b18b1f5ae1e5f6b6c82d468f6e248371a1014142495146d8022038afa4c58fb697ad8757f36f77fcf1ae4e39f17a10ed5f150a3788785f0b6e42012102cb969af83427bfb1d271a7eb16f7fa3d16794a93369d0da293f721e925af9135024730440220652bfde710afa0c64cd5dfd967525d37003c55b708cb34ea4a50a47b85eb1bac02200e518b663bfcfb4386e981c4872ac1a726c3e5fc0f3acc82303168563c7ce690012102cb969af83427bfb1d271a7eb16f7fa3d16794a93369d0da293f721e925af913500000000")
+	testUnsignedTx, _ = hex.DecodeString("0100000002a1f14ec5d026a95c9c28909d07480575f1e82587290ab58d238d893c6c4e5e020100000000ffffffffd1376afd51d837578e3f74f1716f95332752f84b9847bc6b7d56190111c49c210100000000ffffffff02463cc323000000001976a914dd3c22b42d29ea8ab7ec454e8bce628a07200ccd88ac0027b929000000001976a9148a94b43c8ab88812884b1f9aa5b9b8fdc0839fb388ac00000000")
+	testSignedTx, _   = hex.DecodeString("0100000002a1f14ec5d026a95c9c28909d07480575f1e82587290ab58d238d893c6c4e5e02010000006b483045022100c79d2c41be75b52bd02c4b2324e9397f2c21ceefc71266d4f2e1f11d307bf6380220432ac4ab6ff6fd4ce77242c6522e7f676155faab84d99061a1fc582b00e6dee4012102cb969af83427bfb1d271a7eb16f7fa3d16794a93369d0da293f721e925af9135ffffffffd1376afd51d837578e3f74f1716f95332752f84b9847bc6b7d56190111c49c21010000006a47304402207f338b68ae2cd4f51b98836a2f5a8740bf16ea1529803e33d844d08f2ea6c4bf02201be37e8e32a713a97e0326b3284541cf08ad46c9631010b03d3990c0fab604bc012102cb969af83427bfb1d271a7eb16f7fa3d16794a93369d0da293f721e925af9135ffffffff02463cc323000000001976a914dd3c22b42d29ea8ab7ec454e8bce628a07200ccd88ac0027b929000000001976a9148a94b43c8ab88812884b1f9aa5b9b8fdc0839fb388ac00000000")
 )
 
 func getUtxos() []*wallet.Utxo {
@@ -23,14 +23,14 @@ func getUtxos() []*wallet.Utxo {
 	op6, _ := wire.NewOutPointFromString("58ab39331daa512aa0cdbc2b7adbfc0e6a7b96bb5e076402e8d10b9447c44c97:1")
 	op7, _ := wire.NewOutPointFromString("5ea497f8d6edd2471303c2091e2e84770e40c83d1849cd08ac9edd2102b7f164:0")
 	op8, _ := wire.NewOutPointFromString("72639920285ff6ed212b1a14b65a4175f5409ba25c8c735a725560eafa027dbe:0")
-	script1, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
-	script2, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
-	script3, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
-	script4, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
-	script5, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
-	script6, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
-	script7, _ := hex.DecodeString("0014df0683535861d41af232009259b5d3811d4471a8")
-	script8, _ := hex.DecodeString("0014a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
+	script1, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
+	script2, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
+	script3, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
+	script4, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
+	script5, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
+	script6, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
+	script7, _ := hex.DecodeString("76a914df0683535861d41af232009259b5d3811d4471a888ac")
+	script8, _ := hex.DecodeString("76a914a30a0cf1da8c0c36ae8d637b674663ccf2b31e4588ac")
 
 	var utxos = make([]*wallet.Utxo, 0, 10)
 	utxos = []*wallet.Utxo{
@@ -105,7 +105,7 @@ func getUtxos() []*wallet.Utxo {
 func TestSignTx(t *testing.T) {
 	tx := wire.NewMsgTx(wire.TxVersion)
 	r := bytes.NewBuffer(testUnsignedTx)
-	_ = tx.Deserialize(r)
+	_ = tx.DeserializeNoWitness(r)
 	info := &wallet.SigningInfo{
 		UnsignedTx: tx,
 		VerifyTx:   true,
@@ -7,7 +7,6 @@ import (
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
 	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
@@ -67,7 +66,7 @@ func Test_gatherCoins(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	script1, err := hex.DecodeString("0014b8da433782cd9d142f32f726926bcc8161beeaef")
+	script1, err := hex.DecodeString("76a914b8da433782cd9d142f32f726926bcc8161beeaef88ac")
 	if err != nil {
 		t.Error(err)
 	}
@@ -86,7 +85,7 @@ func Test_gatherCoins(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	script2, err := hex.DecodeString("0014df0683535861d41af232009259b5d3811d4471a8")
+	script2, err := hex.DecodeString("76a914df0683535861d41af232009259b5d3811d4471a888ac")
 	if err != nil {
 		t.Error(err)
 	}
@@ -99,7 +98,7 @@ func Test_gatherCoins(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	coins := w.gatherCoins(false)
+	coins := w.gatherCoins(wallet.DefaultAccount, false)
 	for _, coin := range coins {
 		fmt.Println(coin.Hash().String(), coin.Index(), coin.NumConfs(), coin.Value(), coin.PkScript())
 	}
@@ -113,14 +112,14 @@ func Test_gatherCoins(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	coins = w.gatherCoins(false)
+	coins = w.gatherCoins(wallet.DefaultAccount, false)
 	if len(coins) > 0 {
 		t.Fatal("should be no unfrozen coin in map")
 	}
 }
 
-// The wallet is segwit by default. Here we test making a transaction from 2 inputs
-func Test_newSegwitMultiInputTransaction(t *testing.T) {
+// Dash is P2PKH only. Here we test making a transaction from 2 inputs
+func Test_newMultiInputTransaction(t *testing.T) {
 	w := MockWallet(pw)
 	w.blockchainTip = 500
 	// utxo 1
@@ -129,7 +128,7 @@ func Test_newSegwitMultiInputTransaction(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	script1, err := hex.DecodeString("0014b8da433782cd9d142f32f726926bcc8161beeaef")
+	script1, err := hex.DecodeString("76a914b8da433782cd9d142f32f726926bcc8161beeaef88ac")
 	if err != nil {
 		t.Error(err)
 	}
@@ -148,7 +147,7 @@ func Test_newSegwitMultiInputTransaction(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	script2, err := hex.DecodeString("0014df0683535861d41af232009259b5d3811d4471a8")
+	script2, err := hex.DecodeString("76a914df0683535861d41af232009259b5d3811d4471a888ac")
 	if err != nil {
 		t.Error(err)
 	}
@@ -163,7 +162,7 @@ func Test_newSegwitMultiInputTransaction(t *testing.T) {
 	}
 
 	// to harness ->
-	address, err := btcutil.DecodeAddress("bcrt1qqfepzsehqytlfvm3gmmx3zrz3yhjw2nm3yuccd", &chaincfg.RegressionNetParams)
+	address, err := w.DecodeAddress("yLMpV1pHssCVt7R5hqwVGH9VngDj1sKCoR")
 	if err != nil {
 		t.Error(err)
 	}
@@ -179,72 +178,8 @@ func Test_newSegwitMultiInputTransaction(t *testing.T) {
 	}
 }
 
-// default segwit transaction - 1 utxo consumed
-func Test_newSegwitTransaction(t *testing.T) {
-	w := MockWallet("abc")
-	w.blockchainTip = 500
-
-	// A real Tx from harness->goele
-
-	// make one utxo
-	txid := "50b636d971e7d4d918d92876d6d53a22ccc960e051f540108056ca4ad6ec080c"
-	vout := 0
-	witnessProgram := "a30a0cf1da8c0c36ae8d637b674663ccf2b31e45"
-	h1Txid, err := chainhash.NewHashFromStr(txid)
-	if err != nil {
-		t.Error(err)
-	}
-	h1OutIndex := uint32(vout)
-	h1WitnessProgram, err := hex.DecodeString(witnessProgram)
-	if err != nil {
-		t.Error(err)
-	}
-
-	segwitAddress, swerr := btcutil.NewAddressWitnessPubKeyHash(h1WitnessProgram, w.params)
-	if swerr != nil {
-		t.Error(err)
-	}
-
-	fmt.Println("Segwit address", segwitAddress.EncodeAddress())
-
-	script, err := txscript.PayToAddrScript(segwitAddress)
-	if err != nil {
-		t.Error(err)
-	}
-	op := wire.OutPoint{
-		Hash:  *h1Txid,
-		Index: h1OutIndex,
-	}
-	err = w.txstore.Utxos().Put(wallet.Utxo{
-		Op:           op,
-		ScriptPubkey: script,
-		AtHeight:     421,
-		Value:        10030000})
-	if err != nil {
-		t.Error(err)
-	}
-
-	// to harness ->
-	address, err := btcutil.DecodeAddress("bcrt1q322tg0y2hzyp9zztr7d2twdclhqg88anvzxwwr", &chaincfg.RegressionNetParams)
-	if err != nil {
-		t.Error(err)
-	}
-
-	_, _, err = w.Spend(
-		"abc",
-		int64(3000000),
-		address,
-		wallet.NORMAL,
-	)
-	if err != nil {
-		t.Error(err)
-	}
-}
-
-// The wallet is segwit by default. But we may want to spend from a legacy address
-// we previously passed to an entity (CEX?) that does not yet send to segwit bech32
-// addresses. Hopefully never though!
-func Test_newLegacyTransaction(t *testing.T) {
+// default P2PKH transaction - 1 utxo consumed
+func Test_newTransaction(t *testing.T) {
 	w := MockWallet("abc")
 	w.blockchainTip = 500
 
@@ -287,7 +222,7 @@ func Test_newLegacyTransaction(t *testing.T) {
 	}
 
 	// to harness ->
-	address, err := btcutil.DecodeAddress("bcrt1q322tg0y2hzyp9zztr7d2twdclhqg88anvzxwwr", &chaincfg.RegressionNetParams)
+	address, err := w.DecodeAddress("yYxCEpAtBaVMpp9deiCCNL8C8AggR37XZ2")
 	if err != nil {
 		t.Error(err)
 	}
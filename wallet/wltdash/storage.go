@@ -5,13 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
 
-// Encrypted storage for firo. Stored as an encrypted blob in the wallet database.
+// Encrypted storage for dash. Stored as an encrypted blob in the wallet database.
 
 type Storage struct {
 	Version string `json:"version"`
@@ -19,19 +20,36 @@ type Storage struct {
 	Xpub    string `json:"xpub"`
 	ShaPw   []byte `json:"shapw"`
 	Seed    []byte `json:"seed,omitempty"`
+	// SLIP-44 coin type of the key derivation path
+	HDCoinType uint32 `json:"hdcointype"`
+	// HD accounts made with CreateAccount. The wallet.DefaultAccount is
+	// not listed.
+	Accounts []wallet.Account `json:"accounts,omitempty"`
 }
 
+// Storage versions
+const (
+	// keys derived with wallet.HDCoinTypeLegacy whatever the coin and network
+	StorageVersionLegacyPath = "0.1"
+	// keys derived with Storage.HDCoinType
+	StorageVersionCoinType = "0.2"
+
+	StorageVersionCurrent = StorageVersionCoinType
+)
+
 // String returns the string representation of the Storage.
 func (s *Storage) String() string {
 	b := new(bytes.Buffer)
-	fmt.Fprintf(b, "{\n%s\n%s\n%s\n%v\n%v\n}\n", s.Version, s.Xprv, s.Xpub, s.ShaPw, s.Seed)
+	fmt.Fprintf(b, "{\n%s\n%s\n%s\n%v\n%v\n%d\n%v\n}\n", s.Version, s.Xprv, s.Xpub, s.ShaPw, s.Seed, s.HDCoinType, s.Accounts)
 	return b.String()
 }
 
 type StorageManager struct {
 	datastore wallet.Enc
 	params    *chaincfg.Params
-	store     *Storage
+	// mtx guards store.Accounts
+	mtx   sync.RWMutex
+	store *Storage
 }
 
 func NewStorageManager(db wallet.Enc, params *chaincfg.Params) *StorageManager {
@@ -39,13 +57,19 @@ func NewStorageManager(db wallet.Enc, params *chaincfg.Params) *StorageManager {
 		datastore: db,
 		params:    params,
 		store: &Storage{
-			Version: "0.1",
+			Version: StorageVersionCurrent,
 		},
 	}
 	return sm
 }
 
 func (sm *StorageManager) Put(pw string) error {
+	sm.mtx.RLock()
+	defer sm.mtx.RUnlock()
+	return sm.put(pw)
+}
+
+func (sm *StorageManager) put(pw string) error {
 	if len(pw) == 0 {
 		return errors.New("no password")
 	}
@@ -80,3 +104,56 @@ func (sm *StorageManager) IsValidPw(pw string) bool {
 	shaPw := chainhash.HashB([]byte(pw))
 	return bytes.Equal(sm.store.ShaPw, shaPw)
 }
+
+// migrateLegacyPath records the legacy key derivation path of a wallet made
+// before StorageVersionCoinType so its funds stay reachable. Does nothing for
+// newer storage.
+func (sm *StorageManager) migrateLegacyPath(pw string) error {
+	if sm.store.Version != StorageVersionLegacyPath {
+		return nil
+	}
+	sm.store.HDCoinType = wallet.HDCoinTypeLegacy
+	sm.store.Version = StorageVersionCoinType
+	return sm.Put(pw)
+}
+
+// accountNumbers returns the numbers of the created HD accounts.
+func (s *Storage) accountNumbers() []uint32 {
+	numbers := make([]uint32, 0, len(s.Accounts))
+	for _, account := range s.Accounts {
+		numbers = append(numbers, account.Number)
+	}
+	return numbers
+}
+
+// accounts returns the created HD accounts.
+func (sm *StorageManager) accounts() []wallet.Account {
+	sm.mtx.RLock()
+	defer sm.mtx.RUnlock()
+	return append([]wallet.Account(nil), sm.store.Accounts...)
+}
+
+// addAccount records a new HD account numbered after the others and stores
+// it. The account is not recorded if it cannot be stored.
+func (sm *StorageManager) addAccount(pw, name string) (uint32, error) {
+	sm.mtx.Lock()
+	defer sm.mtx.Unlock()
+	if name == defaultAccountName {
+		return 0, ErrDupAccountName
+	}
+	for _, account := range sm.store.Accounts {
+		if account.Name == name {
+			return 0, ErrDupAccountName
+		}
+	}
+	number := uint32(len(sm.store.Accounts)) + 1
+	sm.store.Accounts = append(sm.store.Accounts, wallet.Account{
+		Number: number,
+		Name:   name,
+	})
+	if err := sm.put(pw); err != nil {
+		sm.store.Accounts = sm.store.Accounts[:len(sm.store.Accounts)-1]
+		return 0, err
+	}
+	return number, nil
+}
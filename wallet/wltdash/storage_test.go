@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/bisoncraft/go-electrum-client/wallet"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
@@ -91,3 +92,42 @@ func TestValidPw(t *testing.T) {
 	}
 	fmt.Println("valid pw")
 }
+
+func TestMigrateLegacyPath(t *testing.T) {
+	sm := createStorageManager()
+	// storage from before the coin type was recorded
+	legacy := fmt.Sprintf(`{"version":"0.1","xprv":"%s","xpub":"%s"}`, xprv, xpub)
+	err := sm.datastore.PutEncrypted([]byte(legacy), pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sm.Get(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sm.migrateLegacyPath(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reload from the datastore
+	migrated := NewStorageManager(sm.datastore, sm.params)
+	err = migrated.Get(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated.store.Version != StorageVersionCoinType ||
+		migrated.store.HDCoinType != wallet.HDCoinTypeLegacy {
+		t.Fatalf("not migrated: %s", migrated.store)
+	}
+
+	// newer storage is left alone
+	migrated.store.HDCoinType = 5
+	err = migrated.migrateLegacyPath(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated.store.HDCoinType != 5 {
+		t.Fatal("coin type changed")
+	}
+}
@@ -18,7 +18,7 @@ var (
 
 // PROBABLY CANNOT DO THIS FOR SPARK .. TRANSPARENT MAYBE!
 
-// Build (one or more) segwit transaction that sweeps all coins owned by a
+// Build (one or more) P2PKH transaction that sweeps all coins owned by a
 // private key external to our wallet .. into our wallet.
 // Each tx has many inputs but only one output. There are no change outputs.
 
@@ -36,13 +36,13 @@ func (w *DashElectrumWallet) SweepCoins(
 	var prevOutScripts map[int][]byte
 	var prevOutValues map[int]int64
 
-	// segwit output which makes this always a segwit transaction
-	walletAddressSegwit, err := w.GetUnusedAddress(wallet.RECEIVING)
+	// P2PKH output - Dash has no segwit
+	walletAddress, err := w.GetUnusedAddress(wallet.RECEIVING)
 	if err != nil {
 		return nil, err
 	}
 	// make an output script that can spend the inputs to our wallet
-	p2wpkhScript, err := txscript.PayToAddrScript(walletAddressSegwit)
+	p2pkhScript, err := txscript.PayToAddrScript(walletAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -70,8 +70,8 @@ func (w *DashElectrumWallet) SweepCoins(
 		sweepTx.TxIn = append(sweepTx.TxIn, input)
 	}
 
-	// get vsize estimate before adding the output
-	sweepSize := txSerialSizeEst(sweepTx)
+	// get worst case size estimate before adding the output
+	sweepSize := EstimateSerializeSize(len(sweepTx.TxIn), nil, true, P2PKH)
 
 	// get the fee
 	feePerKB := btcutil.Amount(w.GetFeePerByte(feeLevel)) * 1000
@@ -79,7 +79,7 @@ func (w *DashElectrumWallet) SweepCoins(
 
 	// add single output
 	outValue := totalValue - int64(fee)
-	sweepTx.AddTxOut(wire.NewTxOut(outValue, p2wpkhScript))
+	sweepTx.AddTxOut(wire.NewTxOut(outValue, p2pkhScript))
 	err = txrules.CheckOutput(sweepTx.TxOut[0], feePerKB)
 	if err != nil {
 		return nil, err
@@ -87,23 +87,13 @@ func (w *DashElectrumWallet) SweepCoins(
 
 	// sign
 
-	// NewTxSigHashes uses the PrevOutFetcher only for detecting a taproot
-	// output, so we can provide a dummy.
+	// The script engine uses the PrevOutFetcher only for taproot outputs, so
+	// we can provide a dummy.
 	prevOutFetcher := new(txscript.CannedPrevOutputFetcher)
 
 	for idx, input := range sweepTx.TxIn {
-		sigHashes := txscript.NewTxSigHashes(sweepTx, prevOutFetcher)
 		prevOutScriptTy := txscript.GetScriptClass(prevOutScripts[idx])
 		switch prevOutScriptTy {
-		case txscript.WitnessV0PubKeyHashTy:
-			sig, err := txscript.WitnessSignature(sweepTx, sigHashes, idx, prevOutValues[idx],
-				prevOutScripts[idx], txscript.SigHashAll, privKeyToSignOutputs[idx], true)
-			if err != nil {
-				return nil, err
-			}
-			// add witness
-			input.SignatureScript = nil
-			input.Witness = append(input.Witness, sig...)
 		case txscript.PubKeyHashTy:
 			sig, err := txscript.SignatureScript(sweepTx, idx,
 				prevOutScripts[idx], txscript.SigHashAll, privKeyToSignOutputs[idx], true)
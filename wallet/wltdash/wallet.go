@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -40,6 +41,9 @@ type DashElectrumWallet struct {
 	keyManager          *KeyManager
 	subscriptionManager *SubscriptionManager
 
+	// signer signs with the HD keys, see SetSigner
+	signer wallet.Signer
+
 	mutex *sync.RWMutex
 
 	creationDate time.Time
@@ -103,7 +107,8 @@ func makeDashElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	}
 
 	sm := NewStorageManager(config.DB.Enc(), config.Params)
-	sm.store.Version = "0.1"
+	sm.store.Version = StorageVersionCurrent
+	sm.store.HDCoinType = config.HDCoinType()
 	sm.store.Xprv = mPrivKey.String()
 	sm.store.Xpub = mPubKey.String()
 	sm.store.ShaPw = chainhash.HashB([]byte(pw))
@@ -116,12 +121,13 @@ func makeDashElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	}
 	w.storageManager = sm
 
-	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey)
+	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey, sm.store.HDCoinType)
 	mPrivKey.Zero()
 	mPubKey.Zero()
 	if err != nil {
 		return nil, err
 	}
+	w.signer = &hdSigner{km: w.keyManager}
 
 	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
 	if err != nil {
@@ -134,6 +140,10 @@ func makeDashElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	if err != nil {
 		return nil, err
 	}
+	err = config.DB.Cfg().PutDataVersion(wallet.DataVersionCurrent)
+	if err != nil {
+		return nil, err
+	}
 
 	return w, nil
 }
@@ -154,6 +164,10 @@ func loadDashElectrumWallet(config *wallet.WalletConfig, pw string) (*DashElectr
 	if err != nil {
 		return nil, err
 	}
+	err = sm.migrateLegacyPath(pw)
+	if err != nil {
+		return nil, err
+	}
 
 	mPrivKey, err := hdkeychain.NewKeyFromString(sm.store.Xprv)
 	if err != nil {
@@ -168,11 +182,12 @@ func loadDashElectrumWallet(config *wallet.WalletConfig, pw string) (*DashElectr
 		mutex:          new(sync.RWMutex),
 	}
 
-	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey)
+	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey, sm.store.HDCoinType, sm.store.accountNumbers()...)
 	mPrivKey.Zero()
 	if err != nil {
 		return nil, err
 	}
+	w.signer = &hdSigner{km: w.keyManager}
 
 	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
 	if err != nil {
@@ -211,13 +226,18 @@ func (w *DashElectrumWallet) Params() *chaincfg.Params {
 }
 
 func (w *DashElectrumWallet) CurrencyCode() string {
-	if w.params.Name == chaincfg.MainNetParams.Name {
-		return "btc"
+	if w.params.Net == DashMainNetParams.Net {
+		return "dash"
 	} else {
-		return "tbtc"
+		return "tdash"
 	}
 }
 
+// WatchOnly is always false. Dash wallets are made from a seed.
+func (w *DashElectrumWallet) WatchOnly() bool {
+	return false
+}
+
 func (w *DashElectrumWallet) IsDust(amount int64) bool {
 	// This is a per mempool policy thing .. < 1000 sats for now
 	return btcutil.Amount(amount) < txrules.DefaultRelayFeePerKb
@@ -227,44 +247,36 @@ func (w *DashElectrumWallet) IsDust(amount int64) bool {
 // It is used for Rescan and has no concept of gap-limit. It is expected that
 // keys made here are just temporarily used to generate addresses for rescan.
 func (w *DashElectrumWallet) GetAddress(kp *wallet.KeyPath /*, addressType*/) (btcutil.Address, error) {
-	key, err := w.keyManager.generateChildKey(kp.Change, uint32(kp.Index))
+	key, err := w.keyManager.generateChildKey(kp.Account, kp.Change, uint32(kp.Index))
 	if err != nil {
 		return nil, err
 	}
+	// Dash has no segwit - P2PKH only
 	address, err := key.Address(w.params)
 	key.Zero()
 	if err != nil {
 		return nil, err
 	}
-	script := address.ScriptAddress()
-	segwitAddress, err := btcutil.NewAddressWitnessPubKeyHash(script, w.params)
-	if err != nil {
-		return nil, err
-	}
-	return segwitAddress, nil
+	return address, nil
 }
 
 func (w *DashElectrumWallet) GetUnusedAddress(purpose wallet.KeyChange) (btcutil.Address, error) {
-	key, err := w.keyManager.GetUnusedKey(purpose)
+	key, err := w.keyManager.GetUnusedKey(wallet.DefaultAccount, purpose)
 	if err != nil {
 		return nil, nil
 	}
+	// Dash has no segwit - P2PKH only
 	address, err := key.Address(w.params)
 	key.Zero()
 	if err != nil {
 		return nil, nil
 	}
-	script := address.ScriptAddress()
-	segwitAddress, swerr := btcutil.NewAddressWitnessPubKeyHash(script, w.params)
-	if swerr != nil {
-		return nil, swerr
-	}
-	return segwitAddress, nil
+	return address, nil
 }
 
-// For receiving simple payments from legacy wallets only!
+// All Dash addresses are legacy P2PKH so this is GetUnusedAddress(RECEIVING).
 func (w *DashElectrumWallet) GetUnusedLegacyAddress() (btcutil.Address, error) {
-	key, err := w.keyManager.GetUnusedKey(wallet.RECEIVING)
+	key, err := w.keyManager.GetUnusedKey(wallet.DefaultAccount, wallet.RECEIVING)
 	if err != nil {
 		return nil, nil
 	}
@@ -301,7 +313,7 @@ func (w *DashElectrumWallet) MarkAddressUsed(address btcutil.Address) error {
 }
 
 func (w *DashElectrumWallet) DecodeAddress(addr string) (btcutil.Address, error) {
-	return btcutil.DecodeAddress(addr, w.params)
+	return DecodeAddress(addr, w.params)
 }
 
 func (w *DashElectrumWallet) ScriptToAddress(script []byte) (btcutil.Address, error) {
@@ -368,6 +380,15 @@ func (w *DashElectrumWallet) IsMine(queryAddress btcutil.Address) bool {
 }
 
 func (w *DashElectrumWallet) Balance() (int64, int64, int64, error) {
+	utxos, err := w.txstore.Utxos().GetAll()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return w.balance(utxos)
+}
+
+// balance returns the confirmed, unconfirmed and locked amounts of utxos.
+func (w *DashElectrumWallet) balance(utxos []wallet.Utxo) (int64, int64, int64, error) {
 
 	isStxoConfirmed := func(utxo wallet.Utxo, stxos []wallet.Stxo) bool {
 		for _, stxo := range stxos {
@@ -389,10 +410,6 @@ func (w *DashElectrumWallet) Balance() (int64, int64, int64, error) {
 	confirmed := int64(0)
 	unconfirmed := int64(0)
 	locked := int64(0)
-	utxos, err := w.txstore.Utxos().GetAll()
-	if err != nil {
-		return 0, 0, 0, err
-	}
 	stxos, err := w.txstore.Stxos().GetAll()
 	if err != nil {
 		return 0, 0, 0, err
@@ -522,6 +539,52 @@ func (w *DashElectrumWallet) UpdateTip(newTip int64) {
 	w.blockchainTip = newTip
 }
 
+// MigrateTxTimes sets the times of confirmed transactions stored before
+// DataVersionBlockTimes to their block times. Does nothing for newer wallet
+// data. Progress is saved after each transaction so if blockTime fails the
+// migration carries on from there on the next load.
+func (w *DashElectrumWallet) MigrateTxTimes(blockTime func(height int64) (time.Time, error)) error {
+	version, err := w.txstore.Cfg().GetDataVersion()
+	if err != nil {
+		return err
+	}
+	if version >= wallet.DataVersionBlockTimes {
+		return nil
+	}
+	cursor, err := w.txstore.Cfg().GetMigrationCursor()
+	if err != nil {
+		return err
+	}
+	txns, err := w.txstore.Txns().GetAll(true)
+	if err != nil {
+		return err
+	}
+	// in txid order; the cursor is the last one done
+	sort.Slice(txns, func(i, j int) bool { return txns[i].Txid < txns[j].Txid })
+	for _, txn := range txns {
+		if txn.Height <= 0 || txn.Txid <= cursor {
+			continue
+		}
+		timestamp, err := blockTime(txn.Height)
+		if err != nil {
+			return err
+		}
+		err = w.txstore.Txns().UpdateHeight(txn.Txid, int(txn.Height), timestamp)
+		if err != nil {
+			return err
+		}
+		err = w.txstore.Cfg().PutMigrationCursor(txn.Txid)
+		if err != nil {
+			return err
+		}
+	}
+	err = w.txstore.Cfg().PutDataVersion(wallet.DataVersionBlockTimes)
+	if err != nil {
+		return err
+	}
+	return w.txstore.Cfg().PutMigrationCursor("")
+}
+
 /////////////////////////////
 // implementations in send.go
 
@@ -0,0 +1,162 @@
+package wltfiro
+
+// Firo exchange addresses.
+//
+// An exchange address (EXX... on mainnet) pays to a pubkey hash like P2PKH but
+// the output script is prefixed with OP_EXCHANGEADDR. Firo consensus only lets
+// such outputs be funded from transparent inputs so exchanges can refuse coins
+// that come straight from Spark/Lelantus. The address is base58check with a
+// three byte prefix, 0x01 0xb9 and a per network byte; ElectrumX calls the
+// 0xb9 the P2EPKH_VERBYTE.
+//
+// The wallet sends to exchange addresses but never receives on them.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// OP_EXCHANGEADDR marks an exchange address output script.
+const OP_EXCHANGEADDR = 0xe0
+
+// P2EPKH_VERBYTE is the second byte of every exchange address prefix.
+const P2EPKH_VERBYTE = 0xb9
+
+// exchangeAddrPrefixes are the exchange address prefixes for each network.
+var exchangeAddrPrefixes = map[wire.BitcoinNet][3]byte{
+	FiroMainNetParams.Net: {0x01, P2EPKH_VERBYTE, 0xbb}, // EXX
+	FiroTestNetParams.Net: {0x01, P2EPKH_VERBYTE, 0xb1}, // EXT
+	FiroRegtestParams.Net: {0x01, P2EPKH_VERBYTE, 0xac}, // EXR
+}
+
+var errNotExchangeAddress = errors.New("not an exchange address")
+
+// ErrExchangeAddressNet is returned for an exchange address of another network.
+var ErrExchangeAddressNet = errors.New("exchange address is for another network")
+
+// AddressExchangePubKeyHash is a Firo exchange address. It satisfies the
+// btcutil.Address interface.
+type AddressExchangePubKeyHash struct {
+	hash   [20]byte
+	prefix [3]byte
+}
+
+// NewAddressExchangePubKeyHash returns a new exchange address for a 20 byte
+// pubkey hash.
+func NewAddressExchangePubKeyHash(pkHash []byte, params *chaincfg.Params) (*AddressExchangePubKeyHash, error) {
+	if len(pkHash) != 20 {
+		return nil, errors.New("pkHash must be 20 bytes")
+	}
+	prefix, ok := exchangeAddrPrefixes[params.Net]
+	if !ok {
+		return nil, errors.New("no exchange addresses for " + params.Name)
+	}
+	addr := &AddressExchangePubKeyHash{prefix: prefix}
+	copy(addr.hash[:], pkHash)
+	return addr, nil
+}
+
+// decodeExchangeAddress decodes an exchange address for the network params.
+// It returns errNotExchangeAddress if addr is not an exchange address of any
+// network.
+func decodeExchangeAddress(addr string, params *chaincfg.Params) (*AddressExchangePubKeyHash, error) {
+	b := base58.Decode(addr)
+	if len(b) != 3+20+4 || b[0] != 0x01 || b[1] != P2EPKH_VERBYTE {
+		return nil, errNotExchangeAddress
+	}
+	sum := checksum(b[:23])
+	if !bytes.Equal(sum[:], b[23:]) {
+		return nil, base58.ErrChecksum
+	}
+	var prefix [3]byte
+	copy(prefix[:], b[:3])
+	var known bool
+	for _, p := range exchangeAddrPrefixes {
+		known = known || p == prefix
+	}
+	if !known {
+		return nil, errNotExchangeAddress
+	}
+	if exchangeAddrPrefixes[params.Net] != prefix {
+		return nil, ErrExchangeAddressNet
+	}
+	exx := &AddressExchangePubKeyHash{prefix: prefix}
+	copy(exx.hash[:], b[3:23])
+	return exx, nil
+}
+
+// EncodeAddress returns the base58check encoding of the exchange address.
+//
+// NOTE: This method is part of the Address interface.
+func (a *AddressExchangePubKeyHash) EncodeAddress() string {
+	b := make([]byte, 0, 3+20+4)
+	b = append(b, a.prefix[:]...)
+	b = append(b, a.hash[:]...)
+	sum := checksum(b)
+	return base58.Encode(append(b, sum[:]...))
+}
+
+// ScriptAddress returns the pubkey hash.
+//
+// NOTE: This method is part of the Address interface.
+func (a *AddressExchangePubKeyHash) ScriptAddress() []byte {
+	return a.hash[:]
+}
+
+// IsForNet returns whether the exchange address is for the network params.
+//
+// NOTE: This method is part of the Address interface.
+func (a *AddressExchangePubKeyHash) IsForNet(params *chaincfg.Params) bool {
+	prefix, ok := exchangeAddrPrefixes[params.Net]
+	return ok && prefix == a.prefix
+}
+
+// String returns the encoded address.
+//
+// NOTE: This method is part of the Address interface.
+func (a *AddressExchangePubKeyHash) String() string {
+	return a.EncodeAddress()
+}
+
+// PkScript returns the output script paying to the exchange address:
+//
+//	OP_EXCHANGEADDR OP_DUP OP_HASH160 <20 byte hash> OP_EQUALVERIFY OP_CHECKSIG
+func (a *AddressExchangePubKeyHash) PkScript() ([]byte, error) {
+	return txscript.NewScriptBuilder().
+		AddOp(OP_EXCHANGEADDR).
+		AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).
+		AddData(a.hash[:]).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+}
+
+// isExchangeScript returns whether script pays to an exchange address.
+func isExchangeScript(script []byte) bool {
+	return len(script) == 26 && script[0] == OP_EXCHANGEADDR &&
+		txscript.IsPayToPubKeyHash(script[1:])
+}
+
+// PayToAddrScript returns the output script for a Firo address. Exchange
+// addresses get their OP_EXCHANGEADDR script and others the standard script.
+func PayToAddrScript(address btcutil.Address) ([]byte, error) {
+	if exx, ok := address.(*AddressExchangePubKeyHash); ok {
+		return exx.PkScript()
+	}
+	return txscript.PayToAddrScript(address)
+}
+
+func checksum(b []byte) (sum [4]byte) {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	copy(sum[:], h[:4])
+	return
+}
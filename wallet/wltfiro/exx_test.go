@@ -0,0 +1,125 @@
+package wltfiro
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestDecodeAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		params *chaincfg.Params
+		valid  bool
+		exx    bool
+	}{
+		{addr: "aDMD8WtB17cYPg4cENsiF4LZcujy6d76Dd", params: FiroMainNetParams, valid: true},
+		{addr: "3swvmaJF2tCAAFipgUx26bPWnbCPu4GS4z", params: FiroMainNetParams, valid: true},
+		{addr: "TNbxPfpGw3jeVJh8pFDHzviBvLMw5PmzoX", params: FiroTestNetParams, valid: true},
+		{addr: "2Egk599GWSjRz1NZdsDuX22w3QrFhTUiWDn", params: FiroTestNetParams, valid: true},
+		{addr: "EXXT6XQ1BAVrGYdeBkuMNkjQUyyNeuUwCWBV", params: FiroMainNetParams, valid: true, exx: true},
+		{addr: "EXRR2UdNhqr9KQN96TY4NyTXQBXonko7yDSm", params: FiroRegtestParams, valid: true, exx: true},
+		// other network
+		{addr: "TNbxPfpGw3jeVJh8pFDHzviBvLMw5PmzoX", params: FiroMainNetParams},
+		{addr: "EXXT6XQ1BAVrGYdeBkuMNkjQUyyNeuUwCWBV", params: FiroRegtestParams},
+		// bad checksum
+		{addr: "EXXT6XQ1BAVrGYdeBkuMNkjQUyyNeuUwCWBW", params: FiroMainNetParams},
+		// bitcoin addresses
+		{addr: "1DZ3eMoaGXjbnCBpHUm1gQEe2VKq8kaRjV", params: FiroMainNetParams},
+		{addr: "bcrt1q322tg0y2hzyp9zztr7d2twdclhqg88anvzxwwr", params: FiroRegtestParams},
+	}
+	for _, tt := range tests {
+		addr, err := DecodeAddress(tt.addr, tt.params)
+		if !tt.valid {
+			if err == nil {
+				t.Fatalf("%s decoded for %s", tt.addr, tt.params.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.addr, err)
+		}
+		if addr.EncodeAddress() != tt.addr {
+			t.Fatalf("%s re-encoded as %s", tt.addr, addr.EncodeAddress())
+		}
+		_, isExx := addr.(*AddressExchangePubKeyHash)
+		if isExx != tt.exx {
+			t.Fatalf("%s: unexpected address type %T", tt.addr, addr)
+		}
+	}
+	_, err := DecodeAddress("EXXT6XQ1BAVrGYdeBkuMNkjQUyyNeuUwCWBV", FiroTestNetParams)
+	if !errors.Is(err, ErrExchangeAddressNet) {
+		t.Fatalf("expected other network error, got %v", err)
+	}
+}
+
+func TestExchangeAddressScript(t *testing.T) {
+	exx, err := DecodeAddress("EXXT6XQ1BAVrGYdeBkuMNkjQUyyNeuUwCWBV", FiroMainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := PayToAddrScript(exx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("e076a9148a94b43c8ab8881284962c7daaa5b9b8fc1083f688ac")
+	if !bytes.Equal(script, want) {
+		t.Fatalf("bad script %x", script)
+	}
+	w := MockWallet("abc")
+	w.params = FiroMainNetParams
+	addr, err := w.ScriptToAddress(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.EncodeAddress() != exx.EncodeAddress() {
+		t.Fatalf("script to address gave %s", addr)
+	}
+}
+
+func Test_newExchangeAddressTransaction(t *testing.T) {
+	w := MockWallet("abc")
+	w.blockchainTip = 500
+
+	h, _ := chainhash.NewHashFromStr("50b636d971e7d4d918d92876d6d53a22ccc960e051f540108056ca4ad6ec080c")
+	pkHash, _ := hex.DecodeString("a30a0cf1da8c0c36ae8d637b674663ccf2b31e45")
+	ours, err := btcutil.NewAddressPubKeyHash(pkHash, w.params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := PayToAddrScript(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.txstore.Utxos().Put(wallet.Utxo{
+		Op:           wire.OutPoint{Hash: *h, Index: 0},
+		ScriptPubkey: script,
+		AtHeight:     421,
+		Value:        10030000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exx, err := w.DecodeAddress("EXRR2UdNhqr9KQN96TY4NyTXQBXonko7yDSm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tx, err := w.Spend("abc", 100000, exx, wallet.NORMAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exxScript, _ := PayToAddrScript(exx)
+	for _, out := range tx.TxOut {
+		if bytes.Equal(out.PkScript, exxScript) && out.Value == 100000 {
+			return
+		}
+	}
+	t.Fatal("no exchange address output")
+}
@@ -0,0 +1,97 @@
+package wltfiro
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Firo chain parameters. Only the fields used by the wallet are filled in:
+// network magic, address and key encodings and the genesis hash. Firo has no
+// segwit so there is no bech32 prefix. Transparent addresses are P2PKH and
+// P2SH; exchange addresses are in exx.go.
+//
+// See https://github.com/firoorg/firo/blob/master/src/chainparams.cpp
+
+// FiroMainNetParams are the Firo mainnet parameters. Addresses start with a or
+// Z (P2PKH) and 3 (P2SH).
+var FiroMainNetParams = &chaincfg.Params{
+	Name:             "mainnet",
+	Net:              wire.BitcoinNet(0xf1fed9e3),
+	DefaultPort:      "8168",
+	GenesisHash:      newHashFromStr("4381deb85b1b2c9843c222944b616d997516dcbd6a964e1eaf0def0830695233"),
+	PubKeyHashAddrID: 0x52, // a
+	ScriptHashAddrID: 0x07, // 3
+	PrivateKeyID:     0xd2,
+	HDPrivateKeyID:   [4]byte{0x04, 0x88, 0xad, 0xe4}, // xprv
+	HDPublicKeyID:    [4]byte{0x04, 0x88, 0xb2, 0x1e}, // xpub
+	HDCoinType:       136,
+}
+
+// FiroTestNetParams are the Firo testnet parameters. Addresses start with T
+// (P2PKH) and 2 (P2SH).
+var FiroTestNetParams = &chaincfg.Params{
+	Name:             "testnet",
+	Net:              wire.BitcoinNet(0xeabefccf),
+	DefaultPort:      "18168",
+	GenesisHash:      newHashFromStr("aa22adcc12becaf436027ffe62a8fb21b234c58c23865291e5dc52cf53f64fca"),
+	PubKeyHashAddrID: 0x41, // T
+	ScriptHashAddrID: 0xb2, // 2
+	PrivateKeyID:     0xb9,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94}, // tprv
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf}, // tpub
+	HDCoinType:       1,
+}
+
+// FiroRegtestParams are the Firo regtest parameters. Addresses are encoded as
+// for testnet but private keys use the bitcoin testnet WIF byte.
+var FiroRegtestParams = &chaincfg.Params{
+	Name:             "regtest",
+	Net:              wire.BitcoinNet(0xdab5bffa),
+	DefaultPort:      "18444",
+	GenesisHash:      newHashFromStr("a42b98f04cc2916e8adfb5d9db8a2227c4629bc205748ed2f33180b636ee885b"),
+	PubKeyHashAddrID: 0x41,
+	ScriptHashAddrID: 0xb2,
+	PrivateKeyID:     0xef,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94},
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf},
+	HDCoinType:       1,
+}
+
+var ErrNotFiroAddress = errors.New("not a firo P2PKH, P2SH or exchange address")
+
+// DecodeAddress decodes a Firo address for the network params. P2PKH, P2SH
+// and exchange (EXX) addresses are accepted and anything else is refused.
+func DecodeAddress(addr string, params *chaincfg.Params) (btcutil.Address, error) {
+	exx, err := decodeExchangeAddress(addr, params)
+	if err == nil {
+		return exx, nil
+	}
+	if err != errNotExchangeAddress {
+		return nil, err
+	}
+	address, err := btcutil.DecodeAddress(addr, params)
+	if err != nil {
+		return nil, err
+	}
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressScriptHash:
+	default:
+		return nil, ErrNotFiroAddress
+	}
+	if !address.IsForNet(params) {
+		return nil, ErrNotFiroAddress
+	}
+	return address, nil
+}
+
+func newHashFromStr(s string) *chainhash.Hash {
+	hash, err := chainhash.NewHashFromStr(s)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
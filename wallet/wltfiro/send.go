@@ -9,46 +9,15 @@ import (
 	"fmt"
 
 	"github.com/bisoncraft/go-electrum-client/wallet"
-	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/coinset"
 	"github.com/btcsuite/btcd/btcutil/txsort"
-	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 )
 
-// secretSource is used to locate keys and redemption scripts while signing a
-// transaction. secretSource satisfies the txauthor.SecretsSource interface.
-type secretSource struct {
-	w *FiroElectrumWallet
-}
-
-// ChainParams returns the chain parameters.
-func (ss *secretSource) ChainParams() *chaincfg.Params {
-	return ss.w.params
-}
-
-// GetKey fetches a private key for the specified address.
-func (ss *secretSource) GetKey(address btcutil.Address) (*btcec.PrivateKey, bool, error) {
-	extKey, err := ss.w.keyManager.GetKeyForScript(address.ScriptAddress())
-	if err != nil {
-		return nil, false, err
-	}
-	privKey, err := extKey.ECPrivKey()
-	if err != nil {
-		return nil, false, err
-	}
-	return privKey, true, nil
-}
-
-// GetScript fetches the redemption script for the specified p2sh/p2wsh address.
-func (ss *secretSource) GetScript(address btcutil.Address) ([]byte, error) {
-	return txscript.PayToAddrScript(address)
-}
-
 // satisfies coinset.Coin
 type unspentCoin struct {
 	TxHash       *chainhash.Hash
@@ -76,10 +45,11 @@ func newUnspentCoin(txHash *chainhash.Hash, index uint32, value btcutil.Amount,
 	return coinset.Coin(unspent)
 }
 
-// gatherCoins aggregates acceptable utxos into a slice of coinset.Coin's
-func (w *FiroElectrumWallet) gatherCoins(excludeUnconfirmed bool) []coinset.Coin {
+// gatherCoins aggregates acceptable utxos of the account into a slice of
+// coinset.Coin's
+func (w *FiroElectrumWallet) gatherCoins(account uint32, excludeUnconfirmed bool) []coinset.Coin {
 	tip := w.blockchainTip
-	utxos, _ := w.txstore.Utxos().GetAll()
+	utxos, _ := w.accountUtxos(account)
 	var unspentCoins []coinset.Coin
 	for _, u := range utxos {
 		if u.WatchOnly {
@@ -101,43 +71,153 @@ func (w *FiroElectrumWallet) gatherCoins(excludeUnconfirmed bool) []coinset.Coin
 	return unspentCoins
 }
 
-// Spend creates and signs a new transaction from wallet coins
+// Spend creates and signs a new transaction from the coins of the
+// wallet.DefaultAccount
 func (w *FiroElectrumWallet) Spend(
 	pw string,
 	amount int64,
 	address btcutil.Address,
 	feeLevel wallet.FeeLevel) (int, *wire.MsgTx, error) {
 
+	return w.AccountSpend(pw, wallet.DefaultAccount, amount, address, feeLevel)
+}
+
+// AccountSpend creates and signs a new transaction from the coins of an
+// account. Change goes to an address of the account.
+func (w *FiroElectrumWallet) AccountSpend(
+	pw string,
+	account uint32,
+	amount int64,
+	address btcutil.Address,
+	feeLevel wallet.FeeLevel) (int, *wire.MsgTx, error) {
+
+	outputs := []wallet.TransactionOutput{{Address: address, Value: amount}}
+	return w.SpendMany(pw, account, outputs, feeLevel, nil)
+}
+
+// SpendMany creates and signs a new transaction paying every output from the
+// coins of an account. Change goes to an address of the account. The options,
+// if any, choose the coins to spend or avoid and may set the fee.
+func (w *FiroElectrumWallet) SpendMany(
+	pw string,
+	account uint32,
+	outputs []wallet.TransactionOutput,
+	feeLevel wallet.FeeLevel,
+	opts *wallet.SpendOptions) (int, *wire.MsgTx, error) {
+
 	if ok := w.storageManager.IsValidPw(pw); !ok {
 		return 0, nil, errors.New("invalid password")
 	}
+	if w.getSigner() == nil {
+		return 0, nil, wallet.ErrWatchOnly
+	}
+	if !w.keyManager.hasAccount(account) {
+		return 0, nil, wallet.ErrUnknownAccount
+	}
 
-	changeIndex, tx, err := w.buildTx(amount, address, feeLevel)
+	changeIndex, tx, err := w.buildTx(account, outputs, feeLevel, opts)
 	if err != nil {
 		return 0, nil, err
 	}
 	return changeIndex, tx, nil
 }
 
-// buildTx builds a normal Pay to (witness) pubkey hash transaction.
-func (w *FiroElectrumWallet) buildTx(
+// BuildUnsignedTx builds a transaction from the coins of an account like
+// AccountSpend but does not sign it.
+func (w *FiroElectrumWallet) BuildUnsignedTx(
+	account uint32,
 	amount int64,
 	address btcutil.Address,
 	feeLevel wallet.FeeLevel) (int, *wire.MsgTx, error) {
 
-	// Check for dust
-	if w.IsDust(amount) {
-		return 0, nil, wallet.ErrDustAmount
+	if !w.keyManager.hasAccount(account) {
+		return 0, nil, wallet.ErrUnknownAccount
+	}
+	outputs := []wallet.TransactionOutput{{Address: address, Value: amount}}
+	authoredTx, _, err := w.authorTx(account, outputs, feeLevel, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return authoredTx.ChangeIndex, authoredTx.Tx, nil
+}
+
+// EstimateSpend previews SpendMany: the coins it would spend, the outputs with
+// the change and the estimated vsize and fee. It signs nothing and works for a
+// WatchOnly wallet.
+func (w *FiroElectrumWallet) EstimateSpend(
+	account uint32,
+	outputs []wallet.TransactionOutput,
+	feeLevel wallet.FeeLevel,
+	opts *wallet.SpendOptions) (*wallet.SpendEstimate, error) {
+
+	if !w.keyManager.hasAccount(account) {
+		return nil, wallet.ErrUnknownAccount
 	}
+	authoredTx, prevOuts, err := w.authorTx(account, outputs, feeLevel, opts)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.NewSpendEstimate(authoredTx.Tx, authoredTx.ChangeIndex, prevOuts), nil
+}
+
+// buildTx builds a normal Pay to pubkey hash transaction from the coins of
+// the account. The payee may also be an exchange address.
+func (w *FiroElectrumWallet) buildTx(
+	account uint32,
+	outputs []wallet.TransactionOutput,
+	feeLevel wallet.FeeLevel,
+	opts *wallet.SpendOptions) (int, *wire.MsgTx, error) {
 
-	// check payto address
-	script, err := txscript.PayToAddrScript(address)
+	authoredTx, prevScripts, err := w.authorTx(account, outputs, feeLevel, opts)
 	if err != nil {
 		return 0, nil, err
 	}
 
+	// Sign
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevScripts)
+	for idx, txIn := range authoredTx.Tx.TxIn {
+		err = w.signInput(authoredTx.Tx, idx, prevScripts[txIn.PreviousOutPoint], prevOutFetcher)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	return authoredTx.ChangeIndex, authoredTx.Tx, nil
+}
+
+// authorTx makes the unsigned, BIP69 sorted, transaction of buildTx paying the
+// outputs. It returns the prevouts of its inputs for signing.
+func (w *FiroElectrumWallet) authorTx(
+	account uint32,
+	outputs []wallet.TransactionOutput,
+	feeLevel wallet.FeeLevel,
+	opts *wallet.SpendOptions) (*txauthor.AuthoredTx, map[wire.OutPoint]*wire.TxOut, error) {
+
+	if len(outputs) == 0 {
+		return nil, nil, wallet.ErrNoOutputs
+	}
+	var txOuts []*wire.TxOut
+	for _, output := range outputs {
+		// Check for dust
+		if w.IsDust(output.Value) {
+			return nil, nil, wallet.ErrDustAmount
+		}
+		// check payto address
+		script, err := PayToAddrScript(output.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+		txOuts = append(txOuts, wire.NewTxOut(output.Value, script))
+	}
+
 	// create input source
-	coins := w.gatherCoins(true)
+	include, coins, err := wallet.CoinControl(w.gatherCoins(account, true), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var includeTotal btcutil.Amount
+	for _, c := range include {
+		includeTotal += c.Value()
+	}
 	for i, coin := range coins {
 		fmt.Println(i, coin.Hash().String(), coin.Index(), coin.PkScript())
 	}
@@ -150,32 +230,50 @@ func (w *FiroElectrumWallet) buildTx(
 		inputValues []btcutil.Amount,
 		scripts [][]byte, err error) {
 
-		coinSelector := coinset.MaxValueAgeCoinSelector{MaxInputs: 10000, MinChangeAmount: btcutil.Amount(0)}
-		coins, err := coinSelector.CoinSelect(target, coins)
-		if err != nil {
-			return total, inputs, []btcutil.Amount{}, scripts, wallet.ErrInsufficientFunds
+		// the included coins and, if they fall short, the selector's
+		selected := include
+		if target > includeTotal {
+			if opts != nil && opts.IncludeOnly {
+				return total, inputs, []btcutil.Amount{}, scripts,
+					fmt.Errorf("%w: %v of %v", wallet.ErrChosenCoinsInsufficient, includeTotal, target)
+			}
+			coinSelector := coinset.MaxValueAgeCoinSelector{MaxInputs: 10000, MinChangeAmount: btcutil.Amount(0)}
+			more, err := coinSelector.CoinSelect(target-includeTotal, coins)
+			if err != nil {
+				return total, inputs, []btcutil.Amount{}, scripts, wallet.ErrInsufficientFunds
+			}
+			selected = append(include[:len(include):len(include)], more.Coins()...)
 		}
 		prevScripts = make(map[wire.OutPoint]*wire.TxOut)
-		for _, c := range coins.Coins() {
+		for _, c := range selected {
 			total += c.Value()
 			outpoint := wire.NewOutPoint(c.Hash(), c.Index())
 			in := wire.NewTxIn(outpoint, []byte{}, [][]byte{})
 			in.Sequence = uint32(0xffffffff)
 			inputs = append(inputs, in)
+			// the input scripts size the fee - all P2PKH on Firo
+			scripts = append(scripts, c.PkScript())
 			prevScripts[*outpoint] = wire.NewTxOut(int64(c.Value()), c.PkScript())
 		}
 		return total, inputs, []btcutil.Amount{}, scripts, nil
 	}
 
 	// Get the fee per kilobyte
-	feePerKB := int64(w.GetFeePerByte(feeLevel)) * 1000
-
-	// outputs
-	out := wire.NewTxOut(amount, script)
+	feePerKB, err := w.feeProvider.SpendFeePerKB(feeLevel, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	// an absolute fee is paid to a placeholder output that is removed after
+	// coin selection
+	var feeOut *wire.TxOut
+	if opts != nil && opts.Fee > 0 {
+		feeOut = wire.NewTxOut(opts.Fee, nil)
+		txOuts = append(txOuts, feeOut)
+	}
 
 	// create change source
 	changeSource := func() ([]byte, error) {
-		address, err := w.GetUnusedAddress(wallet.CHANGE)
+		address, err := w.GetUnusedAccountAddress(account, wallet.CHANGE)
 		if err != nil {
 			return []byte{}, err
 		}
@@ -185,49 +283,49 @@ func (w *FiroElectrumWallet) buildTx(
 		}
 		return script, nil
 	}
-	var scriptSize int = 33 // max size, over-estimated
+	var scriptSize int = P2PKHPkScriptSize
 	changeOutputsSource := txauthor.ChangeSource{
 		NewScript:  changeSource,
 		ScriptSize: scriptSize,
 	}
 
-	outputs := []*wire.TxOut{out}
-
 	authoredTx, err := txauthor.NewUnsignedTransaction(
-		outputs,
+		txOuts,
 		btcutil.Amount(feePerKB),
 		inputSource,
 		&changeOutputsSource)
 	if err != nil {
-		return 0, nil, err
+		return nil, nil, err
+	}
+	if feeOut != nil {
+		n := len(txOuts) - 1
+		authoredTx.Tx.TxOut = append(authoredTx.Tx.TxOut[:n:n], authoredTx.Tx.TxOut[n+1:]...)
+		if authoredTx.ChangeIndex > n {
+			authoredTx.ChangeIndex--
+		}
+		if err := wallet.CheckRelayFee(opts.Fee, wallet.EstimateVirtualSize(authoredTx.Tx, prevScripts)); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	// BIP 69 sorting
+	// BIP 69 sorting moves the change output
+	var changeScript []byte
+	if authoredTx.ChangeIndex >= 0 {
+		changeScript = authoredTx.Tx.TxOut[authoredTx.ChangeIndex].PkScript
+	}
 	txsort.InPlaceSort(authoredTx.Tx)
+	for i, txOut := range authoredTx.Tx.TxOut {
+		if changeScript != nil && bytes.Equal(txOut.PkScript, changeScript) {
+			authoredTx.ChangeIndex = i
+		}
+	}
 
 	b := make([]byte, 0, 300)
 	br := bytes.NewBuffer(b)
-	authoredTx.Tx.Serialize(br)
+	authoredTx.Tx.SerializeNoWitness(br)
 	fmt.Println("unsigned tx:", hex.EncodeToString(br.Bytes()))
 
-	// Sign
-	var prevPkScripts [][]byte
-	var inputValues []btcutil.Amount
-	for _, txIn := range authoredTx.Tx.TxIn {
-		op := txIn.PreviousOutPoint
-		prevOut := prevScripts[op]
-		inputValues = append(inputValues, btcutil.Amount(prevOut.Value))
-		prevPkScripts = append(prevPkScripts, prevOut.PkScript)
-		// Zero the previous witness and signature script or else
-		// AddAllInputScripts does some weird stuff.
-		txIn.SignatureScript = nil
-		txIn.Witness = nil
-	}
-	err = txauthor.AddAllInputScripts(authoredTx.Tx, prevPkScripts, inputValues, &secretSource{w})
-	if err != nil {
-		return 0, nil, err
-	}
-	return authoredTx.ChangeIndex, authoredTx.Tx, nil
+	return authoredTx, prevScripts, nil
 }
 
 func (w *FiroElectrumWallet) GetFeePerByte(feeLevel wallet.FeeLevel) int64 {
@@ -241,7 +339,7 @@ func (w *FiroElectrumWallet) EstimateFee(
 
 	tx := new(wire.MsgTx)
 	for _, out := range outs {
-		scriptPubKey, _ := txscript.PayToAddrScript(out.Address)
+		scriptPubKey, _ := PayToAddrScript(out.Address)
 		output := wire.NewTxOut(out.Value, scriptPubKey)
 		tx.TxOut = append(tx.TxOut, output)
 	}
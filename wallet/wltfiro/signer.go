@@ -0,0 +1,134 @@
+package wltfiro
+
+import (
+	"fmt"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// The wallet signs through a wallet.Signer. It makes the sighashes and input
+// scripts itself and only asks the Signer for signatures, which it checks
+// against its own public keys before using them.
+
+// hdSigner is the in process wallet.Signer of the wallet. It signs with the
+// private keys of the KeyManager.
+type hdSigner struct {
+	km *KeyManager
+}
+
+var _ = wallet.Signer(&hdSigner{})
+
+func (s *hdSigner) SignECDSA(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	hash, err := input.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.km.generateChildKey(keyPath.Account, keyPath.Change, uint32(keyPath.Index))
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+	privKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	defer privKey.Zero()
+	return ecdsa.Sign(privKey, hash).Serialize(), nil
+}
+
+// SignSchnorr fails, Firo has no taproot.
+func (s *hdSigner) SignSchnorr(keyPath wallet.KeyPath, input *wallet.SigningInput) ([]byte, error) {
+	return nil, wallet.ErrSchnorrNotSupported
+}
+
+// SetSigner sets the signer of the wallet's transactions, for example a
+// wallet.RemoteSigner. A nil signer makes the wallet unable to sign.
+func (w *FiroElectrumWallet) SetSigner(signer wallet.Signer) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.signer = signer
+}
+
+// getSigner returns the signer of the wallet or nil if it cannot sign.
+func (w *FiroElectrumWallet) getSigner() wallet.Signer {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.signer
+}
+
+// walletKey is the key path and public key of a wallet script.
+type walletKey struct {
+	keyPath wallet.KeyPath
+	pubKey  *btcec.PublicKey
+}
+
+// walletKey returns the wallet key paying to pkScript. ok is false if the
+// script is not the wallet's.
+func (w *FiroElectrumWallet) walletKey(pkScript []byte) (*walletKey, bool) {
+	address, err := w.ScriptToAddress(pkScript)
+	if err != nil {
+		return nil, false
+	}
+	keyPath, err := w.keyManager.datastore.GetPathForKey(address.ScriptAddress())
+	if err != nil {
+		return nil, false
+	}
+	key, err := w.keyManager.generateChildKey(keyPath.Account, keyPath.Change, uint32(keyPath.Index))
+	if err != nil {
+		return nil, false
+	}
+	pubKey, err := key.ECPubKey()
+	key.Zero()
+	if err != nil {
+		return nil, false
+	}
+	return &walletKey{keyPath: keyPath, pubKey: pubKey}, true
+}
+
+// signInput signs input idx of tx spending prevOut, a wallet output, and sets
+// its signature script. Firo has no segwit: P2PKH inputs signed with the
+// legacy sighash.
+func (w *FiroElectrumWallet) signInput(tx *wire.MsgTx, idx int, prevOut *wire.TxOut,
+	prevOutFetcher txscript.PrevOutputFetcher) error {
+
+	signer := w.getSigner()
+	if signer == nil {
+		return wallet.ErrWatchOnly
+	}
+	k, ok := w.walletKey(prevOut.PkScript)
+	if !ok {
+		return fmt.Errorf("input %s is not a wallet coin", tx.TxIn[idx].PreviousOutPoint)
+	}
+	// note we do not really support P2PK for outbound txs
+	if class := txscript.GetScriptClass(prevOut.PkScript); class != txscript.PubKeyHashTy {
+		return fmt.Errorf("signing for script type %v unsupported", class)
+	}
+	input := wallet.NewSigningInput(tx, idx, prevOutFetcher, nil, txscript.SigHashAll)
+	hash, err := input.SigHash()
+	if err != nil {
+		return err
+	}
+	sig, err := signer.SignECDSA(k.keyPath, input)
+	if err != nil {
+		return err
+	}
+	parsed, err := ecdsa.ParseDERSignature(sig)
+	if err != nil {
+		return err
+	}
+	if !parsed.Verify(hash, k.pubKey) {
+		return wallet.ErrBadSignature
+	}
+	sig = append(sig, byte(txscript.SigHashAll))
+	sigScript, err := txscript.NewScriptBuilder().AddData(sig).AddData(k.pubKey.SerializeCompressed()).Script()
+	if err != nil {
+		return err
+	}
+	tx.TxIn[idx].SignatureScript = sigScript
+	tx.TxIn[idx].Witness = nil
+	return nil
+}
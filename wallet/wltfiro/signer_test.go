@@ -0,0 +1,81 @@
+package wltfiro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/bisoncraft/go-electrum-client/wallet"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSignTxRemoteSigner(t *testing.T) {
+	w := MockWallet("abc")
+	w.blockchainTip = 500
+	for _, utxo := range getUtxos() {
+		if err := w.txstore.Utxos().Put(*utxo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go wallet.ServeSigner(l, w.signer)
+	w.SetSigner(wallet.NewRemoteSigner("unix", path))
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	_ = tx.DeserializeNoWitness(bytes.NewBuffer(testUnsignedTx))
+	signed, err := w.SignTx("abc", &wallet.SigningInfo{UnsignedTx: tx, VerifyTx: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RFC6979 signatures are the same signed remotely
+	if !bytes.Equal(signed, testSignedTx) {
+		t.Fatal("remote signed tx differs")
+	}
+
+	w.SetSigner(nil)
+	tx = wire.NewMsgTx(wire.TxVersion)
+	_ = tx.DeserializeNoWitness(bytes.NewBuffer(testUnsignedTx))
+	if _, err := w.SignTx("abc", &wallet.SigningInfo{UnsignedTx: tx}); !errors.Is(err, wallet.ErrWatchOnly) {
+		t.Fatalf("expected %v got %v", wallet.ErrWatchOnly, err)
+	}
+}
+
+// A malformed request gets an error response and the connection is closed.
+func TestServeSignerBadRequest(t *testing.T) {
+	w := MockWallet("abc")
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go wallet.ServeSigner(l, w.signer)
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("{\"id\":1,\"method\":}\n")); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(conn)
+	var resp wallet.SignResponse
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 0 || resp.Error == "" {
+		t.Fatalf("expected an error response got %+v", resp)
+	}
+	if err := dec.Decode(&resp); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the connection closed got %v", err)
+	}
+}
@@ -1,7 +1,5 @@
 package wltfiro
 
-import "github.com/btcsuite/btcd/wire"
-
 const (
 	// PubKeyLength is the length of a serialized compressed public key.
 	PubKeyLength = 33
@@ -148,22 +146,3 @@ const (
 
 	witnessWeight = 4 // github.com/btcsuite/btcd/blockchain.WitnessScaleFactor
 )
-
-// msgTxVBytes retuns vbytes. Call with MsgTx + the input(s) defined but no output yet
-func msgTxVBytes(msgTx *wire.MsgTx) uint64 {
-	baseSize := msgTx.SerializeSizeStripped()
-	totalSize := msgTx.SerializeSize()
-	txWeight := baseSize*(witnessWeight-1) + totalSize
-	// vbytes is ceil(tx_weight/4)
-	return uint64(txWeight+(witnessWeight-1)) / witnessWeight // +3 before / 4 to round up
-}
-
-// Sweep worst case serial size estimates for fees - segwit
-func txSerialSizeEst(msgTx *wire.MsgTx) int {
-	size := msgTxVBytes(msgTx)
-	// include marker and flag weight.
-	witnessVBytes := uint64(((DefaultSigScriptSize + 2 + 3) / 4))
-	// witnessVBytes := uint64(((DefaultSigScriptSize + 2 + 3) / 4) * len(msgTx.TxIn))
-	size += witnessVBytes + P2WPKHOutputSize
-	return int(size)
-}
@@ -3,7 +3,6 @@ package wltfiro
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"sync"
 	"time"
 
@@ -56,13 +55,7 @@ func (ts *TxStore) PopulateAdrs() {
 		if err != nil {
 			continue
 		}
-		script := address.ScriptAddress()
-		segwitAddress, swerr := btcutil.NewAddressWitnessPubKeyHash(script, ts.params)
-		if swerr != nil {
-			fmt.Println(swerr)
-			continue
-		}
-		ts.adrs = append(ts.adrs, segwitAddress)
+		ts.adrs = append(ts.adrs, address)
 		k.Zero()
 	}
 	ts.addrMutex.Unlock()
@@ -211,13 +204,17 @@ func (ts *TxStore) AddTransaction(tx *wire.MsgTx, height int64, timestamp time.T
 			// new txn{}
 			txn.Timestamp = timestamp
 			var buf bytes.Buffer
-			tx.BtcEncode(&buf, wire.ProtocolVersion, wire.WitnessEncoding)
+			tx.BtcEncode(&buf, wire.ProtocolVersion, wire.BaseEncoding)
 			ts.Txns().Put(buf.Bytes(), tx.TxHash().String(), value, height, txn.Timestamp, hits == 0)
 			ts.txids[tx.TxHash().String()] = height
 		}
 		// check the height before committing so we don't allow rogue electrumX servers
 		// to send us a loose tx that resets our height to zero.
 		if err == nil && txn.Height <= 0 {
+			// once confirmed the block time replaces the first seen time
+			if height > 0 {
+				txn.Timestamp = timestamp
+			}
 			ts.Txns().UpdateHeight(tx.TxHash().String(), int(height), txn.Timestamp)
 			ts.txids[tx.TxHash().String()] = height
 		}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -40,6 +41,9 @@ type FiroElectrumWallet struct {
 	keyManager          *KeyManager
 	subscriptionManager *SubscriptionManager
 
+	// signer signs with the HD keys, see SetSigner
+	signer wallet.Signer
+
 	mutex *sync.RWMutex
 
 	creationDate time.Time
@@ -103,7 +107,8 @@ func makeFiroElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	}
 
 	sm := NewStorageManager(config.DB.Enc(), config.Params)
-	sm.store.Version = "0.1"
+	sm.store.Version = StorageVersionCurrent
+	sm.store.HDCoinType = config.HDCoinType()
 	sm.store.Xprv = mPrivKey.String()
 	sm.store.Xpub = mPubKey.String()
 	sm.store.ShaPw = chainhash.HashB([]byte(pw))
@@ -116,12 +121,13 @@ func makeFiroElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	}
 	w.storageManager = sm
 
-	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey)
+	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey, sm.store.HDCoinType)
 	mPrivKey.Zero()
 	mPubKey.Zero()
 	if err != nil {
 		return nil, err
 	}
+	w.signer = &hdSigner{km: w.keyManager}
 
 	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
 	if err != nil {
@@ -134,6 +140,10 @@ func makeFiroElectrumWallet(config *wallet.WalletConfig, pw string, seed []byte)
 	if err != nil {
 		return nil, err
 	}
+	err = config.DB.Cfg().PutDataVersion(wallet.DataVersionCurrent)
+	if err != nil {
+		return nil, err
+	}
 
 	return w, nil
 }
@@ -154,6 +164,10 @@ func loadFiroElectrumWallet(config *wallet.WalletConfig, pw string) (*FiroElectr
 	if err != nil {
 		return nil, err
 	}
+	err = sm.migrateLegacyPath(pw)
+	if err != nil {
+		return nil, err
+	}
 
 	mPrivKey, err := hdkeychain.NewKeyFromString(sm.store.Xprv)
 	if err != nil {
@@ -168,11 +182,12 @@ func loadFiroElectrumWallet(config *wallet.WalletConfig, pw string) (*FiroElectr
 		mutex:          new(sync.RWMutex),
 	}
 
-	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey)
+	w.keyManager, err = NewKeyManager(config.DB.Keys(), w.params, mPrivKey, sm.store.HDCoinType, sm.store.accountNumbers()...)
 	mPrivKey.Zero()
 	if err != nil {
 		return nil, err
 	}
+	w.signer = &hdSigner{km: w.keyManager}
 
 	w.txstore, err = NewTxStore(w.params, config.DB, w.keyManager)
 	if err != nil {
@@ -211,13 +226,18 @@ func (w *FiroElectrumWallet) Params() *chaincfg.Params {
 }
 
 func (w *FiroElectrumWallet) CurrencyCode() string {
-	if w.params.Name == chaincfg.MainNetParams.Name {
-		return "btc"
+	if w.params.Net == FiroMainNetParams.Net {
+		return "firo"
 	} else {
-		return "tbtc"
+		return "tfiro"
 	}
 }
 
+// WatchOnly is always false. Firo wallets are made from a seed.
+func (w *FiroElectrumWallet) WatchOnly() bool {
+	return false
+}
+
 func (w *FiroElectrumWallet) IsDust(amount int64) bool {
 	// This is a per mempool policy thing .. < 1000 sats for now
 	return btcutil.Amount(amount) < txrules.DefaultRelayFeePerKb
@@ -227,44 +247,36 @@ func (w *FiroElectrumWallet) IsDust(amount int64) bool {
 // It is used for Rescan and has no concept of gap-limit. It is expected that
 // keys made here are just temporarily used to generate addresses for rescan.
 func (w *FiroElectrumWallet) GetAddress(kp *wallet.KeyPath /*, addressType*/) (btcutil.Address, error) {
-	key, err := w.keyManager.generateChildKey(kp.Change, uint32(kp.Index))
+	key, err := w.keyManager.generateChildKey(kp.Account, kp.Change, uint32(kp.Index))
 	if err != nil {
 		return nil, err
 	}
+	// Firo has no segwit - P2PKH only
 	address, err := key.Address(w.params)
 	key.Zero()
 	if err != nil {
 		return nil, err
 	}
-	script := address.ScriptAddress()
-	segwitAddress, err := btcutil.NewAddressWitnessPubKeyHash(script, w.params)
-	if err != nil {
-		return nil, err
-	}
-	return segwitAddress, nil
+	return address, nil
 }
 
 func (w *FiroElectrumWallet) GetUnusedAddress(purpose wallet.KeyChange) (btcutil.Address, error) {
-	key, err := w.keyManager.GetUnusedKey(purpose)
+	key, err := w.keyManager.GetUnusedKey(wallet.DefaultAccount, purpose)
 	if err != nil {
 		return nil, nil
 	}
+	// Firo has no segwit - P2PKH only
 	address, err := key.Address(w.params)
 	key.Zero()
 	if err != nil {
 		return nil, nil
 	}
-	script := address.ScriptAddress()
-	segwitAddress, swerr := btcutil.NewAddressWitnessPubKeyHash(script, w.params)
-	if swerr != nil {
-		return nil, swerr
-	}
-	return segwitAddress, nil
+	return address, nil
 }
 
-// For receiving simple payments from legacy wallets only!
+// All Firo receive addresses are legacy P2PKH so this is GetUnusedAddress(RECEIVING).
 func (w *FiroElectrumWallet) GetUnusedLegacyAddress() (btcutil.Address, error) {
-	key, err := w.keyManager.GetUnusedKey(wallet.RECEIVING)
+	key, err := w.keyManager.GetUnusedKey(wallet.DefaultAccount, wallet.RECEIVING)
 	if err != nil {
 		return nil, nil
 	}
@@ -301,10 +313,13 @@ func (w *FiroElectrumWallet) MarkAddressUsed(address btcutil.Address) error {
 }
 
 func (w *FiroElectrumWallet) DecodeAddress(addr string) (btcutil.Address, error) {
-	return btcutil.DecodeAddress(addr, w.params)
+	return DecodeAddress(addr, w.params)
 }
 
 func (w *FiroElectrumWallet) ScriptToAddress(script []byte) (btcutil.Address, error) {
+	if isExchangeScript(script) {
+		return NewAddressExchangePubKeyHash(script[4:24], w.params)
+	}
 	_, addresses, _, err := txscript.ExtractPkScriptAddrs(script, w.params)
 	if err != nil {
 		return &btcutil.AddressPubKeyHash{}, err
@@ -316,7 +331,7 @@ func (w *FiroElectrumWallet) ScriptToAddress(script []byte) (btcutil.Address, er
 }
 
 func (w *FiroElectrumWallet) AddressToScript(address btcutil.Address) ([]byte, error) {
-	return txscript.PayToAddrScript(address)
+	return PayToAddrScript(address)
 }
 
 func (w *FiroElectrumWallet) AddSubscription(subcription *wallet.Subscription) error {
@@ -368,6 +383,15 @@ func (w *FiroElectrumWallet) IsMine(queryAddress btcutil.Address) bool {
 }
 
 func (w *FiroElectrumWallet) Balance() (int64, int64, int64, error) {
+	utxos, err := w.txstore.Utxos().GetAll()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return w.balance(utxos)
+}
+
+// balance returns the confirmed, unconfirmed and locked amounts of utxos.
+func (w *FiroElectrumWallet) balance(utxos []wallet.Utxo) (int64, int64, int64, error) {
 
 	isStxoConfirmed := func(utxo wallet.Utxo, stxos []wallet.Stxo) bool {
 		for _, stxo := range stxos {
@@ -389,10 +413,6 @@ func (w *FiroElectrumWallet) Balance() (int64, int64, int64, error) {
 	confirmed := int64(0)
 	unconfirmed := int64(0)
 	locked := int64(0)
-	utxos, err := w.txstore.Utxos().GetAll()
-	if err != nil {
-		return 0, 0, 0, err
-	}
 	stxos, err := w.txstore.Stxos().GetAll()
 	if err != nil {
 		return 0, 0, 0, err
@@ -522,6 +542,52 @@ func (w *FiroElectrumWallet) UpdateTip(newTip int64) {
 	w.blockchainTip = newTip
 }
 
+// MigrateTxTimes sets the times of confirmed transactions stored before
+// DataVersionBlockTimes to their block times. Does nothing for newer wallet
+// data. Progress is saved after each transaction so if blockTime fails the
+// migration carries on from there on the next load.
+func (w *FiroElectrumWallet) MigrateTxTimes(blockTime func(height int64) (time.Time, error)) error {
+	version, err := w.txstore.Cfg().GetDataVersion()
+	if err != nil {
+		return err
+	}
+	if version >= wallet.DataVersionBlockTimes {
+		return nil
+	}
+	cursor, err := w.txstore.Cfg().GetMigrationCursor()
+	if err != nil {
+		return err
+	}
+	txns, err := w.txstore.Txns().GetAll(true)
+	if err != nil {
+		return err
+	}
+	// in txid order; the cursor is the last one done
+	sort.Slice(txns, func(i, j int) bool { return txns[i].Txid < txns[j].Txid })
+	for _, txn := range txns {
+		if txn.Height <= 0 || txn.Txid <= cursor {
+			continue
+		}
+		timestamp, err := blockTime(txn.Height)
+		if err != nil {
+			return err
+		}
+		err = w.txstore.Txns().UpdateHeight(txn.Txid, int(txn.Height), timestamp)
+		if err != nil {
+			return err
+		}
+		err = w.txstore.Cfg().PutMigrationCursor(txn.Txid)
+		if err != nil {
+			return err
+		}
+	}
+	err = w.txstore.Cfg().PutDataVersion(wallet.DataVersionBlockTimes)
+	if err != nil {
+		return err
+	}
+	return w.txstore.Cfg().PutMigrationCursor("")
+}
+
 /////////////////////////////
 // implementations in send.go
 